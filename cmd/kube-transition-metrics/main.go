@@ -1,21 +1,45 @@
 package main
 
 import (
-	"net/http"
-	//nolint:gosec
-	_ "net/http/pprof"
+	"context"
+	"io"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
+	"github.com/BackMarket-oss/kube-transition-metrics/internal/jobs"
+	"github.com/BackMarket-oss/kube-transition-metrics/internal/labelmapper"
 	"github.com/BackMarket-oss/kube-transition-metrics/internal/logging"
 	"github.com/BackMarket-oss/kube-transition-metrics/internal/options"
+	"github.com/BackMarket-oss/kube-transition-metrics/internal/owners"
+	"github.com/BackMarket-oss/kube-transition-metrics/internal/podannotations"
 	"github.com/BackMarket-oss/kube-transition-metrics/internal/prommetrics"
+	"github.com/BackMarket-oss/kube-transition-metrics/internal/rollouts"
+	"github.com/BackMarket-oss/kube-transition-metrics/internal/server"
+	"github.com/BackMarket-oss/kube-transition-metrics/internal/services"
+	"github.com/BackMarket-oss/kube-transition-metrics/internal/slo"
+	"github.com/BackMarket-oss/kube-transition-metrics/internal/slowpods"
 	"github.com/BackMarket-oss/kube-transition-metrics/internal/statistics"
-	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/BackMarket-oss/kube-transition-metrics/internal/statistics/state"
+	"github.com/BackMarket-oss/kube-transition-metrics/internal/statistics/types"
+	"github.com/BackMarket-oss/kube-transition-metrics/internal/summaries"
+	"github.com/BackMarket-oss/kube-transition-metrics/internal/volumes"
+	"github.com/BackMarket-oss/kube-transition-metrics/internal/workloadreports"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
+	corev1 "k8s.io/api/core/v1"
+	apimachinerytypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	corev1listers "k8s.io/client-go/listers/core/v1"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/clock"
 )
 
 func getKubeconfigInCluster() *rest.Config {
@@ -70,39 +94,444 @@ func main() {
 
 	defer prommetrics.Unregister()
 
-	options := options.Parse()
-	logging.SetOptions(options)
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "replay":
+			replay(os.Args[2:])
 
-	config := getKubeconfig(options)
+			return
+		case "audit":
+			replayAuditLogs(os.Args[2:])
+
+			return
+		case "simulate":
+			simulate(os.Args[2:])
+
+			return
+		}
+	}
+
+	opts := options.Parse()
+	logging.SetOptions(opts)
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, syscall.SIGINT)
+	defer stop()
+
+	go options.NewReloader(opts, logging.SetOptions).Run(ctx)
+
+	config := getKubeconfig(opts)
 
 	clientset, err := kubernetes.NewForConfig(config)
 	if err != nil {
 		log.Panic().Err(err).Msg("Failed to build kubernetes client")
 	}
 
-	metricOutput := zerolog.MultiLevelWriter(os.Stdout, logging.NewValidationWriter())
+	// The same clock timestamps the transitions observed by the event loops, the collectors and the trackers.
+	realClock := clock.RealClock{}
+	metricOutput := zerolog.MultiLevelWriter(os.Stdout, logging.NewValidationWriter(opts))
+
+	var summaryAggregator *summaries.Aggregator
+
+	if opts.SummaryInterval > 0 {
+		// The aggregator consumes the records written to the metric output, and reports its summaries to stdout.
+		summaryAggregator = summaries.NewAggregator(
+			metricOutput, time.Duration(opts.SummaryInterval*float64(time.Second)), realClock)
+		metricOutput = zerolog.MultiLevelWriter(metricOutput, summaryAggregator)
+
+		go summaryAggregator.Run(ctx)
+	}
+
+	// The namespace informer is shared by the label mapper and the pod annotator.
+	var namespaces corev1listers.NamespaceLister
+	if opts.NamespaceLabelMappings() || opts.PodAnnotationNamespaceLabel != "" {
+		namespaces = newNamespaceLister(ctx, clientset)
+	}
+
+	mapper := labelmapper.NewMapper(opts, namespaces)
+	podLabelers := []state.PodLabeler{mapper}
+
+	var ownerResolver *owners.Resolver
+
+	if opts.ResolveOwners {
+		ownerResolver = newOwnerResolver(ctx, config, clientset)
+		podLabelers = append(podLabelers, ownerResolver)
+	}
+
+	var servicesIndex *services.Index
+
+	if opts.ResolveServices {
+		servicesIndex = newServicesIndex(ctx, clientset)
+		podLabelers = append(podLabelers, servicesIndex)
+	}
+
+	series, labelLimits := opts.CardinalityLimits(options.LimitedMetric)
+	podTransitionObserver := statistics.NewPodTransitionObserver(
+		mapper,
+		prommetrics.NewCardinalityGuard(options.LimitedMetric, mapper.PrometheusLabelNames(), series, labelLimits),
+	)
+	prometheus.MustRegister(podTransitionObserver)
+
+	defer prometheus.Unregister(podTransitionObserver)
+
+	podObservers := []types.PodStatisticObserver{podTransitionObserver}
+
+	var (
+		rolloutTracker     *rollouts.Tracker
+		imagePullObservers []types.ImagePullStatisticObserver
+		rolloutObservers   []rollouts.RolloutObserver
+		// flushers are closed after the statistic event loops are drained, so that their final flush includes the last
+		// statistics.
+		flushers []interface{ Close() }
+	)
+
+	// The options validation ensures the owner resolver is available when reporting workloads.
+	if opts.WorkloadReportInterval > 0 {
+		workloadReporter := newWorkloadReporter(opts, config, realClock, ownerResolver)
+		podObservers = append(podObservers, workloadReporter)
+		imagePullObservers = append(imagePullObservers, workloadReporter)
+		rolloutObservers = append(rolloutObservers, workloadReporter)
+		flushers = append(flushers, workloadReporter)
+
+		go workloadReporter.Run(ctx)
+	}
+
+	// The options validation ensures the owner resolver is available when tracking rollouts.
+	if opts.TrackRollouts {
+		rolloutTracker = newRolloutTracker(ctx, clientset, realClock, metricOutput, ownerResolver, rolloutObservers...)
+		podObservers = append(podObservers, rolloutTracker)
+		imagePullObservers = append(imagePullObservers, rolloutTracker)
+	}
+
+	if len(opts.SLOs) > 0 {
+		sloEvaluator := slo.NewEvaluator(opts.SLOs, metricOutput, realClock, podLabelers...)
+		prometheus.MustRegister(sloEvaluator)
+
+		defer prometheus.Unregister(sloEvaluator)
+
+		podObservers = append(podObservers, sloEvaluator)
+		imagePullObservers = append(imagePullObservers, sloEvaluator)
+	}
+
+	if opts.SlowPodEventThreshold > 0 || opts.SlowImagePullEventThreshold > 0 {
+		eventBroadcaster := record.NewBroadcaster(record.WithContext(ctx))
+		eventBroadcaster.StartRecordingToSink(&typedcorev1.EventSinkImpl{Interface: clientset.CoreV1().Events("")})
+
+		defer eventBroadcaster.Shutdown()
 
-	podStatisticEventLoop := statistics.NewStatisticEventLoop(options, metricOutput)
-	defer podStatisticEventLoop.Close()
+		recorder := eventBroadcaster.NewRecorder(scheme.Scheme, corev1.EventSource{Component: "kube-transition-metrics"})
 
+		// The options validation ensures the owner resolver is available when creating Events on the owners.
+		var notifierOwners *owners.Resolver
+		if opts.SlowPodOwnerEvents {
+			notifierOwners = ownerResolver
+		}
+
+		notifier := slowpods.NewNotifier(opts, recorder, realClock, notifierOwners)
+		podObservers = append(podObservers, notifier)
+		imagePullObservers = append(imagePullObservers, notifier)
+	}
+
+	if opts.PodAnnotationNamespaceLabel != "" {
+		annotator := newPodAnnotator(opts, config, namespaces)
+		podObservers = append(podObservers, annotator)
+		flushers = append(flushers, annotator)
+
+		go annotator.Run(ctx)
+	}
+
+	var jobTracker *jobs.Tracker
+
+	if opts.TrackJobs {
+		jobTracker = newJobTracker(ctx, clientset, realClock, metricOutput)
+		podObservers = append(podObservers, jobTracker)
+	}
+
+	podStatisticEventLoop := statistics.NewStatisticEventLoop(
+		opts,
+		metricOutput,
+		statistics.WithPodLabelers(podLabelers...),
+		statistics.WithPodStatisticObservers(podObservers...),
+		statistics.WithClock(realClock),
+	)
 	podStatisticEventLoop.Start()
 
-	imagePullStatisticEventLoop := statistics.NewImagePullStatisticEventLoop(options, metricOutput)
-	defer imagePullStatisticEventLoop.Close()
+	if servicesIndex != nil {
+		handleEndpointReady(ctx, servicesIndex, podStatisticEventLoop)
+	}
+
+	var volumeWatcher *volumes.Watcher
+
+	if opts.TrackVolumes {
+		volumeWatcher = newVolumeWatcher(ctx, clientset, podStatisticEventLoop)
+	}
 
+	imagePullStatisticEventLoop := statistics.NewImagePullStatisticEventLoop(
+		opts,
+		metricOutput,
+		statistics.WithPodLabelers(podLabelers...),
+		statistics.WithImagePullStatisticObservers(imagePullObservers...),
+	)
 	imagePullStatisticEventLoop.Start()
 
-	podCollector := statistics.NewPodCollector(options, podStatisticEventLoop, imagePullStatisticEventLoop)
-	go podCollector.Run(clientset)
+	collectorOpts := []statistics.PodCollectorOption{statistics.WithCollectorClock(realClock)}
+
+	if opts.RecordPath != "" {
+		recordFile := openRecordFile(opts.RecordPath)
+		defer closeRecordFile(recordFile)
+
+		collectorOpts = append(collectorOpts, statistics.WithWatchRecorder(statistics.NewWatchRecorder(recordFile)))
+	}
+
+	podCollector := statistics.NewPodCollector(opts, podStatisticEventLoop, imagePullStatisticEventLoop, collectorOpts...)
+	collectorDone := make(chan struct{})
+
+	go func() {
+		defer close(collectorDone)
+
+		podCollector.Run(ctx, clientset)
+	}()
+
+	httpServer := server.New(opts, podCollector)
+	if opts.ServeSummaries {
+		httpServer.Handle("/summaries", summaryAggregator)
+	}
+
+	httpServer.Start()
+
+	<-ctx.Done()
+	// Restore the default signal handling, so that a second signal terminates the process immediately.
+	stop()
+	log.Info().Msg("Received termination signal, shutting down ...")
+
+	// The services index must stop sending endpoint events before the pod statistic event loop is closed.
+	closers := []interface{ Close() }{podStatisticEventLoop, imagePullStatisticEventLoop}
+	if servicesIndex != nil {
+		closers = append([]interface{ Close() }{servicesIndex}, closers...)
+	}
+
+	if rolloutTracker != nil {
+		closers = append([]interface{ Close() }{rolloutTracker}, closers...)
+	}
+
+	if jobTracker != nil {
+		closers = append([]interface{ Close() }{jobTracker}, closers...)
+	}
+
+	if volumeWatcher != nil {
+		closers = append([]interface{ Close() }{volumeWatcher}, closers...)
+	}
+
+	// The aggregator is flushed last, as it summarizes the records written by the event loops and the other flushers.
+	closers = append(closers, flushers...)
+	if summaryAggregator != nil {
+		closers = append(closers, summaryAggregator)
+	}
+
+	shutdown(opts, httpServer, collectorDone, closers...)
+}
+
+// newNamespaceLister starts the informer caching the namespaces and returns its lister.
+func newNamespaceLister(ctx context.Context, clientset kubernetes.Interface) corev1listers.NamespaceLister {
+	namespaces, err := labelmapper.StartNamespaceInformer(ctx, clientset)
+	if err != nil {
+		log.Panic().Err(err).Msg("Failed to start namespace informer")
+	}
+
+	return namespaces
+}
+
+// newOwnerResolver starts the informers caching the owners of pods and returns the owner resolver.
+func newOwnerResolver(ctx context.Context, config *rest.Config, clientset kubernetes.Interface) *owners.Resolver {
+	dynamicClient, err := dynamic.NewForConfig(config)
+	if err != nil {
+		log.Panic().Err(err).Msg("Failed to build kubernetes dynamic client")
+	}
+
+	resolver, err := owners.StartResolver(ctx, clientset, dynamicClient)
+	if err != nil {
+		log.Panic().Err(err).Msg("Failed to start owner informers")
+	}
+
+	return resolver
+}
+
+// newServicesIndex starts the informers caching the Services and EndpointSlices and returns the services index.
+func newServicesIndex(ctx context.Context, clientset kubernetes.Interface) *services.Index {
+	index, err := services.Start(ctx, clientset)
+	if err != nil {
+		log.Panic().Err(err).Msg("Failed to start service informers")
+	}
+
+	return index
+}
+
+// newRolloutTracker starts the informers watching the workloads and returns the rollout tracker.
+func newRolloutTracker(
+	ctx context.Context,
+	clientset kubernetes.Interface,
+	clock clock.PassiveClock,
+	output io.Writer,
+	ownerResolver *owners.Resolver,
+	observers ...rollouts.RolloutObserver,
+) *rollouts.Tracker {
+	tracker, err := rollouts.Start(ctx, clientset, clock, output, ownerResolver, observers...)
+	if err != nil {
+		log.Panic().Err(err).Msg("Failed to start workload informers")
+	}
+
+	return tracker
+}
+
+// newWorkloadReporter returns the reporter writing the WorkloadStartupReports of the workloads resolved by the owner
+// resolver.
+func newWorkloadReporter(
+	options *options.Options,
+	config *rest.Config,
+	clock clock.WithTicker,
+	ownerResolver *owners.Resolver,
+) *workloadreports.Reporter {
+	dynamicClient, err := dynamic.NewForConfig(config)
+	if err != nil {
+		log.Panic().Err(err).Msg("Failed to build kubernetes dynamic client")
+	}
 
-	http.Handle("/metrics", promhttp.Handler())
+	return workloadreports.NewReporter(
+		dynamicClient,
+		ownerResolver,
+		clock,
+		time.Duration(options.WorkloadReportInterval*float64(time.Second)),
+		time.Duration(options.WorkloadReportStuckThreshold*float64(time.Second)),
+	)
+}
 
-	handler := logging.NewHTTPHandler(http.DefaultServeMux)
-	// No timeouts can be set, but that's OK for us as this HTTP server will not be
-	// exposed publicly.
-	//nolint:gosec
-	err = http.ListenAndServe(options.ListenAddress, handler)
+// newPodAnnotator returns the pod annotator reading the opt-in label from the cached namespaces, patching the pods with
+// a dedicated client rate limited to the pod annotation rate.
+func newPodAnnotator(
+	options *options.Options,
+	config *rest.Config,
+	namespaces corev1listers.NamespaceLister,
+) *podannotations.Annotator {
+	annotationClientset, err := kubernetes.NewForConfig(podannotations.ClientConfig(config, options))
 	if err != nil {
-		log.Panic().Err(err).Msg(err.Error())
+		log.Panic().Err(err).Msg("Failed to build kubernetes client")
+	}
+
+	return podannotations.NewAnnotator(options, annotationClientset, namespaces)
+}
+
+// newJobTracker starts the informer watching the Jobs and returns the job tracker.
+func newJobTracker(
+	ctx context.Context,
+	clientset kubernetes.Interface,
+	clock clock.PassiveClock,
+	output io.Writer,
+) *jobs.Tracker {
+	tracker, err := jobs.Start(ctx, clientset, clock, output)
+	if err != nil {
+		log.Panic().Err(err).Msg("Failed to start job informer")
+	}
+
+	return tracker
+}
+
+// newVolumeWatcher starts the informer watching the Events of PersistentVolumeClaims and sends their provisioning
+// Events to the pod statistic event loop.
+func newVolumeWatcher(
+	ctx context.Context,
+	clientset kubernetes.Interface,
+	podStatisticEventLoop types.PodStatisticEventLoop,
+) *volumes.Watcher {
+	watcher, err := volumes.Start(ctx, clientset)
+	if err != nil {
+		log.Panic().Err(err).Msg("Failed to start claim event informer")
+	}
+
+	err = watcher.HandleClaimEvents(func(event *corev1.Event) {
+		if _, err := podStatisticEventLoop.ClaimVolumeEvent(ctx, event); err != nil {
+			log.Debug().Err(err).Str("claim_name", event.InvolvedObject.Name).Msg("Failed to send claim volume event")
+		}
+	})
+	if err != nil {
+		log.Panic().Err(err).Msg("Failed to watch claim events")
+	}
+
+	return watcher
+}
+
+// handleEndpointReady sends the pod endpoint readiness detected by the services index to the pod statistic event loop.
+func handleEndpointReady(
+	ctx context.Context,
+	index *services.Index,
+	podStatisticEventLoop types.PodStatisticEventLoop,
+) {
+	err := index.HandleEndpointReady(func(uid apimachinerytypes.UID, service string) {
+		if _, err := podStatisticEventLoop.PodEndpointReady(ctx, uid, service); err != nil {
+			log.Debug().Err(err).Str("pod_uid", string(uid)).Msg("Failed to send pod endpoint ready event")
+		}
+	})
+	if err != nil {
+		log.Panic().Err(err).Msg("Failed to watch EndpointSlices")
+	}
+}
+
+// openRecordFile opens the file the watch events are recorded to, appending to it if it already exists.
+func openRecordFile(path string) *os.File {
+	//nolint:gosec // The path is provided by the operator.
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o600)
+	if err != nil {
+		log.Panic().Err(err).Str("record_path", path).Msg("Failed to open the watch event record file")
+	}
+
+	return file
+}
+
+// closeRecordFile closes the file the watch events are recorded to, once the collectors have stopped.
+func closeRecordFile(file *os.File) {
+	if err := file.Close(); err != nil {
+		log.Error().Err(err).Str("record_path", file.Name()).Msg("Failed to close the watch event record file")
+	}
+}
+
+// shutdown stops the HTTP server, waits for the collectors to stop, closes the closers in order, which drains the
+// statistic event loops and then flushes the reports, and flushes the metric output, giving up after the configured
+// shutdown timeout.
+func shutdown(
+	options *options.Options,
+	httpServer *server.Server,
+	collectorDone <-chan struct{},
+	closers ...interface{ Close() },
+) {
+	timeout := time.Duration(options.ShutdownTimeout * float64(time.Second))
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	if err := httpServer.Shutdown(ctx); err != nil {
+		log.Error().Err(err).Msg("Failed to gracefully shutdown HTTP server")
+	}
+
+	drained := make(chan struct{})
+
+	go func() {
+		defer close(drained)
+
+		// The collectors must be stopped before closing the event loops, as sending to a closed event loop panics.
+		<-collectorDone
+
+		for _, closer := range closers {
+			closer.Close()
+		}
+	}()
+
+	select {
+	case <-drained:
+		log.Info().Msg("Statistic event loops drained")
+	case <-ctx.Done():
+		log.Error().Msg("Timed out waiting for statistic event loops to drain, some statistics may be lost")
+	}
+
+	// Errors are expected when stdout is a pipe or a terminal, which are not buffered anyway.
+	if err := os.Stdout.Sync(); err != nil {
+		log.Debug().Err(err).Msg("Failed to sync metric output")
 	}
 }
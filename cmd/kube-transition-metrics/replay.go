@@ -0,0 +1,133 @@
+package main
+
+import (
+	"compress/gzip"
+	"context"
+	"io"
+	"iter"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/BackMarket-oss/kube-transition-metrics/internal/audit"
+	"github.com/BackMarket-oss/kube-transition-metrics/internal/labelmapper"
+	"github.com/BackMarket-oss/kube-transition-metrics/internal/logging"
+	"github.com/BackMarket-oss/kube-transition-metrics/internal/options"
+	"github.com/BackMarket-oss/kube-transition-metrics/internal/statistics"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+// replay runs the replay subcommand, which computes the statistics offline from the Pod and Event watch events
+// recorded with --record-path, and writes the metric records to stdout.
+// The recorded watch events are read in order from the files given as positional arguments, or from stdin if there
+// are none.
+func replay(args []string) {
+	opts := options.ParseArgs(args)
+	logging.SetOptions(opts)
+
+	inputs, closeInputs := openReplayInputs(opts.Args())
+	defer closeInputs()
+
+	runReplay(opts, statistics.DecodeRecordedWatchEvents(io.MultiReader(inputs...)))
+}
+
+// replayAuditLogs runs the audit subcommand, which computes the statistics offline from the Pod and Event watch events
+// reconstructed from Kubernetes audit logs, and writes the metric records to stdout.
+// The audit logs of each API server are read from the files given as positional arguments, or from stdin if there are
+// none.
+func replayAuditLogs(args []string) {
+	opts := options.ParseArgs(args)
+	logging.SetOptions(opts)
+
+	inputs, closeInputs := openReplayInputs(opts.Args())
+	defer closeInputs()
+
+	runReplay(opts, audit.DecodeWatchEvents(inputs...))
+}
+
+// runReplay replays the watch events through the statistic event loops, writing the metric records to stdout.
+func runReplay(opts *options.Options, events iter.Seq2[*statistics.RecordedWatchEvent, error]) {
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, syscall.SIGINT)
+	defer stop()
+
+	metricOutput := zerolog.MultiLevelWriter(os.Stdout, logging.NewValidationWriter(opts))
+
+	// The namespaces are not available offline, so the namespace label mappings are left empty.
+	mapper := labelmapper.NewMapper(opts, nil)
+	clock := statistics.NewVirtualClock()
+
+	podStatisticEventLoop := statistics.NewStatisticEventLoop(
+		opts,
+		metricOutput,
+		statistics.WithPodLabelers(mapper),
+		statistics.WithClock(clock),
+	)
+	podStatisticEventLoop.Start()
+
+	imagePullStatisticEventLoop := statistics.NewImagePullStatisticEventLoop(
+		opts,
+		metricOutput,
+		statistics.WithPodLabelers(mapper),
+	)
+	imagePullStatisticEventLoop.Start()
+
+	replayer := statistics.NewReplayer(opts, podStatisticEventLoop, imagePullStatisticEventLoop, clock)
+	err := replayer.Replay(ctx, events)
+
+	// The replayer has stopped sending events, so the event loops can be drained.
+	podStatisticEventLoop.Close()
+	imagePullStatisticEventLoop.Close()
+
+	if err != nil {
+		log.Panic().Err(err).Msg("Failed to replay the watch events")
+	}
+}
+
+// openReplayInputs opens the files given as positional arguments, decompressing the files with the .gz extension, or
+// returns stdin if there are none.
+// The returned function closes the files.
+func openReplayInputs(paths []string) ([]io.Reader, func()) {
+	if len(paths) == 0 {
+		return []io.Reader{os.Stdin}, func() {}
+	}
+
+	inputs := make([]io.Reader, 0, len(paths))
+	closers := make([]io.Closer, 0, len(paths))
+	closeInputs := func() {
+		for _, closer := range closers {
+			if err := closer.Close(); err != nil {
+				log.Debug().Err(err).Msg("Failed to close replay input")
+			}
+		}
+	}
+
+	for _, path := range paths {
+		//nolint:gosec // The paths are provided by the operator.
+		file, err := os.Open(path)
+		if err != nil {
+			closeInputs()
+			log.Panic().Err(err).Str("replay_path", path).Msg("Failed to open replay input")
+		}
+
+		closers = append(closers, file)
+
+		if !strings.HasSuffix(path, ".gz") {
+			inputs = append(inputs, file)
+
+			continue
+		}
+
+		reader, err := gzip.NewReader(file)
+		if err != nil {
+			closeInputs()
+			log.Panic().Err(err).Str("replay_path", path).Msg("Failed to decompress replay input")
+		}
+
+		closers = append(closers, reader)
+		inputs = append(inputs, reader)
+	}
+
+	return inputs, closeInputs
+}
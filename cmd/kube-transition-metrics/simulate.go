@@ -0,0 +1,49 @@
+package main
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/BackMarket-oss/kube-transition-metrics/internal/labelmapper"
+	"github.com/BackMarket-oss/kube-transition-metrics/internal/logging"
+	"github.com/BackMarket-oss/kube-transition-metrics/internal/options"
+	"github.com/BackMarket-oss/kube-transition-metrics/internal/simulation"
+	"github.com/BackMarket-oss/kube-transition-metrics/internal/statistics"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+// simulate runs the simulate subcommand, which feeds the synthetic pod lifecycles of the profile given as positional
+// argument through the collectors and the statistic event loops, writes the metric records to stdout, and logs the
+// report of the simulation.
+func simulate(args []string) {
+	opts := options.ParseArgs(args)
+	logging.SetOptions(opts)
+
+	if len(opts.Args()) != 1 {
+		log.Panic().Strs("args", opts.Args()).Msg("The simulate subcommand requires a single profile path")
+	}
+
+	profile, err := simulation.LoadProfile(opts.Args()[0])
+	if err != nil {
+		log.Panic().Err(err).Msg("Failed to load the simulation profile")
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, syscall.SIGINT)
+	defer stop()
+
+	metricOutput := zerolog.MultiLevelWriter(os.Stdout, logging.NewValidationWriter(opts))
+	// There are no namespaces to read labels from, so the namespace label mappings are left empty.
+	mapper := labelmapper.NewMapper(opts, nil)
+
+	simulator := simulation.NewSimulator(opts, profile, metricOutput, statistics.WithPodLabelers(mapper))
+
+	report, err := simulator.Run(ctx)
+	if err != nil {
+		log.Panic().Err(err).Msg("Failed to run the simulation")
+	}
+
+	log.Info().Any("simulation_report", report).Msg("Simulation complete")
+}
@@ -0,0 +1,363 @@
+// Package audit reconstructs the Pod and Event watch events from Kubernetes audit logs, to compute the statistics of
+// historical pods offline.
+package audit
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"net/http"
+
+	"github.com/BackMarket-oss/kube-transition-metrics/internal/statistics"
+	"github.com/rs/zerolog/log"
+	corev1 "k8s.io/api/core/v1"
+	eventsv1 "k8s.io/api/events/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	apimachinerytypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+// stageResponseComplete is the stage of the audit events logged once the response is sent, which include the
+// response object.
+const stageResponseComplete = "ResponseComplete"
+
+// eventsAPIGroup is the API group of the events.k8s.io/v1 Events, which are converted to core/v1 Events.
+const eventsAPIGroup = "events.k8s.io"
+
+// Event is the subset of an audit.k8s.io/v1 Event used to reconstruct the watch events.
+type Event struct {
+	// Stage is the stage of the request handling when the audit event was logged.
+	Stage string `json:"stage"`
+	// Verb is the Kubernetes verb of the request.
+	Verb string `json:"verb"`
+	// ObjectRef is the object targeted by the request.
+	ObjectRef *ObjectReference `json:"objectRef,omitempty"`
+	// ResponseStatus is the status of the response, if any.
+	ResponseStatus *metav1.Status `json:"responseStatus,omitempty"`
+	// RequestObject is the object of the request, logged at the Request level.
+	RequestObject json.RawMessage `json:"requestObject,omitempty"`
+	// ResponseObject is the object of the response, logged at the RequestResponse level.
+	ResponseObject json.RawMessage `json:"responseObject,omitempty"`
+	// StageTimestamp is the time the audit event reached the stage.
+	StageTimestamp metav1.MicroTime `json:"stageTimestamp"`
+}
+
+// ObjectReference is the subset of an audit.k8s.io/v1 ObjectReference used to reconstruct the watch events.
+type ObjectReference struct {
+	Resource    string                `json:"resource,omitempty"`
+	Namespace   string                `json:"namespace,omitempty"`
+	Name        string                `json:"name,omitempty"`
+	UID         apimachinerytypes.UID `json:"uid,omitempty"`
+	APIGroup    string                `json:"apiGroup,omitempty"`
+	APIVersion  string                `json:"apiVersion,omitempty"`
+	Subresource string                `json:"subresource,omitempty"`
+}
+
+// DecodeWatchEvents returns the Pod and Event watch events reconstructed from the audit events read from the inputs,
+// e.g. the audit log files of each API server, which are merged in chronological order.
+// Each input must be in chronological order, as written by the log backend of an API server.
+// Only the pods created within the audit logs are included, as the statistics of the pods created earlier would be
+// incomplete.
+// The iteration stops after the first decoding error.
+func DecodeWatchEvents(inputs ...io.Reader) iter.Seq2[*statistics.RecordedWatchEvent, error] {
+	return func(yield func(*statistics.RecordedWatchEvent, error) bool) {
+		converter := &converter{pods: make(map[apimachinerytypes.UID]struct{})}
+
+		for auditEvent, err := range merge(inputs) {
+			if err != nil {
+				yield(nil, err)
+
+				return
+			}
+
+			watchEvent, ok, err := converter.convert(auditEvent)
+			if err != nil {
+				yield(nil, err)
+
+				return
+			}
+
+			if ok && !yield(watchEvent, nil) {
+				return
+			}
+		}
+	}
+}
+
+// decode returns the audit events read from the input.
+func decode(input io.Reader) iter.Seq2[*Event, error] {
+	return func(yield func(*Event, error) bool) {
+		decoder := json.NewDecoder(input)
+
+		for {
+			auditEvent := &Event{}
+			if err := decoder.Decode(auditEvent); errors.Is(err, io.EOF) {
+				return
+			} else if err != nil {
+				yield(nil, fmt.Errorf("failed to decode audit event: %w", err))
+
+				return
+			}
+
+			if !yield(auditEvent, nil) {
+				return
+			}
+		}
+	}
+}
+
+// merge returns the audit events read from the inputs, merged in chronological order of their stage timestamps.
+// The audit events with the same timestamp are returned in the order of the inputs.
+func merge(inputs []io.Reader) iter.Seq2[*Event, error] {
+	return func(yield func(*Event, error) bool) {
+		nexts := make([]func() (*Event, error, bool), len(inputs))
+		heads := make([]*Event, len(inputs))
+
+		for i, input := range inputs {
+			next, stop := iter.Pull2(decode(input))
+			defer stop()
+
+			nexts[i] = next
+		}
+
+		// pull reads the next audit event of the input, returning false if the iteration must stop.
+		pull := func(i int) bool {
+			auditEvent, err, ok := nexts[i]()
+			if err != nil {
+				yield(nil, err)
+
+				return false
+			}
+
+			if ok {
+				heads[i] = auditEvent
+			} else {
+				heads[i] = nil
+			}
+
+			return true
+		}
+
+		for i := range inputs {
+			if !pull(i) {
+				return
+			}
+		}
+
+		for {
+			earliest := -1
+
+			for i, head := range heads {
+				if head != nil && (earliest < 0 || head.StageTimestamp.Before(&heads[earliest].StageTimestamp)) {
+					earliest = i
+				}
+			}
+
+			if earliest < 0 {
+				return
+			}
+
+			if !yield(heads[earliest], nil) || !pull(earliest) {
+				return
+			}
+		}
+	}
+}
+
+// converter converts the audit events to watch events, tracking the pods created within the audit logs.
+type converter struct {
+	// pods are the UIDs of the pods created within the audit logs and not yet deleted.
+	pods map[apimachinerytypes.UID]struct{}
+	// warned indicates the warning about the audit events without objects was logged.
+	warned bool
+}
+
+// convert returns the watch event reconstructed from the audit event, or false if the audit event is not relevant.
+func (c *converter) convert(auditEvent *Event) (*statistics.RecordedWatchEvent, bool, error) {
+	if auditEvent.Stage != stageResponseComplete || auditEvent.ObjectRef == nil || !succeeded(auditEvent) {
+		return nil, false, nil
+	}
+
+	switch {
+	case auditEvent.ObjectRef.Resource == "pods" && auditEvent.ObjectRef.APIGroup == "":
+		return c.convertPod(auditEvent)
+	case auditEvent.ObjectRef.Resource == "events" && auditEvent.ObjectRef.Subresource == "":
+		return c.convertEvent(auditEvent)
+	default:
+		return nil, false, nil
+	}
+}
+
+// convertPod returns the Pod watch event reconstructed from the response of a request modifying a pod.
+func (c *converter) convertPod(auditEvent *Event) (*statistics.RecordedWatchEvent, bool, error) {
+	var eventType watch.EventType
+
+	switch {
+	case auditEvent.Verb == "create" && auditEvent.ObjectRef.Subresource == "":
+		eventType = watch.Added
+	case auditEvent.Verb == "update" || auditEvent.Verb == "patch":
+		// The binding and eviction subresources are created, and their responses are not pods.
+		eventType = watch.Modified
+	case auditEvent.Verb == "delete" && auditEvent.ObjectRef.Subresource == "":
+		eventType = watch.Deleted
+	default:
+		return nil, false, nil
+	}
+
+	if len(auditEvent.ResponseObject) == 0 {
+		c.warnMissingObject()
+
+		return nil, false, nil
+	}
+
+	pod := &corev1.Pod{}
+	if err := json.Unmarshal(auditEvent.ResponseObject, pod); err != nil {
+		return nil, false, fmt.Errorf("failed to decode pod %s/%s: %w",
+			auditEvent.ObjectRef.Namespace, auditEvent.ObjectRef.Name, err)
+	}
+
+	// The response of a deletion may be a Status instead of the pod.
+	if pod.Kind != "Pod" || pod.UID == "" {
+		return nil, false, nil
+	}
+
+	switch eventType {
+	case watch.Added:
+		c.pods[pod.UID] = struct{}{}
+	case watch.Deleted:
+		// A graceful deletion only sets the deletion timestamp of the pod, which is removed once its grace period is 0.
+		if pod.DeletionGracePeriodSeconds != nil && *pod.DeletionGracePeriodSeconds > 0 {
+			eventType = watch.Modified
+		}
+	}
+
+	if _, ok := c.pods[pod.UID]; !ok {
+		return nil, false, nil
+	}
+
+	if eventType == watch.Deleted {
+		delete(c.pods, pod.UID)
+	}
+
+	return &statistics.RecordedWatchEvent{Time: auditEvent.StageTimestamp.Time, Type: eventType, Pod: pod}, true, nil
+}
+
+// convertEvent returns the Event watch event reconstructed from the request or response of a request creating or
+// updating an Event of a pod.
+func (c *converter) convertEvent(auditEvent *Event) (*statistics.RecordedWatchEvent, bool, error) {
+	var eventType watch.EventType
+
+	switch auditEvent.Verb {
+	case "create":
+		eventType = watch.Added
+	case "update", "patch":
+		eventType = watch.Modified
+	default:
+		return nil, false, nil
+	}
+
+	object := auditEvent.ResponseObject
+	// The request object of an update is the complete Event, unlike the one of a patch.
+	if len(object) == 0 && auditEvent.Verb != "patch" {
+		object = auditEvent.RequestObject
+	}
+
+	if len(object) == 0 {
+		c.warnMissingObject()
+
+		return nil, false, nil
+	}
+
+	event, err := decodeEvent(auditEvent.ObjectRef.APIGroup, object)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to decode event %s/%s: %w",
+			auditEvent.ObjectRef.Namespace, auditEvent.ObjectRef.Name, err)
+	}
+
+	if event.InvolvedObject.Kind != "Pod" {
+		return nil, false, nil
+	}
+
+	if _, ok := c.pods[event.InvolvedObject.UID]; !ok {
+		return nil, false, nil
+	}
+
+	return &statistics.RecordedWatchEvent{Time: auditEvent.StageTimestamp.Time, Type: eventType, Event: event}, true, nil
+}
+
+// warnMissingObject warns once that audit events without objects are skipped.
+func (c *converter) warnMissingObject() {
+	if c.warned {
+		return
+	}
+
+	c.warned = true
+
+	log.Warn().Msg("Skipping audit events without objects, the audit policy must log the pods and events at the " +
+		"RequestResponse level")
+}
+
+// succeeded indicates if the request of the audit event succeeded.
+func succeeded(auditEvent *Event) bool {
+	if auditEvent.ResponseStatus == nil || auditEvent.ResponseStatus.Code == 0 {
+		return true
+	}
+
+	return auditEvent.ResponseStatus.Code >= http.StatusOK && auditEvent.ResponseStatus.Code < http.StatusMultipleChoices
+}
+
+// decodeEvent decodes a core/v1 Event, or an events.k8s.io/v1 Event converted to a core/v1 Event.
+func decodeEvent(apiGroup string, object json.RawMessage) (*corev1.Event, error) {
+	if apiGroup != eventsAPIGroup {
+		event := &corev1.Event{}
+		if err := json.Unmarshal(object, event); err != nil {
+			//nolint:wrapcheck
+			return nil, err
+		}
+
+		return event, nil
+	}
+
+	event := &eventsv1.Event{}
+	if err := json.Unmarshal(object, event); err != nil {
+		//nolint:wrapcheck
+		return nil, err
+	}
+
+	return convertEventsV1(event), nil
+}
+
+// convertEventsV1 converts an events.k8s.io/v1 Event to a core/v1 Event, as served by the core/v1 API.
+func convertEventsV1(event *eventsv1.Event) *corev1.Event {
+	converted := &corev1.Event{
+		ObjectMeta:          event.ObjectMeta,
+		InvolvedObject:      event.Regarding,
+		Reason:              event.Reason,
+		Message:             event.Note,
+		Source:              event.DeprecatedSource,
+		FirstTimestamp:      event.DeprecatedFirstTimestamp,
+		LastTimestamp:       event.DeprecatedLastTimestamp,
+		Count:               event.DeprecatedCount,
+		Type:                event.Type,
+		EventTime:           event.EventTime,
+		Action:              event.Action,
+		Related:             event.Related,
+		ReportingController: event.ReportingController,
+		ReportingInstance:   event.ReportingInstance,
+	}
+
+	if event.Series != nil {
+		converted.Series = &corev1.EventSeries{Count: event.Series.Count, LastObservedTime: event.Series.LastObservedTime}
+		converted.Count = event.Series.Count
+		converted.LastTimestamp = metav1.NewTime(event.Series.LastObservedTime.Time)
+	}
+
+	// The image pull statistics are timestamped from the last timestamp of the Events.
+	if converted.LastTimestamp.IsZero() {
+		converted.LastTimestamp = metav1.NewTime(event.EventTime.Time)
+	}
+
+	return converted
+}
@@ -0,0 +1,158 @@
+package audit
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/BackMarket-oss/kube-transition-metrics/internal/statistics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	eventsv1 "k8s.io/api/events/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	apimachinerytypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+var created = time.Date(2023, 8, 28, 0, 0, 0, 0, time.UTC)
+
+func at(seconds int) metav1.MicroTime {
+	return metav1.NewMicroTime(created.Add(time.Duration(seconds) * time.Second))
+}
+
+func newTestingPod(uid apimachinerytypes.UID, gracePeriod *int64) *corev1.Pod {
+	return &corev1.Pod{
+		TypeMeta: metav1.TypeMeta{Kind: "Pod", APIVersion: "v1"},
+		ObjectMeta: metav1.ObjectMeta{
+			UID:                        uid,
+			Name:                       "test-pod",
+			Namespace:                  "test-namespace",
+			DeletionGracePeriodSeconds: gracePeriod,
+		},
+	}
+}
+
+func newAuditEvent(
+	t *testing.T,
+	verb string,
+	ref ObjectReference,
+	timestamp metav1.MicroTime,
+	object any,
+) *Event {
+	t.Helper()
+
+	auditEvent := &Event{
+		Stage:          stageResponseComplete,
+		Verb:           verb,
+		ObjectRef:      &ref,
+		ResponseStatus: &metav1.Status{Code: http.StatusOK},
+		StageTimestamp: timestamp,
+	}
+
+	if object != nil {
+		raw, err := json.Marshal(object)
+		require.NoError(t, err)
+
+		auditEvent.ResponseObject = raw
+	}
+
+	return auditEvent
+}
+
+func encodeAuditLog(t *testing.T, auditEvents ...*Event) io.Reader {
+	t.Helper()
+
+	output := &bytes.Buffer{}
+	encoder := json.NewEncoder(output)
+
+	for _, auditEvent := range auditEvents {
+		require.NoError(t, encoder.Encode(auditEvent))
+	}
+
+	return output
+}
+
+func TestDecodeWatchEvents(t *testing.T) {
+	pods := ObjectReference{Resource: "pods", Namespace: "test-namespace", Name: "test-pod"}
+	podStatus := ObjectReference{Resource: "pods", Namespace: "test-namespace", Name: "test-pod", Subresource: "status"}
+	events := ObjectReference{Resource: "events", Namespace: "test-namespace"}
+	eventsV1 := ObjectReference{Resource: "events", Namespace: "test-namespace", APIGroup: eventsAPIGroup}
+	pulling := &corev1.Event{
+		InvolvedObject: corev1.ObjectReference{Kind: "Pod", UID: "test-uid"},
+		Reason:         "Pulling",
+	}
+	pulled := &eventsv1.Event{
+		Regarding: corev1.ObjectReference{Kind: "Pod", UID: "test-uid"},
+		Reason:    "Pulled",
+		EventTime: at(3),
+	}
+	unknownPodEvent := &corev1.Event{InvolvedObject: corev1.ObjectReference{Kind: "Pod", UID: "other-uid"}}
+	forbidden := newAuditEvent(t, "patch", podStatus, at(2), newTestingPod("test-uid", nil))
+	forbidden.ResponseStatus.Code = http.StatusForbidden
+	requestReceived := newAuditEvent(t, "patch", podStatus, at(2), newTestingPod("test-uid", nil))
+	requestReceived.Stage = "RequestReceived"
+
+	// The audit logs of two API servers, each in chronological order.
+	first := encodeAuditLog(t,
+		newAuditEvent(t, "patch", podStatus, at(0), newTestingPod("other-uid", nil)),
+		newAuditEvent(t, "create", pods, at(1), newTestingPod("test-uid", nil)),
+		forbidden,
+		requestReceived,
+		newAuditEvent(t, "create", events, at(2), unknownPodEvent),
+		newAuditEvent(t, "create", eventsV1, at(3), pulled),
+		newAuditEvent(t, "delete", pods, at(5), newTestingPod("test-uid", new(int64(30)))),
+		newAuditEvent(t, "delete", pods, at(7), newTestingPod("test-uid", new(int64(0)))),
+	)
+	second := encodeAuditLog(t,
+		newAuditEvent(t, "create", events, at(2), pulling),
+		newAuditEvent(t, "patch", podStatus, at(4), nil),
+		newAuditEvent(t, "patch", podStatus, at(4), newTestingPod("test-uid", nil)),
+		newAuditEvent(t, "patch", podStatus, at(8), newTestingPod("test-uid", nil)),
+	)
+
+	var watchEvents []*statistics.RecordedWatchEvent
+
+	for watchEvent, err := range DecodeWatchEvents(first, second) {
+		require.NoError(t, err)
+
+		watchEvents = append(watchEvents, watchEvent)
+	}
+
+	require.Len(t, watchEvents, 6, "Expected only the watch events of the pod created within the audit logs")
+
+	assert.Equal(t, watch.Added, watchEvents[0].Type)
+	assert.WithinDuration(t, at(1).Time, watchEvents[0].Time, 0)
+	require.NotNil(t, watchEvents[0].Pod)
+
+	assert.Equal(t, watch.Added, watchEvents[1].Type)
+	require.NotNil(t, watchEvents[1].Event)
+	assert.Equal(t, "Pulling", watchEvents[1].Event.Reason)
+
+	require.NotNil(t, watchEvents[2].Event)
+	assert.Equal(t, "Pulled", watchEvents[2].Event.Reason)
+	assert.WithinDuration(t, at(3).Time, watchEvents[2].Event.LastTimestamp.Time, 0,
+		"Expected the last timestamp of events.k8s.io Events to be set from their event time")
+
+	assert.Equal(t, watch.Modified, watchEvents[3].Type)
+	assert.WithinDuration(t, at(4).Time, watchEvents[3].Time, 0)
+
+	assert.Equal(t, watch.Modified, watchEvents[4].Type, "Expected a graceful deletion to modify the pod")
+	assert.Equal(t, watch.Deleted, watchEvents[5].Type)
+	assert.WithinDuration(t, at(7).Time, watchEvents[5].Time, 0)
+}
+
+func TestDecodeWatchEventsInvalid(t *testing.T) {
+	for _, err := range DecodeWatchEvents(strings.NewReader(`{"stage":`)) {
+		require.Error(t, err, "Expected truncated audit logs to be rejected")
+	}
+
+	invalidPod := `{"stage":"ResponseComplete","verb":"create","objectRef":{"resource":"pods"},"responseObject":[]}`
+	for _, err := range DecodeWatchEvents(strings.NewReader(invalidPod)) {
+		require.Error(t, err, "Expected invalid pods to be rejected")
+	}
+}
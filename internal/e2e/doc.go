@@ -0,0 +1,7 @@
+// Package e2e contains the end-to-end test scenarios, which drive the pod collector, its image pull collectors and
+// the statistic event loops through scripted Pod and Event watch sequences served by a fake clientset, and assert on
+// the metric records they report.
+//
+// The scenarios use a fake clock, so the transitions observed by the collectors and the image pull cancel delay are
+// deterministic.
+package e2e
@@ -0,0 +1,297 @@
+package e2e
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/BackMarket-oss/kube-transition-metrics/internal/options"
+	"github.com/BackMarket-oss/kube-transition-metrics/internal/statistics"
+	"github.com/BackMarket-oss/kube-transition-metrics/internal/testhelpers"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	apimachinerytypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+	clocktesting "k8s.io/utils/clock/testing"
+)
+
+// waitTimeout is how long the scenarios wait for the collectors to start watching.
+const waitTimeout = 5 * time.Second
+
+// barrierUID is the UID of the pod deleted after each scripted Pod watch event, which is not tracked by the pod
+// collector.
+const barrierUID apimachinerytypes.UID = "barrier-uid"
+
+// scriptedWatcher is a [watch.Interface] whose watch events are sent by the scenario.
+// Its result channel is unbuffered, so a send returns once the receiver has taken the watch event.
+type scriptedWatcher struct {
+	result   chan watch.Event
+	stopChan chan struct{}
+	stopOnce sync.Once
+}
+
+func newScriptedWatcher() *scriptedWatcher {
+	return &scriptedWatcher{
+		result:   make(chan watch.Event),
+		stopChan: make(chan struct{}),
+	}
+}
+
+// ResultChan implements [watch.Interface.ResultChan].
+func (w *scriptedWatcher) ResultChan() <-chan watch.Event {
+	return w.result
+}
+
+// Stop implements [watch.Interface.Stop].
+func (w *scriptedWatcher) Stop() {
+	w.stopOnce.Do(func() {
+		close(w.stopChan)
+	})
+}
+
+// send sends the watch event, returning false if the watcher was stopped before the event was received.
+func (w *scriptedWatcher) send(event watch.Event) bool {
+	select {
+	case w.result <- event:
+		return true
+	case <-w.stopChan:
+		return false
+	}
+}
+
+// stopped indicates if the watcher was stopped by its receiver.
+func (w *scriptedWatcher) stopped() bool {
+	select {
+	case <-w.stopChan:
+		return true
+	default:
+		return false
+	}
+}
+
+// scenario runs the pod collector and the statistic event loops against a fake clientset, whose Pod and Event watches
+// are scripted by the test.
+// Each scripted watch event is fully handled by the collectors before the scenario methods return, so that the fake
+// clock can be advanced between them.
+type scenario struct {
+	t      *testing.T
+	ctx    context.Context
+	cancel context.CancelFunc
+	clock  *clocktesting.FakeClock
+
+	podOutput       *testhelpers.MetricWriter
+	imagePullOutput *testhelpers.MetricWriter
+
+	// eventLoops are closed in order once the pod collector has stopped.
+	eventLoops    []interface{ Close() }
+	collectorDone chan struct{}
+
+	// resourceVersion is the resource version of the last scripted Pod watch event.
+	resourceVersion int
+
+	// mu protects the watchers, which are created by the collectors.
+	mu            sync.Mutex
+	podWatcher    *scriptedWatcher
+	eventWatchers map[apimachinerytypes.UID]*scriptedWatcher
+}
+
+// newScenario starts the pod collector with the initial pods listed, and waits for it to watch the Pods.
+func newScenario(t *testing.T, opts *options.Options, start time.Time, initialPods ...corev1.Pod) *scenario {
+	t.Helper()
+
+	testhelpers.ConfigureLogging(t, opts)
+
+	ctx, cancel := context.WithCancel(t.Context())
+	s := &scenario{
+		t:               t,
+		ctx:             ctx,
+		cancel:          cancel,
+		clock:           clocktesting.NewFakeClock(start),
+		podOutput:       testhelpers.NewMetricWriter(t),
+		imagePullOutput: testhelpers.NewMetricWriter(t),
+		collectorDone:   make(chan struct{}),
+		resourceVersion: 1,
+		eventWatchers:   make(map[apimachinerytypes.UID]*scriptedWatcher),
+	}
+	t.Cleanup(cancel)
+
+	clientset := fake.NewClientset()
+	clientset.PrependReactor("list", "pods", func(k8stesting.Action) (bool, runtime.Object, error) {
+		return true, &corev1.PodList{ListMeta: metav1.ListMeta{ResourceVersion: "1"}, Items: initialPods}, nil
+	})
+	clientset.PrependWatchReactor("pods", func(k8stesting.Action) (bool, watch.Interface, error) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		s.podWatcher = newScriptedWatcher()
+
+		return true, s.podWatcher, nil
+	})
+	clientset.PrependWatchReactor("events", func(action k8stesting.Action) (bool, watch.Interface, error) {
+		//nolint:forcetypeassert // The watch reactors only receive watch actions.
+		restrictions := action.(k8stesting.WatchAction).GetWatchRestrictions()
+		uid, _ := restrictions.Fields.RequiresExactMatch("involvedObject.uid")
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		watcher := newScriptedWatcher()
+		s.eventWatchers[apimachinerytypes.UID(uid)] = watcher
+
+		return true, watcher, nil
+	})
+
+	podStatisticEventLoop := statistics.NewStatisticEventLoop(opts, s.podOutput, statistics.WithClock(s.clock))
+	podStatisticEventLoop.Start()
+
+	imagePullStatisticEventLoop := statistics.NewImagePullStatisticEventLoop(opts, s.imagePullOutput)
+	imagePullStatisticEventLoop.Start()
+
+	s.eventLoops = []interface{ Close() }{podStatisticEventLoop, imagePullStatisticEventLoop}
+
+	collector := statistics.NewPodCollector(
+		opts,
+		podStatisticEventLoop,
+		imagePullStatisticEventLoop,
+		statistics.WithCollectorClock(s.clock),
+	)
+
+	go func() {
+		defer close(s.collectorDone)
+
+		collector.Run(ctx, clientset)
+	}()
+
+	require.Eventually(t, func() bool {
+		return collector.Ready() && s.currentPodWatcher() != nil
+	}, waitTimeout, time.Millisecond, "Expected the pod collector to watch the Pods")
+
+	return s
+}
+
+func (s *scenario) currentPodWatcher() *scriptedWatcher {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.podWatcher
+}
+
+// eventWatcher waits for the image pull collector of the pod to watch its Events, and returns its watcher.
+func (s *scenario) eventWatcher(uid apimachinerytypes.UID) *scriptedWatcher {
+	s.t.Helper()
+
+	var watcher *scriptedWatcher
+
+	require.Eventually(s.t, func() bool {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		watcher = s.eventWatchers[uid]
+
+		return watcher != nil
+	}, waitTimeout, time.Millisecond, "Expected an image pull collector to watch the Events of pod %s", uid)
+
+	return watcher
+}
+
+// createPod sends an Added watch event of the pod.
+func (s *scenario) createPod(pod *corev1.Pod) {
+	s.t.Helper()
+	s.sendPod(watch.Added, pod)
+}
+
+// updatePod sends a Modified watch event of the pod.
+func (s *scenario) updatePod(pod *corev1.Pod) {
+	s.t.Helper()
+	s.sendPod(watch.Modified, pod)
+}
+
+// deletePod sends a Deleted watch event of the pod.
+func (s *scenario) deletePod(pod *corev1.Pod) {
+	s.t.Helper()
+	s.sendPod(watch.Deleted, pod)
+}
+
+// sendPod sends a Pod watch event, and returns once the pod collector has handled it.
+// The pod collector receives the watch events through a RetryWatcher, which forwards the Pod watch events one at a
+// time and swallows the Bookmarks: once the Bookmark following the deletion of the barrier pod is received, the
+// barrier was forwarded, so the pod collector has finished handling the scripted watch event.
+func (s *scenario) sendPod(eventType watch.EventType, pod *corev1.Pod) {
+	s.t.Helper()
+
+	watcher := s.currentPodWatcher()
+	barrier := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{UID: barrierUID, Name: "barrier", Namespace: "barrier"}}
+
+	for _, event := range []watch.Event{
+		{Type: eventType, Object: pod.DeepCopy()},
+		{Type: watch.Deleted, Object: barrier.DeepCopy()},
+		{Type: watch.Bookmark, Object: barrier.DeepCopy()},
+	} {
+		s.resourceVersion++
+		//nolint:forcetypeassert // All the scripted watch events are Pods.
+		event.Object.(*corev1.Pod).ResourceVersion = strconv.Itoa(s.resourceVersion)
+
+		require.True(s.t, watcher.send(event), "Expected the pod collector to receive the %s watch event", event.Type)
+	}
+}
+
+// createEvent sends an Added watch event of the Event to the image pull collector of its involved pod, and returns
+// once the collector has handled it.
+func (s *scenario) createEvent(event *corev1.Event) {
+	s.t.Helper()
+
+	require.True(s.t, s.sendEvent(watch.Added, event), "Expected the image pull collector to receive the Event")
+}
+
+// sendEvent sends an Event watch event to the image pull collector of its involved pod, returning false if the
+// collector stopped watching before receiving it.
+// The image pull collector receives the watch events directly, so once the following ignored Event is received, the
+// collector has finished handling the scripted watch event.
+func (s *scenario) sendEvent(eventType watch.EventType, event *corev1.Event) bool {
+	s.t.Helper()
+
+	watcher := s.eventWatcher(event.InvolvedObject.UID)
+	barrier := &corev1.Event{InvolvedObject: event.InvolvedObject}
+
+	return watcher.send(watch.Event{Type: eventType, Object: event.DeepCopy()}) &&
+		watcher.send(watch.Event{Type: watch.Added, Object: barrier})
+}
+
+// advance advances the fake clock of the collectors and the pod statistic event loop.
+func (s *scenario) advance(duration time.Duration) {
+	s.clock.Step(duration)
+}
+
+// finish stops the pod collector, drains the statistic event loops, and returns the pod and image pull metric
+// records they reported.
+func (s *scenario) finish() ([]map[string]any, []map[string]any) {
+	s.t.Helper()
+
+	s.cancel()
+	<-s.collectorDone
+
+	for _, eventLoop := range s.eventLoops {
+		eventLoop.Close()
+	}
+
+	return testhelpers.DecodeMetricOutput(s.t, s.podOutput), testhelpers.DecodeMetricOutput(s.t, s.imagePullOutput)
+}
+
+// recordsOfType returns the metric records of the given type.
+func recordsOfType(records []map[string]any, recordType string) []map[string]any {
+	var filtered []map[string]any
+
+	for _, record := range records {
+		if record["type"] == recordType {
+			filtered = append(filtered, record)
+		}
+	}
+
+	return filtered
+}
@@ -0,0 +1,175 @@
+package e2e
+
+import (
+	"testing"
+	"time"
+
+	"github.com/BackMarket-oss/kube-transition-metrics/internal/options"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	apimachinerytypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+var created = time.Date(2023, 8, 28, 0, 0, 0, 0, time.UTC)
+
+func at(seconds int) time.Time {
+	return created.Add(time.Duration(seconds) * time.Second)
+}
+
+func newScenarioOptions(imagePullCancelDelay float64) *options.Options {
+	return &options.Options{
+		StatisticEventQueueLength: 10,
+		ImagePullCancelDelay:      imagePullCancelDelay,
+		KubeWatchTimeout:          60,
+		KubeWatchMaxEvents:        100,
+		LogLevel:                  zerolog.FatalLevel,
+	}
+}
+
+// newPendingPod creates a pod scheduled and initialized, whose container is waiting for its image to be pulled.
+func newPendingPod(uid apimachinerytypes.UID) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			UID:               uid,
+			Name:              "test-pod-" + string(uid),
+			Namespace:         "test-namespace",
+			CreationTimestamp: metav1.NewTime(created),
+		},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{
+				{Name: "test-container", Image: "test-image"},
+			},
+		},
+		Status: corev1.PodStatus{
+			Phase: corev1.PodPending,
+			Conditions: []corev1.PodCondition{
+				{Type: corev1.PodScheduled, Status: corev1.ConditionTrue, LastTransitionTime: metav1.NewTime(at(1))},
+				{Type: corev1.PodInitialized, Status: corev1.ConditionTrue, LastTransitionTime: metav1.NewTime(at(1))},
+			},
+		},
+	}
+}
+
+// newRunningPod creates the pending pod once its container is running and ready, without the timestamps reported by
+// the kubelet, so the running transition is timestamped when it is observed.
+func newRunningPod(uid apimachinerytypes.UID, ready time.Time) *corev1.Pod {
+	pod := newPendingPod(uid)
+	pod.Status.Phase = corev1.PodRunning
+	pod.Status.Conditions = append(pod.Status.Conditions,
+		corev1.PodCondition{Type: corev1.PodReady, Status: corev1.ConditionTrue, LastTransitionTime: metav1.NewTime(ready)})
+	pod.Status.ContainerStatuses = []corev1.ContainerStatus{
+		{
+			Name:    "test-container",
+			State:   corev1.ContainerState{Running: &corev1.ContainerStateRunning{}},
+			Started: new(true),
+			Ready:   true,
+		},
+	}
+
+	return pod
+}
+
+// newImagePullEvent creates a Pulling or Pulled Event of the container of the pod.
+func newImagePullEvent(uid apimachinerytypes.UID, reason string, timestamp time.Time) *corev1.Event {
+	return &corev1.Event{
+		ObjectMeta: metav1.ObjectMeta{UID: apimachinerytypes.UID(string(uid) + "-" + reason)},
+		InvolvedObject: corev1.ObjectReference{
+			Kind:      "Pod",
+			UID:       uid,
+			FieldPath: "spec.containers{test-container}",
+		},
+		Reason:        reason,
+		LastTimestamp: metav1.NewTime(timestamp),
+	}
+}
+
+func TestScenarioPodStartup(t *testing.T) {
+	s := newScenario(t, newScenarioOptions(0), created)
+
+	s.createPod(newPendingPod("test-uid"))
+	s.createEvent(newImagePullEvent("test-uid", "Pulling", at(1)))
+	s.createEvent(newImagePullEvent("test-uid", "Pulled", at(2)))
+	s.advance(4 * time.Second)
+	s.updatePod(newRunningPod("test-uid", at(4)))
+
+	podRecords, imagePullRecords := s.finish()
+
+	pods := recordsOfType(podRecords, "pod")
+	require.Len(t, pods, 1, "Expected a pod metric")
+	assert.Equal(t, false, pods[0]["partial"])
+
+	containers := recordsOfType(podRecords, "container")
+	require.Len(t, containers, 1, "Expected a container metric")
+	container, _ := containers[0]["container"].(map[string]any)
+	require.NotNil(t, container)
+	// The container was observed running when the pod was modified, at the time of the fake clock.
+	assert.Equal(t, "observed", container["running_timestamp_source"])
+	assert.InDelta(t, 2.0, container["pulled_to_running_seconds"], 0.001)
+
+	imagePulls := recordsOfType(imagePullRecords, "image_pull")
+	require.Len(t, imagePulls, 1, "Expected an image pull metric")
+	assert.Equal(t, false, imagePulls[0]["partial"])
+}
+
+func TestScenarioInitialPodsIgnored(t *testing.T) {
+	s := newScenario(t, newScenarioOptions(0), created, *newPendingPod("initial-uid"))
+
+	s.updatePod(newRunningPod("initial-uid", at(4)))
+	s.deletePod(newRunningPod("initial-uid", at(4)))
+
+	podRecords, imagePullRecords := s.finish()
+
+	assert.Empty(t, podRecords, "Expected no metrics for the pods existing before the collector started")
+	assert.Empty(t, imagePullRecords)
+}
+
+func TestScenarioPodDeletedBeforeReady(t *testing.T) {
+	s := newScenario(t, newScenarioOptions(0), created)
+
+	s.createPod(newPendingPod("test-uid"))
+	s.createEvent(newImagePullEvent("test-uid", "Pulling", at(1)))
+	s.advance(2 * time.Second)
+	s.deletePod(newPendingPod("test-uid"))
+
+	podRecords, imagePullRecords := s.finish()
+
+	pods := recordsOfType(podRecords, "pod")
+	require.Len(t, pods, 1, "Expected a partial pod metric to be reported when the pod is deleted")
+	assert.Equal(t, true, pods[0]["partial"])
+
+	imagePulls := recordsOfType(imagePullRecords, "image_pull")
+	require.Len(t, imagePulls, 1, "Expected a partial image pull metric to be reported when the pod is deleted")
+	assert.Equal(t, true, imagePulls[0]["partial"])
+}
+
+func TestScenarioImagePullCancelDelay(t *testing.T) {
+	s := newScenario(t, newScenarioOptions(3), created)
+
+	s.createPod(newPendingPod("test-uid"))
+	s.createEvent(newImagePullEvent("test-uid", "Pulling", at(1)))
+	s.advance(4 * time.Second)
+	s.updatePod(newRunningPod("test-uid", at(4)))
+
+	// The Pulled Event may be delivered after the pod is running, within the image pull cancel delay.
+	s.createEvent(newImagePullEvent("test-uid", "Pulled", at(2)))
+
+	require.Eventually(t, s.clock.HasWaiters, waitTimeout, time.Millisecond,
+		"Expected the image pull collector to wait for the image pull cancel delay")
+	s.advance(3 * time.Second)
+
+	watcher := s.eventWatcher("test-uid")
+	require.Eventually(t, watcher.stopped, waitTimeout, time.Millisecond,
+		"Expected the image pull collector to stop once the image pull cancel delay elapsed")
+	assert.False(t, s.sendEvent(watch.Added, newImagePullEvent("test-uid", "Pulling", at(8))),
+		"Expected no Events to be received once the image pull collector stopped")
+
+	_, imagePullRecords := s.finish()
+
+	imagePulls := recordsOfType(imagePullRecords, "image_pull")
+	require.Len(t, imagePulls, 1, "Expected an image pull metric")
+	assert.Equal(t, false, imagePulls[0]["partial"])
+}
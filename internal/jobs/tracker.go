@@ -0,0 +1,224 @@
+// Package jobs watches the Jobs to report the latency of each run of a Job, from the time its CronJob scheduled it to
+// its completion, along with the statistics of its pods.
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/BackMarket-oss/kube-transition-metrics/internal/statistics/state"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/utils/clock"
+)
+
+// ErrCacheSync is returned when the Job informer cache fails to sync.
+var ErrCacheSync = errors.New("failed to sync job informer cache")
+
+// Tracker tracks the Jobs created after it started, and reports a job statistic when each Job completes, fails, or is
+// deleted.
+//
+// Tracker implements the PodStatisticObserver and PodUpdateObserver interfaces of
+// [github.com/BackMarket-oss/kube-transition-metrics/internal/statistics/types].
+type Tracker struct {
+	output io.Writer
+
+	// mu protects jobs, which are updated from both the informer handlers and the pod statistic event loop.
+	mu   sync.Mutex
+	jobs map[types.NamespacedName]*state.JobStatistic
+
+	// factory is the informer factory, it is nil if the tracker was not started by [Start].
+	factory informers.SharedInformerFactory
+}
+
+// NewTracker creates a new Tracker reporting job statistics to the output.
+func NewTracker(output io.Writer) *Tracker {
+	return &Tracker{
+		output: output,
+		jobs:   map[types.NamespacedName]*state.JobStatistic{},
+	}
+}
+
+// Start starts the informer watching the Jobs until the context is done, waits for its initial sync, and returns a
+// Tracker backed by it.
+// The Jobs existing when the tracker starts are not tracked, and the Jobs are timestamped with the clock as they are
+// observed.
+func Start(
+	ctx context.Context,
+	clientset kubernetes.Interface,
+	clock clock.PassiveClock,
+	output io.Writer,
+) (*Tracker, error) {
+	tracker := NewTracker(output)
+	tracker.factory = informers.NewSharedInformerFactoryWithOptions(clientset, 0, informers.WithTransform(strip))
+	informer := tracker.factory.Batch().V1().Jobs().Informer()
+
+	_, err := informer.AddEventHandler(cache.ResourceEventHandlerDetailedFuncs{
+		AddFunc: func(obj any, isInInitialList bool) {
+			if !isInInitialList {
+				tracker.jobAdded(obj, clock.Now())
+			}
+		},
+		UpdateFunc: func(_, newObj any) {
+			tracker.jobUpdated(newObj, clock.Now())
+		},
+		DeleteFunc: func(obj any) {
+			tracker.jobDeleted(obj)
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to add job event handler: %w", err)
+	}
+
+	tracker.factory.Start(ctx.Done())
+
+	if !cache.WaitForCacheSync(ctx.Done(), informer.HasSynced) {
+		return nil, fmt.Errorf("%w: %w", ErrCacheSync, ctx.Err())
+	}
+
+	return tracker, nil
+}
+
+// Close waits for the informer to stop after the context passed to [Start] is done, so that no more job statistics
+// are reported.
+func (t *Tracker) Close() {
+	if t.factory != nil {
+		t.factory.Shutdown()
+	}
+}
+
+// ObservePodStatistic does nothing, as the pods of Jobs are tracked until they terminate, from their pod statistic, by
+// [Tracker.ObservePodUpdate].
+// ObservePodStatistic implements
+// [github.com/BackMarket-oss/kube-transition-metrics/internal/statistics/types.PodStatisticObserver].
+func (t *Tracker) ObservePodStatistic(*corev1.Pod, *state.PodStatistic) {}
+
+// ObservePodUpdate records the pod and its pod statistic in the statistic of its Job, if the Job is tracked.
+// ObservePodUpdate implements
+// [github.com/BackMarket-oss/kube-transition-metrics/internal/statistics/types.PodUpdateObserver].
+func (t *Tracker) ObservePodUpdate(pod *corev1.Pod, podStatistic *state.PodStatistic) {
+	ownerRef := metav1.GetControllerOfNoCopy(pod)
+	if ownerRef == nil || ownerRef.Kind != "Job" || ownerRef.APIVersion != "batch/v1" {
+		return
+	}
+
+	key := types.NamespacedName{Namespace: pod.Namespace, Name: ownerRef.Name}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if statistic, ok := t.jobs[key]; ok {
+		t.jobs[key] = statistic.PodUpdated(pod, podStatistic)
+	}
+}
+
+// jobAdded starts tracking a Job created after the tracker started.
+func (t *Tracker) jobAdded(obj any, now time.Time) {
+	job, ok := obj.(*batchv1.Job)
+	if !ok {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.finishOrTrack(job, state.NewJobStatistic(job), now)
+}
+
+// jobUpdated reports the statistic of a tracked Job once it completes or fails.
+func (t *Tracker) jobUpdated(obj any, now time.Time) {
+	job, ok := obj.(*batchv1.Job)
+	if !ok {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if statistic, ok := t.jobs[keyOf(job)]; ok {
+		t.finishOrTrack(job, statistic, now)
+	}
+}
+
+// jobDeleted reports the statistic of a tracked Job deleted before it finished as partial.
+func (t *Tracker) jobDeleted(obj any) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+
+	job, ok := obj.(*batchv1.Job)
+	if !ok {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if statistic, ok := t.jobs[keyOf(job)]; ok {
+		statistic.Report(t.output)
+		delete(t.jobs, keyOf(job))
+	}
+}
+
+// finishOrTrack reports the statistic if the Job finished, or keeps tracking it otherwise.
+// The caller must hold the lock.
+func (t *Tracker) finishOrTrack(job *batchv1.Job, statistic *state.JobStatistic, now time.Time) {
+	statistic = statistic.Finish(now, job)
+	if statistic.Partial() {
+		t.jobs[keyOf(job)] = statistic
+
+		return
+	}
+
+	statistic.Report(t.output)
+	delete(t.jobs, keyOf(job))
+}
+
+// keyOf returns the key of the Job.
+func keyOf(job *batchv1.Job) types.NamespacedName {
+	return types.NamespacedName{Namespace: job.Namespace, Name: job.Name}
+}
+
+// strip keeps only the fields of the Jobs used to report their statistics, dropping the pod template, to limit the
+// memory used by the cache.
+// strip implements [k8s.io/client-go/tools/cache.TransformFunc].
+func strip(object any) (any, error) {
+	job, ok := object.(*batchv1.Job)
+	if !ok {
+		// Tombstones of deleted objects are passed through unchanged.
+		return object, nil
+	}
+
+	stripped := &batchv1.Job{
+		TypeMeta: job.TypeMeta,
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              job.Name,
+			Namespace:         job.Namespace,
+			UID:               job.UID,
+			ResourceVersion:   job.ResourceVersion,
+			CreationTimestamp: job.CreationTimestamp,
+			OwnerReferences:   job.OwnerReferences,
+		},
+		Spec: batchv1.JobSpec{BackoffLimit: job.Spec.BackoffLimit},
+		Status: batchv1.JobStatus{
+			Conditions: job.Status.Conditions,
+			Succeeded:  job.Status.Succeeded,
+			Failed:     job.Status.Failed,
+		},
+	}
+
+	if scheduled, ok := job.Annotations[state.CronJobScheduledTimestampAnnotation]; ok {
+		stripped.Annotations = map[string]string{state.CronJobScheduledTimestampAnnotation: scheduled}
+	}
+
+	return stripped, nil
+}
@@ -0,0 +1,114 @@
+package jobs
+
+import (
+	"testing"
+	"time"
+
+	"github.com/BackMarket-oss/kube-transition-metrics/internal/options"
+	"github.com/BackMarket-oss/kube-transition-metrics/internal/statistics/state"
+	"github.com/BackMarket-oss/kube-transition-metrics/internal/testhelpers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+func newTestingJob(name string, created time.Time) *batchv1.Job {
+	return &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:         "test-namespace",
+			Name:              name,
+			CreationTimestamp: metav1.NewTime(created),
+		},
+	}
+}
+
+func newTestingPod(job, name string, created time.Time) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:         "test-namespace",
+			Name:              name,
+			CreationTimestamp: metav1.NewTime(created),
+			OwnerReferences: []metav1.OwnerReference{
+				{APIVersion: "batch/v1", Kind: "Job", Name: job, Controller: new(true)},
+			},
+		},
+		Status: corev1.PodStatus{Phase: corev1.PodPending},
+	}
+}
+
+func TestTrackerReportsFinishedJob(t *testing.T) {
+	testhelpers.ConfigureLogging(t, &options.Options{})
+
+	writer := testhelpers.NewMetricWriter(t)
+	tracker := NewTracker(writer)
+	created := time.Date(2023, 8, 28, 0, 0, 0, 0, time.UTC)
+
+	job := newTestingJob("migrate", created)
+	tracker.jobAdded(job, created)
+	for _, pod := range []*corev1.Pod{
+		newTestingPod("migrate", "migrate-a", created.Add(time.Second)),
+		newTestingPod("untracked", "untracked-a", created.Add(time.Second)),
+	} {
+		tracker.ObservePodUpdate(pod, state.NewPodStatistic(created.Add(time.Second), pod))
+	}
+	tracker.jobUpdated(job, created.Add(2*time.Second))
+	assert.Empty(t, testhelpers.DecodeMetricOutput(t, writer), "Expected no report for a running Job")
+
+	failed := job.DeepCopy()
+	failed.Status.Failed = 7
+	failed.Status.Conditions = []batchv1.JobCondition{
+		{Type: batchv1.JobFailed, Status: corev1.ConditionTrue, Reason: "BackoffLimitExceeded"},
+	}
+	tracker.jobUpdated(failed, created.Add(time.Minute))
+	tracker.jobUpdated(failed, created.Add(2*time.Minute))
+
+	metrics := testhelpers.DecodeMetricOutput(t, writer)
+	require.Len(t, metrics, 1, "Expected the Job to be reported once")
+	assert.Equal(t, false, metrics[0]["partial"])
+	assert.Equal(t, "migrate", metrics[0]["kube_job"])
+
+	jobMetrics, ok := metrics[0]["job"].(map[string]any)
+	require.True(t, ok, "Expected job metrics to be an object")
+	assert.Equal(t, true, jobMetrics["failed"])
+	assert.InDelta(t, 7, jobMetrics["retries"], 1e-5)
+	assert.InDelta(t, 60, jobMetrics["creation_to_finished_seconds"], 1e-5)
+	assert.InDelta(t, 1, jobMetrics["pods_created"], 1e-5)
+}
+
+func TestTrackerReportsDeletedJob(t *testing.T) {
+	testhelpers.ConfigureLogging(t, &options.Options{})
+
+	writer := testhelpers.NewMetricWriter(t)
+	tracker := NewTracker(writer)
+	created := time.Date(2023, 8, 28, 0, 0, 0, 0, time.UTC)
+
+	job := newTestingJob("migrate", created)
+	tracker.jobAdded(job, created)
+	tracker.jobDeleted(cache.DeletedFinalStateUnknown{Key: "test-namespace/migrate", Obj: job})
+	tracker.jobDeleted(newTestingJob("untracked", created))
+
+	metrics := testhelpers.DecodeMetricOutput(t, writer)
+	require.Len(t, metrics, 1, "Expected only the tracked Job to be reported")
+	assert.Equal(t, true, metrics[0]["partial"])
+}
+
+func TestStrip(t *testing.T) {
+	t.Parallel()
+
+	job := newTestingJob("migrate", time.Now())
+	job.Annotations = map[string]string{"example.com/other": "value"}
+	job.Spec.Template.Spec.Containers = []corev1.Container{{Name: "migrate", Image: "migrate:1"}}
+	job.Status.Succeeded = 1
+
+	stripped, err := strip(job)
+	require.NoError(t, err)
+
+	strippedJob, ok := stripped.(*batchv1.Job)
+	require.True(t, ok, "Expected a Job")
+	assert.Empty(t, strippedJob.Annotations)
+	assert.Empty(t, strippedJob.Spec.Template.Spec.Containers)
+	assert.Equal(t, int32(1), strippedJob.Status.Succeeded)
+}
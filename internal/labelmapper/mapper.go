@@ -0,0 +1,147 @@
+// Package labelmapper maps pod labels, pod annotations and namespace labels to additional fields of the metric records
+// according to the label mappings of the options.
+package labelmapper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/BackMarket-oss/kube-transition-metrics/internal/options"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+// ErrNamespaceCacheSync is returned when the namespace informer cache fails to sync.
+var ErrNamespaceCacheSync = errors.New("failed to sync namespace informer cache")
+
+// Mapper resolves the label mappings of the options for a pod.
+//
+// Mapper implements [github.com/BackMarket-oss/kube-transition-metrics/internal/statistics/state.PodLabeler] and
+// [github.com/BackMarket-oss/kube-transition-metrics/internal/statistics/types.PrometheusLabeler].
+type Mapper struct {
+	options *options.Options
+	// namespaces is used to look up the labels of the pod namespace, namespace label mappings are ignored when nil.
+	namespaces corev1listers.NamespaceLister
+}
+
+// NewMapper creates a new Mapper.
+// The namespaces lister may be nil if no label mapping reads the labels of namespaces.
+func NewMapper(options *options.Options, namespaces corev1listers.NamespaceLister) *Mapper {
+	return &Mapper{
+		options:    options,
+		namespaces: namespaces,
+	}
+}
+
+// StartNamespaceInformer starts an informer caching the namespaces of the cluster until the context is done, and waits
+// for its initial sync.
+func StartNamespaceInformer(
+	ctx context.Context,
+	clientset kubernetes.Interface,
+) (corev1listers.NamespaceLister, error) {
+	factory := informers.NewSharedInformerFactory(clientset, 0)
+	namespaceInformer := factory.Core().V1().Namespaces()
+	// The informer must be requested before starting the factory.
+	lister := namespaceInformer.Lister()
+
+	factory.Start(ctx.Done())
+
+	if !cache.WaitForCacheSync(ctx.Done(), namespaceInformer.Informer().HasSynced) {
+		return nil, fmt.Errorf("%w: %w", ErrNamespaceCacheSync, ctx.Err())
+	}
+
+	return lister, nil
+}
+
+// Values returns the value of each mapped field for the pod, fields without a value are omitted.
+// The label mappings are read from the current options, so that they can be reloaded.
+func (m *Mapper) Values(pod *corev1.Pod) map[string]string {
+	mappings := m.options.Current().LabelMappings
+	values := make(map[string]string, len(mappings))
+
+	var namespace *corev1.Namespace
+
+	for _, mapping := range mappings {
+		var (
+			value string
+			ok    bool
+		)
+
+		switch mapping.Source {
+		case options.PodLabelSource:
+			value, ok = pod.Labels[mapping.Key]
+		case options.PodAnnotationSource:
+			value, ok = pod.Annotations[mapping.Key]
+		case options.NamespaceLabelSource:
+			if namespace == nil {
+				namespace = m.namespace(pod.Namespace)
+			}
+
+			if namespace != nil {
+				value, ok = namespace.Labels[mapping.Key]
+			}
+		}
+
+		if ok {
+			values[mapping.Field] = value
+		}
+	}
+
+	return values
+}
+
+// PodLabels returns a function that adds the mapped fields of the pod to the event.
+// PodLabels implements [github.com/BackMarket-oss/kube-transition-metrics/internal/statistics/state.PodLabeler].
+func (m *Mapper) PodLabels(pod *corev1.Pod) func(event *zerolog.Event) {
+	values := m.Values(pod)
+
+	return func(event *zerolog.Event) {
+		for field, value := range values {
+			event.Str(field, value)
+		}
+	}
+}
+
+// PrometheusLabelNames returns the names of the mapped fields used as Prometheus labels.
+// The Prometheus labels are structural, they are read from the initial options and never reloaded.
+func (m *Mapper) PrometheusLabelNames() []string {
+	return m.options.PrometheusLabels
+}
+
+// PrometheusLabels returns the Prometheus labels of the pod for the mapped fields selected by PrometheusLabelNames.
+// Labels without a value are set to the empty string.
+func (m *Mapper) PrometheusLabels(pod *corev1.Pod) prometheus.Labels {
+	values := m.Values(pod)
+	labels := make(prometheus.Labels, len(m.options.PrometheusLabels))
+
+	for _, name := range m.options.PrometheusLabels {
+		labels[name] = values[name]
+	}
+
+	return labels
+}
+
+// namespace looks up the namespace by name from the informer cache, it returns nil if not found.
+func (m *Mapper) namespace(name string) *corev1.Namespace {
+	if m.namespaces == nil {
+		log.Debug().Str("kube_namespace", name).Msg("Namespace informer is not running, ignoring namespace label mappings")
+
+		return nil
+	}
+
+	namespace, err := m.namespaces.Get(name)
+	if err != nil {
+		log.Debug().Err(err).Str("kube_namespace", name).Msg("Failed to get namespace from cache")
+
+		return nil
+	}
+
+	return namespace
+}
@@ -0,0 +1,97 @@
+package labelmapper
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/BackMarket-oss/kube-transition-metrics/internal/options"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+func newTestingOptions() *options.Options {
+	return &options.Options{
+		LabelMappings: []options.LabelMapping{
+			{Field: "team", Source: options.NamespaceLabelSource, Key: "example.com/team"},
+			{Field: "tier", Source: options.PodLabelSource, Key: "example.com/tier"},
+			{Field: "cost_center", Source: options.PodAnnotationSource, Key: "example.com/cost-center"},
+		},
+		PrometheusLabels: []string{"team", "cost_center"},
+	}
+}
+
+func newTestingNamespaceLister(t *testing.T, namespaces ...*corev1.Namespace) corev1listers.NamespaceLister {
+	t.Helper()
+
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	for _, namespace := range namespaces {
+		require.NoError(t, indexer.Add(namespace), "Failed to add namespace to indexer")
+	}
+
+	return corev1listers.NewNamespaceLister(indexer)
+}
+
+func newTestingPod() *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "test-pod",
+			Namespace:   "test-namespace",
+			Labels:      map[string]string{"example.com/tier": "frontend"},
+			Annotations: map[string]string{"example.com/cost-center": "cc-42"},
+		},
+	}
+}
+
+func TestMapperValues(t *testing.T) {
+	t.Parallel()
+
+	namespaces := newTestingNamespaceLister(t, &corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   "test-namespace",
+			Labels: map[string]string{"example.com/team": "test-team"},
+		},
+	})
+	mapper := NewMapper(newTestingOptions(), namespaces)
+
+	assert.Equal(t, map[string]string{
+		"team":        "test-team",
+		"tier":        "frontend",
+		"cost_center": "cc-42",
+	}, mapper.Values(newTestingPod()))
+}
+
+func TestMapperWithoutNamespaces(t *testing.T) {
+	t.Parallel()
+
+	mapper := NewMapper(newTestingOptions(), nil)
+
+	assert.Equal(t, map[string]string{
+		"tier":        "frontend",
+		"cost_center": "cc-42",
+	}, mapper.Values(newTestingPod()), "Expected namespace label mappings to be ignored")
+	assert.Equal(t, prometheus.Labels{
+		"team":        "",
+		"cost_center": "cc-42",
+	}, mapper.PrometheusLabels(newTestingPod()), "Expected missing Prometheus labels to be empty")
+}
+
+func TestMapperPodLabels(t *testing.T) {
+	t.Parallel()
+
+	mapper := NewMapper(newTestingOptions(), newTestingNamespaceLister(t))
+
+	buf := &bytes.Buffer{}
+	logger := zerolog.New(buf)
+	logger.Log().Func(mapper.PodLabels(newTestingPod())).Send()
+
+	record := map[string]any{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &record), "Failed to decode log record")
+	assert.Equal(t, map[string]any{"tier": "frontend", "cost_center": "cc-42"}, record)
+}
@@ -4,11 +4,15 @@ import (
 	"bytes"
 	"embed"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"io/fs"
 	"path/filepath"
+	"slices"
+	"sync"
 
+	"github.com/BackMarket-oss/kube-transition-metrics/internal/options"
 	"github.com/rs/zerolog/log"
 	jsonschema "github.com/santhosh-tekuri/jsonschema/v5"
 )
@@ -16,10 +20,77 @@ import (
 //go:embed schemas
 var schemaFiles embed.FS
 
+// metricRecordSchema is the path of the schema of the metric records.
+const metricRecordSchema = "schemas/kube_transition_metrics.schema.json"
+
 // NewValidationWriter compiles the JSONSchema a producers a Writer which validates the provided JSON documents against
 // the schema.
+// The fields of the current label mappings of the options are accepted as custom labels, the schema is recompiled
+// when they are changed by a reload.
 // It discards the data after validation.
-func NewValidationWriter() io.Writer {
+func NewValidationWriter(options *options.Options) io.Writer {
+	writer := &validationWriter{options: options}
+	writer.compile(options.Current())
+
+	return writer
+}
+
+type validationWriter struct {
+	options *options.Options
+
+	// mu protects the schema, which is recompiled when the label mappings are reloaded.
+	mu sync.Mutex
+	// compiled are the options the schema was compiled for.
+	compiled *options.Options
+	// fields are the fields of the label mappings accepted by the schema.
+	fields []string
+	schema *jsonschema.Schema
+}
+
+func (w *validationWriter) Write(data []byte) (int, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var document any
+
+	err := decoder.Decode(&document)
+	if err != nil {
+		err = fmt.Errorf("failed to decode document for schema validation: %w", err)
+	} else {
+		err = w.currentSchema().Validate(document)
+		if err != nil {
+			err = fmt.Errorf("document is not validated by schema: %w", err)
+		}
+	}
+
+	return len(data), err
+}
+
+// currentSchema returns the schema accepting the fields of the current label mappings.
+func (w *validationWriter) currentSchema() *jsonschema.Schema {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if current := w.options.Current(); current != w.compiled {
+		w.compile(current)
+	}
+
+	return w.schema
+}
+
+// compile compiles the schema accepting the fields of the label mappings of the options, unless they are unchanged.
+func (w *validationWriter) compile(options *options.Options) {
+	w.compiled = options
+
+	fields := make([]string, 0, len(options.LabelMappings))
+	for _, mapping := range options.LabelMappings {
+		fields = append(fields, mapping.Field)
+	}
+
+	if w.schema != nil && slices.Equal(fields, w.fields) {
+		return
+	}
+
 	compiler := jsonschema.NewCompiler()
 	//nolint:wrapcheck
 	err := fs.WalkDir(schemaFiles, ".", func(path string, dirEntry fs.DirEntry, err error) error {
@@ -31,44 +102,63 @@ func NewValidationWriter() io.Writer {
 			return nil
 		}
 
-		reader, err := schemaFiles.Open(path)
+		data, err := schemaFiles.ReadFile(path)
 		if err != nil {
 			return err
 		}
 
-		return compiler.AddResource(filepath.Clean(path), reader)
+		if path == metricRecordSchema {
+			data, err = withCustomLabels(data, fields)
+			if err != nil {
+				return err
+			}
+		}
+
+		return compiler.AddResource(filepath.Clean(path), bytes.NewReader(data))
 	})
 	if err != nil {
 		log.Panic().Err(err).Msg("Failed to add all jsonschema resources")
 	}
 
-	schema, err := compiler.Compile("schemas/kube_transition_metrics.schema.json")
+	schema, err := compiler.Compile(metricRecordSchema)
 	if err != nil {
 		log.Panic().Err(err).Msg("Failed to compile jsonschema")
 	}
 
-	return &validationWriter{schema: schema}
+	w.fields = fields
+	w.schema = schema
 }
 
-type validationWriter struct {
-	schema *jsonschema.Schema
-}
+// withCustomLabels adds the fields to the properties of kube_transition_metrics in the schema of the metric records,
+// as custom labels.
+func withCustomLabels(data []byte, fields []string) ([]byte, error) {
+	if len(fields) == 0 {
+		return data, nil
+	}
 
-func (w *validationWriter) Write(data []byte) (int, error) {
-	decoder := json.NewDecoder(bytes.NewReader(data))
-	decoder.UseNumber()
+	var document map[string]any
 
-	var document any
+	err := json.Unmarshal(data, &document)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode metric record schema: %w", err)
+	}
 
-	err := decoder.Decode(&document)
+	properties, _ := document["properties"].(map[string]any)
+	metricRecord, _ := properties["kube_transition_metrics"].(map[string]any)
+
+	metrics, ok := metricRecord["properties"].(map[string]any)
+	if !ok {
+		return nil, errors.New("metric record schema has no kube_transition_metrics properties")
+	}
+
+	for _, field := range fields {
+		metrics[field] = map[string]any{"$ref": "#/$defs/custom_label"}
+	}
+
+	data, err = json.Marshal(document)
 	if err != nil {
-		err = fmt.Errorf("failed to decode document for schema validation: %w", err)
-	} else {
-		err = w.schema.Validate(document)
-		if err != nil {
-			err = fmt.Errorf("document is not validated by schema: %w", err)
-		}
+		return nil, fmt.Errorf("failed to encode metric record schema: %w", err)
 	}
 
-	return len(data), err
+	return data, nil
 }
@@ -0,0 +1,43 @@
+package logging
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/BackMarket-oss/kube-transition-metrics/internal/options"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// labeledRecord returns a pod record with the custom field.
+func labeledRecord(field string) []byte {
+	return []byte(`{"time": "2023-08-28T00:00:00Z", "kube_transition_metrics": {"type": "pod", "partial": false, ` +
+		`"kube_namespace": "default", "pod_name": "web", "` + field + `": "value", ` +
+		`"pod": {"creation_timestamp": "2023-08-28T00:00:00Z"}}}`)
+}
+
+func TestValidationWriterCustomLabels(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path,
+		[]byte("labelMappings: [{field: team, source: podLabel, key: example.com/team}]\n"), 0o600))
+
+	opts := options.ParseArgs([]string{"--config", path})
+	writer := NewValidationWriter(opts)
+
+	_, err := writer.Write(labeledRecord("team"))
+	require.NoError(t, err, "Expected mapped field to be accepted")
+
+	_, err = writer.Write(labeledRecord("tier"))
+	require.Error(t, err, "Expected unmapped field to be rejected")
+
+	require.NoError(t, os.WriteFile(path,
+		[]byte("labelMappings: [{field: tier, source: podLabel, key: example.com/tier}]\n"), 0o600))
+	options.NewReloader(opts).Reload()
+
+	_, err = writer.Write(labeledRecord("tier"))
+	require.NoError(t, err, "Expected reloaded mapped field to be accepted")
+
+	_, err = writer.Write(labeledRecord("team"))
+	assert.Error(t, err, "Expected field no longer mapped to be rejected")
+}
@@ -0,0 +1,82 @@
+package options
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	flag "github.com/spf13/pflag"
+	"sigs.k8s.io/yaml"
+)
+
+// EnvironmentPrefix is the prefix of environment variables overriding options.
+// The variable name is derived from the flag name, e.g. --log-level is set by KUBE_TRANSITION_METRICS_LOG_LEVEL.
+const EnvironmentPrefix = "KUBE_TRANSITION_METRICS_"
+
+// parseConfigPath finds the configuration file path from the command-line arguments or the environment, ignoring all
+// the other flags.
+// It allows the configuration file to be loaded before the command-line flags are parsed, so that the flags take
+// precedence.
+func parseConfigPath(args []string) (string, error) {
+	var configPath string
+
+	flagSet := flag.NewFlagSet("config", flag.ContinueOnError)
+	flagSet.ParseErrorsAllowlist.UnknownFlags = true
+	flagSet.Usage = func() {}
+	flagSet.SetOutput(io.Discard)
+	flagSet.StringVar(&configPath, "config", "", "")
+
+	if err := flagSet.Parse(args); err != nil && !errors.Is(err, flag.ErrHelp) {
+		return "", fmt.Errorf("failed to parse command-line arguments: %w", err)
+	}
+
+	if !flagSet.Changed("config") {
+		configPath = os.Getenv(environmentName("config"))
+	}
+
+	return configPath, nil
+}
+
+// loadConfigFile reads the YAML or JSON configuration file into the options.
+// Fields absent from the file are left unchanged, and unknown fields are rejected.
+func loadConfigFile(path string, options *Options) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read configuration file: %w", err)
+	}
+
+	if err := yaml.UnmarshalStrict(data, options); err != nil {
+		return fmt.Errorf("failed to parse configuration file %s: %w", path, err)
+	}
+
+	return nil
+}
+
+// applyEnvironment sets the flags that were not set on the command-line from their environment variables.
+func applyEnvironment(flagSet *flag.FlagSet) error {
+	var errs []error
+
+	flagSet.VisitAll(func(f *flag.Flag) {
+		if f.Changed || f.Name == "config" {
+			return
+		}
+
+		value, ok := os.LookupEnv(environmentName(f.Name))
+		if !ok {
+			return
+		}
+
+		if err := flagSet.Set(f.Name, value); err != nil {
+			errs = append(errs, fmt.Errorf("invalid value for %s: %w", environmentName(f.Name), err))
+		}
+	})
+
+	return errors.Join(errs...)
+}
+
+// environmentName returns the name of the environment variable for the flag name.
+func environmentName(flagName string) string {
+	return EnvironmentPrefix + strings.ToUpper(strings.ReplaceAll(flagName, "-", "_"))
+}
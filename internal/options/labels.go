@@ -0,0 +1,270 @@
+package options
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// LabelSource is the Kubernetes metadata a [LabelMapping] reads its value from.
+type LabelSource string
+
+const (
+	// PodLabelSource reads the value from a label of the pod.
+	PodLabelSource LabelSource = "podLabel"
+	// PodAnnotationSource reads the value from an annotation of the pod.
+	PodAnnotationSource LabelSource = "podAnnotation"
+	// NamespaceLabelSource reads the value from a label of the pod's namespace.
+	NamespaceLabelSource LabelSource = "namespaceLabel"
+)
+
+// LabelMapping maps a Kubernetes label or annotation to a field of the metric records.
+type LabelMapping struct {
+	// Field is the name of the field in the metric records.
+	Field string `json:"field"`
+	// Source is the Kubernetes metadata the value is read from.
+	Source LabelSource `json:"source"`
+	// Key is the label or annotation key, e.g. example.com/team.
+	Key string `json:"key"`
+}
+
+// String formats the label mapping as accepted by the --label-mapping flag.
+func (m LabelMapping) String() string {
+	return fmt.Sprintf("%s=%s:%s", m.Field, m.Source, m.Key)
+}
+
+// PrometheusLabelLimit limits the cardinality of a Prometheus metric, see
+// [github.com/BackMarket-oss/kube-transition-metrics/internal/prommetrics.CardinalityGuard].
+type PrometheusLabelLimit struct {
+	// Metric is the name of the limited metric, which must be [LimitedMetric].
+	Metric string `json:"metric"`
+	// Label is the name of the label whose distinct values are limited, or empty to limit the distinct label
+	// combinations of the metric.
+	Label string `json:"label,omitempty"`
+	// Limit is the maximum number of distinct values of the label, or of distinct label combinations of the metric.
+	Limit int `json:"limit"`
+}
+
+// String formats the limit as accepted by the --prometheus-label-limit flag.
+func (l PrometheusLabelLimit) String() string {
+	if l.Label == "" {
+		return fmt.Sprintf("%s=%d", l.Metric, l.Limit)
+	}
+
+	return fmt.Sprintf("%s:%s=%d", l.Metric, l.Label, l.Limit)
+}
+
+// CardinalityLimits returns the limit of the distinct label combinations of the metric, which is 0 if they are not
+// limited, and the limits of the distinct values of its labels, see [PrometheusLabelLimit].
+func (o *Options) CardinalityLimits(metric string) (int, map[string]int) {
+	var series int
+
+	labels := make(map[string]int)
+
+	for _, limit := range o.PrometheusLabelLimits {
+		switch {
+		case limit.Metric != metric:
+		case limit.Label == "":
+			series = limit.Limit
+		default:
+			labels[limit.Label] = limit.Limit
+		}
+	}
+
+	return series, labels
+}
+
+// LimitedMetric is the only metric whose cardinality can be limited, as it is the only one labeled by the label
+// mappings.
+const LimitedMetric = "pod_transition_seconds"
+
+// fieldNameRegex matches the valid field names for label mappings, which must also be valid Prometheus label names.
+var fieldNameRegex = regexp.MustCompile(`^[a-z_][a-z0-9_]*$`)
+
+// metricNameRegex matches the valid Prometheus metric names.
+var metricNameRegex = regexp.MustCompile(`^[a-zA-Z_:][a-zA-Z0-9_:]*$`)
+
+// reservedFields are the fields of the metric records which cannot be overridden by label mappings.
+//
+//nolint:gochecknoglobals // This is a constant set of reserved field names.
+var reservedFields = map[string]struct{}{
+	"type": {}, "partial": {}, "kube_namespace": {}, "pod_name": {}, "kube_node": {}, "kube_qos": {},
+	"kube_priority_class": {}, "kube_runtime_class": {}, "kube_ownerref_kind": {}, "kube_ownerref_name": {},
+	"kube_cron_job": {}, "kube_cronjob": {}, "kube_rollout": {}, "kube_top_owner_kind": {}, "kube_top_owner_name": {},
+	"kube_daemon_set": {}, "kube_deployment": {}, "kube_job": {}, "kube_replica_set": {}, "kube_stateful_set": {},
+	"kube_service": {}, "kube_app_component": {}, "kube_app_instance": {}, "kube_app_managed_by": {},
+	"kube_app_name": {}, "kube_app_part_of": {}, "kube_app_version": {}, "container_name": {}, "short_image": {},
+	"image_name": {}, "image_tag": {}, "pod": {}, "container": {}, "image_pull": {}, "endpoint": {}, "rollout": {},
+	"job": {}, "ephemeral_container": {}, "resize": {}, "volume": {}, "summary": {}, "slo_violation": {},
+}
+
+// validateLabelMappings checks the label mappings and the Prometheus labels selected from them.
+func (o *Options) validateLabelMappings(invalid func(option, format string, args ...any)) {
+	fields := make(map[string]struct{}, len(o.LabelMappings))
+
+	for _, mapping := range o.LabelMappings {
+		switch {
+		case !fieldNameRegex.MatchString(mapping.Field):
+			invalid("labelMappings", "field %q must match %s", mapping.Field, fieldNameRegex)
+		case isReservedField(mapping.Field):
+			invalid("labelMappings", "field %q is reserved", mapping.Field)
+		}
+
+		if _, duplicate := fields[mapping.Field]; duplicate {
+			invalid("labelMappings", "field %q is mapped more than once", mapping.Field)
+		}
+
+		fields[mapping.Field] = struct{}{}
+
+		switch mapping.Source {
+		case PodLabelSource, PodAnnotationSource, NamespaceLabelSource:
+		default:
+			invalid("labelMappings", "source %q of field %q must be one of %q, %q or %q",
+				mapping.Source, mapping.Field, PodLabelSource, PodAnnotationSource, NamespaceLabelSource)
+		}
+
+		if mapping.Key == "" {
+			invalid("labelMappings", "key of field %q must not be empty", mapping.Field)
+		}
+	}
+
+	for _, label := range o.PrometheusLabels {
+		if _, ok := fields[label]; !ok {
+			invalid("prometheusLabels", "label %q is not a field of labelMappings", label)
+		}
+
+		// pod_transition_seconds is always labeled by transition.
+		if label == "transition" {
+			invalid("prometheusLabels", "label %q is reserved by pod_transition_seconds", label)
+		}
+	}
+
+	for _, limit := range o.PrometheusLabelLimits {
+		switch {
+		case !metricNameRegex.MatchString(limit.Metric):
+			invalid("prometheusLabelLimits", "metric %q of limit %s must match %s", limit.Metric, limit, metricNameRegex)
+		case limit.Metric != LimitedMetric:
+			invalid("prometheusLabelLimits", "metric %q of limit %s cannot be limited, only %s can", limit.Metric, limit,
+				LimitedMetric)
+		}
+
+		if limit.Label != "" && !fieldNameRegex.MatchString(limit.Label) {
+			invalid("prometheusLabelLimits", "label %q of limit %s must match %s", limit.Label, limit, fieldNameRegex)
+		}
+
+		if limit.Limit <= 0 {
+			invalid("prometheusLabelLimits", "limit %s must be positive", limit)
+		}
+	}
+}
+
+// isReservedField indicates if the field is reserved for the built-in fields of the metric records.
+func isReservedField(field string) bool {
+	_, ok := reservedFields[field]
+
+	return ok
+}
+
+// labelMappingsValue implements [flag.Value] for a list of [LabelMapping] in the form field=source:key.
+type labelMappingsValue struct {
+	mappings *[]LabelMapping
+	changed  bool
+}
+
+// String implements [flag.Value.String].
+// It returns an empty string when there are no mappings, so that no default is shown in the usage.
+func (v *labelMappingsValue) String() string {
+	if len(*v.mappings) == 0 {
+		return ""
+	}
+
+	formatted := make([]string, 0, len(*v.mappings))
+	for _, mapping := range *v.mappings {
+		formatted = append(formatted, mapping.String())
+	}
+
+	return "[" + strings.Join(formatted, ",") + "]"
+}
+
+// Set implements [flag.Value.Set].
+// The first use replaces the mappings from the configuration file, subsequent uses append to them.
+func (v *labelMappingsValue) Set(value string) error {
+	field, sourceKey, ok := strings.Cut(value, "=")
+	if !ok {
+		return fmt.Errorf("label mapping %q must be in the form field=source:key", value)
+	}
+
+	source, key, ok := strings.Cut(sourceKey, ":")
+	if !ok {
+		return fmt.Errorf("label mapping %q must be in the form field=source:key", value)
+	}
+
+	mapping := LabelMapping{Field: field, Source: LabelSource(source), Key: key}
+
+	if !v.changed {
+		*v.mappings = nil
+		v.changed = true
+	}
+
+	*v.mappings = append(*v.mappings, mapping)
+
+	return nil
+}
+
+// Type implements [flag.Value.Type].
+func (v *labelMappingsValue) Type() string {
+	return "field=source:key"
+}
+
+// prometheusLabelLimitsValue implements [flag.Value] for a list of [PrometheusLabelLimit] in the form metric=limit or
+// metric:label=limit.
+type prometheusLabelLimitsValue struct {
+	limits  *[]PrometheusLabelLimit
+	changed bool
+}
+
+// String implements [flag.Value.String].
+// It returns an empty string when there are no limits, so that no default is shown in the usage.
+func (v *prometheusLabelLimitsValue) String() string {
+	if len(*v.limits) == 0 {
+		return ""
+	}
+
+	formatted := make([]string, 0, len(*v.limits))
+	for _, limit := range *v.limits {
+		formatted = append(formatted, limit.String())
+	}
+
+	return "[" + strings.Join(formatted, ",") + "]"
+}
+
+// Set implements [flag.Value.Set].
+// The first use replaces the limits from the configuration file, subsequent uses append to them.
+func (v *prometheusLabelLimitsValue) Set(value string) error {
+	metricLabel, limitValue, ok := strings.Cut(value, "=")
+	if !ok {
+		return fmt.Errorf("prometheus label limit %q must be in the form metric=limit or metric:label=limit", value)
+	}
+
+	limit, err := strconv.Atoi(limitValue)
+	if err != nil {
+		return fmt.Errorf("prometheus label limit %q must be an integer: %w", value, err)
+	}
+
+	metric, label, _ := strings.Cut(metricLabel, ":")
+
+	if !v.changed {
+		*v.limits = nil
+		v.changed = true
+	}
+
+	*v.limits = append(*v.limits, PrometheusLabelLimit{Metric: metric, Label: label, Limit: limit})
+
+	return nil
+}
+
+// Type implements [flag.Value.Type].
+func (v *prometheusLabelLimitsValue) Type() string {
+	return "metric[:label]=limit"
+}
@@ -1,76 +1,311 @@
 package options
 
 import (
+	"errors"
+	"fmt"
 	"log"
+	"os"
+	"slices"
+	"strings"
+	"sync/atomic"
 
 	"github.com/rs/zerolog"
 	flag "github.com/spf13/pflag"
 )
 
 // Options contains the options for the controller.
+//
+// Options are loaded from the configuration file, environment variables and command-line flags, in increasing order of
+// precedence.
+// The JSON field names are used as keys in the configuration file.
 type Options struct {
-	// ListenAddress is the host and port for the HTTP server delivering prometheus metrics and pprof profiling.
-	ListenAddress string
+	// ConfigPath is the path to the YAML or JSON configuration file.
+	ConfigPath string `json:"-"`
+	// ConfigReloadInterval is the interval (in seconds) between checks for modifications of the configuration file,
+	// polling is disabled when 0.
+	ConfigReloadInterval float64 `json:"configReloadInterval"`
+	// ListenAddress is the host and port for the HTTP server delivering prometheus metrics and health checks.
+	ListenAddress string `json:"listenAddress"`
+	// PprofListenAddress is the host and port for the HTTP server delivering pprof profiling, disabled when empty.
+	PprofListenAddress string `json:"pprofListenAddress"`
+	// HTTPReadTimeout is the maximum duration (in seconds) for reading an entire request, including the body.
+	HTTPReadTimeout float64 `json:"httpReadTimeout"`
+	// HTTPWriteTimeout is the maximum duration (in seconds) before timing out writes of the response.
+	HTTPWriteTimeout float64 `json:"httpWriteTimeout"`
+	// TLSCertFile is the path to the PEM encoded TLS certificate, TLS is disabled when empty.
+	TLSCertFile string `json:"tlsCertFile"`
+	// TLSKeyFile is the path to the PEM encoded TLS private key matching TLSCertFile.
+	TLSKeyFile string `json:"tlsKeyFile"`
+	// ShutdownTimeout is the maximum duration (in seconds) to wait for in-flight HTTP requests to complete and the
+	// event loops to drain when shutting down.
+	ShutdownTimeout float64 `json:"shutdownTimeout"`
 	// KubeconfigPath is the path to the kube configuration file.
-	KubeconfigPath string
+	KubeconfigPath string `json:"kubeconfigPath"`
 	// ImagePullCancelDelay is the delay before canceling an image pull routine to ensure all events related to the pod
 	// have been processed.
-	ImagePullCancelDelay float64
+	ImagePullCancelDelay float64 `json:"imagePullCancelDelay"`
 	// KubeWatchTimeout is the timeout for the Kubernetes Watch API.
-	KubeWatchTimeout int64
+	KubeWatchTimeout int64 `json:"kubeWatchTimeout"`
 	// KubeWatchMaxEvents is the maximum number of events to receive from the Kubernetes Watch API per response.
-	KubeWatchMaxEvents int64
+	KubeWatchMaxEvents int64 `json:"kubeWatchMaxEvents"`
 	// StatisticEventQueueLength is the maximum number of queued statistic events.
 	//
 	// TODO(Izzette): consider splitting into PodStatisticEventQueueLength and ImagePullStatisticEventQueueLength
-	StatisticEventQueueLength int
+	StatisticEventQueueLength int `json:"statisticEventQueueLength"`
 	// EmitPartialStatistics enables emitting statistics for pods that have not yet become Ready and image pulls that have
 	// not yet completed.
-	EmitPartialStatistics bool
+	EmitPartialStatistics bool `json:"emitPartial"`
+	// Namespaces is the list of namespaces for which pods are tracked, all namespaces are tracked when empty.
+	Namespaces []string `json:"namespaces"`
+	// ExcludeNamespaces is the list of namespaces for which pods are never tracked.
+	ExcludeNamespaces []string `json:"excludeNamespaces"`
+	// ResolveOwners enables resolving the full owner chain of pods, e.g. the Deployment of a ReplicaSet or the CronJob of
+	// a Job, from informer caches.
+	ResolveOwners bool `json:"resolveOwners"`
+	// ResolveServices enables resolving the Services selecting pods, and reporting when the pod addresses first appear as
+	// ready in EndpointSlices, from informer caches.
+	ResolveServices bool `json:"resolveServices"`
+	// TrackRollouts enables tracking the rollouts of Deployments, StatefulSets and DaemonSets, and reporting the
+	// aggregated statistics of the pods of each rollout. It requires ResolveOwners.
+	TrackRollouts bool `json:"trackRollouts"`
+	// TrackJobs enables tracking the Jobs, and reporting the latency of each run from the time its CronJob scheduled it
+	// to its completion.
+	TrackJobs bool `json:"trackJobs"`
+	// TrackVolumes enables tracking the provisioning, attach and mount of the volumes of pods from Events.
+	TrackVolumes bool `json:"trackVolumes"`
+	// SummaryInterval is the interval (in seconds) between the summary records of the durations of the pods per
+	// namespace and owner over rolling windows, summaries are disabled when 0.
+	SummaryInterval float64 `json:"summaryInterval"`
+	// ServeSummaries enables serving the latest summary records over HTTP. It requires SummaryInterval.
+	ServeSummaries bool `json:"serveSummaries"`
+	// SlowPodEventThreshold is the creation to ready duration (in seconds) above which a Warning Event is created on the
+	// pod, disabled when 0.
+	SlowPodEventThreshold float64 `json:"slowPodEventThreshold"`
+	// SlowImagePullEventThreshold is the image pull duration (in seconds) above which a Warning Event is created on the
+	// pod, disabled when 0.
+	SlowImagePullEventThreshold float64 `json:"slowImagePullEventThreshold"`
+	// SlowPodOwnerEvents enables creating the Warning Events of the slow pods on their top-level owners too. It requires
+	// ResolveOwners and SlowPodEventThreshold.
+	SlowPodOwnerEvents bool `json:"slowPodOwnerEvents"`
+	// SlowPodEventRate is the maximum rate (per second) of the Warning Events of the slow pods and image pulls.
+	SlowPodEventRate float64 `json:"slowPodEventRate"`
+	// WorkloadReportInterval is the interval (in seconds) between the updates of the WorkloadStartupReport custom
+	// resources of the Deployments and StatefulSets, reports are disabled when 0. It requires ResolveOwners.
+	WorkloadReportInterval float64 `json:"workloadReportInterval"`
+	// WorkloadReportStuckThreshold is the duration (in seconds) after its creation after which a pod which is not yet
+	// Ready is reported as stuck in the WorkloadStartupReport of its workload.
+	WorkloadReportStuckThreshold float64 `json:"workloadReportStuckThreshold"`
+	// PodAnnotationNamespaceLabel is the label of the namespaces whose pods are annotated with their startup durations
+	// once Ready, a namespace opts in when the label is set to true. Pod annotations are disabled when empty.
+	PodAnnotationNamespaceLabel string `json:"podAnnotationNamespaceLabel"`
+	// PodAnnotationRate is the maximum rate (per second) of the patches of the pod annotations.
+	PodAnnotationRate float64 `json:"podAnnotationRate"`
+	// LabelMappings maps pod labels, pod annotations and namespace labels to additional fields of the metric records.
+	LabelMappings []LabelMapping `json:"labelMappings"`
+	// PrometheusLabels are the fields of LabelMappings which are also added as labels to the Prometheus pod transition
+	// metrics.
+	PrometheusLabels []string `json:"prometheusLabels"`
+	// PrometheusLabelLimits limits the cardinality of the Prometheus metrics, the label values and combinations
+	// exceeding the limits are folded into a single overflow value.
+	PrometheusLabelLimits []PrometheusLabelLimit `json:"prometheusLabelLimits"`
+	// SLOs are the latency service level objectives evaluated against the complete pod and image pull statistics.
+	// They can only be set in the configuration file.
+	SLOs []SLO `json:"slos"`
 	// LogLevel is the global logging level.
-	LogLevel zerolog.Level
+	LogLevel zerolog.Level `json:"logLevel"`
+	// RecordPath is the path to the file the Pod and Event watch events are recorded to, for replaying them offline.
+	// Recording is disabled when empty.
+	RecordPath string `json:"recordPath"`
+
+	// current points to the latest reloaded options, shared by all the copies of the options.
+	// It is nil unless the options were created by [Parse].
+	current *atomic.Pointer[Options]
+	// args are the command-line arguments the options were parsed from, they are parsed again on reload.
+	args []string
+	// positional are the command-line arguments remaining after the flags.
+	positional []string
+}
+
+// Parse parses the options from the command-line arguments of the process and returns them as a pointer to an Options
+// struct.
+// The process exits if the options are invalid.
+func Parse() *Options {
+	return ParseArgs(os.Args[1:])
+}
+
+// ParseArgs parses the options from the provided command-line arguments, e.g. the arguments following a subcommand,
+// and returns them as a pointer to an Options struct.
+// The process exits if the options are invalid.
+func ParseArgs(args []string) *Options {
+	options, err := parse(args)
+	if errors.Is(err, flag.ErrHelp) {
+		os.Exit(0)
+	} else if err != nil {
+		log.Fatalf("Invalid options: %v\n", err)
+	}
+
+	options.args = args
+	options.current = &atomic.Pointer[Options]{}
+	options.current.Store(options)
+
+	return options
+}
+
+// Current returns the latest version of the options, including any hot-reloaded settings.
+// Long-lived components should call Current each time they need to read a setting that may be reloaded, instead of
+// keeping the returned value.
+func (o *Options) Current() *Options {
+	if o.current == nil {
+		return o
+	}
+
+	return o.current.Load()
+}
+
+// Args returns the command-line arguments remaining after the flags, e.g. the file to replay.
+func (o *Options) Args() []string {
+	return o.positional
+}
+
+// NamespaceLabelMappings indicates if any of the label mappings read the labels of namespaces.
+func (o *Options) NamespaceLabelMappings() bool {
+	for _, mapping := range o.LabelMappings {
+		if mapping.Source == NamespaceLabelSource {
+			return true
+		}
+	}
+
+	return false
 }
 
-// Parse parses the options and returns them as a pointer to an Options struct.
+// NamespaceIncluded indicates if pods in the namespace should be tracked according to the Namespaces and
+// ExcludeNamespaces filters.
+func (o *Options) NamespaceIncluded(namespace string) bool {
+	if slices.Contains(o.ExcludeNamespaces, namespace) {
+		return false
+	}
+
+	return len(o.Namespaces) == 0 || slices.Contains(o.Namespaces, namespace)
+}
+
+// parse parses the options from the configuration file, environment variables and command-line arguments.
+func parse(args []string) (*Options, error) {
+	configPath, err := parseConfigPath(args)
+	if err != nil {
+		return nil, err
+	}
+
+	options := &Options{ConfigPath: configPath}
+	flagSet := newFlagSet(options)
+
+	if configPath != "" {
+		if err := loadConfigFile(configPath, options); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := flagSet.Parse(args); err != nil {
+		return nil, fmt.Errorf("failed to parse command-line arguments: %w", err)
+	}
+
+	options.positional = flagSet.Args()
+
+	if err := applyEnvironment(flagSet); err != nil {
+		return nil, err
+	}
+
+	if err := options.Validate(); err != nil {
+		return nil, err
+	}
+
+	return options, nil
+}
+
+// newFlagSet creates the command-line flags, setting their default values on the provided options.
 //
 //nolint:funlen
-func Parse() *Options {
-	options := Options{}
-	flag.StringVar(
+func newFlagSet(options *Options) *flag.FlagSet {
+	flagSet := flag.NewFlagSet("kube-transition-metrics", flag.ContinueOnError)
+	flagSet.StringVar(
+		&options.ConfigPath,
+		"config",
+		options.ConfigPath,
+		"The path to a YAML or JSON configuration file. Command-line flags and environment variables override the "+
+			"values in the configuration file. Non-structural settings are reloaded when the file changes or on SIGHUP.")
+	flagSet.Float64Var(
+		&options.ConfigReloadInterval,
+		"config-reload-interval",
+		10,
+		"The interval (in seconds) between checks for modifications of the configuration file. Polling is disabled "+
+			"when 0, the configuration is then only reloaded on SIGHUP.")
+	flagSet.StringVar(
 		&options.ListenAddress,
 		"listen-address",
 		"127.0.0.1:8080",
 		"The host and port for HTTP server delivering prometheus metrics over "+
-			"`/metrics` and pprof profiling over `/debug/pprof` endpoints.")
-	flag.StringVar(
+			"`/metrics`, liveness over `/healthz` and readiness over `/readyz` endpoints.")
+	flagSet.StringVar(
+		&options.PprofListenAddress,
+		"pprof-listen-address",
+		"",
+		"The host and port for a separate HTTP server delivering pprof profiling over "+
+			"`/debug/pprof` endpoints. The pprof server is disabled when empty.")
+	flagSet.Float64Var(
+		&options.HTTPReadTimeout,
+		"http-read-timeout",
+		10,
+		"The maximum duration (in seconds) for reading an entire HTTP request, including the body.")
+	flagSet.Float64Var(
+		&options.HTTPWriteTimeout,
+		"http-write-timeout",
+		30,
+		"The maximum duration (in seconds) before timing out writes of the HTTP response.")
+	flagSet.StringVar(
+		&options.TLSCertFile,
+		"tls-cert-file",
+		"",
+		"The path to the PEM encoded TLS certificate used to serve HTTPS. The certificate is reloaded when the file "+
+			"changes. TLS is disabled when empty.")
+	flagSet.StringVar(
+		&options.TLSKeyFile,
+		"tls-key-file",
+		"",
+		"The path to the PEM encoded TLS private key matching --tls-cert-file.")
+	flagSet.Float64Var(
+		&options.ShutdownTimeout,
+		"shutdown-timeout",
+		30,
+		"The maximum duration (in seconds) to wait for in-flight HTTP requests to complete and the statistic event "+
+			"queues to drain on SIGTERM.")
+	flagSet.StringVar(
 		&options.KubeconfigPath,
 		"kubeconfig-path",
 		"",
 		"The path to the kube configuration file, if it's not set the value of "+
 			"`$KUBECONFIG` will be used, if that's not set `$HOME/.kube/config` will "+
 			"be used.")
-	flag.Float64Var(
+	flagSet.Float64Var(
 		&options.ImagePullCancelDelay,
 		"image-pull-cancel-delay",
 		3,
 		"The delay (in seconds) before canceling an image pull collector routine to ensure all events related to the pod "+
 			"have been processed. (ADVANCED)")
-	flag.Int64Var(
+	flagSet.Int64Var(
 		&options.KubeWatchTimeout,
 		"kube-watch-timeout",
 		60,
 		"The Kubernetes Watch API timeout (ADVANCED)")
-	flag.Int64Var(
+	flagSet.Int64Var(
 		&options.KubeWatchMaxEvents,
 		"kube-watch-max-events",
 		100,
 		"The Kubernetes Watch maximum events per response (ADVANCED)")
-	flag.IntVar(
+	flagSet.IntVar(
 		&options.StatisticEventQueueLength,
 		"statistic-event-queue-length",
 		1000,
 		"The maximum number of queued statistic events (ADVANCED)")
-	flag.BoolVar(
+	flagSet.BoolVar(
 		&options.EmitPartialStatistics,
 		"emit-partial",
 		false,
@@ -78,23 +313,174 @@ func Parse() *Options {
 			"set to false, pods that never become Ready and image pulls that never complete will not be included in the "+
 			"statistics. Partial statistics will always be emitted for pods that are deleted before they become Ready. When "+
 			"set to true, multiple statistics will be emitted for the same pod/image pull. (ADVANCED)")
+	flagSet.StringSliceVar(
+		&options.Namespaces,
+		"namespaces",
+		nil,
+		"The comma-separated list of namespaces for which pods are tracked. All namespaces are tracked when empty.")
+	flagSet.StringSliceVar(
+		&options.ExcludeNamespaces,
+		"exclude-namespaces",
+		nil,
+		"The comma-separated list of namespaces for which pods are never tracked.")
+	flagSet.BoolVar(
+		&options.ResolveOwners,
+		"resolve-owners",
+		true,
+		"Resolve the full owner chain of pods to add the kube_deployment, kube_cron_job, kube_rollout, "+
+			"kube_top_owner_kind and kube_top_owner_name fields. Requires permissions to list and watch ReplicaSets, Jobs "+
+			"and Argo Rollouts, which are cached in memory.")
+	flagSet.BoolVar(
+		&options.ResolveServices,
+		"resolve-services",
+		false,
+		"Resolve the Services selecting pods to add the kube_service field, and emit endpoint statistics when the pod "+
+			"addresses first appear as ready in EndpointSlices. Requires permissions to list and watch Services and "+
+			"EndpointSlices, which are cached in memory.")
+	flagSet.BoolVar(
+		&options.TrackRollouts,
+		"track-rollouts",
+		false,
+		"Track the rollouts of Deployments, StatefulSets and DaemonSets, and emit rollout statistics aggregating the "+
+			"pods of each rollout when it completes. Requires --resolve-owners, and permissions to list and watch "+
+			"Deployments, StatefulSets and DaemonSets, which are cached in memory.")
+	flagSet.BoolVar(
+		&options.TrackJobs,
+		"track-jobs",
+		false,
+		"Track the Jobs, and emit job statistics with the latency from the CronJob schedule time to the Job creation, "+
+			"its first pod running and its completion, along with its pods and retries. Requires permissions to list "+
+			"and watch Jobs, which are cached in memory.")
+	flagSet.BoolVar(
+		&options.TrackVolumes,
+		"track-volumes",
+		false,
+		"Track the provisioning, attach and mount of the volumes of pods backed by PersistentVolumeClaims from the Events "+
+			"of the pods and claims, and emit volume statistics along with the pod statistics. The Events of all the "+
+			"PersistentVolumeClaims are cached in memory.")
+	flagSet.Float64Var(
+		&options.SummaryInterval,
+		"summary-interval",
+		0,
+		"The interval (in seconds) between the summary statistics of the durations of the pod, container and image "+
+			"pull statistics per namespace and owner, over rolling windows of 1m, 5m and 1h. Summaries are disabled "+
+			"when 0.")
+	flagSet.BoolVar(
+		&options.ServeSummaries,
+		"serve-summaries",
+		false,
+		"Serve the latest summary statistics as a JSON array over /summaries on --listen-address. Requires "+
+			"--summary-interval.")
+	flagSet.Float64Var(
+		&options.SlowPodEventThreshold,
+		"slow-pod-event-threshold",
+		0,
+		"The creation to ready duration (in seconds) above which a Warning Event with a breakdown of the critical path "+
+			"is created on the pod. Requires permissions to create and patch events. Disabled when 0.")
+	flagSet.Float64Var(
+		&options.SlowImagePullEventThreshold,
+		"slow-image-pull-event-threshold",
+		0,
+		"The image pull duration (in seconds) above which a Warning Event is created on the pod. Requires permissions "+
+			"to create and patch events. Disabled when 0.")
+	flagSet.BoolVar(
+		&options.SlowPodOwnerEvents,
+		"slow-pod-owner-events",
+		false,
+		"Also create the Warning Events of the slow pods on their top-level owners, e.g. their Deployment. Requires "+
+			"--resolve-owners and --slow-pod-event-threshold.")
+	flagSet.Float64Var(
+		&options.SlowPodEventRate,
+		"slow-pod-event-rate",
+		1,
+		"The maximum rate (per second) of the Warning Events of slow pods and image pulls, after a burst of 10. The "+
+			"Events are also deduplicated per object over 10 minutes.")
+	flagSet.Float64Var(
+		&options.WorkloadReportInterval,
+		"workload-report-interval",
+		0,
+		"The interval (in seconds) between the updates of the WorkloadStartupReport custom resources of the "+
+			"Deployments and StatefulSets, with their recent pod startup percentiles, last rollout, slowest images and "+
+			"stuck pods. Requires --resolve-owners, the WorkloadStartupReport CRD, and permissions to get, create and "+
+			"update WorkloadStartupReports and their status. Disabled when 0.")
+	flagSet.Float64Var(
+		&options.WorkloadReportStuckThreshold,
+		"workload-report-stuck-threshold",
+		300,
+		"The duration (in seconds) after its creation after which a pod which is not yet Ready is reported as stuck in "+
+			"the WorkloadStartupReport of its workload.")
+	flagSet.StringVar(
+		&options.PodAnnotationNamespaceLabel,
+		"pod-annotation-namespace-label",
+		"",
+		"The label of the namespaces whose pods are annotated with the compact JSON of their startup durations under "+
+			"kube-transition-metrics/startup once Ready, e.g. example.com/annotate-startup. A namespace opts in when "+
+			"the label is set to true. Requires permissions to patch pods. Disabled when empty.")
+	flagSet.Float64Var(
+		&options.PodAnnotationRate,
+		"pod-annotation-rate",
+		5,
+		"The maximum rate (per second) of the patches of the pod annotations, after a burst of 10.")
+	flagSet.Var(
+		&labelMappingsValue{mappings: &options.LabelMappings},
+		"label-mapping",
+		"Map a pod label, pod annotation or namespace label to an additional field of the metric records, in the form "+
+			"field=source:key where source is one of podLabel, podAnnotation or namespaceLabel, e.g. "+
+			"team=namespaceLabel:example.com/team. Can be repeated.")
+	flagSet.StringSliceVar(
+		&options.PrometheusLabels,
+		"prometheus-labels",
+		nil,
+		"The comma-separated list of --label-mapping fields to also add as labels of the pod_transition_seconds "+
+			"Prometheus metric. Beware of the cardinality of the selected labels.")
+	flagSet.Var(
+		&prometheusLabelLimitsValue{limits: &options.PrometheusLabelLimits},
+		"prometheus-label-limit",
+		"Limit the cardinality of a Prometheus metric, in the form metric=limit to limit its distinct label "+
+			"combinations, or metric:label=limit to limit the distinct values of a label, e.g. "+
+			"pod_transition_seconds:team=50. The values exceeding the limit are folded into __other__. Only "+
+			"pod_transition_seconds, which is labeled by the label mappings, can be limited. Can be repeated.")
+
+	flagSet.StringVar(
+		&options.RecordPath,
+		"record-path",
+		"",
+		"The path to a file to record the Pod and Event watch events to, as newline-delimited JSON which can be "+
+			"replayed offline with the replay subcommand. Recording is disabled when empty.")
 
-	logLevel := flag.String(
+	options.LogLevel = zerolog.InfoLevel
+	flagSet.Var(
+		(*levelValue)(&options.LogLevel),
 		"log-level",
-		"INFO",
 		`The global logging level, one of "trace", "debug", "info", "warn", `+
 			`"error", "fatal", "panic", "disabled", or "" (empty string). This option's`+
 			`values are case-insensitive. Setting a value of "disabled" will result in`+
 			`no metrics being emitted.`)
 
-	flag.Parse()
+	return flagSet
+}
+
+// levelValue implements [flag.Value] for [zerolog.Level].
+type levelValue zerolog.Level
+
+// String implements [flag.Value.String].
+func (l *levelValue) String() string {
+	return strings.ToUpper(zerolog.Level(*l).String())
+}
 
-	logLevelParsed, err := zerolog.ParseLevel(*logLevel)
+// Set implements [flag.Value.Set].
+func (l *levelValue) Set(value string) error {
+	level, err := zerolog.ParseLevel(value)
 	if err != nil {
-		log.Fatalf("Invalid value for --log-level (%s): %q\n", *logLevel, err)
-	} else {
-		options.LogLevel = logLevelParsed
+		return fmt.Errorf("invalid log level %q: %w", value, err)
 	}
 
-	return &options
+	*l = levelValue(level)
+
+	return nil
+}
+
+// Type implements [flag.Value.Type].
+func (l *levelValue) Type() string {
+	return "string"
 }
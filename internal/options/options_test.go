@@ -0,0 +1,413 @@
+package options
+
+import (
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600), "Failed to write configuration file")
+
+	return path
+}
+
+func TestParseDefaults(t *testing.T) {
+	options, err := parse([]string{})
+	require.NoError(t, err, "Expected default options to be valid")
+
+	assert.Equal(t, "127.0.0.1:8080", options.ListenAddress)
+	assert.Equal(t, zerolog.InfoLevel, options.LogLevel)
+	assert.Equal(t, 1000, options.StatisticEventQueueLength)
+	assert.False(t, options.EmitPartialStatistics)
+}
+
+func TestParsePrecedence(t *testing.T) {
+	path := writeConfig(t, `
+listenAddress: 0.0.0.0:9090
+logLevel: debug
+emitPartial: true
+statisticEventQueueLength: 10
+namespaces: [from-file]
+`)
+
+	t.Setenv("KUBE_TRANSITION_METRICS_LOG_LEVEL", "warn")
+	t.Setenv("KUBE_TRANSITION_METRICS_STATISTIC_EVENT_QUEUE_LENGTH", "20")
+
+	options, err := parse([]string{"--config", path, "--statistic-event-queue-length=30", "--namespaces=from-flag"})
+	require.NoError(t, err, "Expected options to be valid")
+
+	assert.Equal(t, path, options.ConfigPath)
+	assert.Equal(t, "0.0.0.0:9090", options.ListenAddress, "Expected file to override default")
+	assert.True(t, options.EmitPartialStatistics, "Expected file to override default")
+	assert.Equal(t, zerolog.WarnLevel, options.LogLevel, "Expected environment to override file")
+	assert.Equal(t, 30, options.StatisticEventQueueLength, "Expected flag to override environment and file")
+	assert.Equal(t, []string{"from-flag"}, options.Namespaces, "Expected flag to replace file list")
+}
+
+func TestParseConfigFromEnvironment(t *testing.T) {
+	path := writeConfig(t, `{"listenAddress": "0.0.0.0:9090"}`)
+	t.Setenv("KUBE_TRANSITION_METRICS_CONFIG", path)
+
+	options, err := parse([]string{})
+	require.NoError(t, err, "Expected options to be valid")
+	assert.Equal(t, "0.0.0.0:9090", options.ListenAddress, "Expected JSON configuration file to be loaded")
+}
+
+func TestParseUnknownConfigField(t *testing.T) {
+	path := writeConfig(t, "listenAdress: 0.0.0.0:9090\n")
+
+	_, err := parse([]string{"--config", path})
+	require.Error(t, err, "Expected unknown field to be rejected")
+	assert.Contains(t, err.Error(), "listenAdress")
+}
+
+func TestValidate(t *testing.T) {
+	options, err := parse([]string{})
+	require.NoError(t, err)
+
+	options.ListenAddress = "not-an-address"
+	options.TLSCertFile = "/tls.crt"
+	options.KubeWatchTimeout = 0
+	options.Namespaces = []string{"both"}
+	options.ExcludeNamespaces = []string{"both"}
+	options.ResolveOwners = false
+	options.TrackRollouts = true
+	options.ServeSummaries = true
+
+	err = options.Validate()
+	require.Error(t, err, "Expected invalid options to be rejected")
+
+	for _, option := range []string{
+		"listenAddress", "tlsCertFile", "kubeWatchTimeout", "namespaces", "trackRollouts", "serveSummaries",
+	} {
+		assert.Contains(t, err.Error(), option+": ", "Expected error to name the invalid option")
+	}
+}
+
+func TestNamespaceIncluded(t *testing.T) {
+	t.Parallel()
+
+	options := &Options{}
+	assert.True(t, options.NamespaceIncluded("any"), "Expected all namespaces to be included by default")
+
+	options.ExcludeNamespaces = []string{"kube-system"}
+	assert.False(t, options.NamespaceIncluded("kube-system"))
+	assert.True(t, options.NamespaceIncluded("default"))
+
+	options.Namespaces = []string{"team-a"}
+	assert.True(t, options.NamespaceIncluded("team-a"))
+	assert.False(t, options.NamespaceIncluded("default"))
+}
+
+func TestReload(t *testing.T) {
+	path := writeConfig(t, "logLevel: info\nstatisticEventQueueLength: 10\n")
+	args := []string{"--config", path}
+
+	options, err := parse(args)
+	require.NoError(t, err)
+
+	options.args = args
+	options.current = &atomic.Pointer[Options]{}
+	options.current.Store(options)
+
+	var reloaded *Options
+
+	reloader := NewReloader(options, func(next *Options) { reloaded = next })
+
+	require.NoError(t, os.WriteFile(path, []byte("logLevel: debug\nemitPartial: true\nstatisticEventQueueLength: 20\n"), 0o600))
+	reloader.Reload()
+
+	require.NotNil(t, reloaded, "Expected reload callback to be called")
+	assert.Same(t, reloaded, options.Current(), "Expected current options to be the reloaded options")
+	assert.Equal(t, zerolog.DebugLevel, options.Current().LogLevel, "Expected log level to be reloaded")
+	assert.True(t, options.Current().EmitPartialStatistics, "Expected emit partial to be reloaded")
+	assert.Equal(t, 10, options.Current().StatisticEventQueueLength, "Expected structural option to be unchanged")
+	assert.Equal(t, zerolog.InfoLevel, options.LogLevel, "Expected original options to be unchanged")
+
+	require.NoError(t, os.WriteFile(path, []byte("logLevel: invalid\n"), 0o600))
+	reloader.Reload()
+	assert.Equal(t, zerolog.DebugLevel, options.Current().LogLevel, "Expected invalid configuration to be ignored")
+}
+
+func TestReloadNamespaceLabelMappings(t *testing.T) {
+	path := writeConfig(t, "labelMappings: [{field: tier, source: podLabel, key: example.com/tier}]\n")
+	args := []string{"--config", path}
+
+	options, err := parse(args)
+	require.NoError(t, err)
+
+	options.args = args
+	options.current = &atomic.Pointer[Options]{}
+	options.current.Store(options)
+
+	reloader := NewReloader(options)
+
+	require.NoError(t, os.WriteFile(path, []byte(`labelMappings:
+  - {field: tier, source: podLabel, key: example.com/tier}
+  - {field: team, source: namespaceLabel, key: example.com/team}
+`), 0o600))
+	reloader.Reload()
+	assert.Equal(t, options.LabelMappings, options.Current().LabelMappings,
+		"Expected namespace label mappings to require a restart")
+
+	require.NoError(t, os.WriteFile(path, []byte(`labelMappings:
+  - {field: cost_center, source: podAnnotation, key: example.com/cost-center}
+`), 0o600))
+	reloader.Reload()
+	require.Len(t, options.Current().LabelMappings, 1)
+	assert.Equal(t, "cost_center", options.Current().LabelMappings[0].Field, "Expected label mappings to be reloaded")
+}
+
+func TestParseLabelMappings(t *testing.T) {
+	path := writeConfig(t, `
+labelMappings:
+  - field: team
+    source: namespaceLabel
+    key: example.com/team
+prometheusLabels: [team]
+`)
+
+	options, err := parse([]string{"--config", path})
+	require.NoError(t, err, "Expected options to be valid")
+	assert.Equal(t, []LabelMapping{
+		{Field: "team", Source: NamespaceLabelSource, Key: "example.com/team"},
+	}, options.LabelMappings)
+	assert.True(t, options.NamespaceLabelMappings())
+
+	options, err = parse([]string{
+		"--config", path,
+		"--label-mapping=tier=podLabel:example.com/tier",
+		"--label-mapping=cost_center=podAnnotation:example.com/cost-center",
+		"--prometheus-labels=tier",
+	})
+	require.NoError(t, err, "Expected options to be valid")
+	assert.Equal(t, []LabelMapping{
+		{Field: "tier", Source: PodLabelSource, Key: "example.com/tier"},
+		{Field: "cost_center", Source: PodAnnotationSource, Key: "example.com/cost-center"},
+	}, options.LabelMappings, "Expected flags to replace file mappings")
+	assert.False(t, options.NamespaceLabelMappings())
+
+	_, err = parse([]string{"--label-mapping=team"})
+	require.Error(t, err, "Expected malformed label mapping to be rejected")
+}
+
+func TestValidateLabelMappings(t *testing.T) {
+	t.Parallel()
+
+	options := &Options{
+		ListenAddress:      "127.0.0.1:8080",
+		KubeWatchTimeout:   1,
+		KubeWatchMaxEvents: 1,
+		LabelMappings: []LabelMapping{
+			{Field: "Team", Source: PodLabelSource, Key: "team"},
+			{Field: "kube_namespace", Source: PodLabelSource, Key: "namespace"},
+			{Field: "tier", Source: "nodeLabel", Key: "tier"},
+			{Field: "tier", Source: PodLabelSource, Key: ""},
+			{Field: "transition", Source: PodLabelSource, Key: "example.com/transition"},
+		},
+		PrometheusLabels: []string{"unknown", "transition"},
+	}
+
+	err := options.Validate()
+	require.Error(t, err, "Expected invalid label mappings to be rejected")
+
+	for _, message := range []string{
+		`field "Team" must match`,
+		`field "kube_namespace" is reserved`,
+		`source "nodeLabel" of field "tier"`,
+		`field "tier" is mapped more than once`,
+		`key of field "tier" must not be empty`,
+		`prometheusLabels: label "unknown" is not a field of labelMappings`,
+		`prometheusLabels: label "transition" is reserved by pod_transition_seconds`,
+	} {
+		assert.Contains(t, err.Error(), message)
+	}
+}
+
+func TestParsePrometheusLabelLimits(t *testing.T) {
+	path := writeConfig(t, `
+prometheusLabelLimits:
+  - metric: pod_transition_seconds
+    limit: 1000
+`)
+
+	options, err := parse([]string{"--config", path})
+	require.NoError(t, err, "Expected options to be valid")
+	assert.Equal(t, []PrometheusLabelLimit{{Metric: "pod_transition_seconds", Limit: 1000}}, options.PrometheusLabelLimits)
+
+	options, err = parse([]string{
+		"--config", path,
+		"--prometheus-label-limit=pod_transition_seconds=500",
+		"--prometheus-label-limit=pod_transition_seconds:team=50",
+	})
+	require.NoError(t, err, "Expected options to be valid")
+
+	series, labels := options.CardinalityLimits("pod_transition_seconds")
+	assert.Equal(t, 500, series, "Expected flags to replace file limits")
+	assert.Equal(t, map[string]int{"team": 50}, labels)
+
+	_, err = parse([]string{"--prometheus-label-limit=pod_transition_seconds"})
+	require.Error(t, err, "Expected limit without value to be rejected")
+
+	_, err = parse([]string{"--prometheus-label-limit=pod_transition_seconds=many"})
+	require.Error(t, err, "Expected non-integer limit to be rejected")
+}
+
+func TestValidatePrometheusLabelLimits(t *testing.T) {
+	t.Parallel()
+
+	options := &Options{
+		ListenAddress:      "127.0.0.1:8080",
+		KubeWatchTimeout:   1,
+		KubeWatchMaxEvents: 1,
+		PrometheusLabelLimits: []PrometheusLabelLimit{
+			{Metric: "pod-transition", Limit: 1},
+			{Metric: "pod_transition_seconds", Label: "Team", Limit: 1},
+			{Metric: "pod_transition_seconds", Limit: 0},
+			{Metric: "kube_transition_metrics_image_pulls", Limit: 1},
+		},
+	}
+
+	err := options.Validate()
+	require.Error(t, err, "Expected invalid limits to be rejected")
+
+	for _, message := range []string{
+		`prometheusLabelLimits: metric "pod-transition" of limit pod-transition=1 must match`,
+		`prometheusLabelLimits: label "Team" of limit pod_transition_seconds:Team=1 must match`,
+		`prometheusLabelLimits: limit pod_transition_seconds=0 must be positive`,
+		`prometheusLabelLimits: metric "kube_transition_metrics_image_pulls" of limit ` +
+			`kube_transition_metrics_image_pulls=1 cannot be limited, only pod_transition_seconds can`,
+	} {
+		assert.Contains(t, err.Error(), message)
+	}
+}
+
+func TestParseSLOs(t *testing.T) {
+	path := writeConfig(t, `
+slos:
+  - name: web-ready
+    namespaces: [web]
+    threshold: 60
+    objective: 0.95
+  - name: image-pull
+    transition: image_pull
+    registries: [docker.io]
+    threshold: 30
+    objective: 0.99
+    periodDays: 7
+`)
+
+	options, err := parse([]string{"--config", path})
+	require.NoError(t, err, "Expected options to be valid")
+	require.Len(t, options.SLOs, 2)
+	assert.Equal(t, "creation_to_ready", options.SLOs[0].TransitionOrDefault())
+	assert.InDelta(t, 30, options.SLOs[0].PeriodDaysOrDefault(), 0)
+	assert.Equal(t, []string{"docker.io"}, options.SLOs[1].Registries)
+	assert.InDelta(t, 7, options.SLOs[1].PeriodDaysOrDefault(), 0)
+}
+
+func TestValidateSLOs(t *testing.T) {
+	t.Parallel()
+
+	options := &Options{
+		ListenAddress:      "127.0.0.1:8080",
+		KubeWatchTimeout:   1,
+		KubeWatchMaxEvents: 1,
+		SLOs: []SLO{
+			{Threshold: 1, Objective: 0.9},
+			{Name: "ready", Threshold: 0, Objective: 1},
+			{Name: "ready", Transition: "creation_to_running", Threshold: 1, Objective: 0.9, PeriodDays: -1},
+			{Name: "scheduled", Transition: "creation_to_scheduled", Registries: []string{"docker.io"}, Threshold: 1,
+				Objective: 0.9},
+		},
+	}
+
+	err := options.Validate()
+	require.Error(t, err, "Expected invalid SLOs to be rejected")
+
+	for _, message := range []string{
+		`slos: name must not be empty`,
+		`slos: threshold of SLO "ready" must be positive`,
+		`slos: objective of SLO "ready" must be between 0 and 1 exclusive`,
+		`slos: name "ready" is used more than once`,
+		`slos: transition "creation_to_running" of SLO "ready" must be one of`,
+		`slos: periodDays of SLO "ready" must not be negative`,
+		`slos: registries of SLO "scheduled" are only supported by the image_pull transition`,
+	} {
+		assert.Contains(t, err.Error(), message)
+	}
+}
+
+func TestValidateSlowPodEvents(t *testing.T) {
+	t.Parallel()
+
+	options := &Options{
+		ListenAddress:               "127.0.0.1:8080",
+		KubeWatchTimeout:            1,
+		KubeWatchMaxEvents:          1,
+		SlowImagePullEventThreshold: 60,
+		SlowPodOwnerEvents:          true,
+	}
+
+	err := options.Validate()
+	require.Error(t, err, "Expected invalid slow pod Events to be rejected")
+
+	for _, message := range []string{
+		`slowPodEventRate: must be greater than 0`,
+		`slowPodOwnerEvents: requires resolveOwners and slowPodEventThreshold`,
+	} {
+		assert.Contains(t, err.Error(), message)
+	}
+}
+
+func TestValidateWorkloadReports(t *testing.T) {
+	t.Parallel()
+
+	options := &Options{
+		ListenAddress:                "127.0.0.1:8080",
+		KubeWatchTimeout:             1,
+		KubeWatchMaxEvents:           1,
+		WorkloadReportInterval:       60,
+		WorkloadReportStuckThreshold: -1,
+	}
+
+	err := options.Validate()
+	require.Error(t, err, "Expected invalid workload reports to be rejected")
+
+	for _, message := range []string{
+		`workloadReportInterval: requires resolveOwners`,
+		`workloadReportStuckThreshold: must not be negative`,
+	} {
+		assert.Contains(t, err.Error(), message)
+	}
+}
+
+func TestValidatePodAnnotations(t *testing.T) {
+	t.Parallel()
+
+	options := &Options{
+		ListenAddress:               "127.0.0.1:8080",
+		KubeWatchTimeout:            1,
+		KubeWatchMaxEvents:          1,
+		PodAnnotationNamespaceLabel: "example.com/annotate startup",
+	}
+
+	err := options.Validate()
+	require.Error(t, err, "Expected invalid pod annotations to be rejected")
+
+	for _, message := range []string{
+		`podAnnotationNamespaceLabel: must be a label key`,
+		`podAnnotationRate: must be greater than 0`,
+	} {
+		assert.Contains(t, err.Error(), message)
+	}
+}
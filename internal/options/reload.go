@@ -0,0 +1,144 @@
+package options
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"reflect"
+	"syscall"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// applyReloadable copies the non-structural options, which can be changed without restarting, from src to dst.
+func applyReloadable(dst, src *Options) {
+	dst.LogLevel = src.LogLevel
+	dst.EmitPartialStatistics = src.EmitPartialStatistics
+	dst.Namespaces = src.Namespaces
+	dst.ExcludeNamespaces = src.ExcludeNamespaces
+	dst.LabelMappings = src.LabelMappings
+}
+
+// Reloader reloads the non-structural options when the configuration file is modified or on SIGHUP.
+// Changes to structural options, such as listen addresses or queue lengths, are ignored with a warning as they
+// require a restart.
+type Reloader struct {
+	options  *Options
+	onReload []func(*Options)
+
+	configModTime time.Time
+}
+
+// NewReloader creates a new Reloader for options created by [Parse].
+// The onReload functions are called with the new options after each successful reload.
+func NewReloader(options *Options, onReload ...func(*Options)) *Reloader {
+	reloader := &Reloader{
+		options:  options,
+		onReload: onReload,
+	}
+	reloader.configModTime, _ = reloader.modTime()
+
+	return reloader
+}
+
+// Run reloads the options on SIGHUP, and when the configuration file is modified if polling is enabled.
+// It blocks until the context is canceled.
+func (r *Reloader) Run(ctx context.Context) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP)
+
+	defer signal.Stop(signals)
+
+	var poll <-chan time.Time
+
+	if interval := r.options.ConfigReloadInterval; r.options.ConfigPath != "" && interval > 0 {
+		ticker := time.NewTicker(time.Duration(interval * float64(time.Second)))
+		defer ticker.Stop()
+
+		poll = ticker.C
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-signals:
+			log.Info().Msg("Received SIGHUP, reloading configuration")
+			r.Reload()
+		case <-poll:
+			modTime, err := r.modTime()
+			if err != nil {
+				log.Error().Err(err).Str("config_path", r.options.ConfigPath).Msg("Failed to stat configuration file")
+
+				continue
+			}
+
+			if !modTime.Equal(r.configModTime) {
+				log.Info().Str("config_path", r.options.ConfigPath).Msg("Configuration file modified, reloading")
+				r.Reload()
+			}
+		}
+	}
+}
+
+// Reload parses the options again and publishes the new non-structural options.
+// The current options are kept if the new options are invalid.
+func (r *Reloader) Reload() {
+	if modTime, err := r.modTime(); err == nil {
+		r.configModTime = modTime
+	}
+
+	reloaded, err := parse(r.options.args)
+	if err != nil {
+		log.Error().Err(err).Msg("Invalid configuration, keeping the current configuration")
+
+		return
+	}
+
+	current := r.options.Current()
+
+	// The namespace informer is only started if namespace label mappings are configured on startup.
+	if reloaded.NamespaceLabelMappings() && !r.options.NamespaceLabelMappings() {
+		log.Warn().Msg("Namespace label mappings added since startup require a restart, label mappings not reloaded")
+
+		reloaded.LabelMappings = current.LabelMappings
+	}
+
+	next := *current
+	applyReloadable(&next, reloaded)
+
+	// Compare the structural options only, by copying the reloadable options and internal fields over.
+	structural := *reloaded
+	applyReloadable(&structural, current)
+	structural.current = current.current
+	structural.args = current.args
+	structural.positional = current.positional
+
+	if !reflect.DeepEqual(&structural, current) {
+		log.Warn().Msg("Structural configuration changes are ignored until the controller is restarted")
+	}
+
+	r.options.current.Store(&next)
+
+	for _, onReload := range r.onReload {
+		onReload(&next)
+	}
+
+	log.Info().Msg("Configuration reloaded")
+}
+
+// modTime returns the modification time of the configuration file, or the zero time if there is none.
+func (r *Reloader) modTime() (time.Time, error) {
+	if r.options.ConfigPath == "" {
+		return time.Time{}, nil
+	}
+
+	info, err := os.Stat(r.options.ConfigPath)
+	if err != nil {
+		//nolint:wrapcheck
+		return time.Time{}, err
+	}
+
+	return info.ModTime(), nil
+}
@@ -0,0 +1,99 @@
+package options
+
+import "slices"
+
+// SLOTransitionImagePull is the [SLO.Transition] measuring the duration of the image pulls of the containers.
+const SLOTransitionImagePull = "image_pull"
+
+// defaultSLOPeriodDays is the default [SLO.PeriodDays].
+const defaultSLOPeriodDays = 30
+
+// sloPodTransitions are the pod transitions an [SLO] can measure, as observed by the pod_transition_seconds
+// Prometheus histogram.
+//
+//nolint:gochecknoglobals // This is a constant list of transitions.
+var sloPodTransitions = []string{
+	"creation_to_scheduled",
+	"scheduled_to_initialized",
+	"initialized_to_ready",
+	"creation_to_ready",
+}
+
+// SLO is a latency service level objective, e.g. 95% of the pods of a namespace become Ready within 60 seconds over 30
+// days, evaluated against the complete pod and image pull statistics.
+type SLO struct {
+	// Name identifies the SLO in the slo label of the Prometheus metrics and in the slo_violation records.
+	Name string `json:"name"`
+	// Transition is the measured duration, one of the pod transitions of the pod_transition_seconds metric, e.g.
+	// creation_to_ready, or image_pull. It defaults to creation_to_ready.
+	Transition string `json:"transition,omitempty"`
+	// Namespaces are the namespaces of the pods the SLO applies to, it applies to all the namespaces when empty.
+	Namespaces []string `json:"namespaces,omitempty"`
+	// Registries are the registries of the images the image_pull SLO applies to, e.g. docker.io, it applies to all
+	// the registries when empty.
+	Registries []string `json:"registries,omitempty"`
+	// Threshold is the maximum duration (in seconds) of a good event.
+	Threshold float64 `json:"threshold"`
+	// Objective is the target ratio of good events, e.g. 0.95.
+	Objective float64 `json:"objective"`
+	// PeriodDays is the period (in days) of the error budget. It defaults to 30.
+	PeriodDays float64 `json:"periodDays,omitempty"`
+}
+
+// TransitionOrDefault returns the measured duration, defaulting to creation_to_ready.
+func (s SLO) TransitionOrDefault() string {
+	if s.Transition == "" {
+		return "creation_to_ready"
+	}
+
+	return s.Transition
+}
+
+// PeriodDaysOrDefault returns the period (in days) of the error budget, defaulting to 30.
+func (s SLO) PeriodDaysOrDefault() float64 {
+	if s.PeriodDays == 0 {
+		return defaultSLOPeriodDays
+	}
+
+	return s.PeriodDays
+}
+
+// validateSLOs checks the names, transitions, thresholds, objectives and periods of the SLOs.
+func (o *Options) validateSLOs(invalid func(option, format string, args ...any)) {
+	names := make(map[string]struct{}, len(o.SLOs))
+
+	for _, slo := range o.SLOs {
+		if slo.Name == "" {
+			invalid("slos", "name must not be empty")
+		}
+
+		if _, duplicate := names[slo.Name]; duplicate {
+			invalid("slos", "name %q is used more than once", slo.Name)
+		}
+
+		names[slo.Name] = struct{}{}
+
+		transition := slo.TransitionOrDefault()
+		if transition != SLOTransitionImagePull && !slices.Contains(sloPodTransitions, transition) {
+			invalid("slos", "transition %q of SLO %q must be one of %q or %q",
+				transition, slo.Name, sloPodTransitions, SLOTransitionImagePull)
+		}
+
+		if len(slo.Registries) > 0 && transition != SLOTransitionImagePull {
+			invalid("slos", "registries of SLO %q are only supported by the %s transition",
+				slo.Name, SLOTransitionImagePull)
+		}
+
+		if slo.Threshold <= 0 {
+			invalid("slos", "threshold of SLO %q must be positive, got %v", slo.Name, slo.Threshold)
+		}
+
+		if slo.Objective <= 0 || slo.Objective >= 1 {
+			invalid("slos", "objective of SLO %q must be between 0 and 1 exclusive, got %v", slo.Name, slo.Objective)
+		}
+
+		if slo.PeriodDays < 0 {
+			invalid("slos", "periodDays of SLO %q must not be negative, got %v", slo.Name, slo.PeriodDays)
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package options
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"slices"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// ValidationError describes an invalid option.
+type ValidationError struct {
+	// Option is the name of the invalid option, as used in the configuration file.
+	Option string
+	// Message describes why the option is invalid.
+	Message string
+}
+
+// Error implements the error interface for ValidationError.
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Option, e.Message)
+}
+
+// Validate checks the options for invalid values, it returns all the problems found joined in a single error.
+func (o *Options) Validate() error {
+	var errs []error
+
+	invalid := func(option, format string, args ...any) {
+		errs = append(errs, &ValidationError{Option: option, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if _, _, err := net.SplitHostPort(o.ListenAddress); err != nil {
+		invalid("listenAddress", "must be a host and port: %v", err)
+	}
+
+	if o.PprofListenAddress != "" {
+		if _, _, err := net.SplitHostPort(o.PprofListenAddress); err != nil {
+			invalid("pprofListenAddress", "must be a host and port: %v", err)
+		} else if o.PprofListenAddress == o.ListenAddress {
+			invalid("pprofListenAddress", "must differ from listenAddress")
+		}
+	}
+
+	if (o.TLSCertFile == "") != (o.TLSKeyFile == "") {
+		invalid("tlsCertFile", "must be set together with tlsKeyFile")
+	}
+
+	for option, value := range map[string]float64{
+		"configReloadInterval":         o.ConfigReloadInterval,
+		"httpReadTimeout":              o.HTTPReadTimeout,
+		"httpWriteTimeout":             o.HTTPWriteTimeout,
+		"shutdownTimeout":              o.ShutdownTimeout,
+		"imagePullCancelDelay":         o.ImagePullCancelDelay,
+		"summaryInterval":              o.SummaryInterval,
+		"slowPodEventThreshold":        o.SlowPodEventThreshold,
+		"slowImagePullEventThreshold":  o.SlowImagePullEventThreshold,
+		"workloadReportInterval":       o.WorkloadReportInterval,
+		"workloadReportStuckThreshold": o.WorkloadReportStuckThreshold,
+	} {
+		if value < 0 {
+			invalid(option, "must not be negative, got %v", value)
+		}
+	}
+
+	if o.KubeWatchTimeout <= 0 {
+		invalid("kubeWatchTimeout", "must be greater than 0, got %d", o.KubeWatchTimeout)
+	}
+
+	if o.KubeWatchMaxEvents <= 0 {
+		invalid("kubeWatchMaxEvents", "must be greater than 0, got %d", o.KubeWatchMaxEvents)
+	}
+
+	if o.StatisticEventQueueLength < 0 {
+		invalid("statisticEventQueueLength", "must not be negative, got %d", o.StatisticEventQueueLength)
+	}
+
+	for _, namespace := range o.Namespaces {
+		if slices.Contains(o.ExcludeNamespaces, namespace) {
+			invalid("namespaces", "namespace %q is also excluded by excludeNamespaces", namespace)
+		}
+	}
+
+	if o.TrackRollouts && !o.ResolveOwners {
+		invalid("trackRollouts", "requires resolveOwners")
+	}
+
+	if o.ServeSummaries && o.SummaryInterval == 0 {
+		invalid("serveSummaries", "requires summaryInterval")
+	}
+
+	if (o.SlowPodEventThreshold > 0 || o.SlowImagePullEventThreshold > 0) && o.SlowPodEventRate <= 0 {
+		invalid("slowPodEventRate", "must be greater than 0, got %v", o.SlowPodEventRate)
+	}
+
+	if o.SlowPodOwnerEvents && (!o.ResolveOwners || o.SlowPodEventThreshold == 0) {
+		invalid("slowPodOwnerEvents", "requires resolveOwners and slowPodEventThreshold")
+	}
+
+	if o.WorkloadReportInterval > 0 && !o.ResolveOwners {
+		invalid("workloadReportInterval", "requires resolveOwners")
+	}
+
+	if o.PodAnnotationNamespaceLabel != "" {
+		if errs := validation.IsQualifiedName(o.PodAnnotationNamespaceLabel); len(errs) > 0 {
+			invalid("podAnnotationNamespaceLabel", "must be a label key: %s", strings.Join(errs, "; "))
+		}
+
+		if o.PodAnnotationRate <= 0 {
+			invalid("podAnnotationRate", "must be greater than 0, got %v", o.PodAnnotationRate)
+		}
+	}
+
+	o.validateLabelMappings(invalid)
+	o.validateSLOs(invalid)
+
+	// Sort the errors to keep the messages stable, as map iteration order is random.
+	slices.SortFunc(errs, func(a, b error) int {
+		return strings.Compare(a.Error(), b.Error())
+	})
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,127 @@
+package owners
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/rs/zerolog/log"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/dynamic/dynamicinformer"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+)
+
+// ErrCacheSync is returned when the owner informer caches fail to sync.
+var ErrCacheSync = errors.New("failed to sync owner informer caches")
+
+// RolloutResource is the Argo Rollouts resource, resolved with the dynamic client when installed in the cluster.
+//
+//nolint:gochecknoglobals // This is a constant resource definition.
+var RolloutResource = schema.GroupVersionResource{Group: "argoproj.io", Version: "v1alpha1", Resource: "rollouts"}
+
+// StartResolver starts the informers caching the owners of pods until the context is done, waits for their initial
+// sync, and returns a Resolver backed by them.
+// Argo Rollouts are only cached if the resource is served by the cluster.
+func StartResolver(
+	ctx context.Context,
+	clientset kubernetes.Interface,
+	dynamicClient dynamic.Interface,
+) (*Resolver, error) {
+	// Only the metadata of the owners is needed, drop the rest to limit the memory used by the caches.
+	factory := informers.NewSharedInformerFactoryWithOptions(clientset, 0, informers.WithTransform(stripOwner))
+	replicaSetInformer := factory.Apps().V1().ReplicaSets()
+	jobInformer := factory.Batch().V1().Jobs()
+	// The informers must be requested before starting the factory.
+	replicaSets := replicaSetInformer.Lister()
+	jobs := jobInformer.Lister()
+	synced := []cache.InformerSynced{replicaSetInformer.Informer().HasSynced, jobInformer.Informer().HasSynced}
+
+	var rollouts cache.GenericLister
+
+	if rolloutsServed(clientset) {
+		dynamicFactory := dynamicinformer.NewDynamicSharedInformerFactory(dynamicClient, 0)
+		rolloutInformer := dynamicFactory.ForResource(RolloutResource)
+
+		if err := rolloutInformer.Informer().SetTransform(stripOwner); err != nil {
+			return nil, fmt.Errorf("failed to set rollout informer transform: %w", err)
+		}
+
+		rollouts = rolloutInformer.Lister()
+		synced = append(synced, rolloutInformer.Informer().HasSynced)
+
+		dynamicFactory.Start(ctx.Done())
+	}
+
+	factory.Start(ctx.Done())
+
+	if !cache.WaitForCacheSync(ctx.Done(), synced...) {
+		return nil, fmt.Errorf("%w: %w", ErrCacheSync, ctx.Err())
+	}
+
+	return NewResolver(replicaSets, jobs, rollouts), nil
+}
+
+// rolloutsServed indicates if the Argo Rollouts resource is served by the cluster.
+func rolloutsServed(clientset kubernetes.Interface) bool {
+	resources, err := clientset.Discovery().ServerResourcesForGroupVersion(RolloutResource.GroupVersion().String())
+	if err != nil {
+		log.Info().Err(err).Msg("Argo Rollouts are not available, owner chains will stop at the Rollout")
+
+		return false
+	}
+
+	for _, resource := range resources.APIResources {
+		if resource.Name == RolloutResource.Resource {
+			return true
+		}
+	}
+
+	return false
+}
+
+// stripOwner keeps only the metadata needed to resolve the owner chain.
+// stripOwner implements [cache.TransformFunc].
+func stripOwner(object any) (any, error) {
+	// Tombstones of deleted objects are passed through unchanged.
+	accessor, err := meta.Accessor(object)
+	if err != nil {
+		//nolint:nilerr
+		return object, nil
+	}
+
+	objectMeta := metav1.ObjectMeta{
+		Name:            accessor.GetName(),
+		Namespace:       accessor.GetNamespace(),
+		UID:             accessor.GetUID(),
+		ResourceVersion: accessor.GetResourceVersion(),
+		OwnerReferences: accessor.GetOwnerReferences(),
+	}
+
+	switch object := object.(type) {
+	case *appsv1.ReplicaSet:
+		return &appsv1.ReplicaSet{TypeMeta: object.TypeMeta, ObjectMeta: objectMeta}, nil
+	case *batchv1.Job:
+		return &batchv1.Job{TypeMeta: object.TypeMeta, ObjectMeta: objectMeta}, nil
+	case *unstructured.Unstructured:
+		stripped := &unstructured.Unstructured{}
+		stripped.SetAPIVersion(object.GetAPIVersion())
+		stripped.SetKind(object.GetKind())
+		stripped.SetName(objectMeta.Name)
+		stripped.SetNamespace(objectMeta.Namespace)
+		stripped.SetUID(objectMeta.UID)
+		stripped.SetResourceVersion(objectMeta.ResourceVersion)
+		stripped.SetOwnerReferences(objectMeta.OwnerReferences)
+
+		return stripped, nil
+	default:
+		return object, nil
+	}
+}
@@ -0,0 +1,143 @@
+// Package owners resolves the full chain of controllers owning a pod, e.g. Pod → ReplicaSet → Deployment or
+// Pod → Job → CronJob, from informer caches.
+package owners
+
+import (
+	"strings"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	appsv1listers "k8s.io/client-go/listers/apps/v1"
+	batchv1listers "k8s.io/client-go/listers/batch/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+// maxChainLength is the maximum length of the owner chain, to protect against owner reference cycles.
+const maxChainLength = 8
+
+// ownerKindFields maps the kinds of the indirect owners to the fields of the metric records.
+//
+//nolint:gochecknoglobals // This is a constant map of owner kinds to metric labels.
+var ownerKindFields = map[string]string{
+	"CronJob":    "kube_cron_job",
+	"Deployment": "kube_deployment",
+	"Rollout":    "kube_rollout",
+}
+
+// deprecatedOwnerKindFields maps the kinds of the indirect owners to the previous names of their fields, which are
+// still emitted until they are removed.
+//
+//nolint:gochecknoglobals // This is a constant map of owner kinds to metric labels.
+var deprecatedOwnerKindFields = map[string]string{
+	"CronJob": "kube_cronjob",
+}
+
+// Resolver resolves the owner chain of pods from informer caches.
+//
+// Resolver implements [github.com/BackMarket-oss/kube-transition-metrics/internal/statistics/state.PodLabeler].
+type Resolver struct {
+	replicaSets appsv1listers.ReplicaSetLister
+	jobs        batchv1listers.JobLister
+	// rollouts lists Argo Rollouts as unstructured objects, it is nil when Argo Rollouts are not installed.
+	rollouts cache.GenericLister
+}
+
+// NewResolver creates a new Resolver from the provided listers.
+// The rollouts lister may be nil, in which case the chain stops at the Rollout.
+func NewResolver(
+	replicaSets appsv1listers.ReplicaSetLister,
+	jobs batchv1listers.JobLister,
+	rollouts cache.GenericLister,
+) *Resolver {
+	return &Resolver{
+		replicaSets: replicaSets,
+		jobs:        jobs,
+		rollouts:    rollouts,
+	}
+}
+
+// Chain returns the controller owner references of the pod, from the direct controller to the top-level owner.
+// The chain stops at the first owner which is not cached, or which has no controller.
+func (r *Resolver) Chain(pod *corev1.Pod) []metav1.OwnerReference {
+	// Most chains are of length 2, e.g. ReplicaSet → Deployment.
+	chain := make([]metav1.OwnerReference, 0, 2) //nolint:mnd
+
+	ownerRef := metav1.GetControllerOfNoCopy(pod)
+	for ownerRef != nil && len(chain) < maxChainLength {
+		chain = append(chain, *ownerRef)
+		ownerRef = r.controllerOf(pod.Namespace, ownerRef)
+	}
+
+	return chain
+}
+
+// PodLabels returns a function that adds the owner labels of the pod to the event.
+// PodLabels implements [github.com/BackMarket-oss/kube-transition-metrics/internal/statistics/state.PodLabeler].
+func (r *Resolver) PodLabels(pod *corev1.Pod) func(event *zerolog.Event) {
+	chain := r.Chain(pod)
+
+	return func(event *zerolog.Event) {
+		if len(chain) == 0 {
+			return
+		}
+
+		for _, ownerRef := range chain[1:] {
+			if field, ok := ownerKindFields[ownerRef.Kind]; ok {
+				event.Str(field, ownerRef.Name)
+			}
+
+			if field, ok := deprecatedOwnerKindFields[ownerRef.Kind]; ok {
+				event.Str(field, ownerRef.Name)
+			}
+		}
+
+		top := chain[len(chain)-1]
+		event.Str("kube_top_owner_kind", strings.ToLower(top.Kind))
+		event.Str("kube_top_owner_name", top.Name)
+	}
+}
+
+// controllerOf returns the controller owner reference of the owner, or nil if the owner is not cached or has no
+// controller.
+func (r *Resolver) controllerOf(namespace string, ownerRef *metav1.OwnerReference) *metav1.OwnerReference {
+	var (
+		owner metav1.Object
+		err   error
+	)
+
+	switch {
+	case ownerRef.Kind == "ReplicaSet" && strings.HasPrefix(ownerRef.APIVersion, "apps/"):
+		owner, err = r.replicaSets.ReplicaSets(namespace).Get(ownerRef.Name)
+	case ownerRef.Kind == "Job" && strings.HasPrefix(ownerRef.APIVersion, "batch/"):
+		owner, err = r.jobs.Jobs(namespace).Get(ownerRef.Name)
+	case ownerRef.Kind == "Rollout" && strings.HasPrefix(ownerRef.APIVersion, "argoproj.io/") && r.rollouts != nil:
+		var object any
+
+		object, err = r.rollouts.ByNamespace(namespace).Get(ownerRef.Name)
+		if err == nil {
+			owner, err = meta.Accessor(object)
+		}
+	default:
+		return nil
+	}
+
+	if err != nil {
+		log.Debug().Err(err).
+			Str("kube_namespace", namespace).
+			Str("owner_kind", ownerRef.Kind).
+			Str("owner_name", ownerRef.Name).
+			Msg("Failed to get owner from cache")
+
+		return nil
+	}
+
+	// The owner in the cache may be a different object with the same name.
+	if owner.GetUID() != ownerRef.UID {
+		return nil
+	}
+
+	return metav1.GetControllerOfNoCopy(owner)
+}
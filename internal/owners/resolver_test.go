@@ -0,0 +1,204 @@
+package owners
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	appsv1listers "k8s.io/client-go/listers/apps/v1"
+	batchv1listers "k8s.io/client-go/listers/batch/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+func newTestingOwnerRef(apiVersion, kind, name string) metav1.OwnerReference {
+	return metav1.OwnerReference{
+		APIVersion: apiVersion,
+		Kind:       kind,
+		Name:       name,
+		UID:        types.UID(kind + "-" + name),
+		Controller: new(true),
+	}
+}
+
+func newTestingObjectMeta(kind, name string, ownerRefs ...metav1.OwnerReference) metav1.ObjectMeta {
+	return metav1.ObjectMeta{
+		Namespace:       "test-namespace",
+		Name:            name,
+		UID:             types.UID(kind + "-" + name),
+		OwnerReferences: ownerRefs,
+	}
+}
+
+func newTestingIndexer(t *testing.T, objects ...any) cache.Indexer {
+	t.Helper()
+
+	indexer := cache.NewIndexer(
+		cache.MetaNamespaceKeyFunc,
+		cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc},
+	)
+	for _, object := range objects {
+		require.NoError(t, indexer.Add(object), "Failed to add object to indexer")
+	}
+
+	return indexer
+}
+
+func newTestingResolver(t *testing.T) *Resolver {
+	t.Helper()
+
+	replicaSets := newTestingIndexer(t,
+		&appsv1.ReplicaSet{ObjectMeta: newTestingObjectMeta("ReplicaSet", "web-5d4f8",
+			newTestingOwnerRef("apps/v1", "Deployment", "web"))},
+		&appsv1.ReplicaSet{ObjectMeta: newTestingObjectMeta("ReplicaSet", "canary-7b9c",
+			newTestingOwnerRef("argoproj.io/v1alpha1", "Rollout", "canary"))},
+		&appsv1.ReplicaSet{ObjectMeta: newTestingObjectMeta("ReplicaSet", "orphan-1a2b")},
+	)
+	jobs := newTestingIndexer(t,
+		&batchv1.Job{ObjectMeta: newTestingObjectMeta("Job", "backup-28000",
+			newTestingOwnerRef("batch/v1", "CronJob", "backup"))},
+	)
+
+	rollout := &unstructured.Unstructured{}
+	rollout.SetAPIVersion("argoproj.io/v1alpha1")
+	rollout.SetKind("Rollout")
+	rollout.SetNamespace("test-namespace")
+	rollout.SetName("canary")
+	rollout.SetUID("Rollout-canary")
+
+	rollouts := newTestingIndexer(t, rollout)
+
+	return NewResolver(
+		appsv1listers.NewReplicaSetLister(replicaSets),
+		batchv1listers.NewJobLister(jobs),
+		cache.NewGenericLister(rollouts, RolloutResource.GroupResource()),
+	)
+}
+
+func newTestingPod(ownerRefs ...metav1.OwnerReference) *corev1.Pod {
+	return &corev1.Pod{ObjectMeta: newTestingObjectMeta("Pod", "test-pod", ownerRefs...)}
+}
+
+func podLabels(t *testing.T, resolver *Resolver, pod *corev1.Pod) map[string]any {
+	t.Helper()
+
+	buf := &bytes.Buffer{}
+	logger := zerolog.New(buf)
+	logger.Log().Func(resolver.PodLabels(pod)).Send()
+
+	record := map[string]any{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &record), "Failed to decode log record")
+
+	return record
+}
+
+func TestResolverPodLabels(t *testing.T) {
+	t.Parallel()
+
+	resolver := newTestingResolver(t)
+
+	tests := []struct {
+		name     string
+		pod      *corev1.Pod
+		expected map[string]any
+	}{
+		{
+			name: "Deployment",
+			pod:  newTestingPod(newTestingOwnerRef("apps/v1", "ReplicaSet", "web-5d4f8")),
+			expected: map[string]any{
+				"kube_deployment":     "web",
+				"kube_top_owner_kind": "deployment",
+				"kube_top_owner_name": "web",
+			},
+		},
+		{
+			name: "CronJob",
+			pod:  newTestingPod(newTestingOwnerRef("batch/v1", "Job", "backup-28000")),
+			expected: map[string]any{
+				"kube_cron_job":       "backup",
+				"kube_cronjob":        "backup",
+				"kube_top_owner_kind": "cronjob",
+				"kube_top_owner_name": "backup",
+			},
+		},
+		{
+			name: "Rollout",
+			pod:  newTestingPod(newTestingOwnerRef("apps/v1", "ReplicaSet", "canary-7b9c")),
+			expected: map[string]any{
+				"kube_rollout":        "canary",
+				"kube_top_owner_kind": "rollout",
+				"kube_top_owner_name": "canary",
+			},
+		},
+		{
+			name: "ReplicaSet without controller",
+			pod:  newTestingPod(newTestingOwnerRef("apps/v1", "ReplicaSet", "orphan-1a2b")),
+			expected: map[string]any{
+				"kube_top_owner_kind": "replicaset",
+				"kube_top_owner_name": "orphan-1a2b",
+			},
+		},
+		{
+			name:     "StatefulSet",
+			pod:      newTestingPod(newTestingOwnerRef("apps/v1", "StatefulSet", "db")),
+			expected: map[string]any{"kube_top_owner_kind": "statefulset", "kube_top_owner_name": "db"},
+		},
+		{
+			name:     "uncached ReplicaSet",
+			pod:      newTestingPod(newTestingOwnerRef("apps/v1", "ReplicaSet", "unknown")),
+			expected: map[string]any{"kube_top_owner_kind": "replicaset", "kube_top_owner_name": "unknown"},
+		},
+		{
+			name:     "no controller",
+			pod:      newTestingPod(),
+			expected: map[string]any{},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, test.expected, podLabels(t, resolver, test.pod))
+		})
+	}
+}
+
+func TestResolverChainChecksUID(t *testing.T) {
+	t.Parallel()
+
+	resolver := newTestingResolver(t)
+
+	// The pod references a previous ReplicaSet with the same name, which was replaced in the cache.
+	ownerRef := newTestingOwnerRef("apps/v1", "ReplicaSet", "web-5d4f8")
+	ownerRef.UID = "previous-uid"
+
+	chain := resolver.Chain(newTestingPod(ownerRef))
+	assert.Equal(t, []metav1.OwnerReference{ownerRef}, chain, "Expected chain to stop at the replaced ReplicaSet")
+}
+
+func TestStripOwner(t *testing.T) {
+	t.Parallel()
+
+	replicaSet := &appsv1.ReplicaSet{
+		ObjectMeta: newTestingObjectMeta("ReplicaSet", "web-5d4f8", newTestingOwnerRef("apps/v1", "Deployment", "web")),
+		Spec:       appsv1.ReplicaSetSpec{Replicas: new(int32(3))},
+	}
+
+	stripped, err := stripOwner(replicaSet)
+	require.NoError(t, err)
+
+	strippedReplicaSet, ok := stripped.(*appsv1.ReplicaSet)
+	require.True(t, ok, "Expected a ReplicaSet")
+	assert.Nil(t, strippedReplicaSet.Spec.Replicas, "Expected spec to be dropped")
+	assert.Equal(t, replicaSet.OwnerReferences, strippedReplicaSet.OwnerReferences, "Expected owners to be kept")
+	assert.Equal(t, replicaSet.UID, strippedReplicaSet.UID, "Expected UID to be kept")
+}
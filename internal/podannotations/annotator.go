@@ -0,0 +1,220 @@
+// Package podannotations annotates the Ready pods of the opted-in namespaces with the compact JSON of their startup
+// durations, so that tools listing pods can show them without reading the metric records.
+package podannotations
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/BackMarket-oss/kube-transition-metrics/internal/options"
+	"github.com/BackMarket-oss/kube-transition-metrics/internal/prommetrics"
+	"github.com/BackMarket-oss/kube-transition-metrics/internal/statistics/state"
+	"github.com/rs/zerolog/log"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	apimachinerytypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/rest"
+)
+
+// AnnotationKey is the annotation of the pods holding their startup durations.
+const AnnotationKey = "kube-transition-metrics/startup"
+
+// fieldManager is the field manager of the patches of the annotations.
+const fieldManager = "kube-transition-metrics"
+
+// patchBurst is the number of patches sent at once before the rate limit of the client applies.
+const patchBurst = 10
+
+// patchQueueLength is the number of patches waiting for the rate limit of the client, the patches of the pods
+// becoming Ready while the queue is full are dropped.
+const patchQueueLength = 1000
+
+// startup is the value of the annotation, the durations are in seconds, rounded to the millisecond.
+type startup struct {
+	CreationToScheduled    float64 `json:"creation_to_scheduled_seconds"`
+	ScheduledToInitialized float64 `json:"scheduled_to_initialized_seconds"`
+	InitializedToReady     float64 `json:"initialized_to_ready_seconds"`
+	CreationToReady        float64 `json:"creation_to_ready_seconds"`
+	// ImagePull is the duration of the slowest image pull of the pod, omitted when all the images were already
+	// present on the node.
+	ImagePull float64 `json:"image_pull_seconds,omitempty"`
+}
+
+// patch is a pending patch of the annotation of a pod.
+type patch struct {
+	namespace string
+	name      string
+	body      []byte
+}
+
+// Annotator patches the pods of the namespaces whose opt-in label is set to true with their startup durations, under
+// the [AnnotationKey] annotation, once their statistic is complete.
+// The patches are sent by [Annotator.Run], throttled by the rate limiter of the client, see [ClientConfig].
+//
+// Annotator implements the PodStatisticObserver interface of
+// [github.com/BackMarket-oss/kube-transition-metrics/internal/statistics/types].
+type Annotator struct {
+	client     kubernetes.Interface
+	namespaces corev1listers.NamespaceLister
+	label      string
+
+	queue chan patch
+}
+
+// ClientConfig returns a copy of the config whose clients are rate limited to the pod annotation rate of the options,
+// after a burst of 10.
+func ClientConfig(config *rest.Config, options *options.Options) *rest.Config {
+	config = rest.CopyConfig(config)
+	config.QPS = float32(options.PodAnnotationRate)
+	config.Burst = patchBurst
+
+	return config
+}
+
+// NewAnnotator creates a new Annotator patching the pods with the client, in the namespaces opted in with the label
+// of the options, read from the namespace lister.
+// The client should be rate limited, see [ClientConfig].
+func NewAnnotator(
+	options *options.Options,
+	client kubernetes.Interface,
+	namespaces corev1listers.NamespaceLister,
+) *Annotator {
+	return &Annotator{
+		client:     client,
+		namespaces: namespaces,
+		label:      options.PodAnnotationNamespaceLabel,
+		queue:      make(chan patch, patchQueueLength),
+	}
+}
+
+// ObservePodStatistic queues the patch of the annotation of the pod, if its namespace opted in.
+// The patch is dropped if the queue is full.
+// ObservePodStatistic implements
+// [github.com/BackMarket-oss/kube-transition-metrics/internal/statistics/types.PodStatisticObserver].
+func (a *Annotator) ObservePodStatistic(pod *corev1.Pod, statistic *state.PodStatistic) {
+	if !a.optedIn(pod.Namespace) {
+		return
+	}
+
+	body, err := patchBody(pod.UID, newStartup(statistic))
+	if err != nil {
+		log.Error().Err(err).
+			Str("kube_namespace", pod.Namespace).
+			Str("pod_name", pod.Name).
+			Msg("Failed to encode startup annotation")
+
+		return
+	}
+
+	select {
+	case a.queue <- patch{namespace: pod.Namespace, name: pod.Name, body: body}:
+	default:
+		prommetrics.PodAnnotationPatches.WithLabelValues("dropped").Inc()
+	}
+}
+
+// optedIn indicates if the opt-in label of the namespace is set to true.
+func (a *Annotator) optedIn(name string) bool {
+	namespace, err := a.namespaces.Get(name)
+	if err != nil {
+		return false
+	}
+
+	return namespace.Labels[a.label] == "true"
+}
+
+// Run sends the queued patches until the context is done.
+func (a *Annotator) Run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case patch := <-a.queue:
+			a.patch(ctx, patch)
+		}
+	}
+}
+
+// Close sends the patches still queued, after the statistic event loops are drained and [Run] returned.
+// The context of [Run] is done, so the patches are sent with a new context.
+func (a *Annotator) Close() {
+	for {
+		select {
+		case patch := <-a.queue:
+			a.patch(context.Background(), patch)
+		default:
+			return
+		}
+	}
+}
+
+// patch sends the patch of the annotation of the pod, waiting for the rate limiter of the client.
+func (a *Annotator) patch(ctx context.Context, patch patch) {
+	_, err := a.client.CoreV1().Pods(patch.namespace).Patch(
+		ctx, patch.name, apimachinerytypes.MergePatchType, patch.body, metav1.PatchOptions{FieldManager: fieldManager})
+
+	switch {
+	case err == nil:
+		prommetrics.PodAnnotationPatches.WithLabelValues("patched").Inc()
+	case apierrors.IsNotFound(err) || apierrors.IsConflict(err):
+		// The pod was deleted, or replaced by a pod with the same name, before the patch was sent.
+		prommetrics.PodAnnotationPatches.WithLabelValues("gone").Inc()
+	default:
+		prommetrics.PodAnnotationPatches.WithLabelValues("failed").Inc()
+		log.Error().Err(err).
+			Str("kube_namespace", patch.namespace).
+			Str("pod_name", patch.name).
+			Msg("Failed to patch startup annotation")
+	}
+}
+
+// newStartup returns the startup durations of the complete pod statistic.
+func newStartup(statistic *state.PodStatistic) startup {
+	var imagePull time.Duration
+
+	for _, pull := range statistic.ImagePulls() {
+		if !pull.AlreadyPresent() {
+			imagePull = max(imagePull, pull.Duration())
+		}
+	}
+
+	return startup{
+		CreationToScheduled:    seconds(statistic.ScheduledTimestamp().Sub(statistic.CreationTimestamp())),
+		ScheduledToInitialized: seconds(statistic.InitializedTimestamp().Sub(statistic.ScheduledTimestamp())),
+		InitializedToReady:     seconds(statistic.ReadyTimestamp().Sub(statistic.InitializedTimestamp())),
+		CreationToReady:        seconds(statistic.ReadyTimestamp().Sub(statistic.CreationTimestamp())),
+		ImagePull:              seconds(imagePull),
+	}
+}
+
+// patchBody returns the JSON merge patch setting the annotation of the pod to the startup durations.
+// The UID of the pod is a precondition of the patch, so that a pod replaced by another pod with the same name, e.g.
+// of a StatefulSet, is not annotated.
+func patchBody(uid apimachinerytypes.UID, startup startup) ([]byte, error) {
+	value, err := json.Marshal(startup)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode startup durations: %w", err)
+	}
+
+	body, err := json.Marshal(map[string]any{
+		"metadata": map[string]any{
+			"uid":         uid,
+			"annotations": map[string]string{AnnotationKey: string(value)},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode patch: %w", err)
+	}
+
+	return body, nil
+}
+
+// seconds returns the duration in seconds, rounded to the millisecond.
+func seconds(duration time.Duration) float64 {
+	return duration.Round(time.Millisecond).Seconds()
+}
@@ -0,0 +1,189 @@
+package podannotations
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/BackMarket-oss/kube-transition-metrics/internal/options"
+	"github.com/BackMarket-oss/kube-transition-metrics/internal/prommetrics"
+	"github.com/BackMarket-oss/kube-transition-metrics/internal/statistics/state"
+	"github.com/BackMarket-oss/kube-transition-metrics/internal/testhelpers"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/cache"
+)
+
+// newTestingPod returns a pod of the namespace scheduled after 1.5s, initialized after 30s and Ready after 42s.
+func newTestingPod(namespace, name string) *corev1.Pod {
+	return testhelpers.NewReadyPod(namespace, name, testhelpers.Created, testhelpers.PodTransitions{
+		Scheduled:   1500 * time.Millisecond,
+		Initialized: 30 * time.Second,
+		Ready:       42 * time.Second,
+	})
+}
+
+// newTestingStatistic returns the complete statistic of the pod, whose image is pulled from 2s to 27.25s.
+func newTestingStatistic(pod *corev1.Pod) *state.PodStatistic {
+	return state.NewPodStatistic(testhelpers.Created, pod).
+		Update(testhelpers.Created, pod).
+		ImagePullEvent("app", testhelpers.ImagePullEvent("Pulling", 2*time.Second)).
+		ImagePullEvent("app", testhelpers.ImagePullEvent("Pulled", 27250*time.Millisecond))
+}
+
+// newTestingNamespaces returns a lister of the opted-in namespace and of a namespace which did not opt in.
+func newTestingNamespaces(t *testing.T) corev1listers.NamespaceLister {
+	t.Helper()
+
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	require.NoError(t, indexer.Add(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+		Name:   "opted-in",
+		Labels: map[string]string{"example.com/annotate-startup": "true"},
+	}}))
+	require.NoError(t, indexer.Add(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+		Name:   "opted-out",
+		Labels: map[string]string{"example.com/annotate-startup": "false"},
+	}}))
+
+	return corev1listers.NewNamespaceLister(indexer)
+}
+
+func newTestingAnnotator(t *testing.T, pods ...*corev1.Pod) (*Annotator, *fake.Clientset) {
+	t.Helper()
+
+	testhelpers.ConfigureLogging(t, &options.Options{})
+
+	objects := make([]runtime.Object, 0, len(pods))
+	for _, pod := range pods {
+		objects = append(objects, pod)
+	}
+
+	client := fake.NewClientset(objects...)
+	annotator := NewAnnotator(
+		&options.Options{PodAnnotationNamespaceLabel: "example.com/annotate-startup"},
+		client,
+		newTestingNamespaces(t),
+	)
+
+	return annotator, client
+}
+
+func TestAnnotatorPatch(t *testing.T) {
+	t.Parallel()
+
+	pod := newTestingPod("opted-in", "web-1")
+	annotator, client := newTestingAnnotator(t, pod)
+
+	annotator.ObservePodStatistic(pod, newTestingStatistic(pod))
+	require.Len(t, annotator.queue, 1)
+	annotator.patch(context.Background(), <-annotator.queue)
+
+	patched, err := client.CoreV1().Pods("opted-in").Get(context.Background(), "web-1", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"creation_to_scheduled_seconds": 1.5,
+		"scheduled_to_initialized_seconds": 28.5,
+		"initialized_to_ready_seconds": 12,
+		"creation_to_ready_seconds": 42,
+		"image_pull_seconds": 25.25
+	}`, patched.Annotations[AnnotationKey])
+	assert.NotContains(t, patched.Annotations[AnnotationKey], " ", "annotation should be compact")
+}
+
+func TestAnnotatorNamespaces(t *testing.T) {
+	t.Parallel()
+
+	annotator, _ := newTestingAnnotator(t)
+
+	for _, namespace := range []string{"opted-out", "unknown"} {
+		pod := newTestingPod(namespace, "web-1")
+		annotator.ObservePodStatistic(pod, newTestingStatistic(pod))
+	}
+
+	assert.Empty(t, annotator.queue)
+}
+
+func TestAnnotatorOutcomes(t *testing.T) {
+	t.Parallel()
+
+	annotator, _ := newTestingAnnotator(t)
+	outcome := func(outcome string) float64 {
+		return testutil.ToFloat64(prommetrics.PodAnnotationPatches.WithLabelValues(outcome))
+	}
+
+	// The pod was deleted before the patch was sent.
+	gone := outcome("gone")
+	pod := newTestingPod("opted-in", "web-1")
+	annotator.ObservePodStatistic(pod, newTestingStatistic(pod))
+	annotator.patch(context.Background(), <-annotator.queue)
+	assert.InDelta(t, gone+1, outcome("gone"), 0)
+
+	dropped := outcome("dropped")
+	for range patchQueueLength + 1 {
+		annotator.ObservePodStatistic(pod, newTestingStatistic(pod))
+	}
+
+	assert.Len(t, annotator.queue, patchQueueLength)
+	assert.InDelta(t, dropped+1, outcome("dropped"), 0)
+}
+
+func TestAnnotatorRun(t *testing.T) {
+	t.Parallel()
+
+	pod := newTestingPod("opted-in", "web-1")
+	annotator, client := newTestingAnnotator(t, pod)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		annotator.Run(ctx)
+	}()
+
+	annotator.ObservePodStatistic(pod, newTestingStatistic(pod))
+	require.Eventually(t, func() bool {
+		patched, err := client.CoreV1().Pods("opted-in").Get(ctx, "web-1", metav1.GetOptions{})
+
+		return err == nil && patched.Annotations[AnnotationKey] != ""
+	}, time.Second, time.Millisecond)
+
+	cancel()
+	<-done
+
+	// The patches queued after the context is done are sent on close.
+	annotator.ObservePodStatistic(pod, newTestingStatistic(pod))
+	require.Len(t, annotator.queue, 1)
+	annotator.Close()
+	assert.Empty(t, annotator.queue)
+
+	patches := 0
+
+	for _, action := range client.Actions() {
+		if action.GetVerb() == "patch" {
+			patches++
+		}
+	}
+
+	assert.Equal(t, 2, patches, "Expected the queued patch to be sent on close")
+}
+
+func TestClientConfig(t *testing.T) {
+	t.Parallel()
+
+	config := &rest.Config{Host: "https://example.com", QPS: 50, Burst: 100}
+	annotationConfig := ClientConfig(config, &options.Options{PodAnnotationRate: 2})
+
+	assert.InDelta(t, 2, annotationConfig.QPS, 0)
+	assert.Equal(t, patchBurst, annotationConfig.Burst)
+	assert.Equal(t, "https://example.com", annotationConfig.Host)
+	assert.InDelta(t, 50, config.QPS, 0, "config should not be modified")
+}
@@ -0,0 +1,140 @@
+package prommetrics
+
+import (
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// OverflowLabelValue is the value the label values exceeding the cardinality limits of a metric are folded into.
+const OverflowLabelValue = "__other__"
+
+// CardinalityGuard limits the cardinality of the guarded labels of a metric, by folding the label values exceeding
+// the limits into [OverflowLabelValue].
+// Each guarded label can be limited to a number of distinct values, and the metric can be limited to a number of
+// distinct combinations of label values, beyond which all the guarded labels of the new combinations are folded.
+// The combination with all the guarded labels folded is always accepted, so the cardinality of the metric stays bounded
+// by the cardinality of its other labels.
+// The folded label values are counted by the label_cardinality_overflows_total metric.
+//
+// A nil *CardinalityGuard does not limit the labels.
+type CardinalityGuard struct {
+	metric        string
+	guardedLabels []string
+	seriesLimit   int
+	labelLimits   map[string]int
+
+	// mu protects the values and series seen, as the metrics may be observed concurrently.
+	mu     sync.Mutex
+	values map[string]map[string]struct{}
+	series map[string]struct{}
+}
+
+// NewCardinalityGuard creates a new CardinalityGuard of the guarded labels of the metric, e.g. the labels selected by
+// the operator.
+// The series limit is the maximum number of distinct label combinations, which are not limited when 0, and the label
+// limits are the maximum numbers of distinct values of the guarded labels.
+// The limits of the labels which are not guarded are ignored.
+func NewCardinalityGuard(
+	metric string,
+	guardedLabels []string,
+	seriesLimit int,
+	labelLimits map[string]int,
+) *CardinalityGuard {
+	guard := &CardinalityGuard{
+		metric:        metric,
+		guardedLabels: guardedLabels,
+		seriesLimit:   seriesLimit,
+		labelLimits:   make(map[string]int),
+		values:        make(map[string]map[string]struct{}),
+		series:        make(map[string]struct{}),
+	}
+
+	for label, limit := range labelLimits {
+		if slices.Contains(guardedLabels, label) {
+			guard.labelLimits[label] = limit
+			guard.values[label] = make(map[string]struct{})
+		}
+	}
+
+	return guard
+}
+
+// Labels returns a copy of the labels of an observation of the metric, with the values exceeding the limits folded
+// into [OverflowLabelValue].
+func (g *CardinalityGuard) Labels(labels prometheus.Labels) prometheus.Labels {
+	if g == nil || (g.seriesLimit == 0 && len(g.labelLimits) == 0) {
+		return labels
+	}
+
+	limited := maps.Clone(labels)
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	for label, limit := range g.labelLimits {
+		value, ok := limited[label]
+		if !ok || value == OverflowLabelValue {
+			continue
+		}
+
+		values := g.values[label]
+		if _, seen := values[value]; seen {
+			continue
+		}
+
+		if len(values) < limit {
+			values[value] = struct{}{}
+
+			continue
+		}
+
+		limited[label] = OverflowLabelValue
+		LabelCardinalityOverflows.WithLabelValues(g.metric, label).Inc()
+	}
+
+	if g.seriesLimit > 0 {
+		g.limitSeries(limited)
+	}
+
+	return limited
+}
+
+// limitSeries folds the guarded labels of a new combination of label values if the series limit is reached.
+func (g *CardinalityGuard) limitSeries(labels prometheus.Labels) {
+	key := seriesKey(labels)
+	if _, seen := g.series[key]; seen {
+		return
+	}
+
+	if len(g.series) < g.seriesLimit {
+		g.series[key] = struct{}{}
+
+		return
+	}
+
+	for _, label := range g.guardedLabels {
+		if value, ok := labels[label]; ok && value != OverflowLabelValue {
+			labels[label] = OverflowLabelValue
+			LabelCardinalityOverflows.WithLabelValues(g.metric, label).Inc()
+		}
+	}
+}
+
+// seriesKey returns a key identifying the combination of label values.
+func seriesKey(labels prometheus.Labels) string {
+	var key strings.Builder
+
+	for _, label := range slices.Sorted(maps.Keys(labels)) {
+		key.WriteString(label)
+		key.WriteByte('=')
+		key.WriteString(labels[label])
+		// The label values are valid UTF-8, so they cannot contain this byte.
+		key.WriteByte(0xff)
+	}
+
+	return key.String()
+}
@@ -0,0 +1,55 @@
+package prommetrics
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCardinalityGuardLabelLimit(t *testing.T) {
+	guard := NewCardinalityGuard("test_label_limit", []string{"team", "tier"}, 0, map[string]int{"team": 2, "node": 1})
+	overflows := LabelCardinalityOverflows.WithLabelValues("test_label_limit", "team")
+	// The counter is global, so only its increase during the test is checked.
+	before := testutil.ToFloat64(overflows)
+
+	for _, team := range []string{"a", "b", "a"} {
+		labels := prometheus.Labels{"team": team, "tier": "web"}
+		assert.Equal(t, labels, guard.Labels(labels), "Expected team %q to be within the limit", team)
+	}
+
+	labels := prometheus.Labels{"team": "c", "tier": "web"}
+	assert.Equal(t, prometheus.Labels{"team": OverflowLabelValue, "tier": "web"}, guard.Labels(labels),
+		"Expected team exceeding the limit to be folded")
+	assert.Equal(t, "c", labels["team"], "Expected the labels not to be modified")
+	assert.InDelta(t, before+1, testutil.ToFloat64(overflows), 0, "Expected the folded value to be counted")
+}
+
+func TestCardinalityGuardSeriesLimit(t *testing.T) {
+	guard := NewCardinalityGuard("test_series_limit", []string{"team", "tier"}, 2, nil)
+	overflows := LabelCardinalityOverflows.WithLabelValues("test_series_limit", "tier")
+	// The counter is global, so only its increase during the test is checked.
+	before := testutil.ToFloat64(overflows)
+
+	for _, labels := range []prometheus.Labels{
+		{"team": "a", "tier": "web"},
+		{"team": "b", "tier": "web"},
+		{"team": "a", "tier": "web"},
+	} {
+		assert.Equal(t, labels, guard.Labels(labels), "Expected %v to be within the limit", labels)
+	}
+
+	folded := prometheus.Labels{"team": OverflowLabelValue, "tier": OverflowLabelValue}
+	assert.Equal(t, folded, guard.Labels(prometheus.Labels{"team": "a", "tier": "db"}),
+		"Expected new combination exceeding the limit to be folded")
+	assert.Equal(t, folded, guard.Labels(folded), "Expected folded combination to always be accepted")
+	assert.InDelta(t, before+1, testutil.ToFloat64(overflows), 0, "Expected the folded value to be counted")
+}
+
+func TestCardinalityGuardNil(t *testing.T) {
+	var guard *CardinalityGuard
+
+	labels := prometheus.Labels{"team": "a"}
+	assert.Equal(t, labels, guard.Labels(labels), "Expected nil guard not to limit the labels")
+}
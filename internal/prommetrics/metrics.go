@@ -111,6 +111,77 @@ var (
 		},
 		[]string{"event_loop"},
 	)
+	// TimestampSkew tracks the delay between the container timestamps reported by the kubelet and the time the
+	// controller observed them.
+	TimestampSkew = prometheus.NewSummaryVec(
+		prometheus.SummaryOpts{
+			Name: "timestamp_skew_seconds",
+			Help: "Delay between the container timestamps reported by the kubelet and their observation in seconds " +
+				"(quarantiles over " + prometheus.DefMaxAge.String() + ")",
+
+			Objectives: summaryObjectives,
+		},
+		[]string{"field"},
+	)
+	// LabelCardinalityOverflows tracks the label values folded by the [CardinalityGuard] of the metrics.
+	LabelCardinalityOverflows = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "label_cardinality_overflows_total",
+			Help: "Total number of label values folded into " + OverflowLabelValue + " by the cardinality limits " +
+				"since the process started",
+		},
+		[]string{"metric", "label"},
+	)
+	// SLOEvents tracks the number of pod transitions and image pulls evaluated by each latency SLO.
+	SLOEvents = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "slo_events_total",
+			Help: "Total number of events evaluated by the latency SLO since the process started",
+		},
+		[]string{"slo"},
+	)
+	// SLOGoodEvents tracks the number of pod transitions and image pulls within the threshold of each latency SLO.
+	SLOGoodEvents = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "slo_good_events_total",
+			Help: "Total number of events within the threshold of the latency SLO since the process started",
+		},
+		[]string{"slo"},
+	)
+	// SLOBurnRate describes the burn rate of the error budget of each latency SLO over each window, collected by the
+	// SLO evaluator.
+	SLOBurnRate = prometheus.NewDesc(
+		"slo_burn_rate",
+		"Ratio of the rate of bad events of the latency SLO over the window to the rate allowed by its objective",
+		[]string{"slo", "window"},
+		nil,
+	)
+	// SLOErrorBudgetRemaining describes the remaining error budget of each latency SLO over its period, collected by the
+	// SLO evaluator.
+	SLOErrorBudgetRemaining = prometheus.NewDesc(
+		"slo_error_budget_remaining",
+		"Ratio of the error budget of the latency SLO remaining over its period, negative once exhausted",
+		[]string{"slo"},
+		nil,
+	)
+	// SlowPodEvents tracks the Warning Events of the slow pods and image pulls, by reason and outcome: created, or
+	// suppressed as a duplicate or by the rate limit.
+	SlowPodEvents = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "slow_pod_events_total",
+			Help: "Total number of Warning Events of slow pods and image pulls since the process started, by outcome",
+		},
+		[]string{"reason", "outcome"},
+	)
+	// PodAnnotationPatches tracks the patches of the startup annotations of pods, by outcome: patched, gone when the
+	// pod was deleted or replaced, failed, or dropped when the queue of the patches is full.
+	PodAnnotationPatches = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "pod_annotation_patches_total",
+			Help: "Total number of patches of the startup annotations of pods since the process started, by outcome",
+		},
+		[]string{"outcome"},
+	)
 
 	collectors = []prometheus.Collector{
 		PodCollectorErrors,
@@ -125,9 +196,30 @@ var (
 		StatisticEventPublish,
 		StatisticEventQueueDepth,
 		StatisticEventProcessing,
+		TimestampSkew,
+		LabelCardinalityOverflows,
+		SLOEvents,
+		SLOGoodEvents,
+		SlowPodEvents,
+		PodAnnotationPatches,
 	}
 )
 
+// NewPodTransitionSeconds creates the histogram of the pod transition durations, labeled by transition and the provided
+// additional label names.
+// It is not registered by [Register] as its labels depend on the options, the caller is responsible for registering it.
+func NewPodTransitionSeconds(labelNames []string) *prometheus.HistogramVec {
+	return prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name: "pod_transition_seconds",
+			Help: "Duration of the pod lifecycle transitions in seconds, observed when the pod first becomes Ready",
+			//nolint:mnd
+			Buckets: prometheus.ExponentialBuckets(0.25, 2, 12),
+		},
+		append([]string{"transition"}, labelNames...),
+	)
+}
+
 // Register registers the prometheus Collectors (metrics) exported by this
 // package.
 func Register() {
@@ -0,0 +1,278 @@
+// Package rollouts watches the Deployments, StatefulSets and DaemonSets to detect their rollouts, and aggregates the
+// statistics of the pods created by each rollout into a single record once the rollout completes.
+package rollouts
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/BackMarket-oss/kube-transition-metrics/internal/statistics/state"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/utils/clock"
+)
+
+// ErrCacheSync is returned when the workload informer caches fail to sync.
+var ErrCacheSync = errors.New("failed to sync workload informer caches")
+
+// OwnerChainer resolves the chain of controllers owning a pod, from the direct controller to the top-level owner.
+//
+// OwnerChainer is implemented by [github.com/BackMarket-oss/kube-transition-metrics/internal/owners.Resolver].
+type OwnerChainer interface {
+	Chain(pod *corev1.Pod) []metav1.OwnerReference
+}
+
+// RolloutObserver is notified by the [Tracker] when it reports a rollout statistic, including the partial rollouts.
+// ObserveRollout is called with the lock of the tracker held, it must not block.
+type RolloutObserver interface {
+	ObserveRollout(statistic *state.RolloutStatistic)
+}
+
+// Tracker tracks the rollouts of workloads and reports a rollout statistic when each rollout completes, stalls, is
+// superseded by another rollout, or when the workload is deleted.
+//
+// Tracker implements the PodStatisticObserver, PodCreationObserver and ImagePullStatisticObserver interfaces of
+// [github.com/BackMarket-oss/kube-transition-metrics/internal/statistics/types].
+type Tracker struct {
+	output    io.Writer
+	owners    OwnerChainer
+	observers []RolloutObserver
+
+	// mu protects rollouts, which are updated from both the informer handlers and the event loops.
+	mu       sync.Mutex
+	rollouts map[workloadKey]*state.RolloutStatistic
+
+	// factory is the informer factory, it is nil if the tracker was not started by [Start].
+	factory informers.SharedInformerFactory
+}
+
+// NewTracker creates a new Tracker reporting rollout statistics to the output, and notifying the observers.
+func NewTracker(output io.Writer, owners OwnerChainer, observers ...RolloutObserver) *Tracker {
+	return &Tracker{
+		output:    output,
+		owners:    owners,
+		observers: observers,
+		rollouts:  map[workloadKey]*state.RolloutStatistic{},
+	}
+}
+
+// Start starts the informers watching the workloads until the context is done, waits for their initial sync, and
+// returns a Tracker backed by them.
+// The rollouts in progress when the tracker starts are not tracked, and the workload updates are timestamped with the
+// clock as they are observed.
+func Start(
+	ctx context.Context,
+	clientset kubernetes.Interface,
+	clock clock.PassiveClock,
+	output io.Writer,
+	owners OwnerChainer,
+	observers ...RolloutObserver,
+) (*Tracker, error) {
+	tracker := NewTracker(output, owners, observers...)
+	tracker.factory = informers.NewSharedInformerFactoryWithOptions(clientset, 0, informers.WithTransform(strip))
+
+	workloadInformers := []cache.SharedIndexInformer{
+		tracker.factory.Apps().V1().Deployments().Informer(),
+		tracker.factory.Apps().V1().StatefulSets().Informer(),
+		tracker.factory.Apps().V1().DaemonSets().Informer(),
+	}
+	synced := make([]cache.InformerSynced, 0, len(workloadInformers))
+
+	for _, informer := range workloadInformers {
+		_, err := informer.AddEventHandler(cache.ResourceEventHandlerDetailedFuncs{
+			AddFunc: func(obj any, isInInitialList bool) {
+				if !isInInitialList {
+					tracker.workloadAdded(obj)
+				}
+			},
+			UpdateFunc: func(oldObj, newObj any) {
+				tracker.workloadUpdated(oldObj, newObj, clock.Now())
+			},
+			DeleteFunc: func(obj any) {
+				tracker.workloadDeleted(obj)
+			},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to add workload event handler: %w", err)
+		}
+
+		synced = append(synced, informer.HasSynced)
+	}
+
+	tracker.factory.Start(ctx.Done())
+
+	if !cache.WaitForCacheSync(ctx.Done(), synced...) {
+		return nil, fmt.Errorf("%w: %w", ErrCacheSync, ctx.Err())
+	}
+
+	return tracker, nil
+}
+
+// Close waits for the informers to stop after the context passed to [Start] is done, so that no more rollout
+// statistics are reported.
+func (t *Tracker) Close() {
+	if t.factory != nil {
+		t.factory.Shutdown()
+	}
+}
+
+// ObservePodCreation adds the pod to the rollout of its workload, if the pod was created by the rollout.
+// ObservePodCreation implements
+// [github.com/BackMarket-oss/kube-transition-metrics/internal/statistics/types.PodCreationObserver].
+func (t *Tracker) ObservePodCreation(pod *corev1.Pod) {
+	t.updatePodRollout(pod, func(rollout *state.RolloutStatistic) *state.RolloutStatistic {
+		return rollout.PodCreated(pod)
+	})
+}
+
+// ObservePodStatistic records the complete statistic of the pod in the rollout of its workload, if the pod was created
+// by the rollout.
+// ObservePodStatistic implements
+// [github.com/BackMarket-oss/kube-transition-metrics/internal/statistics/types.PodStatisticObserver].
+func (t *Tracker) ObservePodStatistic(pod *corev1.Pod, statistic *state.PodStatistic) {
+	t.updatePodRollout(pod, func(rollout *state.RolloutStatistic) *state.RolloutStatistic {
+		return rollout.PodReady(pod, statistic)
+	})
+}
+
+// ObserveImagePullStatistic adds the duration of the image pull to the pod in the rollout of its workload, if the pod
+// was created by the rollout.
+// ObserveImagePullStatistic implements
+// [github.com/BackMarket-oss/kube-transition-metrics/internal/statistics/types.ImagePullStatisticObserver].
+func (t *Tracker) ObserveImagePullStatistic(pod *corev1.Pod, statistic *state.ContainerImagePullStatistic) {
+	t.updatePodRollout(pod, func(rollout *state.RolloutStatistic) *state.RolloutStatistic {
+		return rollout.ImagePulled(pod.UID, statistic.Duration())
+	})
+}
+
+// updatePodRollout applies the update to the rollout of the workload owning the pod, if the pod was created after the
+// rollout started.
+func (t *Tracker) updatePodRollout(pod *corev1.Pod, update func(*state.RolloutStatistic) *state.RolloutStatistic) {
+	key, ok := t.workloadOf(pod)
+	if !ok {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	rollout, ok := t.rollouts[key]
+	// Creation timestamps are truncated to the second, pods created by the rollout may appear to be created before it
+	// was detected.
+	if !ok || pod.CreationTimestamp.Time.Before(rollout.StartedTimestamp().Truncate(time.Second)) {
+		return
+	}
+
+	t.rollouts[key] = update(rollout)
+}
+
+// workloadOf returns the key of the workload owning the pod, ok is false if the pod is not owned by a workload.
+func (t *Tracker) workloadOf(pod *corev1.Pod) (workloadKey, bool) {
+	for _, ownerRef := range t.owners.Chain(pod) {
+		switch ownerRef.Kind {
+		case "Deployment", "StatefulSet", "DaemonSet":
+			if ownerRef.APIVersion == "apps/v1" {
+				return workloadKey{kind: ownerRef.Kind, namespace: pod.Namespace, name: ownerRef.Name}, true
+			}
+		}
+	}
+
+	return workloadKey{}, false
+}
+
+// workloadAdded starts a rollout for a workload created after the tracker started.
+func (t *Tracker) workloadAdded(obj any) {
+	status, ok := statusOf(obj)
+	if !ok {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.rollouts[status.key] = state.NewRolloutStatistic(
+		status.key.kind, status.key.namespace, status.key.name, status.revision, status.created)
+}
+
+// workloadUpdated starts a rollout when the revision of the workload changes, and reports the rollout when it
+// completes or stalls.
+func (t *Tracker) workloadUpdated(oldObj, newObj any, now time.Time) {
+	oldStatus, ok := statusOf(oldObj)
+	if !ok {
+		return
+	}
+
+	status, ok := statusOf(newObj)
+	if !ok {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	rollout, tracked := t.rollouts[status.key]
+
+	if status.revision != oldStatus.revision && status.revision != "" {
+		if tracked && rollout.Revision() == "" {
+			// The revision of a new workload is only set by its controller after its creation.
+			rollout = rollout.WithRevision(status.revision)
+			t.rollouts[status.key] = rollout
+		} else {
+			if tracked {
+				// The rollout was superseded by a new rollout before completing.
+				t.report(rollout)
+			}
+
+			t.rollouts[status.key] = state.NewRolloutStatistic(
+				status.key.kind, status.key.namespace, status.key.name, status.revision, now)
+
+			// The status may still reflect the previous revision, wait for the next update to check for completion.
+			return
+		}
+	}
+
+	if !tracked {
+		return
+	}
+
+	if status.complete || status.stalled {
+		t.report(rollout.Finish(now, status.stalled && !status.complete))
+		delete(t.rollouts, status.key)
+	}
+}
+
+// workloadDeleted reports the rollout of a deleted workload as partial.
+func (t *Tracker) workloadDeleted(obj any) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+
+	status, ok := statusOf(obj)
+	if !ok {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if rollout, ok := t.rollouts[status.key]; ok {
+		t.report(rollout)
+		delete(t.rollouts, status.key)
+	}
+}
+
+// report reports the rollout statistic to the output and notifies the observers.
+func (t *Tracker) report(rollout *state.RolloutStatistic) {
+	rollout.Report(t.output)
+
+	for _, observer := range t.observers {
+		observer.ObserveRollout(rollout)
+	}
+}
@@ -0,0 +1,245 @@
+package rollouts
+
+import (
+	"testing"
+	"time"
+
+	"github.com/BackMarket-oss/kube-transition-metrics/internal/options"
+	"github.com/BackMarket-oss/kube-transition-metrics/internal/statistics/state"
+	"github.com/BackMarket-oss/kube-transition-metrics/internal/testhelpers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/cache"
+)
+
+// chainOwners resolves the owner chain of pods from their owner references, followed by the Deployment named by
+// the deployment label of the pod.
+type chainOwners struct{}
+
+func (chainOwners) Chain(pod *corev1.Pod) []metav1.OwnerReference {
+	chain := append([]metav1.OwnerReference{}, pod.OwnerReferences...)
+	if deployment, ok := pod.Labels["deployment"]; ok {
+		chain = append(chain, metav1.OwnerReference{APIVersion: "apps/v1", Kind: "Deployment", Name: deployment})
+	}
+
+	return chain
+}
+
+func newTestingDeployment(revision string, generation int64, status appsv1.DeploymentStatus) *appsv1.Deployment {
+	return &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:   "test-namespace",
+			Name:        "web",
+			Generation:  generation,
+			Annotations: map[string]string{deploymentRevisionAnnotation: revision},
+		},
+		Spec:   appsv1.DeploymentSpec{Replicas: new(int32(2))},
+		Status: status,
+	}
+}
+
+func newTestingPod(name string, created time.Time) *corev1.Pod {
+	ready := created.Add(5 * time.Second)
+
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:         "test-namespace",
+			Name:              name,
+			UID:               types.UID(name),
+			CreationTimestamp: metav1.NewTime(created),
+			Labels:            map[string]string{"deployment": "web"},
+			OwnerReferences: []metav1.OwnerReference{
+				{APIVersion: "apps/v1", Kind: "ReplicaSet", Name: "web-5d8f", Controller: new(true)},
+			},
+		},
+		Status: corev1.PodStatus{
+			Conditions: []corev1.PodCondition{
+				{Type: corev1.PodScheduled, Status: corev1.ConditionTrue, LastTransitionTime: metav1.NewTime(created)},
+				{Type: corev1.PodInitialized, Status: corev1.ConditionTrue, LastTransitionTime: metav1.NewTime(created)},
+				{Type: corev1.PodReady, Status: corev1.ConditionTrue, LastTransitionTime: metav1.NewTime(ready)},
+			},
+		},
+	}
+}
+
+// testingRolloutObserver records the rollout statistics it observes.
+type testingRolloutObserver struct {
+	observed []*state.RolloutStatistic
+}
+
+func (o *testingRolloutObserver) ObserveRollout(statistic *state.RolloutStatistic) {
+	o.observed = append(o.observed, statistic)
+}
+
+func TestTrackerDeploymentRollout(t *testing.T) {
+	testhelpers.ConfigureLogging(t, &options.Options{})
+
+	writer := testhelpers.NewMetricWriter(t)
+	observer := &testingRolloutObserver{}
+	tracker := NewTracker(writer, chainOwners{}, observer)
+	started := time.Date(2023, 8, 28, 0, 0, 0, 0, time.UTC)
+
+	complete := appsv1.DeploymentStatus{
+		ObservedGeneration: 1, Replicas: 2, UpdatedReplicas: 2, AvailableReplicas: 2,
+	}
+	previous := newTestingDeployment("1", 1, complete)
+	rolling := newTestingDeployment("2", 2, complete)
+	tracker.workloadUpdated(previous, rolling, started)
+	assert.Empty(t, testhelpers.DecodeMetricOutput(t, writer), "Expected no report when the rollout starts")
+
+	// Pods created before the rollout are not part of it.
+	tracker.ObservePodCreation(newTestingPod("web-old", started.Add(-time.Minute)))
+
+	for _, name := range []string{"web-a", "web-b"} {
+		pod := newTestingPod(name, started.Add(time.Second))
+		tracker.ObservePodCreation(pod)
+		tracker.ObserveImagePullStatistic(pod, &state.ContainerImagePullStatistic{})
+		tracker.ObservePodStatistic(pod, state.NewPodStatistic(started, pod).Update(started, pod))
+	}
+
+	progressing := newTestingDeployment("2", 2, appsv1.DeploymentStatus{
+		ObservedGeneration: 2, Replicas: 3, UpdatedReplicas: 2, AvailableReplicas: 2,
+	})
+	tracker.workloadUpdated(rolling, progressing, started.Add(5*time.Second))
+	assert.Empty(t, testhelpers.DecodeMetricOutput(t, writer), "Expected no report while the rollout progresses")
+
+	done := newTestingDeployment("2", 2, appsv1.DeploymentStatus{
+		ObservedGeneration: 2, Replicas: 2, UpdatedReplicas: 2, AvailableReplicas: 2,
+	})
+	tracker.workloadUpdated(progressing, done, started.Add(10*time.Second))
+
+	metrics := testhelpers.DecodeMetricOutput(t, writer)
+	require.Len(t, metrics, 1)
+	assert.Equal(t, false, metrics[0]["partial"])
+	assert.Equal(t, "web", metrics[0]["kube_deployment"])
+
+	rolloutMetrics, ok := metrics[0]["rollout"].(map[string]any)
+	require.True(t, ok, "Expected rollout metrics to be an object")
+	assert.Equal(t, "2", rolloutMetrics["revision"])
+	assert.InDelta(t, 10, rolloutMetrics["duration_seconds"], 1e-5)
+	assert.InDelta(t, 2, rolloutMetrics["pods_created"], 1e-5)
+	assert.InDelta(t, 2, rolloutMetrics["pods_ready"], 1e-5)
+	assert.InDelta(t, 5, rolloutMetrics["creation_to_ready_max_seconds"], 1e-5)
+
+	require.Len(t, observer.observed, 1, "Expected the rollout to be observed")
+	assert.Equal(t, started.Add(10*time.Second), observer.observed[0].FinishedTimestamp())
+
+	tracker.workloadUpdated(done, done, started.Add(time.Minute))
+	assert.Len(t, testhelpers.DecodeMetricOutput(t, writer), 1, "Expected the rollout to be reported once")
+	assert.Len(t, observer.observed, 1, "Expected the rollout to be observed once")
+}
+
+func TestTrackerPartialRollouts(t *testing.T) {
+	testhelpers.ConfigureLogging(t, &options.Options{})
+
+	writer := testhelpers.NewMetricWriter(t)
+	tracker := NewTracker(writer, chainOwners{})
+	started := time.Date(2023, 8, 28, 0, 0, 0, 0, time.UTC)
+
+	first := newTestingDeployment("1", 1, appsv1.DeploymentStatus{})
+	second := newTestingDeployment("2", 2, appsv1.DeploymentStatus{})
+	tracker.workloadUpdated(newTestingDeployment("0", 1, appsv1.DeploymentStatus{}), first, started)
+	tracker.workloadUpdated(first, second, started.Add(time.Minute))
+
+	stalled := newTestingDeployment("2", 2, appsv1.DeploymentStatus{
+		ObservedGeneration: 2,
+		Conditions: []appsv1.DeploymentCondition{{
+			Type:   appsv1.DeploymentProgressing,
+			Status: corev1.ConditionFalse,
+			Reason: progressDeadlineExceededReason,
+		}},
+	})
+	tracker.workloadUpdated(second, stalled, started.Add(2*time.Minute))
+
+	third := newTestingDeployment("3", 3, appsv1.DeploymentStatus{})
+	tracker.workloadUpdated(stalled, third, started.Add(3*time.Minute))
+	tracker.workloadDeleted(cache.DeletedFinalStateUnknown{Key: "test-namespace/web", Obj: third})
+
+	metrics := testhelpers.DecodeMetricOutput(t, writer)
+	require.Len(t, metrics, 3, "Expected superseded, stalled and deleted rollouts to be reported")
+
+	for i, expected := range []struct {
+		revision string
+		stalled  bool
+	}{{"1", false}, {"2", true}, {"3", false}} {
+		assert.Equal(t, true, metrics[i]["partial"])
+
+		rolloutMetrics, ok := metrics[i]["rollout"].(map[string]any)
+		require.True(t, ok, "Expected rollout metrics to be an object")
+		assert.Equal(t, expected.revision, rolloutMetrics["revision"])
+		assert.Equal(t, expected.stalled, rolloutMetrics["stalled"])
+	}
+}
+
+func TestTrackerNewStatefulSet(t *testing.T) {
+	testhelpers.ConfigureLogging(t, &options.Options{})
+
+	writer := testhelpers.NewMetricWriter(t)
+	tracker := NewTracker(writer, chainOwners{})
+	created := time.Date(2023, 8, 28, 0, 0, 0, 0, time.UTC)
+
+	statefulSet := &appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:         "test-namespace",
+			Name:              "db",
+			Generation:        1,
+			CreationTimestamp: metav1.NewTime(created),
+		},
+		Spec: appsv1.StatefulSetSpec{Replicas: new(int32(1))},
+	}
+	tracker.workloadAdded(statefulSet)
+
+	revised := statefulSet.DeepCopy()
+	revised.Status = appsv1.StatefulSetStatus{ObservedGeneration: 1, UpdateRevision: "db-5d8f"}
+	tracker.workloadUpdated(statefulSet, revised, created.Add(time.Second))
+
+	pod := newTestingPod("db-0", created.Add(time.Second))
+	pod.Labels = nil
+	pod.OwnerReferences = []metav1.OwnerReference{
+		{APIVersion: "apps/v1", Kind: "StatefulSet", Name: "db", Controller: new(true)},
+	}
+	tracker.ObservePodCreation(pod)
+
+	ready := revised.DeepCopy()
+	ready.Status = appsv1.StatefulSetStatus{
+		ObservedGeneration: 1,
+		ReadyReplicas:      1,
+		UpdatedReplicas:    1,
+		CurrentRevision:    "db-5d8f",
+		UpdateRevision:     "db-5d8f",
+	}
+	tracker.workloadUpdated(revised, ready, created.Add(time.Minute))
+
+	metrics := testhelpers.DecodeMetricOutput(t, writer)
+	require.Len(t, metrics, 1)
+	assert.Equal(t, false, metrics[0]["partial"])
+	assert.Equal(t, "db", metrics[0]["kube_stateful_set"])
+	assert.Equal(t, "statefulset", metrics[0]["kube_top_owner_kind"])
+
+	rolloutMetrics, ok := metrics[0]["rollout"].(map[string]any)
+	require.True(t, ok, "Expected rollout metrics to be an object")
+	assert.Equal(t, "db-5d8f", rolloutMetrics["revision"])
+	assert.Equal(t, created.Format(time.RFC3339), rolloutMetrics["started_timestamp"])
+	assert.InDelta(t, 1, rolloutMetrics["pods_created"], 1e-5)
+}
+
+func TestStrip(t *testing.T) {
+	t.Parallel()
+
+	deployment := newTestingDeployment("2", 2, appsv1.DeploymentStatus{ObservedGeneration: 2})
+	deployment.Annotations["example.com/other"] = "value"
+	deployment.Spec.Template.Spec.Containers = []corev1.Container{{Name: "web", Image: "web:2"}}
+
+	stripped, err := strip(deployment)
+	require.NoError(t, err)
+
+	strippedDeployment, ok := stripped.(*appsv1.Deployment)
+	require.True(t, ok, "Expected a Deployment")
+	assert.Equal(t, map[string]string{deploymentRevisionAnnotation: "2"}, strippedDeployment.Annotations)
+	assert.Empty(t, strippedDeployment.Spec.Template.Spec.Containers)
+	assert.Equal(t, deploymentStatus(deployment), deploymentStatus(strippedDeployment))
+}
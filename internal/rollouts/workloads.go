@@ -0,0 +1,177 @@
+package rollouts
+
+import (
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// deploymentRevisionAnnotation is set by the Deployment controller to the revision of the latest ReplicaSet.
+	deploymentRevisionAnnotation = "deployment.kubernetes.io/revision"
+	// daemonSetTemplateGenerationAnnotation is set by the DaemonSet controller to the generation of the pod template.
+	daemonSetTemplateGenerationAnnotation = "deprecated.daemonset.template.generation"
+	// progressDeadlineExceededReason is the reason of the Progressing condition of a stalled Deployment.
+	progressDeadlineExceededReason = "ProgressDeadlineExceeded"
+)
+
+// workloadKey identifies a workload.
+type workloadKey struct {
+	kind      string
+	namespace string
+	name      string
+}
+
+// workloadStatus is the rollout status of a workload.
+type workloadStatus struct {
+	key workloadKey
+	// created is the creation timestamp of the workload.
+	created time.Time
+	// revision identifies the pod template of the workload, it changes when a rollout starts.
+	revision string
+	// complete is true when all the replicas are updated to the revision and available.
+	complete bool
+	// stalled is true when the rollout exceeded its progress deadline.
+	stalled bool
+}
+
+// statusOf returns the rollout status of the workload, ok is false for objects which are not workloads.
+func statusOf(object any) (workloadStatus, bool) {
+	switch workload := object.(type) {
+	case *appsv1.Deployment:
+		return deploymentStatus(workload), true
+	case *appsv1.StatefulSet:
+		return statefulSetStatus(workload), true
+	case *appsv1.DaemonSet:
+		return daemonSetStatus(workload), true
+	default:
+		return workloadStatus{}, false
+	}
+}
+
+// deploymentStatus returns the rollout status of a Deployment.
+func deploymentStatus(deployment *appsv1.Deployment) workloadStatus {
+	replicas := replicasOrDefault(deployment.Spec.Replicas)
+	status := deployment.Status
+	stalled := false
+
+	for _, condition := range status.Conditions {
+		if condition.Type == appsv1.DeploymentProgressing &&
+			condition.Status == corev1.ConditionFalse &&
+			condition.Reason == progressDeadlineExceededReason {
+			stalled = true
+		}
+	}
+
+	return workloadStatus{
+		key:      keyOf("Deployment", &deployment.ObjectMeta),
+		created:  deployment.CreationTimestamp.Time,
+		revision: deployment.Annotations[deploymentRevisionAnnotation],
+		complete: status.ObservedGeneration >= deployment.Generation &&
+			status.UpdatedReplicas == replicas &&
+			status.Replicas == replicas &&
+			status.AvailableReplicas == replicas,
+		stalled: stalled,
+	}
+}
+
+// statefulSetStatus returns the rollout status of a StatefulSet.
+func statefulSetStatus(statefulSet *appsv1.StatefulSet) workloadStatus {
+	replicas := replicasOrDefault(statefulSet.Spec.Replicas)
+	status := statefulSet.Status
+
+	return workloadStatus{
+		key:      keyOf("StatefulSet", &statefulSet.ObjectMeta),
+		created:  statefulSet.CreationTimestamp.Time,
+		revision: status.UpdateRevision,
+		complete: status.ObservedGeneration >= statefulSet.Generation &&
+			status.UpdatedReplicas == replicas &&
+			status.ReadyReplicas == replicas &&
+			status.CurrentRevision == status.UpdateRevision,
+	}
+}
+
+// daemonSetStatus returns the rollout status of a DaemonSet.
+func daemonSetStatus(daemonSet *appsv1.DaemonSet) workloadStatus {
+	status := daemonSet.Status
+
+	return workloadStatus{
+		key:      keyOf("DaemonSet", &daemonSet.ObjectMeta),
+		created:  daemonSet.CreationTimestamp.Time,
+		revision: daemonSet.Annotations[daemonSetTemplateGenerationAnnotation],
+		complete: status.ObservedGeneration >= daemonSet.Generation &&
+			status.UpdatedNumberScheduled == status.DesiredNumberScheduled &&
+			status.NumberAvailable == status.DesiredNumberScheduled,
+	}
+}
+
+// keyOf returns the key of the workload.
+func keyOf(kind string, objectMeta *metav1.ObjectMeta) workloadKey {
+	return workloadKey{kind: kind, namespace: objectMeta.Namespace, name: objectMeta.Name}
+}
+
+// replicasOrDefault returns the desired number of replicas, which defaults to 1.
+func replicasOrDefault(replicas *int32) int32 {
+	if replicas == nil {
+		return 1
+	}
+
+	return *replicas
+}
+
+// strip keeps only the fields of the workloads used to track rollouts, dropping the pod template, to limit the memory
+// used by the caches.
+// strip implements [k8s.io/client-go/tools/cache.TransformFunc].
+func strip(object any) (any, error) {
+	switch workload := object.(type) {
+	case *appsv1.Deployment:
+		return &appsv1.Deployment{
+			TypeMeta:   workload.TypeMeta,
+			ObjectMeta: stripObjectMeta(workload.ObjectMeta, deploymentRevisionAnnotation),
+			Spec:       appsv1.DeploymentSpec{Replicas: workload.Spec.Replicas},
+			Status:     workload.Status,
+		}, nil
+	case *appsv1.StatefulSet:
+		return &appsv1.StatefulSet{
+			TypeMeta:   workload.TypeMeta,
+			ObjectMeta: stripObjectMeta(workload.ObjectMeta),
+			Spec:       appsv1.StatefulSetSpec{Replicas: workload.Spec.Replicas},
+			Status:     workload.Status,
+		}, nil
+	case *appsv1.DaemonSet:
+		return &appsv1.DaemonSet{
+			TypeMeta:   workload.TypeMeta,
+			ObjectMeta: stripObjectMeta(workload.ObjectMeta, daemonSetTemplateGenerationAnnotation),
+			Status:     workload.Status,
+		}, nil
+	default:
+		// Tombstones of deleted objects are passed through unchanged.
+		return object, nil
+	}
+}
+
+// stripObjectMeta keeps only the identifying metadata and the provided annotations of the object.
+func stripObjectMeta(objectMeta metav1.ObjectMeta, annotations ...string) metav1.ObjectMeta {
+	stripped := metav1.ObjectMeta{
+		Name:              objectMeta.Name,
+		Namespace:         objectMeta.Namespace,
+		UID:               objectMeta.UID,
+		ResourceVersion:   objectMeta.ResourceVersion,
+		Generation:        objectMeta.Generation,
+		CreationTimestamp: objectMeta.CreationTimestamp,
+	}
+
+	for _, annotation := range annotations {
+		if value, ok := objectMeta.Annotations[annotation]; ok {
+			if stripped.Annotations == nil {
+				stripped.Annotations = map[string]string{}
+			}
+
+			stripped.Annotations[annotation] = value
+		}
+	}
+
+	return stripped
+}
@@ -0,0 +1,170 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/pprof"
+	"time"
+
+	"github.com/BackMarket-oss/kube-transition-metrics/internal/logging"
+	"github.com/BackMarket-oss/kube-transition-metrics/internal/options"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/rs/zerolog/log"
+)
+
+// readHeaderTimeout is the maximum duration for reading the request headers, shared by all servers.
+const readHeaderTimeout = 5 * time.Second
+
+// ReadinessChecker reports whether the controller is ready to produce accurate statistics.
+//
+// Implemented by podCollector in [github.com/BackMarket-oss/kube-transition-metrics/internal/statistics].
+type ReadinessChecker interface {
+	Ready() bool
+}
+
+// Server serves the prometheus metrics, health checks, and optionally pprof profiling over HTTP.
+type Server struct {
+	options *options.Options
+
+	// mux routes the requests of server, additional handlers can be registered with [Server.Handle].
+	mux *http.ServeMux
+	// server serves /metrics, /healthz and /readyz.
+	server *http.Server
+	// pprofServer serves /debug/pprof, it is nil when profiling is disabled.
+	pprofServer *http.Server
+	// certificates is used to serve TLS, it is nil when TLS is disabled.
+	certificates *certificateReloader
+}
+
+// New creates a new Server using the provided options.
+// The readiness checkers must all be ready for /readyz to succeed.
+func New(opts *options.Options, readinessCheckers ...ReadinessChecker) *Server {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.Handler())
+	mux.HandleFunc("/healthz", healthz)
+	mux.Handle("/readyz", readyz(readinessCheckers))
+
+	srv := &Server{
+		options: opts,
+		mux:     mux,
+		server: &http.Server{
+			Addr:              opts.ListenAddress,
+			Handler:           logging.NewHTTPHandler(mux),
+			ReadHeaderTimeout: readHeaderTimeout,
+			ReadTimeout:       seconds(opts.HTTPReadTimeout),
+			WriteTimeout:      seconds(opts.HTTPWriteTimeout),
+		},
+	}
+
+	if opts.TLSCertFile != "" {
+		srv.certificates = newCertificateReloader(opts.TLSCertFile, opts.TLSKeyFile)
+		srv.server.TLSConfig = srv.certificates.tlsConfig()
+	}
+
+	if opts.PprofListenAddress != "" {
+		pprofMux := http.NewServeMux()
+		pprofMux.HandleFunc("/debug/pprof/", pprof.Index)
+		pprofMux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+		pprofMux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+		pprofMux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+		pprofMux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+
+		// No write timeout is set, as CPU profiles and traces stream for a client-defined duration.
+		srv.pprofServer = &http.Server{
+			Addr:              opts.PprofListenAddress,
+			Handler:           logging.NewHTTPHandler(pprofMux),
+			ReadHeaderTimeout: readHeaderTimeout,
+			ReadTimeout:       seconds(opts.HTTPReadTimeout),
+		}
+	}
+
+	return srv
+}
+
+// Handle registers an additional handler for the pattern on the server delivering the prometheus metrics.
+// It must be called before [Server.Start].
+func (s *Server) Handle(pattern string, handler http.Handler) {
+	s.mux.Handle(pattern, handler)
+}
+
+// Start starts listening in new goroutines.
+// The process panics if any of the servers fail to listen.
+func (s *Server) Start() {
+	if s.certificates != nil {
+		// Load the certificate eagerly so that misconfiguration is reported on startup.
+		if _, err := s.certificates.load(); err != nil {
+			log.Panic().Err(err).Msg("Failed to load TLS certificate")
+		}
+	}
+
+	go s.serve(s.server, s.certificates != nil)
+
+	if s.pprofServer != nil {
+		go s.serve(s.pprofServer, false)
+	}
+}
+
+// Shutdown gracefully shuts down the servers, waiting for in-flight requests to complete until the context is
+// canceled.
+func (s *Server) Shutdown(ctx context.Context) error {
+	var errs []error
+
+	for _, server := range []*http.Server{s.server, s.pprofServer} {
+		if server == nil {
+			continue
+		}
+
+		if err := server.Shutdown(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("failed to shutdown HTTP server on %s: %w", server.Addr, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// serve listens on the server address and serves requests until shutdown.
+func (s *Server) serve(server *http.Server, tls bool) {
+	log.Info().Str("listen_address", server.Addr).Bool("tls", tls).Msg("Starting HTTP server")
+
+	var err error
+	if tls {
+		// The certificate is provided by the TLSConfig, so no files are passed here.
+		err = server.ListenAndServeTLS("", "")
+	} else {
+		err = server.ListenAndServe()
+	}
+
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
+		log.Panic().Err(err).Str("listen_address", server.Addr).Msg("HTTP server failed")
+	}
+}
+
+// healthz reports that the process is alive.
+func healthz(writer http.ResponseWriter, _ *http.Request) {
+	writer.WriteHeader(http.StatusOK)
+	_, _ = writer.Write([]byte("ok\n"))
+}
+
+// readyz returns a handler reporting whether all the readiness checkers are ready.
+func readyz(readinessCheckers []ReadinessChecker) http.HandlerFunc {
+	return func(writer http.ResponseWriter, _ *http.Request) {
+		for _, checker := range readinessCheckers {
+			if !checker.Ready() {
+				writer.WriteHeader(http.StatusServiceUnavailable)
+				_, _ = writer.Write([]byte("not ready\n"))
+
+				return
+			}
+		}
+
+		writer.WriteHeader(http.StatusOK)
+		_, _ = writer.Write([]byte("ok\n"))
+	}
+}
+
+// seconds converts a floating point number of seconds to a [time.Duration].
+func seconds(value float64) time.Duration {
+	return time.Duration(value * float64(time.Second))
+}
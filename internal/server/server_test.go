@@ -0,0 +1,78 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/BackMarket-oss/kube-transition-metrics/internal/options"
+	"github.com/BackMarket-oss/kube-transition-metrics/internal/testhelpers"
+	"github.com/stretchr/testify/assert"
+)
+
+type staticReadiness bool
+
+func (r staticReadiness) Ready() bool {
+	return bool(r)
+}
+
+func TestHealthz(t *testing.T) {
+	testhelpers.ConfigureLogging(t, &options.Options{})
+
+	srv := New(&options.Options{}, staticReadiness(false))
+
+	recorder := httptest.NewRecorder()
+	srv.server.Handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+
+	assert.Equal(t, http.StatusOK, recorder.Code, "Expected /healthz to succeed even when not ready")
+}
+
+func TestReadyz(t *testing.T) {
+	testhelpers.ConfigureLogging(t, &options.Options{})
+
+	for _, test := range []struct {
+		name     string
+		checkers []ReadinessChecker
+		expected int
+	}{
+		{name: "NoCheckers", checkers: nil, expected: http.StatusOK},
+		{name: "Ready", checkers: []ReadinessChecker{staticReadiness(true)}, expected: http.StatusOK},
+		{name: "NotReady", checkers: []ReadinessChecker{staticReadiness(false)}, expected: http.StatusServiceUnavailable},
+		{
+			name:     "PartiallyReady",
+			checkers: []ReadinessChecker{staticReadiness(true), staticReadiness(false)},
+			expected: http.StatusServiceUnavailable,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			srv := New(&options.Options{}, test.checkers...)
+
+			recorder := httptest.NewRecorder()
+			srv.server.Handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+			assert.Equal(t, test.expected, recorder.Code, "Unexpected /readyz status code")
+		})
+	}
+}
+
+func TestPprofDisabledByDefault(t *testing.T) {
+	srv := New(&options.Options{})
+	assert.Nil(t, srv.pprofServer, "Expected pprof server to be disabled without a listen address")
+
+	srv = New(&options.Options{PprofListenAddress: "127.0.0.1:6060"})
+	assert.NotNil(t, srv.pprofServer, "Expected pprof server to be enabled with a listen address")
+}
+
+func TestHandle(t *testing.T) {
+	testhelpers.ConfigureLogging(t, &options.Options{})
+
+	srv := New(&options.Options{})
+	srv.Handle("/summaries", http.HandlerFunc(func(writer http.ResponseWriter, _ *http.Request) {
+		writer.WriteHeader(http.StatusTeapot)
+	}))
+
+	recorder := httptest.NewRecorder()
+	srv.server.Handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/summaries", nil))
+
+	assert.Equal(t, http.StatusTeapot, recorder.Code, "Expected the additional handler to serve its pattern")
+}
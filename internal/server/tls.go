@@ -0,0 +1,98 @@
+package server
+
+import (
+	"crypto/tls"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// certificateReloader loads the TLS key pair from disk, and reloads it when either file is modified.
+// This allows certificates rotated by e.g. cert-manager to be picked up without restarting the controller.
+type certificateReloader struct {
+	certFile string
+	keyFile  string
+
+	mu          sync.Mutex
+	certificate *tls.Certificate
+	certModTime time.Time
+	keyModTime  time.Time
+}
+
+// newCertificateReloader creates a new certificateReloader, the key pair is loaded lazily.
+func newCertificateReloader(certFile, keyFile string) *certificateReloader {
+	return &certificateReloader{
+		certFile: certFile,
+		keyFile:  keyFile,
+	}
+}
+
+// tlsConfig returns a TLS configuration serving the reloaded certificate.
+func (r *certificateReloader) tlsConfig() *tls.Config {
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		GetCertificate: func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+			return r.load()
+		},
+	}
+}
+
+// load returns the current certificate, reloading it from disk if either file has been modified since it was last
+// loaded.
+// If reloading fails, the previously loaded certificate continues to be served.
+func (r *certificateReloader) load() (*tls.Certificate, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	certModTime, err := modTime(r.certFile)
+	if err != nil {
+		return r.fallback(err)
+	}
+
+	keyModTime, err := modTime(r.keyFile)
+	if err != nil {
+		return r.fallback(err)
+	}
+
+	if r.certificate != nil && certModTime.Equal(r.certModTime) && keyModTime.Equal(r.keyModTime) {
+		return r.certificate, nil
+	}
+
+	certificate, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return r.fallback(fmt.Errorf("failed to load TLS key pair: %w", err))
+	}
+
+	log.Info().Str("tls_cert_file", r.certFile).Msg("Loaded TLS certificate")
+
+	r.certificate = &certificate
+	r.certModTime = certModTime
+	r.keyModTime = keyModTime
+
+	return r.certificate, nil
+}
+
+// fallback returns the previously loaded certificate if there is one, otherwise the error.
+// It must be called with the lock held.
+func (r *certificateReloader) fallback(err error) (*tls.Certificate, error) {
+	if r.certificate == nil {
+		return nil, err
+	}
+
+	log.Error().Err(err).Str("tls_cert_file", r.certFile).Msg("Failed to reload TLS certificate, keeping previous")
+
+	return r.certificate, nil
+}
+
+// modTime returns the modification time of the file.
+func modTime(path string) (time.Time, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to stat %s: %w", path, err)
+	}
+
+	return info.ModTime(), nil
+}
@@ -0,0 +1,174 @@
+// Package services indexes the Services selecting pods and watches EndpointSlices to detect when the address of a pod
+// first becomes ready, i.e. when traffic starts flowing to the pod.
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+// ErrCacheSync is returned when the Service and EndpointSlice informer caches fail to sync.
+var ErrCacheSync = errors.New("failed to sync service informer caches")
+
+// EndpointReadyHandler is called when the address of a pod first appears as ready in an EndpointSlice of the Service.
+type EndpointReadyHandler func(uid types.UID, service string)
+
+// Index resolves the Services selecting a pod from an informer cache.
+//
+// Index implements [github.com/BackMarket-oss/kube-transition-metrics/internal/statistics/state.PodLabeler].
+type Index struct {
+	services corev1listers.ServiceLister
+	// factory and endpointSlices are the informer factory and EndpointSlice informer, they are nil if the index was not
+	// started by [Start].
+	factory        informers.SharedInformerFactory
+	endpointSlices cache.SharedIndexInformer
+}
+
+// NewIndex creates a new Index from the provided Service lister.
+func NewIndex(services corev1listers.ServiceLister) *Index {
+	return &Index{services: services}
+}
+
+// Start starts the informers caching the Services and EndpointSlices until the context is done, waits for their
+// initial sync, and returns an Index backed by them.
+func Start(ctx context.Context, clientset kubernetes.Interface) (*Index, error) {
+	factory := informers.NewSharedInformerFactoryWithOptions(clientset, 0, informers.WithTransform(strip))
+	serviceInformer := factory.Core().V1().Services()
+	endpointSliceInformer := factory.Discovery().V1().EndpointSlices()
+	// The informers must be requested before starting the factory.
+	services := serviceInformer.Lister()
+
+	factory.Start(ctx.Done())
+
+	if !cache.WaitForCacheSync(
+		ctx.Done(), serviceInformer.Informer().HasSynced, endpointSliceInformer.Informer().HasSynced,
+	) {
+		return nil, fmt.Errorf("%w: %w", ErrCacheSync, ctx.Err())
+	}
+
+	index := NewIndex(services)
+	index.factory = factory
+	index.endpointSlices = endpointSliceInformer.Informer()
+
+	return index, nil
+}
+
+// HandleEndpointReady calls the handler each time the address of a pod becomes ready in an EndpointSlice, but not for
+// the addresses already ready when the handler is added.
+// The index must have been started by [Start].
+func (i *Index) HandleEndpointReady(handler EndpointReadyHandler) error {
+	_, err := i.endpointSlices.AddEventHandler(cache.ResourceEventHandlerDetailedFuncs{
+		AddFunc: func(obj any, isInInitialList bool) {
+			if !isInInitialList {
+				notifyReady(nil, obj, handler)
+			}
+		},
+		UpdateFunc: func(oldObj, newObj any) {
+			notifyReady(oldObj, newObj, handler)
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to add EndpointSlice event handler: %w", err)
+	}
+
+	return nil
+}
+
+// Close waits for the informers to stop after the context passed to [Start] is done, so that the handler is no
+// longer called.
+func (i *Index) Close() {
+	if i.factory != nil {
+		i.factory.Shutdown()
+	}
+}
+
+// Services returns the sorted names of the Services in the namespace of the pod whose selector matches the pod.
+func (i *Index) Services(pod *corev1.Pod) []string {
+	services, err := i.services.Services(pod.Namespace).List(labels.Everything())
+	if err != nil {
+		log.Debug().Err(err).Str("kube_namespace", pod.Namespace).Msg("Failed to list services from cache")
+
+		return nil
+	}
+
+	names := make([]string, 0, len(services))
+
+	for _, service := range services {
+		// Services without a selector have their endpoints managed manually, and never select pods.
+		if len(service.Spec.Selector) == 0 {
+			continue
+		}
+
+		if labels.SelectorFromValidatedSet(service.Spec.Selector).Matches(labels.Set(pod.Labels)) {
+			names = append(names, service.Name)
+		}
+	}
+
+	slices.Sort(names)
+
+	return names
+}
+
+// PodLabels returns a function that adds the kube_service field with the Services selecting the pod to the event.
+// PodLabels implements [github.com/BackMarket-oss/kube-transition-metrics/internal/statistics/state.PodLabeler].
+func (i *Index) PodLabels(pod *corev1.Pod) func(event *zerolog.Event) {
+	services := i.Services(pod)
+
+	return func(event *zerolog.Event) {
+		if len(services) > 0 {
+			event.Strs("kube_service", services)
+		}
+	}
+}
+
+// notifyReady calls the handler for each pod whose address is ready in the new EndpointSlice but not in the old one.
+func notifyReady(oldObj, newObj any, handler EndpointReadyHandler) {
+	newSlice, ok := newObj.(*discoveryv1.EndpointSlice)
+	if !ok {
+		return
+	}
+
+	previouslyReady := map[types.UID]struct{}{}
+	if oldSlice, ok := oldObj.(*discoveryv1.EndpointSlice); ok {
+		previouslyReady = readyPods(oldSlice)
+	}
+
+	service := newSlice.Labels[discoveryv1.LabelServiceName]
+
+	for uid := range readyPods(newSlice) {
+		if _, ok := previouslyReady[uid]; !ok {
+			handler(uid, service)
+		}
+	}
+}
+
+// readyPods returns the UIDs of the pods whose address is ready in the EndpointSlice.
+func readyPods(slice *discoveryv1.EndpointSlice) map[types.UID]struct{} {
+	ready := make(map[types.UID]struct{}, len(slice.Endpoints))
+
+	for _, endpoint := range slice.Endpoints {
+		if endpoint.TargetRef == nil || endpoint.TargetRef.Kind != "Pod" {
+			continue
+		}
+
+		// A nil ready condition should be interpreted as ready.
+		if endpoint.Conditions.Ready == nil || *endpoint.Conditions.Ready {
+			ready[endpoint.TargetRef.UID] = struct{}{}
+		}
+	}
+
+	return ready
+}
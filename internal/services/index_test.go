@@ -0,0 +1,136 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+func newTestingService(namespace, name string, selector map[string]string) *corev1.Service {
+	return &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name},
+		Spec:       corev1.ServiceSpec{Selector: selector},
+	}
+}
+
+func newTestingIndex(t *testing.T) *Index {
+	t.Helper()
+
+	indexer := cache.NewIndexer(
+		cache.MetaNamespaceKeyFunc,
+		cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc},
+	)
+	for _, service := range []*corev1.Service{
+		newTestingService("test-namespace", "web", map[string]string{"app": "web"}),
+		newTestingService("test-namespace", "web-canary", map[string]string{"app": "web", "track": "canary"}),
+		newTestingService("test-namespace", "api", map[string]string{"app": "api"}),
+		newTestingService("test-namespace", "external", nil),
+		newTestingService("other-namespace", "web", map[string]string{"app": "web"}),
+	} {
+		require.NoError(t, indexer.Add(service), "Failed to add service to indexer")
+	}
+
+	return NewIndex(corev1listers.NewServiceLister(indexer))
+}
+
+func newTestingPod(labels map[string]string) *corev1.Pod {
+	return &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "test-namespace", Name: "test-pod", Labels: labels}}
+}
+
+func TestIndexServices(t *testing.T) {
+	t.Parallel()
+
+	index := newTestingIndex(t)
+
+	assert.Equal(t,
+		[]string{"web", "web-canary"},
+		index.Services(newTestingPod(map[string]string{"app": "web", "track": "canary"})))
+	assert.Equal(t, []string{"web"}, index.Services(newTestingPod(map[string]string{"app": "web"})))
+	assert.Empty(t, index.Services(newTestingPod(nil)), "Expected services without selector to be ignored")
+}
+
+func TestIndexPodLabels(t *testing.T) {
+	t.Parallel()
+
+	index := newTestingIndex(t)
+
+	buf := &bytes.Buffer{}
+	logger := zerolog.New(buf)
+	logger.Log().Func(index.PodLabels(newTestingPod(map[string]string{"app": "api"}))).Send()
+
+	record := map[string]any{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &record), "Failed to decode log record")
+	assert.Equal(t, map[string]any{"kube_service": []any{"api"}}, record)
+}
+
+func newTestingEndpointSlice(ready map[types.UID]*bool) *discoveryv1.EndpointSlice {
+	slice := &discoveryv1.EndpointSlice{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "test-namespace",
+			Name:      "web-abcde",
+			Labels:    map[string]string{discoveryv1.LabelServiceName: "web"},
+		},
+	}
+
+	for uid, isReady := range ready {
+		slice.Endpoints = append(slice.Endpoints, discoveryv1.Endpoint{
+			Conditions: discoveryv1.EndpointConditions{Ready: isReady},
+			TargetRef:  &corev1.ObjectReference{Kind: "Pod", UID: uid},
+		})
+	}
+
+	return slice
+}
+
+func TestNotifyReady(t *testing.T) {
+	t.Parallel()
+
+	notified := map[types.UID]string{}
+	handler := func(uid types.UID, service string) {
+		notified[uid] = service
+	}
+
+	oldSlice := newTestingEndpointSlice(map[types.UID]*bool{
+		"already-ready":  new(true),
+		"becoming-ready": new(false),
+	})
+	newSlice := newTestingEndpointSlice(map[types.UID]*bool{
+		"already-ready":   new(true),
+		"becoming-ready":  new(true),
+		"added-ready":     nil,
+		"added-not-ready": new(false),
+	})
+	newSlice.Endpoints = append(newSlice.Endpoints, discoveryv1.Endpoint{
+		TargetRef: &corev1.ObjectReference{Kind: "Node", UID: "not-a-pod"},
+	})
+
+	notifyReady(oldSlice, newSlice, handler)
+
+	assert.Equal(t, map[types.UID]string{"becoming-ready": "web", "added-ready": "web"}, notified)
+}
+
+func TestStrip(t *testing.T) {
+	t.Parallel()
+
+	slice := newTestingEndpointSlice(map[types.UID]*bool{"test-uid": new(true)})
+	slice.Endpoints[0].Addresses = []string{"10.0.0.1"}
+
+	stripped, err := strip(slice)
+	require.NoError(t, err)
+
+	strippedSlice, ok := stripped.(*discoveryv1.EndpointSlice)
+	require.True(t, ok, "Expected an EndpointSlice")
+	assert.Equal(t, readyPods(slice), readyPods(strippedSlice), "Expected ready pods to be kept")
+	assert.Nil(t, strippedSlice.Endpoints[0].Addresses, "Expected addresses to be dropped")
+	assert.Equal(t, slice.Labels, strippedSlice.Labels, "Expected labels to be kept")
+}
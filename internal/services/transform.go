@@ -0,0 +1,49 @@
+package services
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// strip keeps only the fields of the Services and EndpointSlices used by the index, to limit the memory used by the
+// caches.
+// strip implements [k8s.io/client-go/tools/cache.TransformFunc].
+func strip(object any) (any, error) {
+	switch object := object.(type) {
+	case *corev1.Service:
+		return &corev1.Service{
+			TypeMeta:   object.TypeMeta,
+			ObjectMeta: stripObjectMeta(object.ObjectMeta),
+			Spec:       corev1.ServiceSpec{Selector: object.Spec.Selector},
+		}, nil
+	case *discoveryv1.EndpointSlice:
+		endpoints := make([]discoveryv1.Endpoint, 0, len(object.Endpoints))
+		for _, endpoint := range object.Endpoints {
+			endpoints = append(endpoints, discoveryv1.Endpoint{
+				Conditions: discoveryv1.EndpointConditions{Ready: endpoint.Conditions.Ready},
+				TargetRef:  endpoint.TargetRef,
+			})
+		}
+
+		return &discoveryv1.EndpointSlice{
+			TypeMeta:   object.TypeMeta,
+			ObjectMeta: stripObjectMeta(object.ObjectMeta),
+			Endpoints:  endpoints,
+		}, nil
+	default:
+		// Tombstones of deleted objects are passed through unchanged.
+		return object, nil
+	}
+}
+
+// stripObjectMeta keeps only the identifying metadata and the labels of the object.
+func stripObjectMeta(objectMeta metav1.ObjectMeta) metav1.ObjectMeta {
+	return metav1.ObjectMeta{
+		Name:            objectMeta.Name,
+		Namespace:       objectMeta.Namespace,
+		UID:             objectMeta.UID,
+		ResourceVersion: objectMeta.ResourceVersion,
+		Labels:          objectMeta.Labels,
+	}
+}
@@ -0,0 +1,192 @@
+// Package simulation generates synthetic pod lifecycles from a profile and feeds them through the collectors and the
+// statistic event loops with a fake clientset, to load test the controller without a cluster.
+package simulation
+
+import (
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"os"
+	"time"
+
+	"github.com/BackMarket-oss/kube-transition-metrics/internal/options"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+)
+
+// DistributionType is the type of a duration distribution.
+type DistributionType string
+
+const (
+	// DistributionConstant always samples the mean.
+	DistributionConstant DistributionType = "constant"
+	// DistributionUniform samples uniformly between the minimum and the maximum.
+	DistributionUniform DistributionType = "uniform"
+	// DistributionExponential samples the minimum plus an exponentially distributed duration, so that the samples
+	// average to the mean, truncated to the maximum if set.
+	DistributionExponential DistributionType = "exponential"
+)
+
+// Distribution is a distribution of durations sampled for each simulated pod.
+type Distribution struct {
+	// Type is the type of the distribution, it defaults to constant.
+	Type DistributionType `json:"type,omitempty"`
+	// Min is the minimum of the uniform and exponential distributions.
+	Min metav1.Duration `json:"min,omitzero"`
+	// Max is the maximum of the uniform distribution, and truncates the exponential distribution if set.
+	Max metav1.Duration `json:"max,omitzero"`
+	// Mean is the duration of the constant distribution, and the mean of the exponential distribution.
+	Mean metav1.Duration `json:"mean,omitzero"`
+}
+
+// sample returns a duration sampled from the distribution.
+func (d Distribution) sample(random *rand.Rand) time.Duration {
+	switch d.Type {
+	case DistributionUniform:
+		if d.Max.Duration <= d.Min.Duration {
+			return d.Min.Duration
+		}
+
+		return d.Min.Duration + time.Duration(random.Int64N(int64(d.Max.Duration-d.Min.Duration)))
+	case DistributionExponential:
+		sampled := d.Min.Duration + time.Duration(random.ExpFloat64()*float64(d.Mean.Duration-d.Min.Duration))
+		if d.Max.Duration > 0 && sampled > d.Max.Duration {
+			return d.Max.Duration
+		}
+
+		return sampled
+	default:
+		return d.Mean.Duration
+	}
+}
+
+// validate returns the problems of the distribution, named after the profile field.
+func (d Distribution) validate(field string) []error {
+	var errs []error
+
+	invalid := func(format string, args ...any) {
+		errs = append(errs, &options.ValidationError{Option: field, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if d.Min.Duration < 0 || d.Max.Duration < 0 || d.Mean.Duration < 0 {
+		invalid("durations must not be negative")
+	}
+
+	switch d.Type {
+	case "", DistributionConstant:
+	case DistributionUniform:
+		if d.Max.Duration < d.Min.Duration {
+			invalid("max must not be less than min, got %s < %s", d.Max.Duration, d.Min.Duration)
+		}
+	case DistributionExponential:
+		if d.Mean.Duration < d.Min.Duration {
+			invalid("mean must not be less than min, got %s < %s", d.Mean.Duration, d.Min.Duration)
+		}
+	default:
+		invalid("type must be one of %s, %s or %s, got %q",
+			DistributionConstant, DistributionUniform, DistributionExponential, d.Type)
+	}
+
+	return errs
+}
+
+// Profile describes the synthetic pod lifecycles generated by a simulation.
+//
+// Each simulated pod is created pending, scheduled after the scheduling delay, pulls the image of its container for the
+// image pull duration, starts it after the startup delay, becomes ready after the readiness delay, and is deleted after
+// its lifetime.
+// The pods whose image pull fails, or whose container fails to start, are deleted after their lifetime without
+// becoming ready.
+type Profile struct {
+	// Rate is the number of pods created per minute.
+	Rate float64 `json:"rate"`
+	// Duration is how long pods are created for, the simulation ends once all the created pods are deleted.
+	Duration metav1.Duration `json:"duration"`
+	// Namespaces is the number of namespaces the pods are spread across, it defaults to 1.
+	Namespaces int `json:"namespaces,omitempty"`
+	// Seed seeds the random samples, so that simulations are reproducible.
+	Seed uint64 `json:"seed,omitempty"`
+
+	// SchedulingDelay is the delay from the creation of a pod to its scheduling.
+	SchedulingDelay Distribution `json:"schedulingDelay,omitzero"`
+	// ImagePullDuration is the duration of the image pull of the container, from the Pulling to the Pulled Event.
+	ImagePullDuration Distribution `json:"imagePullDuration,omitzero"`
+	// StartupDelay is the delay from the end of the image pull to the container running.
+	StartupDelay Distribution `json:"startupDelay,omitzero"`
+	// ReadinessDelay is the delay from the container running to the pod being ready, e.g. the readiness probe delays.
+	ReadinessDelay Distribution `json:"readinessDelay,omitzero"`
+	// Lifetime is the delay from the pod being ready, or failing, to its deletion.
+	Lifetime Distribution `json:"lifetime,omitzero"`
+
+	// ImagePullFailureRate is the fraction of the pods whose image pull fails.
+	ImagePullFailureRate float64 `json:"imagePullFailureRate,omitempty"`
+	// StartupFailureRate is the fraction of the pods whose container fails to start.
+	StartupFailureRate float64 `json:"startupFailureRate,omitempty"`
+}
+
+// LoadProfile reads the YAML or JSON profile file, and validates it.
+func LoadProfile(path string) (*Profile, error) {
+	//nolint:gosec // The path is provided by the operator.
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read profile: %w", err)
+	}
+
+	profile := &Profile{}
+	if err := yaml.UnmarshalStrict(data, profile); err != nil {
+		return nil, fmt.Errorf("failed to parse profile %s: %w", path, err)
+	}
+
+	if err := profile.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid profile %s: %w", path, err)
+	}
+
+	return profile, nil
+}
+
+// Validate checks the profile for invalid values, it returns all the problems found joined in a single error.
+func (p *Profile) Validate() error {
+	var errs []error
+
+	invalid := func(field, format string, args ...any) {
+		errs = append(errs, &options.ValidationError{Option: field, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if p.Rate <= 0 {
+		invalid("rate", "must be positive, got %v", p.Rate)
+	}
+
+	if p.Duration.Duration <= 0 {
+		invalid("duration", "must be positive, got %s", p.Duration.Duration)
+	}
+
+	if p.Namespaces < 0 {
+		invalid("namespaces", "must not be negative, got %d", p.Namespaces)
+	}
+
+	for field, rate := range map[string]float64{
+		"imagePullFailureRate": p.ImagePullFailureRate,
+		"startupFailureRate":   p.StartupFailureRate,
+	} {
+		if rate < 0 || rate > 1 {
+			invalid(field, "must be between 0 and 1, got %v", rate)
+		}
+	}
+
+	for field, distribution := range map[string]Distribution{
+		"schedulingDelay":   p.SchedulingDelay,
+		"imagePullDuration": p.ImagePullDuration,
+		"startupDelay":      p.StartupDelay,
+		"readinessDelay":    p.ReadinessDelay,
+		"lifetime":          p.Lifetime,
+	} {
+		errs = append(errs, distribution.validate(field)...)
+	}
+
+	return errors.Join(errs...)
+}
+
+// interval returns the delay between the creation of two pods.
+func (p *Profile) interval() time.Duration {
+	return time.Duration(float64(time.Minute) / p.Rate)
+}
@@ -0,0 +1,95 @@
+package simulation
+
+import (
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/BackMarket-oss/kube-transition-metrics/internal/options"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestLoadProfile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "profile.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`
+rate: 10000
+duration: 5m
+namespaces: 20
+imagePullDuration:
+  type: exponential
+  min: 1s
+  mean: 5s
+  max: 1m
+imagePullFailureRate: 0.01
+`), 0o600))
+
+	profile, err := LoadProfile(path)
+	require.NoError(t, err)
+	assert.InDelta(t, 10000.0, profile.Rate, 0)
+	assert.Equal(t, 5*time.Minute, profile.Duration.Duration)
+	assert.Equal(t, DistributionExponential, profile.ImagePullDuration.Type)
+	assert.Equal(t, 6*time.Millisecond, profile.interval())
+
+	require.NoError(t, os.WriteFile(path, []byte(`
+rate: 0
+duration: 5m
+imagePullFailureRate: 2
+readinessDelay:
+  type: uniform
+  min: 2s
+  max: 1s
+`), 0o600))
+
+	_, err = LoadProfile(path)
+
+	var validationErr *options.ValidationError
+
+	require.ErrorAs(t, err, &validationErr)
+	assert.ErrorContains(t, err, "rate: must be positive")
+	assert.ErrorContains(t, err, "imagePullFailureRate: must be between 0 and 1")
+	assert.ErrorContains(t, err, "readinessDelay: max must not be less than min")
+
+	require.NoError(t, os.WriteFile(path, []byte("rate: 1\nunknown: true\n"), 0o600))
+
+	_, err = LoadProfile(path)
+	require.Error(t, err, "Expected unknown fields to be rejected")
+}
+
+func TestDistributionSample(t *testing.T) {
+	//nolint:gosec // The samples only need to be reproducible, not secure.
+	random := rand.New(rand.NewPCG(1, 1))
+	duration := func(d time.Duration) metav1.Duration {
+		return metav1.Duration{Duration: d}
+	}
+
+	constant := Distribution{Mean: duration(time.Second)}
+	assert.Equal(t, time.Second, constant.sample(random))
+
+	uniform := Distribution{Type: DistributionUniform, Min: duration(time.Second), Max: duration(2 * time.Second)}
+	exponential := Distribution{
+		Type: DistributionExponential,
+		Min:  duration(time.Second),
+		Mean: duration(3 * time.Second),
+		Max:  duration(10 * time.Second),
+	}
+
+	var total time.Duration
+
+	for range 1000 {
+		sample := uniform.sample(random)
+		assert.GreaterOrEqual(t, sample, time.Second)
+		assert.Less(t, sample, 2*time.Second)
+
+		sample = exponential.sample(random)
+		assert.GreaterOrEqual(t, sample, time.Second)
+		assert.LessOrEqual(t, sample, 10*time.Second)
+
+		total += sample
+	}
+
+	assert.InDelta(t, 3.0, (total / 1000).Seconds(), 0.3, "Expected the exponential samples to average to the mean")
+}
@@ -0,0 +1,192 @@
+package simulation
+
+import (
+	"runtime"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/BackMarket-oss/kube-transition-metrics/internal/prommetrics"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"k8s.io/utils/clock"
+)
+
+// memorySampleInterval is the interval between the samples of the heap in use during a simulation.
+const memorySampleInterval = time.Second
+
+// Report is the report of a simulation.
+type Report struct {
+	// Interrupted indicates the simulation was stopped before all the simulated pods were deleted.
+	Interrupted bool `json:"interrupted"`
+	// Pods is the number of simulated pods created.
+	Pods int `json:"pods"`
+	// PodWatchEvents is the number of Pod watch events received by the pod collector.
+	PodWatchEvents int `json:"pod_watch_events"`
+	// EventWatchEvents is the number of Event watch events received by the image pull collectors.
+	EventWatchEvents int `json:"event_watch_events"`
+	// ElapsedSeconds is the duration of the simulation, including the draining of the statistic event loops.
+	ElapsedSeconds float64 `json:"elapsed_seconds"`
+	// PodsPerSecond is the throughput of simulated pods.
+	PodsPerSecond float64 `json:"pods_per_second"`
+	// WatchEventsPerSecond is the throughput of Pod and Event watch events.
+	WatchEventsPerSecond float64 `json:"watch_events_per_second"`
+	// MaxLagSeconds is the longest delay of a lifecycle step behind its scheduled time, which grows when the collectors
+	// and the statistic event loops do not keep up with the profile.
+	MaxLagSeconds float64 `json:"max_lag_seconds"`
+	// StatisticEventProcessing summarizes the statistic_event_processing_seconds metric of each event loop.
+	StatisticEventProcessing map[string]EventProcessingReport `json:"statistic_event_processing"`
+	// Memory summarizes the memory used by the process during the simulation.
+	Memory MemoryReport `json:"memory"`
+}
+
+// EventProcessingReport summarizes the time spent processing the statistic events of an event loop.
+type EventProcessingReport struct {
+	// Count is the number of statistic events processed.
+	Count uint64 `json:"count"`
+	// MeanSeconds is the mean time spent processing a statistic event.
+	MeanSeconds float64 `json:"mean_seconds"`
+	// QuantileSeconds are the quantiles of the time spent processing a statistic event, by quantile.
+	QuantileSeconds map[string]float64 `json:"quantile_seconds"`
+}
+
+// MemoryReport summarizes the memory used by the process during a simulation.
+type MemoryReport struct {
+	// PeakHeapInuseBytes is the largest heap in use sampled during the simulation.
+	PeakHeapInuseBytes uint64 `json:"peak_heap_inuse_bytes"`
+	// TotalAllocBytes is the cumulative size of the heap objects allocated during the simulation.
+	TotalAllocBytes uint64 `json:"total_alloc_bytes"`
+	// GCCycles is the number of garbage collection cycles completed during the simulation.
+	GCCycles uint32 `json:"gc_cycles"`
+}
+
+// observeLag records the delay of a lifecycle step behind its scheduled time.
+func (r *Report) observeLag(lag time.Duration) {
+	r.MaxLagSeconds = max(r.MaxLagSeconds, lag.Seconds())
+}
+
+// complete computes the throughputs over the elapsed duration, and gathers the statistic event processing metrics.
+func (r *Report) complete(elapsed time.Duration, memory MemoryReport) {
+	r.ElapsedSeconds = elapsed.Seconds()
+	if r.ElapsedSeconds > 0 {
+		r.PodsPerSecond = float64(r.Pods) / r.ElapsedSeconds
+		r.WatchEventsPerSecond = float64(r.PodWatchEvents+r.EventWatchEvents) / r.ElapsedSeconds
+	}
+
+	r.StatisticEventProcessing = gatherEventProcessing(prommetrics.StatisticEventProcessing)
+	r.Memory = memory
+}
+
+// gatherEventProcessing summarizes the statistic event processing summaries of each event loop.
+// The summaries are cumulative since the process started, and their quantiles are computed over a sliding window.
+func gatherEventProcessing(collector prometheus.Collector) map[string]EventProcessingReport {
+	metrics := make(chan prometheus.Metric)
+
+	go func() {
+		defer close(metrics)
+
+		collector.Collect(metrics)
+	}()
+
+	reports := make(map[string]EventProcessingReport)
+
+	for metric := range metrics {
+		written := &dto.Metric{}
+		if err := metric.Write(written); err != nil || written.GetSummary() == nil {
+			continue
+		}
+
+		var eventLoop string
+
+		for _, label := range written.GetLabel() {
+			if label.GetName() == "event_loop" {
+				eventLoop = label.GetValue()
+			}
+		}
+
+		summary := written.GetSummary()
+		report := EventProcessingReport{
+			Count:           summary.GetSampleCount(),
+			QuantileSeconds: make(map[string]float64, len(summary.GetQuantile())),
+		}
+
+		if report.Count > 0 {
+			report.MeanSeconds = summary.GetSampleSum() / float64(report.Count)
+		}
+
+		for _, quantile := range summary.GetQuantile() {
+			report.QuantileSeconds[strconv.FormatFloat(quantile.GetQuantile(), 'f', -1, 64)] = quantile.GetValue()
+		}
+
+		reports[eventLoop] = report
+	}
+
+	return reports
+}
+
+// memorySampler samples the heap in use periodically, to report its peak during a simulation.
+type memorySampler struct {
+	stopChan chan struct{}
+	done     chan struct{}
+
+	// mu protects peak, which is updated by the sampling goroutine.
+	mu       sync.Mutex
+	peak     uint64
+	baseline runtime.MemStats
+}
+
+// newMemorySampler starts sampling the heap in use until stopped.
+func newMemorySampler(clock clock.WithTicker) *memorySampler {
+	sampler := &memorySampler{
+		stopChan: make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	runtime.ReadMemStats(&sampler.baseline)
+	sampler.peak = sampler.baseline.HeapInuse
+
+	go func() {
+		defer close(sampler.done)
+
+		ticker := clock.NewTicker(memorySampleInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-sampler.stopChan:
+				return
+			case <-ticker.C():
+				sampler.sample()
+			}
+		}
+	}()
+
+	return sampler
+}
+
+// sample samples the heap in use, and returns the memory statistics.
+func (m *memorySampler) sample() runtime.MemStats {
+	var stats runtime.MemStats
+
+	runtime.ReadMemStats(&stats)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.peak = max(m.peak, stats.HeapInuse)
+
+	return stats
+}
+
+// stop stops sampling, and returns the memory used since the sampler started.
+func (m *memorySampler) stop() MemoryReport {
+	close(m.stopChan)
+	<-m.done
+
+	stats := m.sample()
+
+	return MemoryReport{
+		PeakHeapInuseBytes: m.peak,
+		TotalAllocBytes:    stats.TotalAlloc - m.baseline.TotalAlloc,
+		GCCycles:           stats.NumGC - m.baseline.NumGC,
+	}
+}
@@ -0,0 +1,507 @@
+package simulation
+
+import (
+	"container/heap"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/BackMarket-oss/kube-transition-metrics/internal/options"
+	"github.com/BackMarket-oss/kube-transition-metrics/internal/statistics"
+	"github.com/rs/zerolog/log"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	apimachinerytypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+	"k8s.io/utils/clock"
+)
+
+// containerName is the name of the container of the simulated pods.
+const containerName = "app"
+
+// eventWatcherBuffer is the number of Events buffered for each image pull collector, which is more than the number of
+// Events sent for a simulated pod, so that sending them never blocks the simulation.
+const eventWatcherBuffer = 8
+
+// errPodWatchNotStarted is returned when the simulation is interrupted before the pod collector watches the Pods.
+var errPodWatchNotStarted = errors.New("pod collector did not start watching")
+
+// Simulator feeds the synthetic pod lifecycles of a profile through the pod collector, its image pull collectors and
+// the statistic event loops, serving their Pod and Event watches from a fake clientset.
+//
+// The lifecycle steps of all the pods are run in order of their scheduled time by a single goroutine, which is
+// delayed when the collectors do not keep up with the watch events.
+type Simulator struct {
+	options       *options.Options
+	profile       *Profile
+	output        io.Writer
+	eventLoopOpts []statistics.EventLoopOption
+	clock         clock.WithTicker
+	random        *rand.Rand
+
+	// mu protects the watchers, which are created by the collectors.
+	mu              sync.Mutex
+	podWatcher      *simulatedWatcher
+	podWatcherReady chan struct{}
+	eventWatchers   map[apimachinerytypes.UID]*simulatedWatcher
+
+	// The following fields are only accessed by the goroutine running the lifecycle steps.
+	steps           stepQueue
+	sequence        int
+	resourceVersion int
+	end             time.Time
+	report          *Report
+}
+
+// NewSimulator creates a new Simulator reporting the metric records of the simulated pods to the output.
+// The event loop options are applied to both statistic event loops.
+func NewSimulator(
+	opts *options.Options,
+	profile *Profile,
+	output io.Writer,
+	eventLoopOpts ...statistics.EventLoopOption,
+) *Simulator {
+	return &Simulator{
+		options:       opts,
+		profile:       profile,
+		output:        output,
+		eventLoopOpts: eventLoopOpts,
+		clock:         clock.RealClock{},
+		//nolint:gosec // The samples only need to be reproducible, not secure.
+		random:          rand.New(rand.NewPCG(profile.Seed, profile.Seed)),
+		podWatcherReady: make(chan struct{}),
+		eventWatchers:   make(map[apimachinerytypes.UID]*simulatedWatcher),
+		resourceVersion: 1,
+		report:          &Report{},
+	}
+}
+
+// Run simulates the pod lifecycles of the profile, and returns the report of the simulation once all the simulated
+// pods are deleted and the statistic event loops are drained.
+// If the context is canceled, the simulation stops early and the report is marked as interrupted.
+func (s *Simulator) Run(ctx context.Context) (*Report, error) {
+	podStatisticEventLoop := statistics.NewStatisticEventLoop(
+		s.options,
+		s.output,
+		append(s.eventLoopOpts, statistics.WithClock(s.clock))...,
+	)
+	podStatisticEventLoop.Start()
+
+	imagePullStatisticEventLoop := statistics.NewImagePullStatisticEventLoop(s.options, s.output, s.eventLoopOpts...)
+	imagePullStatisticEventLoop.Start()
+
+	collector := statistics.NewPodCollector(
+		s.options,
+		podStatisticEventLoop,
+		imagePullStatisticEventLoop,
+		statistics.WithCollectorClock(s.clock),
+	)
+	collectorCtx, cancelCollector := context.WithCancel(ctx)
+	collectorDone := make(chan struct{})
+
+	// drain stops the pod collector and waits for the event loops to process all the statistic events.
+	drain := func() {
+		cancelCollector()
+		<-collectorDone
+
+		podStatisticEventLoop.Close()
+		imagePullStatisticEventLoop.Close()
+	}
+
+	memory := newMemorySampler(s.clock)
+
+	go func() {
+		defer close(collectorDone)
+
+		collector.Run(collectorCtx, s.newClientset())
+	}()
+
+	select {
+	case <-s.podWatcherReady:
+	case <-ctx.Done():
+		drain()
+		memory.stop()
+
+		return nil, fmt.Errorf("%w: %w", errPodWatchNotStarted, ctx.Err())
+	}
+
+	start := s.clock.Now()
+	s.end = start.Add(s.profile.Duration.Duration)
+	s.schedule(start, func(ctx context.Context) { s.createPod(ctx, start) })
+
+	log.Info().Msgf("Simulating %v pods per minute for %s ...", s.profile.Rate, s.profile.Duration.Duration)
+	s.runSteps(ctx)
+	s.flush(ctx)
+
+	// The report includes the time spent draining the event loops.
+	drain()
+	s.report.complete(s.clock.Since(start), memory.stop())
+
+	return s.report, nil
+}
+
+// runSteps runs the lifecycle steps in order of their scheduled time until none are left, or the context is
+// canceled.
+func (s *Simulator) runSteps(ctx context.Context) {
+	for s.steps.Len() > 0 {
+		//nolint:forcetypeassert // The queue only holds steps.
+		next := heap.Pop(&s.steps).(*step)
+
+		if delay := next.at.Sub(s.clock.Now()); delay > 0 {
+			select {
+			case <-s.clock.After(delay):
+			case <-ctx.Done():
+			}
+		}
+
+		if ctx.Err() != nil {
+			s.report.Interrupted = true
+
+			return
+		}
+
+		s.report.observeLag(s.clock.Since(next.at))
+		next.run(ctx)
+	}
+}
+
+// schedule schedules the lifecycle step to run at the given time.
+func (s *Simulator) schedule(at time.Time, run func(ctx context.Context)) {
+	s.sequence++
+	heap.Push(&s.steps, &step{at: at, sequence: s.sequence, run: run})
+}
+
+// after schedules the lifecycle step to run after a delay sampled from the distribution.
+func (s *Simulator) after(distribution Distribution, run func(ctx context.Context)) {
+	s.schedule(s.clock.Now().Add(distribution.sample(s.random)), run)
+}
+
+// createPod creates a pending pod, and schedules the creation of the next pod until the end of the profile duration.
+// The creations are scheduled at a fixed rate from the time the first pod was scheduled at, even if they are late.
+func (s *Simulator) createPod(ctx context.Context, at time.Time) {
+	index := s.report.Pods
+	s.report.Pods++
+
+	namespaces := max(s.profile.Namespaces, 1)
+	now := metav1.NewTime(s.clock.Now())
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			UID:               apimachinerytypes.UID("simulated-" + strconv.Itoa(index)),
+			Name:              "simulated-" + strconv.Itoa(index),
+			Namespace:         "simulation-" + strconv.Itoa(index%namespaces),
+			CreationTimestamp: now,
+		},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{{Name: containerName, Image: "simulated:latest"}},
+		},
+		Status: corev1.PodStatus{
+			Phase: corev1.PodPending,
+			ContainerStatuses: []corev1.ContainerStatus{
+				{
+					Name:  containerName,
+					Image: "simulated:latest",
+					State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "ContainerCreating"}},
+				},
+			},
+		},
+	}
+
+	s.sendPod(ctx, watch.Added, pod)
+	s.after(s.profile.SchedulingDelay, func(ctx context.Context) { s.schedulePod(ctx, pod) })
+
+	if next := at.Add(s.profile.interval()); next.Before(s.end) {
+		s.schedule(next, func(ctx context.Context) { s.createPod(ctx, next) })
+	}
+}
+
+// schedulePod schedules and initializes the pod, and starts the image pull of its container.
+func (s *Simulator) schedulePod(ctx context.Context, pod *corev1.Pod) {
+	now := metav1.NewTime(s.clock.Now())
+	pod = pod.DeepCopy()
+	pod.Spec.NodeName = "simulated-node"
+	pod.Status.Conditions = append(pod.Status.Conditions,
+		corev1.PodCondition{Type: corev1.PodScheduled, Status: corev1.ConditionTrue, LastTransitionTime: now},
+		corev1.PodCondition{Type: corev1.PodInitialized, Status: corev1.ConditionTrue, LastTransitionTime: now},
+	)
+
+	s.sendPod(ctx, watch.Modified, pod)
+	s.sendEvent(ctx, pod, corev1.EventTypeNormal, "Pulling", `Pulling image "simulated:latest"`)
+	s.after(s.profile.ImagePullDuration, func(ctx context.Context) { s.pullImage(ctx, pod) })
+}
+
+// pullImage completes or fails the image pull of the container.
+func (s *Simulator) pullImage(ctx context.Context, pod *corev1.Pod) {
+	if s.random.Float64() < s.profile.ImagePullFailureRate {
+		s.sendEvent(ctx, pod, corev1.EventTypeWarning, "Failed", `Failed to pull image "simulated:latest"`)
+
+		pod = pod.DeepCopy()
+		pod.Status.ContainerStatuses[0].State.Waiting.Reason = "ErrImagePull"
+		s.sendPod(ctx, watch.Modified, pod)
+		s.after(s.profile.Lifetime, func(ctx context.Context) { s.deletePod(ctx, pod) })
+
+		return
+	}
+
+	s.sendEvent(ctx, pod, corev1.EventTypeNormal, "Pulled", `Successfully pulled image "simulated:latest"`)
+	s.after(s.profile.StartupDelay, func(ctx context.Context) { s.startContainer(ctx, pod) })
+}
+
+// startContainer runs the container, or fails to start it.
+func (s *Simulator) startContainer(ctx context.Context, pod *corev1.Pod) {
+	pod = pod.DeepCopy()
+	status := &pod.Status.ContainerStatuses[0]
+
+	if s.random.Float64() < s.profile.StartupFailureRate {
+		status.State.Waiting.Reason = "CrashLoopBackOff"
+		status.RestartCount = 1
+		s.sendPod(ctx, watch.Modified, pod)
+		s.after(s.profile.Lifetime, func(ctx context.Context) { s.deletePod(ctx, pod) })
+
+		return
+	}
+
+	pod.Status.Phase = corev1.PodRunning
+	status.State = corev1.ContainerState{Running: &corev1.ContainerStateRunning{StartedAt: metav1.NewTime(s.clock.Now())}}
+	status.Started = new(true)
+	s.sendPod(ctx, watch.Modified, pod)
+	s.after(s.profile.ReadinessDelay, func(ctx context.Context) { s.readyPod(ctx, pod) })
+}
+
+// readyPod makes the container and the pod ready.
+func (s *Simulator) readyPod(ctx context.Context, pod *corev1.Pod) {
+	now := metav1.NewTime(s.clock.Now())
+	pod = pod.DeepCopy()
+	pod.Status.ContainerStatuses[0].Ready = true
+	pod.Status.Conditions = append(pod.Status.Conditions,
+		corev1.PodCondition{Type: corev1.ContainersReady, Status: corev1.ConditionTrue, LastTransitionTime: now},
+		corev1.PodCondition{Type: corev1.PodReady, Status: corev1.ConditionTrue, LastTransitionTime: now},
+	)
+
+	s.sendPod(ctx, watch.Modified, pod)
+	s.after(s.profile.Lifetime, func(ctx context.Context) { s.deletePod(ctx, pod) })
+}
+
+// deletePod deletes the pod.
+func (s *Simulator) deletePod(ctx context.Context, pod *corev1.Pod) {
+	now := metav1.NewTime(s.clock.Now())
+	pod = pod.DeepCopy()
+	pod.DeletionTimestamp = &now
+
+	s.sendPod(ctx, watch.Deleted, pod)
+}
+
+// sendPod sends a Pod watch event to the pod collector, blocking until it is received.
+func (s *Simulator) sendPod(ctx context.Context, eventType watch.EventType, pod *corev1.Pod) {
+	s.resourceVersion++
+	pod.ResourceVersion = strconv.Itoa(s.resourceVersion)
+
+	if s.currentPodWatcher().send(ctx, watch.Event{Type: eventType, Object: pod}) {
+		s.report.PodWatchEvents++
+	}
+}
+
+// flush returns once the pod collector has handled all the Pod watch events sent.
+// The pod collector receives the watch events through a RetryWatcher, which forwards the Pod watch events one at a
+// time and swallows the Bookmarks: once the Bookmark following the deletion of an unknown pod is received, the deletion
+// was forwarded, so the pod collector has handled the previous watch events.
+func (s *Simulator) flush(ctx context.Context) {
+	watcher := s.currentPodWatcher()
+
+	for _, eventType := range []watch.EventType{watch.Deleted, watch.Bookmark} {
+		s.resourceVersion++
+		barrier := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				UID:             "simulation-barrier",
+				Name:            "simulation-barrier",
+				Namespace:       "simulation-barrier",
+				ResourceVersion: strconv.Itoa(s.resourceVersion),
+			},
+		}
+
+		if !watcher.send(ctx, watch.Event{Type: eventType, Object: barrier}) {
+			return
+		}
+	}
+}
+
+// sendEvent sends an Event of the container of the pod to its image pull collector.
+func (s *Simulator) sendEvent(ctx context.Context, pod *corev1.Pod, eventType, reason, message string) {
+	now := metav1.NewTime(s.clock.Now())
+	event := &corev1.Event{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      pod.Name + "." + reason,
+			Namespace: pod.Namespace,
+			UID:       apimachinerytypes.UID(string(pod.UID) + "-" + reason),
+		},
+		InvolvedObject: corev1.ObjectReference{
+			Kind:      "Pod",
+			Namespace: pod.Namespace,
+			Name:      pod.Name,
+			UID:       pod.UID,
+			FieldPath: "spec.containers{" + containerName + "}",
+		},
+		Reason:         reason,
+		Message:        message,
+		Type:           eventType,
+		Source:         corev1.EventSource{Component: "kubelet", Host: pod.Spec.NodeName},
+		FirstTimestamp: now,
+		LastTimestamp:  now,
+		Count:          1,
+	}
+
+	if s.eventWatcher(pod.UID).send(ctx, watch.Event{Type: watch.Added, Object: event}) {
+		s.report.EventWatchEvents++
+	}
+}
+
+// newClientset creates the fake clientset serving the simulated Pod and Event watches.
+func (s *Simulator) newClientset() *fake.Clientset {
+	clientset := fake.NewClientset()
+	clientset.PrependReactor("list", "pods", func(k8stesting.Action) (bool, runtime.Object, error) {
+		return true, &corev1.PodList{ListMeta: metav1.ListMeta{ResourceVersion: "1"}}, nil
+	})
+	clientset.PrependWatchReactor("pods", func(k8stesting.Action) (bool, watch.Interface, error) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		if s.podWatcher == nil {
+			s.podWatcher = newSimulatedWatcher(0, nil)
+			close(s.podWatcherReady)
+		}
+
+		return true, s.podWatcher, nil
+	})
+	clientset.PrependWatchReactor("events", func(action k8stesting.Action) (bool, watch.Interface, error) {
+		//nolint:forcetypeassert // The watch reactors only receive watch actions.
+		restrictions := action.(k8stesting.WatchAction).GetWatchRestrictions()
+		uid, _ := restrictions.Fields.RequiresExactMatch("involvedObject.uid")
+
+		return true, s.eventWatcher(apimachinerytypes.UID(uid)), nil
+	})
+
+	return clientset
+}
+
+func (s *Simulator) currentPodWatcher() *simulatedWatcher {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.podWatcher
+}
+
+// eventWatcher returns the watcher of the Events of the pod, creating it if the image pull collector of the pod has
+// not watched them yet.
+// The watcher is forgotten once the image pull collector stops watching.
+func (s *Simulator) eventWatcher(uid apimachinerytypes.UID) *simulatedWatcher {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	watcher, ok := s.eventWatchers[uid]
+	if !ok {
+		watcher = newSimulatedWatcher(eventWatcherBuffer, func(watcher *simulatedWatcher) {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+
+			if s.eventWatchers[uid] == watcher {
+				delete(s.eventWatchers, uid)
+			}
+		})
+		s.eventWatchers[uid] = watcher
+	}
+
+	return watcher
+}
+
+// simulatedWatcher is a [watch.Interface] whose watch events are sent by the Simulator.
+type simulatedWatcher struct {
+	result   chan watch.Event
+	stopChan chan struct{}
+	stopOnce sync.Once
+	onStop   func(*simulatedWatcher)
+}
+
+func newSimulatedWatcher(buffer int, onStop func(*simulatedWatcher)) *simulatedWatcher {
+	return &simulatedWatcher{
+		result:   make(chan watch.Event, buffer),
+		stopChan: make(chan struct{}),
+		onStop:   onStop,
+	}
+}
+
+// ResultChan implements [watch.Interface.ResultChan].
+func (w *simulatedWatcher) ResultChan() <-chan watch.Event {
+	return w.result
+}
+
+// Stop implements [watch.Interface.Stop].
+func (w *simulatedWatcher) Stop() {
+	w.stopOnce.Do(func() {
+		close(w.stopChan)
+
+		if w.onStop != nil {
+			w.onStop(w)
+		}
+	})
+}
+
+// send sends the watch event, returning false if the watcher was stopped or the context canceled before it was
+// received.
+func (w *simulatedWatcher) send(ctx context.Context, event watch.Event) bool {
+	select {
+	case w.result <- event:
+		return true
+	case <-w.stopChan:
+		return false
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// step is a lifecycle step of a simulated pod.
+type step struct {
+	at       time.Time
+	sequence int
+	run      func(ctx context.Context)
+}
+
+// stepQueue is a [heap.Interface] of the lifecycle steps, ordered by their scheduled time and then by the order they
+// were scheduled in.
+type stepQueue []*step
+
+func (q stepQueue) Len() int {
+	return len(q)
+}
+
+func (q stepQueue) Less(i, j int) bool {
+	if q[i].at.Equal(q[j].at) {
+		return q[i].sequence < q[j].sequence
+	}
+
+	return q[i].at.Before(q[j].at)
+}
+
+func (q stepQueue) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+}
+
+func (q *stepQueue) Push(x any) {
+	//nolint:forcetypeassert // The queue only holds steps.
+	*q = append(*q, x.(*step))
+}
+
+func (q *stepQueue) Pop() any {
+	old := *q
+	last := old[len(old)-1]
+	old[len(old)-1] = nil
+	*q = old[:len(old)-1]
+
+	return last
+}
@@ -0,0 +1,123 @@
+package simulation
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/BackMarket-oss/kube-transition-metrics/internal/options"
+	"github.com/BackMarket-oss/kube-transition-metrics/internal/testhelpers"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func newTestingProfile() *Profile {
+	constant := func(d time.Duration) Distribution {
+		return Distribution{Mean: metav1.Duration{Duration: d}}
+	}
+
+	return &Profile{
+		Rate:              6000,
+		Duration:          metav1.Duration{Duration: 200 * time.Millisecond},
+		Namespaces:        3,
+		SchedulingDelay:   constant(time.Millisecond),
+		ImagePullDuration: constant(5 * time.Millisecond),
+		StartupDelay:      constant(time.Millisecond),
+		ReadinessDelay:    constant(2 * time.Millisecond),
+		Lifetime:          constant(10 * time.Millisecond),
+	}
+}
+
+func newTestingOptions(t *testing.T) *options.Options {
+	t.Helper()
+
+	opts := &options.Options{
+		StatisticEventQueueLength: 100,
+		KubeWatchTimeout:          60,
+		KubeWatchMaxEvents:        100,
+		LogLevel:                  zerolog.FatalLevel,
+	}
+	testhelpers.ConfigureLogging(t, opts)
+
+	return opts
+}
+
+func TestSimulator(t *testing.T) {
+	opts := newTestingOptions(t)
+	output := testhelpers.NewMetricWriter(t)
+
+	report, err := NewSimulator(opts, newTestingProfile(), output).Run(t.Context())
+	require.NoError(t, err)
+
+	assert.False(t, report.Interrupted)
+	assert.Equal(t, 20, report.Pods, "Expected a pod to be created every 10ms for 200ms")
+	assert.Equal(t, 5*report.Pods, report.PodWatchEvents, "Expected 5 Pod watch events per pod")
+	assert.Equal(t, 2*report.Pods, report.EventWatchEvents, "Expected a Pulling and a Pulled Event per pod")
+	assert.Positive(t, report.PodsPerSecond)
+	assert.Positive(t, report.Memory.PeakHeapInuseBytes)
+
+	var pods, imagePulls int
+
+	for _, metric := range testhelpers.DecodeMetricOutput(t, output) {
+		switch metric["type"] {
+		case "pod":
+			pods++
+
+			assert.Equal(t, false, metric["partial"])
+		case "image_pull":
+			imagePulls++
+		}
+	}
+
+	assert.Equal(t, report.Pods, pods, "Expected a pod metric for each simulated pod")
+	assert.Equal(t, report.Pods, imagePulls, "Expected an image pull metric for each simulated pod")
+}
+
+func TestSimulatorFailures(t *testing.T) {
+	opts := newTestingOptions(t)
+	output := testhelpers.NewMetricWriter(t)
+	profile := newTestingProfile()
+	profile.ImagePullFailureRate = 1
+
+	report, err := NewSimulator(opts, profile, output).Run(t.Context())
+	require.NoError(t, err)
+
+	assert.Equal(t, 4*report.Pods, report.PodWatchEvents, "Expected the pods to fail to pull their image and be deleted")
+
+	for _, metric := range testhelpers.DecodeMetricOutput(t, output) {
+		if metric["type"] == "pod" {
+			assert.Equal(t, true, metric["partial"], "Expected the pods deleted before becoming ready to be partial")
+		}
+	}
+}
+
+// cancelingWriter cancels the simulation once the first metric record is written.
+type cancelingWriter struct {
+	*testhelpers.MetricWriter
+
+	cancel context.CancelFunc
+}
+
+func (w *cancelingWriter) Write(data []byte) (int, error) {
+	w.cancel()
+
+	return w.MetricWriter.Write(data)
+}
+
+func TestSimulatorInterrupted(t *testing.T) {
+	opts := newTestingOptions(t)
+	profile := newTestingProfile()
+	profile.Duration.Duration = time.Hour
+
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+
+	output := &cancelingWriter{MetricWriter: testhelpers.NewMetricWriter(t), cancel: cancel}
+
+	report, err := NewSimulator(opts, profile, output).Run(ctx)
+	require.NoError(t, err)
+	assert.True(t, report.Interrupted, "Expected the simulation to stop once the context is canceled")
+	assert.Less(t, report.Pods, 1000)
+}
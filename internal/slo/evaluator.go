@@ -0,0 +1,282 @@
+// Package slo evaluates the latency service level objectives declared in the options against the complete pod and
+// image pull statistics, and exports their good and total events and the burn rates of their error budgets.
+package slo
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/BackMarket-oss/kube-transition-metrics/internal/options"
+	"github.com/BackMarket-oss/kube-transition-metrics/internal/prommetrics"
+	"github.com/BackMarket-oss/kube-transition-metrics/internal/statistics/state"
+	"github.com/prometheus/client_golang/prometheus"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/kubernetes/pkg/util/parsers"
+	"k8s.io/utils/clock"
+)
+
+// bucketsPerWindow is the number of buckets each window is divided into, the windows roll by one bucket at a time.
+const bucketsPerWindow = 12
+
+// burnRateWindows are the windows the burn rates are exported over, which are paired in multi-window burn rate alerts,
+// e.g. 5m with 1h, or 6h with 3d.
+//
+//nolint:gochecknoglobals // This is a constant list of windows.
+var burnRateWindows = []time.Duration{
+	5 * time.Minute,
+	30 * time.Minute,
+	time.Hour,
+	2 * time.Hour,
+	6 * time.Hour,
+	24 * time.Hour,
+	72 * time.Hour,
+}
+
+// counterBucket counts the events during a fraction of a window.
+type counterBucket struct {
+	start time.Time
+	good  uint64
+	total uint64
+}
+
+// rollingCounter counts the events during the span of the window, in buckets which are reused as the window rolls.
+type rollingCounter struct {
+	span    time.Duration
+	buckets [bucketsPerWindow]counterBucket
+}
+
+// add counts an event in the bucket of the window at the time.
+func (c *rollingCounter) add(now time.Time, good bool) {
+	width := c.span / bucketsPerWindow
+	start := now.Truncate(width)
+	current := &c.buckets[(start.UnixNano()/int64(width))%bucketsPerWindow]
+
+	if !current.start.Equal(start) {
+		*current = counterBucket{start: start}
+	}
+
+	current.total++
+
+	if good {
+		current.good++
+	}
+}
+
+// counts returns the good and total events during the span of the window before the time.
+func (c *rollingCounter) counts(now time.Time) (uint64, uint64) {
+	var good, total uint64
+
+	for _, bucket := range c.buckets {
+		if bucket.start.After(now.Add(-c.span)) {
+			good += bucket.good
+			total += bucket.total
+		}
+	}
+
+	return good, total
+}
+
+// objective is the state of the evaluation of an SLO.
+type objective struct {
+	options.SLO
+
+	threshold time.Duration
+	// windows are the counters of the burn rate windows, followed by the counter of the period.
+	windows []*rollingCounter
+}
+
+// burnRate returns the ratio of the rate of bad events to the rate allowed by the objective.
+func (o *objective) burnRate(good, total uint64) float64 {
+	if total == 0 {
+		return 0
+	}
+
+	return float64(total-good) / float64(total) / (1 - o.Objective)
+}
+
+// appliesTo indicates if the SLO applies to the pods of the namespace.
+func (o *objective) appliesTo(pod *corev1.Pod) bool {
+	return len(o.Namespaces) == 0 || slices.Contains(o.Namespaces, pod.Namespace)
+}
+
+// Evaluator evaluates the latency SLOs against the complete pod and image pull statistics.
+// It counts the good and total events of each SLO in the slo_good_events_total and slo_events_total metrics, and
+// reports an slo_violation record for each event exceeding the threshold of an SLO.
+//
+// Evaluator implements the PodStatisticObserver and ImagePullStatisticObserver interfaces of
+// [github.com/BackMarket-oss/kube-transition-metrics/internal/statistics/types], and [prometheus.Collector] to
+// collect the burn rates, the evaluator must be registered to export them.
+type Evaluator struct {
+	output   io.Writer
+	labelers []state.PodLabeler
+	clock    clock.PassiveClock
+
+	// mu protects the counters of the objectives, which are updated by both statistic event loops and collected by
+	// the HTTP server.
+	mu         sync.Mutex
+	objectives []*objective
+}
+
+// NewEvaluator creates a new Evaluator of the SLOs, reporting the violations to the output with the labels of the
+// provided labelers.
+// The events are counted in the burn rate windows at the time they are observed, according to the clock.
+func NewEvaluator(
+	slos []options.SLO,
+	output io.Writer,
+	clock clock.PassiveClock,
+	labelers ...state.PodLabeler,
+) *Evaluator {
+	evaluator := &Evaluator{
+		output:   output,
+		labelers: labelers,
+		clock:    clock,
+	}
+
+	for _, slo := range slos {
+		slo.Transition = slo.TransitionOrDefault()
+		slo.PeriodDays = slo.PeriodDaysOrDefault()
+		period := time.Duration(slo.PeriodDays * float64(24*time.Hour))
+
+		windows := make([]*rollingCounter, 0, len(burnRateWindows)+1)
+		for _, span := range append(slices.Clone(burnRateWindows), period) {
+			windows = append(windows, &rollingCounter{span: span})
+		}
+
+		evaluator.objectives = append(evaluator.objectives, &objective{
+			SLO:       slo,
+			threshold: time.Duration(slo.Threshold * float64(time.Second)),
+			windows:   windows,
+		})
+	}
+
+	return evaluator
+}
+
+// ObservePodStatistic evaluates the pod transitions of the complete pod statistic against the SLOs.
+// ObservePodStatistic implements
+// [github.com/BackMarket-oss/kube-transition-metrics/internal/statistics/types.PodStatisticObserver].
+func (e *Evaluator) ObservePodStatistic(pod *corev1.Pod, statistic *state.PodStatistic) {
+	transitions := map[string]time.Duration{
+		"creation_to_scheduled":    statistic.ScheduledTimestamp().Sub(statistic.CreationTimestamp()),
+		"scheduled_to_initialized": statistic.InitializedTimestamp().Sub(statistic.ScheduledTimestamp()),
+		"initialized_to_ready":     statistic.ReadyTimestamp().Sub(statistic.InitializedTimestamp()),
+		"creation_to_ready":        statistic.ReadyTimestamp().Sub(statistic.CreationTimestamp()),
+	}
+
+	for _, objective := range e.objectives {
+		duration, ok := transitions[objective.Transition]
+		if !ok || !objective.appliesTo(pod) {
+			continue
+		}
+
+		if violation := e.evaluate(objective, duration); violation != nil {
+			violation.Report(e.output, pod, e.labelers...)
+		}
+	}
+}
+
+// ObserveImagePullStatistic evaluates the duration of the image pull against the SLOs, unless the image was already
+// present on the node.
+// ObserveImagePullStatistic implements
+// [github.com/BackMarket-oss/kube-transition-metrics/internal/statistics/types.ImagePullStatisticObserver].
+func (e *Evaluator) ObserveImagePullStatistic(pod *corev1.Pod, statistic *state.ContainerImagePullStatistic) {
+	if statistic.AlreadyPresent() {
+		return
+	}
+
+	image := statistic.Image(pod)
+
+	for _, objective := range e.objectives {
+		if objective.Transition != options.SLOTransitionImagePull || !objective.appliesTo(pod) {
+			continue
+		}
+
+		if len(objective.Registries) > 0 && !slices.Contains(objective.Registries, registry(image)) {
+			continue
+		}
+
+		if violation := e.evaluate(objective, statistic.Duration()); violation != nil {
+			violation.WithContainer(statistic.ContainerName(), image).Report(e.output, pod, e.labelers...)
+		}
+	}
+}
+
+// evaluate counts the event of the SLO, and returns the violation if the duration exceeds its threshold, or nil.
+func (e *Evaluator) evaluate(objective *objective, duration time.Duration) *state.SLOViolation {
+	good := duration <= objective.threshold
+	now := e.clock.Now()
+
+	e.mu.Lock()
+	for _, window := range objective.windows {
+		window.add(now, good)
+	}
+	e.mu.Unlock()
+
+	prommetrics.SLOEvents.WithLabelValues(objective.Name).Inc()
+
+	if good {
+		prommetrics.SLOGoodEvents.WithLabelValues(objective.Name).Inc()
+
+		return nil
+	}
+
+	return state.NewSLOViolation(objective.Name, objective.Transition, objective.threshold, objective.Objective, duration)
+}
+
+// Describe implements [prometheus.Collector.Describe].
+func (e *Evaluator) Describe(descs chan<- *prometheus.Desc) {
+	descs <- prommetrics.SLOBurnRate
+	descs <- prommetrics.SLOErrorBudgetRemaining
+}
+
+// Collect collects the burn rates of the SLOs over each window, and their remaining error budgets over their periods.
+// Collect implements [prometheus.Collector.Collect].
+func (e *Evaluator) Collect(metrics chan<- prometheus.Metric) {
+	now := e.clock.Now()
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for _, objective := range e.objectives {
+		for _, window := range objective.windows[:len(burnRateWindows)] {
+			good, total := window.counts(now)
+			metrics <- prometheus.MustNewConstMetric(prommetrics.SLOBurnRate, prometheus.GaugeValue,
+				objective.burnRate(good, total), objective.Name, formatWindow(window.span))
+		}
+
+		good, total := objective.windows[len(burnRateWindows)].counts(now)
+		metrics <- prometheus.MustNewConstMetric(prommetrics.SLOErrorBudgetRemaining, prometheus.GaugeValue,
+			1-objective.burnRate(good, total), objective.Name)
+	}
+}
+
+// formatWindow formats the span of a window in the largest whole unit of days, hours or minutes, e.g. 1h or 3d.
+func formatWindow(span time.Duration) string {
+	day := 24 * time.Hour
+
+	switch {
+	case span%day == 0:
+		return fmt.Sprintf("%dd", span/day)
+	case span%time.Hour == 0:
+		return fmt.Sprintf("%dh", span/time.Hour)
+	default:
+		return fmt.Sprintf("%dm", span/time.Minute)
+	}
+}
+
+// registry returns the registry of the image, e.g. docker.io for the images of Docker Hub, or an empty string if the
+// image cannot be parsed.
+func registry(image string) string {
+	repo, _, _, err := parsers.ParseImageName(image)
+	if err != nil {
+		return ""
+	}
+
+	registry, _, _ := strings.Cut(repo, "/")
+
+	return registry
+}
@@ -0,0 +1,210 @@
+package slo
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/BackMarket-oss/kube-transition-metrics/internal/options"
+	"github.com/BackMarket-oss/kube-transition-metrics/internal/prommetrics"
+	"github.com/BackMarket-oss/kube-transition-metrics/internal/statistics/state"
+	"github.com/BackMarket-oss/kube-transition-metrics/internal/testhelpers"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	clocktesting "k8s.io/utils/clock/testing"
+)
+
+// newReadyPod returns a pod of the namespace which becomes Ready after the delay from its creation.
+func newReadyPod(name, namespace string, ready time.Duration) *corev1.Pod {
+	return testhelpers.NewReadyPod(namespace, name, testhelpers.Created,
+		testhelpers.PodTransitions{Scheduled: time.Second, Initialized: 2 * time.Second, Ready: ready})
+}
+
+// observePod notifies the evaluator of the complete statistic of the pod.
+func observePod(t *testing.T, evaluator *Evaluator, pod *corev1.Pod) {
+	t.Helper()
+
+	statistic := state.NewPodStatistic(testhelpers.Created, pod).Update(testhelpers.Created, pod)
+	require.False(t, statistic.Partial(), "Expected pod statistic to be complete")
+
+	evaluator.ObservePodStatistic(pod, statistic)
+}
+
+// sloEvents returns the events and the good events counted for the SLO.
+// The counters are global, so the tests only check their increase.
+func sloEvents(slo string) (float64, float64) {
+	return testutil.ToFloat64(prommetrics.SLOEvents.WithLabelValues(slo)),
+		testutil.ToFloat64(prommetrics.SLOGoodEvents.WithLabelValues(slo))
+}
+
+func TestEvaluatorPodStatistic(t *testing.T) {
+	testhelpers.ConfigureLogging(t, &options.Options{})
+
+	clock := clocktesting.NewFakeClock(testhelpers.Created)
+	writer := testhelpers.NewMetricWriter(t)
+	evaluator := NewEvaluator([]options.SLO{
+		{Name: "test-pod-ready", Namespaces: []string{"test-namespace"}, Threshold: 60, Objective: 0.75},
+		{Name: "test-pod-scheduled", Transition: "creation_to_scheduled", Threshold: 5, Objective: 0.99},
+	}, writer, clock)
+
+	readyEvents, readyGood := sloEvents("test-pod-ready")
+	_, scheduledGood := sloEvents("test-pod-scheduled")
+
+	observePod(t, evaluator, newReadyPod("fast", "test-namespace", 30*time.Second))
+	observePod(t, evaluator, newReadyPod("slow", "test-namespace", 90*time.Second))
+	observePod(t, evaluator, newReadyPod("other", "other-namespace", 90*time.Second))
+
+	events, good := sloEvents("test-pod-ready")
+	assert.InDelta(t, readyEvents+2, events, 0, "Expected the pods of other namespaces to be ignored")
+	assert.InDelta(t, readyGood+1, good, 0)
+
+	_, good = sloEvents("test-pod-scheduled")
+	assert.InDelta(t, scheduledGood+3, good, 0, "Expected the SLO to apply to all the namespaces by default")
+
+	violations := testhelpers.DecodeMetricOutput(t, writer)
+	require.Len(t, violations, 1, "Expected a violation of the slow pod")
+	assert.Equal(t, "slo_violation", violations[0]["type"])
+	assert.Equal(t, "slow", violations[0]["pod_name"])
+
+	violation, _ := violations[0]["slo_violation"].(map[string]any)
+	require.NotNil(t, violation)
+	assert.Equal(t, "test-pod-ready", violation["slo"])
+	assert.Equal(t, "creation_to_ready", violation["transition"])
+	assert.InDelta(t, 60, violation["threshold_seconds"], 0.001)
+	assert.InDelta(t, 90, violation["duration_seconds"], 0.001)
+
+	// One of the two pods is bad, which is twice the 25% allowed by the objective.
+	expected := `
+# HELP slo_burn_rate Ratio of the rate of bad events of the latency SLO over the window to the rate allowed by its objective
+# TYPE slo_burn_rate gauge
+slo_burn_rate{slo="test-pod-ready",window="5m"} 2
+slo_burn_rate{slo="test-pod-ready",window="30m"} 2
+slo_burn_rate{slo="test-pod-ready",window="1h"} 2
+slo_burn_rate{slo="test-pod-ready",window="2h"} 2
+slo_burn_rate{slo="test-pod-ready",window="6h"} 2
+slo_burn_rate{slo="test-pod-ready",window="1d"} 2
+slo_burn_rate{slo="test-pod-ready",window="3d"} 2
+slo_burn_rate{slo="test-pod-scheduled",window="5m"} 0
+slo_burn_rate{slo="test-pod-scheduled",window="30m"} 0
+slo_burn_rate{slo="test-pod-scheduled",window="1h"} 0
+slo_burn_rate{slo="test-pod-scheduled",window="2h"} 0
+slo_burn_rate{slo="test-pod-scheduled",window="6h"} 0
+slo_burn_rate{slo="test-pod-scheduled",window="1d"} 0
+slo_burn_rate{slo="test-pod-scheduled",window="3d"} 0
+# HELP slo_error_budget_remaining Ratio of the error budget of the latency SLO remaining over its period, negative once exhausted
+# TYPE slo_error_budget_remaining gauge
+slo_error_budget_remaining{slo="test-pod-ready"} -1
+slo_error_budget_remaining{slo="test-pod-scheduled"} 1
+`
+	require.NoError(t, testutil.CollectAndCompare(evaluator, strings.NewReader(expected)))
+
+	clock.Step(10 * time.Minute)
+
+	rates := burnRates(t, evaluator)
+	assert.InDelta(t, 0, rates["test-pod-ready/5m"], 0, "Expected the events to leave the shorter windows")
+	assert.InDelta(t, 2, rates["test-pod-ready/30m"], 0, "Expected the events to remain in the longer windows")
+}
+
+// burnRates collects the burn rates of the evaluator, by SLO and window separated by a slash.
+func burnRates(t *testing.T, evaluator *Evaluator) map[string]float64 {
+	t.Helper()
+
+	gatherer := prometheus.NewPedanticRegistry()
+	require.NoError(t, gatherer.Register(evaluator))
+
+	families, err := gatherer.Gather()
+	require.NoError(t, err)
+
+	rates := make(map[string]float64)
+
+	for _, family := range families {
+		if family.GetName() != "slo_burn_rate" {
+			continue
+		}
+
+		for _, metric := range family.GetMetric() {
+			labels := make(map[string]string)
+			for _, label := range metric.GetLabel() {
+				labels[label.GetName()] = label.GetValue()
+			}
+
+			rates[labels["slo"]+"/"+labels["window"]] = metric.GetGauge().GetValue()
+		}
+	}
+
+	return rates
+}
+
+func TestEvaluatorImagePullStatistic(t *testing.T) {
+	testhelpers.ConfigureLogging(t, &options.Options{})
+
+	clock := clocktesting.NewFakeClock(testhelpers.Created)
+	writer := testhelpers.NewMetricWriter(t)
+	evaluator := NewEvaluator([]options.SLO{
+		{
+			Name:       "test-image-pull",
+			Transition: options.SLOTransitionImagePull,
+			Registries: []string{"registry.example.com"},
+			Threshold:  30,
+			Objective:  0.9,
+		},
+		{
+			Name:       "test-image-pull-docker-hub",
+			Transition: options.SLOTransitionImagePull,
+			Registries: []string{"docker.io"},
+			Threshold:  30,
+			Objective:  0.9,
+		},
+	}, writer, clock)
+
+	pullEvents, pullGood := sloEvents("test-image-pull")
+	dockerHubEvents, _ := sloEvents("test-image-pull-docker-hub")
+
+	pod := newReadyPod("test-pod", "test-namespace", time.Minute)
+	container := pod.Spec.Containers[0]
+
+	pulled := state.NewContainerImagePullStatistic(pod, false, container).
+		Update(testhelpers.ImagePullEvent("Pulling", 2*time.Second)).
+		Update(testhelpers.ImagePullEvent("Pulled", 42*time.Second))
+	evaluator.ObserveImagePullStatistic(pod, pulled)
+
+	alreadyPresent := state.NewContainerImagePullStatistic(pod, false, container).
+		Update(testhelpers.ImagePullEvent("Pulled", 2*time.Second))
+	evaluator.ObserveImagePullStatistic(pod, alreadyPresent)
+
+	events, good := sloEvents("test-image-pull")
+	assert.InDelta(t, pullEvents+1, events, 0, "Expected the images already present to be ignored")
+	assert.InDelta(t, pullGood, good, 0)
+
+	events, _ = sloEvents("test-image-pull-docker-hub")
+	assert.InDelta(t, dockerHubEvents, events, 0, "Expected the images of other registries to be ignored")
+
+	violations := testhelpers.DecodeMetricOutput(t, writer)
+	require.Len(t, violations, 1, "Expected a violation of the slow image pull")
+	assert.Equal(t, "app", violations[0]["container_name"])
+	assert.Equal(t, "registry.example.com/app", violations[0]["image_name"])
+
+	violation, _ := violations[0]["slo_violation"].(map[string]any)
+	require.NotNil(t, violation)
+	assert.Equal(t, "image_pull", violation["transition"])
+	assert.InDelta(t, 40, violation["duration_seconds"], 0.001)
+}
+
+func TestRegistry(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "docker.io", registry("nginx:1.25"))
+	assert.Equal(t, "registry.example.com", registry("registry.example.com/team/app@sha256:"+strings.Repeat("0", 64)))
+	assert.Empty(t, registry("Invalid Image"))
+}
+
+func TestFormatWindow(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "5m", formatWindow(5*time.Minute))
+	assert.Equal(t, "6h", formatWindow(6*time.Hour))
+	assert.Equal(t, "30d", formatWindow(30*24*time.Hour))
+}
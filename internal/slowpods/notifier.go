@@ -0,0 +1,240 @@
+// Package slowpods creates Warning Events on the pods which are slow to become Ready or to pull their images, and
+// optionally on their top-level owners, so that the slow startups are visible with kubectl describe.
+package slowpods
+
+import (
+	"cmp"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/BackMarket-oss/kube-transition-metrics/internal/options"
+	"github.com/BackMarket-oss/kube-transition-metrics/internal/owners"
+	"github.com/BackMarket-oss/kube-transition-metrics/internal/prommetrics"
+	"github.com/BackMarket-oss/kube-transition-metrics/internal/statistics/state"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	apimachinerytypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/flowcontrol"
+	"k8s.io/utils/clock"
+)
+
+const (
+	// ReasonSlowStartup is the reason of the Events of the pods which are slow to become Ready.
+	ReasonSlowStartup = "SlowStartup"
+	// ReasonSlowImagePull is the reason of the Events of the slow image pulls.
+	ReasonSlowImagePull = "SlowImagePull"
+)
+
+// dedupWindow is the window during which at most one Event is created per object, reason and container.
+const dedupWindow = 10 * time.Minute
+
+// eventBurst is the number of Events created at once before the rate limit applies.
+const eventBurst = 10
+
+// maxBreakdownPhases is the maximum number of phases of the critical path in the message of the Events.
+const maxBreakdownPhases = 3
+
+// phaseDescriptions describes the phases of the critical path of the pods in the message of the Events.
+//
+//nolint:gochecknoglobals // This is a constant map of phases to descriptions.
+var phaseDescriptions = map[string]string{
+	"scheduling":      "scheduling",
+	"sandbox":         "sandbox creation",
+	"image_pull":      "image pull",
+	"init_containers": "init containers",
+	"startup_probe":   "startup probe",
+	"readiness_probe": "readiness probe",
+	"readiness_gates": "readiness gates",
+	"other":           "other",
+}
+
+// eventKey identifies the Events deduplicated together.
+type eventKey struct {
+	uid    apimachinerytypes.UID
+	reason string
+	// container is the name of the container of the image pull Events, it is empty for the startup Events.
+	container string
+}
+
+// Notifier creates a Warning Event on each pod whose creation to ready duration exceeds the threshold, with a
+// breakdown of its critical path, and on each pod whose image pull exceeds the threshold.
+// The startup Events are also created on the top-level owners of the pods when the owner resolver is set.
+// The Events are deduplicated per object over a window of 10 minutes, and rate-limited overall, in addition to the
+// aggregation and spam filtering of the recorder.
+//
+// Notifier implements the PodStatisticObserver and ImagePullStatisticObserver interfaces of
+// [github.com/BackMarket-oss/kube-transition-metrics/internal/statistics/types].
+type Notifier struct {
+	recorder           record.EventRecorder
+	clock              clock.PassiveClock
+	limiter            flowcontrol.PassiveRateLimiter
+	podThreshold       time.Duration
+	imagePullThreshold time.Duration
+	// owners resolves the top-level owners of the pods, the Events are only created on the pods when nil.
+	owners *owners.Resolver
+
+	// mu protects the recent Events, which are updated by both statistic event loops.
+	mu     sync.Mutex
+	recent map[eventKey]time.Time
+}
+
+// NewNotifier creates a new Notifier creating the Events with the recorder, according to the thresholds and rate of
+// the options.
+// The owner resolver may be nil, in which case the Events are only created on the pods.
+func NewNotifier(
+	options *options.Options,
+	recorder record.EventRecorder,
+	clock clock.PassiveClock,
+	ownerResolver *owners.Resolver,
+) *Notifier {
+	return &Notifier{
+		recorder: recorder,
+		clock:    clock,
+		limiter: flowcontrol.NewTokenBucketPassiveRateLimiterWithClock(
+			float32(options.SlowPodEventRate), eventBurst, clock),
+		podThreshold:       time.Duration(options.SlowPodEventThreshold * float64(time.Second)),
+		imagePullThreshold: time.Duration(options.SlowImagePullEventThreshold * float64(time.Second)),
+		owners:             ownerResolver,
+		recent:             make(map[eventKey]time.Time),
+	}
+}
+
+// ObservePodStatistic creates the startup Events of the pod if its creation to ready duration exceeds the threshold.
+// ObservePodStatistic implements
+// [github.com/BackMarket-oss/kube-transition-metrics/internal/statistics/types.PodStatisticObserver].
+func (n *Notifier) ObservePodStatistic(pod *corev1.Pod, statistic *state.PodStatistic) {
+	duration := statistic.ReadyTimestamp().Sub(statistic.CreationTimestamp())
+	if n.podThreshold == 0 || duration <= n.podThreshold {
+		return
+	}
+
+	message := fmt.Sprintf("took %s to become Ready", formatSeconds(duration))
+	if phases := breakdown(pod, statistic); phases != "" {
+		message += ": " + phases
+	}
+
+	n.emit(pod, eventKey{uid: pod.UID, reason: ReasonSlowStartup}, "Pod "+message)
+
+	if n.owners == nil {
+		return
+	}
+
+	chain := n.owners.Chain(pod)
+	if len(chain) == 0 {
+		return
+	}
+
+	top := chain[len(chain)-1]
+	owner := &corev1.ObjectReference{
+		Kind:       top.Kind,
+		APIVersion: top.APIVersion,
+		Namespace:  pod.Namespace,
+		Name:       top.Name,
+		UID:        top.UID,
+	}
+	n.emit(owner, eventKey{uid: top.UID, reason: ReasonSlowStartup}, fmt.Sprintf("Pod %s %s", pod.Name, message))
+}
+
+// ObserveImagePullStatistic creates the image pull Event of the pod if the duration of the image pull exceeds the
+// threshold, unless the image was already present on the node.
+// ObserveImagePullStatistic implements
+// [github.com/BackMarket-oss/kube-transition-metrics/internal/statistics/types.ImagePullStatisticObserver].
+func (n *Notifier) ObserveImagePullStatistic(pod *corev1.Pod, statistic *state.ContainerImagePullStatistic) {
+	if n.imagePullThreshold == 0 || statistic.AlreadyPresent() || statistic.Duration() <= n.imagePullThreshold {
+		return
+	}
+
+	key := eventKey{uid: pod.UID, reason: ReasonSlowImagePull, container: statistic.ContainerName()}
+	n.emit(pod, key, fmt.Sprintf("Pulling image %s for container %s took %s",
+		statistic.Image(pod), statistic.ContainerName(), formatSeconds(statistic.Duration())))
+}
+
+// emit creates the Warning Event on the object, unless an Event with the same key was created during the dedup window
+// or the rate limit is exceeded.
+func (n *Notifier) emit(object runtime.Object, key eventKey, message string) {
+	now := n.clock.Now()
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	// The Events are rare, so the expired keys are pruned on each Event.
+	maps.DeleteFunc(n.recent, func(_ eventKey, created time.Time) bool {
+		return now.Sub(created) >= dedupWindow
+	})
+
+	if _, ok := n.recent[key]; ok {
+		prommetrics.SlowPodEvents.WithLabelValues(key.reason, "duplicate").Inc()
+
+		return
+	}
+
+	if !n.limiter.TryAccept() {
+		prommetrics.SlowPodEvents.WithLabelValues(key.reason, "rate_limited").Inc()
+
+		return
+	}
+
+	n.recent[key] = now
+	prommetrics.SlowPodEvents.WithLabelValues(key.reason, "created").Inc()
+	n.recorder.Event(object, corev1.EventTypeWarning, key.reason, message)
+}
+
+// breakdown describes the longest phases of the critical path of the pod, e.g. "120s image pull of nginx:1.25, 40s
+// startup probe", or returns an empty string if the pod never turned Ready.
+// The phases shorter than a second are omitted.
+func breakdown(pod *corev1.Pod, statistic *state.PodStatistic) string {
+	durations, _, ok := statistic.CriticalPath()
+	if !ok {
+		return ""
+	}
+
+	phases := slices.SortedFunc(maps.Keys(durations), func(a, b string) int {
+		return cmp.Or(cmp.Compare(durations[b], durations[a]), strings.Compare(a, b))
+	})
+
+	parts := make([]string, 0, maxBreakdownPhases)
+
+	for _, phase := range phases[:min(maxBreakdownPhases, len(phases))] {
+		if durations[phase] < time.Second {
+			break
+		}
+
+		part := formatSeconds(durations[phase]) + " " + phaseDescriptions[phase]
+		if phase == "image_pull" {
+			if image := slowestImage(pod, statistic); image != "" {
+				part += " of " + image
+			}
+		}
+
+		parts = append(parts, part)
+	}
+
+	return strings.Join(parts, ", ")
+}
+
+// slowestImage returns the image of the longest image pull of the pod, or an empty string if no image was pulled.
+func slowestImage(pod *corev1.Pod, statistic *state.PodStatistic) string {
+	var (
+		image   string
+		longest time.Duration
+	)
+
+	for _, imagePull := range statistic.ImagePulls() {
+		if !imagePull.AlreadyPresent() && imagePull.Duration() > longest {
+			image = imagePull.Image(pod)
+			longest = imagePull.Duration()
+		}
+	}
+
+	return image
+}
+
+// formatSeconds formats the duration in whole seconds, e.g. 182s.
+func formatSeconds(duration time.Duration) string {
+	return fmt.Sprintf("%.0fs", duration.Seconds())
+}
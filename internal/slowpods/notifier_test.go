@@ -0,0 +1,180 @@
+package slowpods
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/BackMarket-oss/kube-transition-metrics/internal/options"
+	"github.com/BackMarket-oss/kube-transition-metrics/internal/owners"
+	"github.com/BackMarket-oss/kube-transition-metrics/internal/statistics/state"
+	"github.com/BackMarket-oss/kube-transition-metrics/internal/testhelpers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	appsv1listers "k8s.io/client-go/listers/apps/v1"
+	batchv1listers "k8s.io/client-go/listers/batch/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
+	clocktesting "k8s.io/utils/clock/testing"
+)
+
+// newSlowPod returns a pod scheduled after 30s, which becomes Ready after 182s.
+// Its container is not reported, so that the rest of the startup is not attributed to the readiness gates.
+func newSlowPod(name string) *corev1.Pod {
+	pod := testhelpers.NewReadyPod("test-namespace", name, testhelpers.Created,
+		testhelpers.PodTransitions{Scheduled: 30 * time.Second, Initialized: 30 * time.Second, Ready: 182 * time.Second})
+	pod.Status.ContainerStatuses = nil
+
+	return pod
+}
+
+// newSlowPodStatistic returns the statistic of the slow pod, whose image is pulled from 30s to 150s.
+func newSlowPodStatistic(pod *corev1.Pod) *state.PodStatistic {
+	return state.NewPodStatistic(testhelpers.Created, pod).
+		Update(testhelpers.Created, pod).
+		ImagePullEvent("app", testhelpers.ImagePullEvent("Pulling", 30*time.Second)).
+		ImagePullEvent("app", testhelpers.ImagePullEvent("Pulled", 150*time.Second))
+}
+
+// newTestingIndexer returns an empty indexer of namespaced objects.
+func newTestingIndexer() cache.Indexer {
+	return cache.NewIndexer(
+		cache.MetaNamespaceKeyFunc,
+		cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc},
+	)
+}
+
+// newTestingResolver returns an owner resolver caching the ReplicaSet web-5d4f8 of the Deployment web.
+func newTestingResolver(t *testing.T) *owners.Resolver {
+	t.Helper()
+
+	replicaSets := newTestingIndexer()
+	require.NoError(t, replicaSets.Add(&appsv1.ReplicaSet{ObjectMeta: metav1.ObjectMeta{
+		Namespace: "test-namespace",
+		Name:      "web-5d4f8",
+		UID:       "replicaset-web",
+		OwnerReferences: []metav1.OwnerReference{
+			{APIVersion: "apps/v1", Kind: "Deployment", Name: "web", UID: "deployment-web", Controller: new(true)},
+		},
+	}}))
+
+	return owners.NewResolver(
+		appsv1listers.NewReplicaSetLister(replicaSets),
+		batchv1listers.NewJobLister(newTestingIndexer()),
+		nil,
+	)
+}
+
+// recordedEvents drains the Events recorded by the fake recorder.
+func recordedEvents(recorder *record.FakeRecorder) []string {
+	var events []string
+
+	for len(recorder.Events) > 0 {
+		events = append(events, <-recorder.Events)
+	}
+
+	return events
+}
+
+func TestNotifierPodStatistic(t *testing.T) {
+	testhelpers.ConfigureLogging(t, &options.Options{})
+
+	recorder := record.NewFakeRecorder(100)
+	recorder.IncludeObject = true
+	notifier := NewNotifier(
+		&options.Options{SlowPodEventThreshold: 120, SlowPodEventRate: 1},
+		recorder,
+		clocktesting.NewFakeClock(testhelpers.Created),
+		newTestingResolver(t),
+	)
+
+	pod := newSlowPod("web-5d4f8-abcde")
+	pod.OwnerReferences = []metav1.OwnerReference{
+		{APIVersion: "apps/v1", Kind: "ReplicaSet", Name: "web-5d4f8", UID: "replicaset-web", Controller: new(true)},
+	}
+	notifier.ObservePodStatistic(pod, newSlowPodStatistic(pod))
+
+	events := recordedEvents(recorder)
+	require.Len(t, events, 2, "Expected an Event on the pod and on its top-level owner")
+	assert.Contains(t, events[0],
+		"Warning SlowStartup Pod took 182s to become Ready: 120s image pull of registry.example.com/app:1.2, "+
+			"32s other, 30s scheduling")
+	assert.Contains(t, events[0], "kind=,", "Expected the Event on the pod")
+	assert.Contains(t, events[1], "Warning SlowStartup Pod web-5d4f8-abcde took 182s to become Ready: 120s image pull")
+	assert.Contains(t, events[1], "kind=Deployment,apiVersion=apps/v1", "Expected the Event on the Deployment")
+
+	fast := newSlowPod("fast")
+	fast.Status.Conditions[2].LastTransitionTime = metav1.NewTime(testhelpers.Created.Add(time.Minute))
+	notifier.ObservePodStatistic(fast, state.NewPodStatistic(testhelpers.Created, fast).Update(testhelpers.Created, fast))
+	assert.Empty(t, recordedEvents(recorder), "Expected no Event for pods within the threshold")
+}
+
+func TestNotifierImagePullStatistic(t *testing.T) {
+	testhelpers.ConfigureLogging(t, &options.Options{})
+
+	recorder := record.NewFakeRecorder(100)
+	notifier := NewNotifier(
+		&options.Options{SlowImagePullEventThreshold: 60, SlowPodEventRate: 1},
+		recorder,
+		clocktesting.NewFakeClock(testhelpers.Created),
+		nil,
+	)
+
+	pod := newSlowPod("test-pod")
+	container := pod.Spec.Containers[0]
+
+	notifier.ObservePodStatistic(pod, newSlowPodStatistic(pod))
+	assert.Empty(t, recordedEvents(recorder), "Expected no startup Event without its threshold")
+
+	notifier.ObserveImagePullStatistic(pod, state.NewContainerImagePullStatistic(pod, false, container).
+		Update(testhelpers.ImagePullEvent("Pulled", 150*time.Second)))
+	assert.Empty(t, recordedEvents(recorder), "Expected no Event for images already present")
+
+	notifier.ObserveImagePullStatistic(pod, state.NewContainerImagePullStatistic(pod, false, container).
+		Update(testhelpers.ImagePullEvent("Pulling", 30*time.Second)).
+		Update(testhelpers.ImagePullEvent("Pulled", 150*time.Second)))
+	assert.Equal(t, []string{
+		"Warning SlowImagePull Pulling image registry.example.com/app:1.2 for container app took 120s",
+	}, recordedEvents(recorder))
+}
+
+func TestNotifierDeduplicationAndRateLimit(t *testing.T) {
+	testhelpers.ConfigureLogging(t, &options.Options{})
+
+	clock := clocktesting.NewFakeClock(testhelpers.Created)
+	recorder := record.NewFakeRecorder(100)
+	notifier := NewNotifier(
+		&options.Options{SlowPodEventThreshold: 120, SlowPodEventRate: 0.01},
+		recorder,
+		clock,
+		nil,
+	)
+
+	pod := newSlowPod("test-pod")
+	notifier.ObservePodStatistic(pod, newSlowPodStatistic(pod))
+	notifier.ObservePodStatistic(pod, newSlowPodStatistic(pod))
+	assert.Len(t, recordedEvents(recorder), 1, "Expected the Events of the same pod to be deduplicated")
+
+	for i := range eventBurst {
+		other := newSlowPod(fmt.Sprintf("test-pod-%d", i))
+		notifier.ObservePodStatistic(other, newSlowPodStatistic(other))
+	}
+
+	assert.Len(t, recordedEvents(recorder), eventBurst-1, "Expected the Events beyond the burst to be dropped")
+
+	clock.Step(dedupWindow)
+	notifier.ObservePodStatistic(pod, newSlowPodStatistic(pod))
+	assert.Len(t, recordedEvents(recorder), 1, "Expected an Event once the window and the rate limit allow it")
+}
+
+func TestBreakdownWithoutReady(t *testing.T) {
+	t.Parallel()
+
+	pod := newSlowPod("test-pod")
+	pod.Status.Conditions = pod.Status.Conditions[:2]
+
+	assert.Empty(t, breakdown(pod, state.NewPodStatistic(testhelpers.Created, pod).Update(testhelpers.Created, pod)))
+}
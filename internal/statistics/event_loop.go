@@ -5,12 +5,14 @@ import (
 	"fmt"
 	"io"
 	"regexp"
+	"slices"
 	"sync"
 	"time"
 
 	"github.com/BackMarket-oss/kube-transition-metrics/internal/options"
 	"github.com/BackMarket-oss/kube-transition-metrics/internal/prommetrics"
 	"github.com/BackMarket-oss/kube-transition-metrics/internal/statistics/state"
+	"github.com/BackMarket-oss/kube-transition-metrics/internal/statistics/types"
 	safeconcurrencytypes "github.com/Izzette/go-safeconcurrency/api/types"
 	"github.com/Izzette/go-safeconcurrency/eventloop"
 	"github.com/Izzette/go-safeconcurrency/eventloop/snapshot"
@@ -23,6 +25,7 @@ import (
 
 // podStatisticEventLoop loops over pod statistic events sent by collectors to track and update metrics.
 type podStatisticEventLoop struct {
+	eventLoopConfig
 	safeconcurrencytypes.EventLoop[*state.PodStatistics]
 
 	options      *options.Options
@@ -35,7 +38,11 @@ type podStatisticEventLoop struct {
 //
 // The returned *podStatisticEventLoop implements
 // [github.com/BackMarket-oss/kube-transition-metrics/internal/statistics/types.PodStatisticEventLoop].
-func NewStatisticEventLoop(options *options.Options, metricOutput io.Writer) *podStatisticEventLoop {
+func NewStatisticEventLoop(
+	options *options.Options,
+	metricOutput io.Writer,
+	opts ...EventLoopOption,
+) *podStatisticEventLoop {
 	s := state.NewPodStatistics([]apimachinerytypes.UID{})
 	snapshot := snapshot.NewCopyable[*state.PodStatistics](s)
 
@@ -47,9 +54,10 @@ func NewStatisticEventLoop(options *options.Options, metricOutput io.Writer) *po
 	buffer := uint(options.StatisticEventQueueLength)
 
 	return &podStatisticEventLoop{
-		options:      options,
-		EventLoop:    eventloop.NewBuffered[*state.PodStatistics](snapshot, buffer),
-		metricOutput: metricOutput,
+		eventLoopConfig: newEventLoopConfig(opts),
+		options:         options,
+		EventLoop:       eventloop.NewBuffered[*state.PodStatistics](snapshot, buffer),
+		metricOutput:    metricOutput,
 	}
 }
 
@@ -104,9 +112,11 @@ func (el *podStatisticEventLoop) PodUpdate(
 ) (safeconcurrencytypes.GenerationID, error) {
 	return el.Send(ctx, &podUpdateEvent{
 		pod:       pod,
-		eventTime: time.Now(),
-		options:   el.options,
+		eventTime: el.clock.Now(),
+		options:   el.options.Current(),
 		output:    el.metricOutput,
+		labelers:  el.labelers,
+		observers: el.podObservers,
 	})
 }
 
@@ -118,9 +128,11 @@ func (el *podStatisticEventLoop) PodDelete(
 	pod *corev1.Pod,
 ) (safeconcurrencytypes.GenerationID, error) {
 	return el.Send(ctx, &podDeleteEvent{
-		options: el.options,
-		pod:     pod,
-		output:  el.metricOutput,
+		options:   el.options.Current(),
+		pod:       pod,
+		output:    el.metricOutput,
+		labelers:  el.labelers,
+		observers: el.podObservers,
 	})
 }
 
@@ -136,6 +148,49 @@ func (el *podStatisticEventLoop) PodResync(
 	})
 }
 
+// PodEndpointReady sends an event to record that the pod address first appeared as ready in an EndpointSlice of the
+// Service.
+// PodEndpointReady implements [types.PodStatisticEventLoop.PodEndpointReady].
+func (el *podStatisticEventLoop) PodEndpointReady(
+	ctx context.Context,
+	uid apimachinerytypes.UID,
+	service string,
+) (safeconcurrencytypes.GenerationID, error) {
+	return el.Send(ctx, &podEndpointReadyEvent{
+		uid:       uid,
+		service:   service,
+		eventTime: el.clock.Now(),
+		output:    el.metricOutput,
+		labelers:  el.labelers,
+	})
+}
+
+// PodEvent sends an event to update the pod statistic from an image pull, sandbox, attach or mount Kubernetes Event of
+// the pod.
+// PodEvent implements [types.PodStatisticEventLoop.PodEvent].
+func (el *podStatisticEventLoop) PodEvent(
+	ctx context.Context,
+	uid apimachinerytypes.UID,
+	k8sEvent *corev1.Event,
+) (safeconcurrencytypes.GenerationID, error) {
+	return el.Send(ctx, &podK8sEvent{
+		uid:      uid,
+		k8sEvent: k8sEvent,
+	})
+}
+
+// ClaimVolumeEvent sends an event to update the statistics of the volumes backed by a PersistentVolumeClaim from a
+// provisioning Event of the claim.
+// ClaimVolumeEvent implements [types.PodStatisticEventLoop.ClaimVolumeEvent].
+func (el *podStatisticEventLoop) ClaimVolumeEvent(
+	ctx context.Context,
+	k8sEvent *corev1.Event,
+) (safeconcurrencytypes.GenerationID, error) {
+	return el.Send(ctx, &claimVolumeEvent{
+		k8sEvent: k8sEvent,
+	})
+}
+
 // watcher watches the state of the event loop and updates the prometheus metrics.
 func (el *podStatisticEventLoop) watcher(
 	ctx context.Context,
@@ -149,6 +204,7 @@ func (el *podStatisticEventLoop) watcher(
 // imagePullStatisticEventLoop loops over image pull statistic events sent by collectors to track and update metrics for
 // image pulls.
 type imagePullStatisticEventLoop struct {
+	eventLoopConfig
 	safeconcurrencytypes.EventLoop[*state.ImagePullStatistics]
 
 	options      *options.Options
@@ -160,7 +216,11 @@ type imagePullStatisticEventLoop struct {
 //
 // The returned *imagePullStatisticEventLoop implements
 // [github.com/BackMarket-oss/kube-transition-metrics/internal/statistics/types.ImagePullStatisticEventLoop].
-func NewImagePullStatisticEventLoop(options *options.Options, metricOutput io.Writer) *imagePullStatisticEventLoop {
+func NewImagePullStatisticEventLoop(
+	options *options.Options,
+	metricOutput io.Writer,
+	opts ...EventLoopOption,
+) *imagePullStatisticEventLoop {
 	s := state.NewImagePullStatistics()
 	snapshot := snapshot.NewCopyable[*state.ImagePullStatistics](s)
 
@@ -172,9 +232,10 @@ func NewImagePullStatisticEventLoop(options *options.Options, metricOutput io.Wr
 	buffer := uint(options.StatisticEventQueueLength)
 
 	return &imagePullStatisticEventLoop{
-		EventLoop:    eventloop.NewBuffered[*state.ImagePullStatistics](snapshot, buffer),
-		options:      options,
-		metricOutput: metricOutput,
+		eventLoopConfig: newEventLoopConfig(opts),
+		EventLoop:       eventloop.NewBuffered[*state.ImagePullStatistics](snapshot, buffer),
+		options:         options,
+		metricOutput:    metricOutput,
 	}
 }
 
@@ -230,10 +291,12 @@ func (el *imagePullStatisticEventLoop) ImagePullUpdate(
 	k8sEvent *corev1.Event,
 ) (safeconcurrencytypes.GenerationID, error) {
 	return el.Send(ctx, &imagePullUpdateEvent{
-		pod:      pod,
-		k8sEvent: k8sEvent,
-		output:   el.metricOutput,
-		options:  el.options,
+		pod:       pod,
+		k8sEvent:  k8sEvent,
+		output:    el.metricOutput,
+		options:   el.options.Current(),
+		labelers:  el.labelers,
+		observers: el.imagePullObservers,
 	})
 }
 
@@ -245,9 +308,10 @@ func (el *imagePullStatisticEventLoop) ImagePullDelete(
 	pod *corev1.Pod,
 ) (safeconcurrencytypes.GenerationID, error) {
 	return el.Send(ctx, &deleteImagePullEvent{
-		options: el.options,
-		pod:     pod,
-		output:  el.metricOutput,
+		options:  el.options.Current(),
+		pod:      pod,
+		output:   el.metricOutput,
+		labelers: el.labelers,
 	})
 }
 
@@ -299,6 +363,8 @@ type podUpdateEvent struct {
 	pod       *corev1.Pod
 	eventTime time.Time
 	output    io.Writer
+	labelers  []state.PodLabeler
+	observers []types.PodStatisticObserver
 }
 
 // Dispatch implements [safeconcurrencytypes.Event.Dispatch].
@@ -313,30 +379,111 @@ func (e *podUpdateEvent) Dispatch(
 	statistic, ok := podStatistics.Get(e.pod.UID)
 	if !ok {
 		statistic = state.NewPodStatistic(e.eventTime, e.pod)
+
+		if e.options.TrackVolumes {
+			statistic = statistic.TrackVolumes(e.pod)
+		}
+
+		for _, observer := range e.observers {
+			if creationObserver, ok := observer.(types.PodCreationObserver); ok {
+				creationObserver.ObservePodCreation(e.pod)
+			}
+		}
 	}
 
-	if !statistic.Partial() {
+	if !statistic.Partial() && !statistic.EphemeralContainersPending(e.pod) && !statistic.ResizePending(e.pod) {
 		log.Trace().Str("pod_uid", string(e.pod.UID)).Msg("Pod statistic is already complete, skipping update")
+		e.observeUpdate(statistic)
 
 		return podStatistics
 	}
 
+	// Ephemeral containers may be added, and the pod may be resized in-place, long after the pod statistic is complete,
+	// in which case only the ephemeral containers and the resize are reported.
+	previous := statistic
 	statistic = statistic.Update(e.eventTime, e.pod)
 	podStatistics = podStatistics.Set(e.pod.UID, statistic)
+	e.observeUpdate(statistic)
 
-	// Emit the pod and container statistics for the pod.
-	if e.options.EmitPartialStatistics || !statistic.Partial() {
-		statistic.Report(e.output, e.pod)
+	if previous.Partial() {
+		// Emit the pod and container statistics for the pod.
+		if e.options.EmitPartialStatistics || !statistic.Partial() {
+			statistic.Report(e.output, e.pod, e.labelers...)
+		}
+
+		// Partial statistics are skipped above, so the statistic has just completed.
+		if !statistic.Partial() {
+			for _, observer := range e.observers {
+				observer.ObservePodStatistic(e.pod, statistic)
+			}
+
+			for field, skew := range statistic.TimestampSkews() {
+				prommetrics.TimestampSkew.WithLabelValues(field).Observe(skew.Seconds())
+			}
+		}
+	}
+
+	e.reportEphemeralContainers(previous, statistic)
+	e.reportResize(previous, statistic)
+
+	// The endpoint readiness received before the pod update with the Ready condition is reported once it is Ready.
+	if previous.ReadyTimestamp().IsZero() && statistic.EndpointReadyAfterReady() {
+		statistic.ReportEndpoint(e.output, e.labelers...)
 	}
 
 	return podStatistics
 }
 
+// observeUpdate notifies the observers implementing [types.PodUpdateObserver] of the update of the pod.
+func (e *podUpdateEvent) observeUpdate(statistic *state.PodStatistic) {
+	for _, observer := range e.observers {
+		if updateObserver, ok := observer.(types.PodUpdateObserver); ok {
+			updateObserver.ObservePodUpdate(e.pod, statistic)
+		}
+	}
+}
+
+// reportResize reports the statistic of the latest in-place resize of the pod if it was updated, once applied or
+// infeasible, or while still partial if partial statistics are emitted.
+func (e *podUpdateEvent) reportResize(previous, statistic *state.PodStatistic) {
+	resize, ok := statistic.ResizeStatistic()
+	if !ok {
+		return
+	}
+
+	if previousResize, _ := previous.ResizeStatistic(); previousResize == resize {
+		return
+	}
+
+	if e.options.EmitPartialStatistics || !resize.Partial() {
+		resize.Report(e.output, e.pod, e.labelers...)
+	}
+}
+
+// reportEphemeralContainers reports the ephemeral container statistics which have just completed, or which are still
+// partial if partial statistics are emitted.
+func (e *podUpdateEvent) reportEphemeralContainers(previous, statistic *state.PodStatistic) {
+	for name, container := range statistic.EphemeralContainerStatistics() {
+		if container.Partial() && !e.options.EmitPartialStatistics {
+			continue
+		}
+
+		if previousContainer, ok := previous.EphemeralContainerStatistic(name); ok && !previousContainer.Partial() {
+			// Already reported as complete.
+			continue
+		}
+
+		container.Report(e.output, e.pod, statistic, e.labelers...)
+	}
+}
+
 // podDeleteEvent is used to delete the pod statistic for a pod after it has been deleted from the Kubernetes API.
 type podDeleteEvent struct {
-	options *options.Options
-	pod     *corev1.Pod
-	output  io.Writer
+	options   *options.Options
+	pod       *corev1.Pod
+	output    io.Writer
+	labelers  []state.PodLabeler
+	observers []types.PodStatisticObserver
 }
 
 // Dispatch implements [safeconcurrencytypes.Event.Dispatch].
@@ -351,12 +498,110 @@ func (e *podDeleteEvent) Dispatch(
 
 	// Emit statistics for pods that are deleted before they become Ready.
 	if statistic.Partial() {
-		statistic.Report(e.output, e.pod)
+		statistic.Report(e.output, e.pod, e.labelers...)
+	}
+
+	for _, observer := range e.observers {
+		if deletionObserver, ok := observer.(types.PodDeletionObserver); ok {
+			deletionObserver.ObservePodDeletion(e.pod)
+		}
 	}
 
 	return podStatistics.Delete(e.pod.UID)
 }
 
+// podEndpointReadyEvent is used to record that the pod address first appeared as ready in an EndpointSlice.
+type podEndpointReadyEvent struct {
+	uid       apimachinerytypes.UID
+	service   string
+	eventTime time.Time
+	output    io.Writer
+	labelers  []state.PodLabeler
+}
+
+// Dispatch implements [safeconcurrencytypes.Event.Dispatch].
+func (e *podEndpointReadyEvent) Dispatch(
+	_ safeconcurrencytypes.GenerationID,
+	podStatistics *state.PodStatistics,
+) *state.PodStatistics {
+	statistic, ok := podStatistics.Get(e.uid)
+	if !ok || !statistic.EndpointReadyTimestamp().IsZero() {
+		return podStatistics
+	}
+
+	statistic = statistic.EndpointReady(e.eventTime, e.service)
+
+	// The EndpointSlice may be received before the pod update with the Ready condition, in which case the endpoint is
+	// reported by the pod update.
+	if !statistic.ReadyTimestamp().IsZero() {
+		statistic.ReportEndpoint(e.output, e.labelers...)
+	}
+
+	return podStatistics.Set(e.uid, statistic)
+}
+
+// podK8sEvent is used to update the pod statistic from an image pull, sandbox, attach or mount Kubernetes Event of the
+// pod.
+type podK8sEvent struct {
+	uid      apimachinerytypes.UID
+	k8sEvent *corev1.Event
+}
+
+// Dispatch implements [safeconcurrencytypes.Event.Dispatch].
+func (e *podK8sEvent) Dispatch(
+	_ safeconcurrencytypes.GenerationID,
+	podStatistics *state.PodStatistics,
+) *state.PodStatistics {
+	statistic, ok := podStatistics.Get(e.uid)
+	// The Events are reported with the pod statistic, Events received once it is complete are ignored.
+	if !ok || !statistic.Partial() {
+		return podStatistics
+	}
+
+	next := statistic
+
+	switch e.k8sEvent.Reason {
+	case "Pulling", "Pulled":
+		containerName, err := containerNameFromFieldPath(e.k8sEvent.InvolvedObject.FieldPath)
+		if err != nil {
+			log.Error().Err(err).Str("pod_uid", string(e.uid)).Send()
+
+			return podStatistics
+		}
+
+		next = statistic.ImagePullEvent(containerName, e.k8sEvent)
+	default:
+		next = statistic.Event(e.k8sEvent)
+	}
+
+	if next != statistic {
+		return podStatistics.Set(e.uid, next)
+	}
+
+	return podStatistics
+}
+
+// claimVolumeEvent is used to update the statistics of the volumes backed by a PersistentVolumeClaim from a
+// provisioning Event of the claim.
+type claimVolumeEvent struct {
+	k8sEvent *corev1.Event
+}
+
+// Dispatch implements [safeconcurrencytypes.Event.Dispatch].
+func (e *claimVolumeEvent) Dispatch(
+	_ safeconcurrencytypes.GenerationID,
+	podStatistics *state.PodStatistics,
+) *state.PodStatistics {
+	return podStatistics.Map(func(_ apimachinerytypes.UID, statistic *state.PodStatistic) (*state.PodStatistic, bool) {
+		// The volumes are reported with the pod statistic, Events received once it is complete are ignored.
+		if !statistic.Partial() {
+			return statistic, true
+		}
+
+		return statistic.ClaimEvent(e.k8sEvent), true
+	})
+}
+
 // resyncEvent is used to resync the event loop if the Kubernetes Watch API times out, and events are lost.
 // resyncEvent implements [safeconcurrencytypes.Event].
 type resyncEvent struct {
@@ -414,10 +659,12 @@ func (e *resyncEvent) Dispatch(
 // imagePullUpdateEvent is used to update the image pull statistic for a pod from the latest Kubernetes Event for image
 // pulling related events.
 type imagePullUpdateEvent struct {
-	options  *options.Options
-	pod      *corev1.Pod
-	k8sEvent *corev1.Event
-	output   io.Writer
+	options   *options.Options
+	pod       *corev1.Pod
+	k8sEvent  *corev1.Event
+	output    io.Writer
+	labelers  []state.PodLabeler
+	observers []types.ImagePullStatisticObserver
 }
 
 // Dispatch implements [safeconcurrencytypes.Event.Dispatch].
@@ -445,7 +692,7 @@ func (e *imagePullUpdateEvent) Dispatch(
 	//nolint:zerologlint
 	e.logWith(log.Trace()).Any("pod_image_pull_statistic", podImagePullStatistic).Msg("Pod image pull statistic")
 
-	containerImagePullStatistic, containerOk := podImagePullStatistic.Get(containerName)
+	containerImagePullStatistic, containerOk := podImagePullStatistic.GetForPod(e.pod, containerName)
 	if !containerOk {
 		// e.logWith returns the zerolog.Event, so we can chain the calls.
 		//nolint:zerologlint
@@ -464,7 +711,14 @@ func (e *imagePullUpdateEvent) Dispatch(
 	containerImagePullStatistic = containerImagePullStatistic.Update(e.k8sEvent)
 
 	if e.options.EmitPartialStatistics || !containerImagePullStatistic.Partial() {
-		containerImagePullStatistic.Report(e.output, e.pod, e.k8sEvent.Message)
+		containerImagePullStatistic.Report(e.output, e.pod, e.k8sEvent.Message, e.labelers...)
+	}
+
+	// Partial statistics are skipped above, so the statistic has just completed.
+	if !containerImagePullStatistic.Partial() {
+		for _, observer := range e.observers {
+			observer.ObserveImagePullStatistic(e.pod, containerImagePullStatistic)
+		}
 	}
 
 	podImagePullStatistic = podImagePullStatistic.Set(containerImagePullStatistic)
@@ -475,8 +729,11 @@ func (e *imagePullUpdateEvent) Dispatch(
 
 // getContainerName parses the container name from the fieldRef of the Kubernetes Event.
 func (e *imagePullUpdateEvent) getContainerName() (string, error) {
-	fieldRef := e.k8sEvent.InvolvedObject.FieldPath
+	return containerNameFromFieldPath(e.k8sEvent.InvolvedObject.FieldPath)
+}
 
+// containerNameFromFieldPath parses the container name from the fieldRef of the involved object of a Kubernetes Event.
+func containerNameFromFieldPath(fieldRef string) (string, error) {
 	matches := fieldPathContainerRegex.FindStringSubmatch(fieldRef)
 	if matches == nil {
 		return "", newParseContainerNameError(fieldRef)
@@ -497,9 +754,10 @@ func (e *imagePullUpdateEvent) logWith(ev *zerolog.Event) *zerolog.Event {
 // deleteImagePullEvent is used to delete the image pull statistic for a pod after it has been deleted from the
 // Kubernetes API.
 type deleteImagePullEvent struct {
-	options *options.Options
-	pod     *corev1.Pod
-	output  io.Writer
+	options  *options.Options
+	pod      *corev1.Pod
+	output   io.Writer
+	labelers []state.PodLabeler
 }
 
 // Dispatch implements [safeconcurrencytypes.Event.Dispatch].
@@ -514,9 +772,17 @@ func (e *deleteImagePullEvent) Dispatch(
 		return statisticState
 	}
 
-	for _, container := range imagePullStatistic.Containers() {
-		if container.Partial() {
-			container.Report(e.output, e.pod, "premature deletion of pod")
+	// The collector of a pod which was already running only collected the image pulls of its pending ephemeral
+	// containers, the image pulls of the other containers were already reported.
+	collected := func(string) bool { return true }
+	if e.pod.Status.Phase == corev1.PodRunning {
+		pending := pendingEphemeralContainers(e.pod)
+		collected = func(name string) bool { return slices.Contains(pending, name) }
+	}
+
+	for name, container := range imagePullStatistic.Containers() {
+		if container.Partial() && collected(name) {
+			container.Report(e.output, e.pod, "premature deletion of pod", e.labelers...)
 		}
 	}
 
@@ -524,7 +790,7 @@ func (e *deleteImagePullEvent) Dispatch(
 }
 
 // fieldPathContainerRegex is used to parse the container name from the fieldRef of the Kubernetes Event.
-var fieldPathContainerRegex = regexp.MustCompile(`^spec\.(?:initC|c)ontainers\{(.*)\}$`)
+var fieldPathContainerRegex = regexp.MustCompile(`^spec\.(?:initC|c|ephemeralC)ontainers\{(.*)\}$`)
 
 // parseContainerNameError is used to indicate that the container name could not be parsed from the involved object's
 // field-path of the Kubernetes Event.
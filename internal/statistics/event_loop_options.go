@@ -0,0 +1,66 @@
+package statistics
+
+import (
+	"github.com/BackMarket-oss/kube-transition-metrics/internal/statistics/state"
+	"github.com/BackMarket-oss/kube-transition-metrics/internal/statistics/types"
+	"k8s.io/utils/clock"
+)
+
+// EventLoopOption configures the optional extensions of the statistic event loops.
+type EventLoopOption func(config *eventLoopConfig)
+
+// eventLoopConfig holds the optional extensions shared by the statistic event loops.
+type eventLoopConfig struct {
+	// labelers add additional labels to the reported metric records.
+	labelers []state.PodLabeler
+	// podObservers are notified when a pod statistic is complete.
+	podObservers []types.PodStatisticObserver
+	// imagePullObservers are notified when a container image pull statistic is complete.
+	imagePullObservers []types.ImagePullStatisticObserver
+	// clock timestamps the observed transitions of the pods.
+	clock clock.PassiveClock
+}
+
+// newEventLoopConfig applies the event loop options to a new eventLoopConfig.
+func newEventLoopConfig(opts []EventLoopOption) eventLoopConfig {
+	config := eventLoopConfig{clock: clock.RealClock{}}
+	for _, opt := range opts {
+		opt(&config)
+	}
+
+	return config
+}
+
+// WithPodLabelers adds the labels of the provided labelers to all the metric records reported by the event loop.
+func WithPodLabelers(labelers ...state.PodLabeler) EventLoopOption {
+	return func(config *eventLoopConfig) {
+		config.labelers = append(config.labelers, labelers...)
+	}
+}
+
+// WithPodStatisticObservers notifies the provided observers when a pod statistic is complete, when a new pod is
+// tracked for the observers implementing [types.PodCreationObserver], and when a pod is deleted for the observers
+// implementing [types.PodDeletionObserver].
+// It only applies to the pod statistic event loop.
+func WithPodStatisticObservers(observers ...types.PodStatisticObserver) EventLoopOption {
+	return func(config *eventLoopConfig) {
+		config.podObservers = append(config.podObservers, observers...)
+	}
+}
+
+// WithImagePullStatisticObservers notifies the provided observers when a container image pull statistic is complete.
+// It only applies to the image pull statistic event loop.
+func WithImagePullStatisticObservers(observers ...types.ImagePullStatisticObserver) EventLoopOption {
+	return func(config *eventLoopConfig) {
+		config.imagePullObservers = append(config.imagePullObservers, observers...)
+	}
+}
+
+// WithClock timestamps the transitions of the pods observed by the event loop with the provided clock instead of the
+// wall clock, e.g. a virtual clock when replaying recorded watch events.
+// It only applies to the pod statistic event loop.
+func WithClock(clock clock.PassiveClock) EventLoopOption {
+	return func(config *eventLoopConfig) {
+		config.clock = clock
+	}
+}
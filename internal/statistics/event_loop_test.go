@@ -8,12 +8,14 @@ import (
 
 	"github.com/BackMarket-oss/kube-transition-metrics/internal/options"
 	"github.com/BackMarket-oss/kube-transition-metrics/internal/statistics/state"
+	"github.com/BackMarket-oss/kube-transition-metrics/internal/statistics/types"
 	"github.com/BackMarket-oss/kube-transition-metrics/internal/testhelpers"
 	"github.com/Izzette/go-safeconcurrency/eventloop"
 	"github.com/rs/zerolog"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	apimachinerytypes "k8s.io/apimachinery/pkg/types"
 )
@@ -269,15 +271,212 @@ func TestPodUpdateSkipsCompletePod(t *testing.T) {
 	podStatistics := state.NewPodStatistics([]apimachinerytypes.UID{})
 	podStatistics = podStatistics.Set("test-uid", statistic)
 
+	observer := &testingPodStatisticObserver{}
 	updateEvent := &podUpdateEvent{
 		pod:       pod,
 		eventTime: created.Add(4 * time.Second),
 		options:   opts,
 		output:    io.Discard,
+		observers: []types.PodStatisticObserver{observer},
 	}
 	nextStats := updateEvent.Dispatch(0, podStatistics)
 
 	assert.Same(t, podStatistics, nextStats, "Expected PodStatistics to be unchanged for complete pod")
+	assert.Equal(t, 1, observer.updated, "Expected updates of complete pods to be observed")
+	assert.Empty(t, observer.observed, "Expected complete pod statistic to not be observed again")
+}
+
+func TestPodUpdateReportsEphemeralContainerOfCompletePod(t *testing.T) {
+	opts := &options.Options{}
+	testhelpers.ConfigureLogging(t, opts)
+
+	created := time.Now()
+	pod := newTestingCompletePod(created)
+
+	statistic := state.NewPodStatistic(created.Add(3*time.Second), pod)
+	podStatistics := state.NewPodStatistics([]apimachinerytypes.UID{})
+	podStatistics = podStatistics.Set("test-uid", statistic)
+
+	debugPod := pod.DeepCopy()
+	debugPod.Spec.EphemeralContainers = []corev1.EphemeralContainer{{
+		EphemeralContainerCommon: corev1.EphemeralContainerCommon{Name: "debugger", Image: "busybox"},
+	}}
+
+	output := testhelpers.NewMetricWriter(t)
+	observer := &testingPodStatisticObserver{}
+	updateEvent := &podUpdateEvent{
+		pod:       debugPod,
+		eventTime: created.Add(time.Minute),
+		options:   opts,
+		output:    output,
+		observers: []types.PodStatisticObserver{observer},
+	}
+	podStatistics = updateEvent.Dispatch(0, podStatistics)
+
+	assert.Empty(t, testhelpers.DecodeMetricOutput(t, output), "Expected no metrics for pending ephemeral container")
+
+	runningPod := debugPod.DeepCopy()
+	runningPod.Status.EphemeralContainerStatuses = []corev1.ContainerStatus{{
+		Name: "debugger",
+		State: corev1.ContainerState{
+			Running: &corev1.ContainerStateRunning{StartedAt: metav1.NewTime(created.Add(time.Minute + 5*time.Second))},
+		},
+	}}
+
+	output = testhelpers.NewMetricWriter(t)
+	updateEvent.pod = runningPod
+	updateEvent.eventTime = created.Add(time.Minute + 6*time.Second)
+	updateEvent.output = output
+	podStatistics = updateEvent.Dispatch(0, podStatistics)
+
+	metrics := testhelpers.DecodeMetricOutput(t, output)
+	require.Len(t, metrics, 1, "Expected only the ephemeral container metric")
+	assert.Equal(t, "ephemeral_container", metrics[0]["type"])
+	assert.Equal(t, "debugger", metrics[0]["container_name"])
+
+	ephemeral, ok := metrics[0]["ephemeral_container"].(map[string]any)
+	require.True(t, ok, "Expected ephemeral_container to be an object")
+	assert.InDelta(t, 5.0, ephemeral["added_to_running_seconds"], 0.001)
+	assert.Equal(t, "authoritative", ephemeral["running_timestamp_source"])
+	assert.Empty(t, observer.observed, "Expected complete pod statistic to not be observed again")
+
+	updateEvent.output = io.Discard
+	nextStats := updateEvent.Dispatch(0, podStatistics)
+	assert.Same(t, podStatistics, nextStats, "Expected running ephemeral container to not be updated again")
+}
+
+func TestPodUpdateReportsResizeOfCompletePod(t *testing.T) {
+	opts := &options.Options{}
+	testhelpers.ConfigureLogging(t, opts)
+
+	created := time.Now()
+	pod := newTestingCompletePod(created)
+
+	podStatistics := state.NewPodStatistics([]apimachinerytypes.UID{})
+	podStatistics = podStatistics.Set("test-uid", state.NewPodStatistic(created.Add(3*time.Second), pod))
+
+	resized := pod.DeepCopy()
+	resized.Spec.Containers[0].Resources.Requests = corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("1Gi")}
+
+	output := testhelpers.NewMetricWriter(t)
+	observer := &testingPodStatisticObserver{}
+	updateEvent := &podUpdateEvent{
+		pod:       resized,
+		eventTime: created.Add(time.Minute),
+		options:   opts,
+		output:    output,
+		observers: []types.PodStatisticObserver{observer},
+	}
+	podStatistics = updateEvent.Dispatch(0, podStatistics)
+
+	metrics := testhelpers.DecodeMetricOutput(t, output)
+	require.Len(t, metrics, 1, "Expected only the resize metric")
+	assert.Equal(t, "resize", metrics[0]["type"])
+	assert.Empty(t, observer.observed, "Expected complete pod statistic to not be observed again")
+
+	updateEvent.output = io.Discard
+	nextStats := updateEvent.Dispatch(0, podStatistics)
+	assert.Same(t, podStatistics, nextStats, "Expected applied resize to not be updated again")
+}
+
+func TestPodUpdateReportsUnchangedPartialResizeOnce(t *testing.T) {
+	opts := &options.Options{EmitPartialStatistics: true}
+	testhelpers.ConfigureLogging(t, opts)
+
+	created := time.Now()
+	pod := newTestingCompletePod(created)
+
+	podStatistics := state.NewPodStatistics([]apimachinerytypes.UID{})
+	podStatistics = podStatistics.Set("test-uid", state.NewPodStatistic(created.Add(3*time.Second), pod))
+
+	// The resize is deferred, until the node has the resources.
+	resized := pod.DeepCopy()
+	resized.Spec.Containers[0].Resources.Requests = corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("1Gi")}
+	resized.Status.Conditions = append(resized.Status.Conditions, corev1.PodCondition{
+		Type:               corev1.PodResizePending,
+		Status:             corev1.ConditionTrue,
+		Reason:             corev1.PodReasonDeferred,
+		LastTransitionTime: metav1.NewTime(created.Add(time.Minute)),
+	})
+
+	output := testhelpers.NewMetricWriter(t)
+	for i := range 3 {
+		podStatistics = (&podUpdateEvent{
+			pod:       resized,
+			eventTime: created.Add(time.Minute + time.Duration(i)*time.Second),
+			options:   opts,
+			output:    output,
+		}).Dispatch(0, podStatistics)
+	}
+
+	metrics := testhelpers.DecodeMetricOutput(t, output)
+	require.Len(t, metrics, 1, "Expected the partial resize metric to only be reported once while unchanged")
+	assert.Equal(t, "resize", metrics[0]["type"])
+}
+
+func TestPodUpdateReportsVolumes(t *testing.T) {
+	opts := &options.Options{TrackVolumes: true}
+	testhelpers.ConfigureLogging(t, opts)
+
+	created := time.Now()
+	pod := newTestingPod(created)
+	pod.Spec.Volumes = []corev1.Volume{{
+		Name: "data",
+		VolumeSource: corev1.VolumeSource{
+			PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: "data-test-pod"},
+		},
+	}}
+
+	podStatistics := state.NewPodStatistics([]apimachinerytypes.UID{})
+	updateEvent := &podUpdateEvent{
+		pod:       pod,
+		eventTime: created,
+		options:   opts,
+		output:    io.Discard,
+	}
+	podStatistics = updateEvent.Dispatch(0, podStatistics)
+
+	claimEvent := &claimVolumeEvent{k8sEvent: &corev1.Event{
+		InvolvedObject: corev1.ObjectReference{
+			Kind:      "PersistentVolumeClaim",
+			Namespace: "test-namespace",
+			Name:      "data-test-pod",
+		},
+		Reason:         state.ClaimReasonProvisioned,
+		Message:        "Successfully provisioned volume pvc-1234",
+		FirstTimestamp: metav1.NewTime(created.Add(time.Second)),
+	}}
+	podStatistics = claimEvent.Dispatch(0, podStatistics)
+
+	volumeEvent := &podK8sEvent{uid: "test-uid", k8sEvent: &corev1.Event{
+		Reason:         state.VolumeReasonAttached,
+		Message:        `AttachVolume.Attach succeeded for volume "pvc-1234"`,
+		FirstTimestamp: metav1.NewTime(created.Add(2 * time.Second)),
+	}}
+	podStatistics = volumeEvent.Dispatch(0, podStatistics)
+
+	output := testhelpers.NewMetricWriter(t)
+	updateEvent.pod = newTestingCompletePod(created)
+	updateEvent.pod.Spec.Volumes = pod.Spec.Volumes
+	updateEvent.eventTime = created.Add(3 * time.Second)
+	updateEvent.output = output
+	podStatistics = updateEvent.Dispatch(0, podStatistics)
+
+	var volume map[string]any
+
+	for _, metric := range testhelpers.DecodeMetricOutput(t, output) {
+		if metric["type"] == "volume" {
+			volume, _ = metric["volume"].(map[string]any)
+		}
+	}
+
+	require.NotNil(t, volume, "Expected a volume metric")
+	assert.Equal(t, "pvc-1234", volume["persistent_volume"])
+	assert.Contains(t, volume, "attached_timestamp")
+	assert.Contains(t, volume, "mounted_timestamp")
+
+	nextStats := volumeEvent.Dispatch(0, podStatistics)
+	assert.Same(t, podStatistics, nextStats, "Expected volume events of complete pods to be ignored")
 }
 
 func TestPodUpdateEmitsPartialStatistics(t *testing.T) {
@@ -593,6 +792,70 @@ func TestDeleteImagePullRemovesTrackedPod(t *testing.T) {
 	assert.Equal(t, true, metrics[0]["partial"], "Expected partial=true")
 }
 
+func TestDeleteImagePullReportsPrematureDeletion(t *testing.T) {
+	opts := &options.Options{}
+	testhelpers.ConfigureLogging(t, opts)
+
+	created := time.Now()
+	pod := newTestingPod(created)
+	pod.Spec.Containers = append(pod.Spec.Containers, corev1.Container{Name: "sidecar", Image: "sidecar-image"})
+
+	// The pod is deleted after the image of the first container is pulled, before any image pull event is received for
+	// the second container.
+	statisticState := state.NewImagePullStatistics()
+	for i, reason := range []string{"Pulling", "Pulled"} {
+		statisticState = (&imagePullUpdateEvent{
+			options: opts,
+			pod:     pod,
+			k8sEvent: &corev1.Event{
+				InvolvedObject: corev1.ObjectReference{FieldPath: "spec.containers{test-container}"},
+				Reason:         reason,
+				LastTimestamp:  metav1.NewTime(created.Add(time.Duration(i+1) * time.Second)),
+			},
+			output: io.Discard,
+		}).Dispatch(0, statisticState)
+	}
+
+	output := testhelpers.NewMetricWriter(t)
+	(&deleteImagePullEvent{options: opts, pod: pod, output: output}).Dispatch(0, statisticState)
+
+	metrics := testhelpers.DecodeMetricOutput(t, output)
+	require.Len(t, metrics, 1, "Expected 1 image_pull metric for the container without image pull event")
+	assert.Equal(t, true, metrics[0]["partial"], "Expected partial=true")
+	assert.Equal(t, "sidecar", metrics[0]["container_name"], "Expected the container without image pull event")
+}
+
+func TestDeleteImagePullOfRunningPodReportsPendingEphemeralContainers(t *testing.T) {
+	opts := &options.Options{}
+	testhelpers.ConfigureLogging(t, opts)
+
+	created := time.Now()
+	pod := newTestingCompletePod(created)
+	pod.Status.Phase = corev1.PodRunning
+	pod.Spec.EphemeralContainers = []corev1.EphemeralContainer{{
+		EphemeralContainerCommon: corev1.EphemeralContainerCommon{Name: "debugger", Image: "busybox:1.36"},
+	}}
+
+	statisticState := (&imagePullUpdateEvent{
+		options: opts,
+		pod:     pod,
+		k8sEvent: &corev1.Event{
+			InvolvedObject: corev1.ObjectReference{FieldPath: "spec.ephemeralContainers{debugger}"},
+			Reason:         "Pulling",
+			LastTimestamp:  metav1.NewTime(created.Add(time.Minute)),
+		},
+		output: io.Discard,
+	}).Dispatch(0, state.NewImagePullStatistics())
+
+	output := testhelpers.NewMetricWriter(t)
+	(&deleteImagePullEvent{options: opts, pod: pod, output: output}).Dispatch(0, statisticState)
+
+	// The image pull of the other container was collected before the pod was running.
+	metrics := testhelpers.DecodeMetricOutput(t, output)
+	require.Len(t, metrics, 1, "Expected 1 image_pull metric for the ephemeral container")
+	assert.Equal(t, "debugger", metrics[0]["container_name"], "Expected the ephemeral container")
+}
+
 func TestDeleteImagePullSkipsUntrackedPod(t *testing.T) {
 	opts := &options.Options{}
 	testhelpers.ConfigureLogging(t, opts)
@@ -609,3 +872,268 @@ func TestDeleteImagePullSkipsUntrackedPod(t *testing.T) {
 
 	assert.Same(t, statisticState, nextState, "Expected state to be unchanged for untracked pod")
 }
+
+// testingPodLabeler adds a fixed team label to the metric records.
+type testingPodLabeler struct{}
+
+func (testingPodLabeler) PodLabels(_ *corev1.Pod) func(event *zerolog.Event) {
+	return func(event *zerolog.Event) {
+		event.Str("team", "test-team")
+	}
+}
+
+// testingPodStatisticObserver records the pod statistics, creations, updates and deletions it observes.
+type testingPodStatisticObserver struct {
+	observed []*state.PodStatistic
+	created  int
+	updated  int
+	deleted  int
+}
+
+func (o *testingPodStatisticObserver) ObservePodStatistic(_ *corev1.Pod, statistic *state.PodStatistic) {
+	o.observed = append(o.observed, statistic)
+}
+
+func (o *testingPodStatisticObserver) ObservePodCreation(_ *corev1.Pod) {
+	o.created++
+}
+
+func (o *testingPodStatisticObserver) ObservePodUpdate(_ *corev1.Pod, _ *state.PodStatistic) {
+	o.updated++
+}
+
+func (o *testingPodStatisticObserver) ObservePodDeletion(_ *corev1.Pod) {
+	o.deleted++
+}
+
+func TestPodUpdateLabelersAndObservers(t *testing.T) {
+	opts := &options.Options{
+		LabelMappings: []options.LabelMapping{
+			{Field: "team", Source: options.PodLabelSource, Key: "example.com/team"},
+		},
+	}
+	testhelpers.ConfigureLogging(t, opts)
+
+	created := time.Now()
+	podStatistics := state.NewPodStatistics([]apimachinerytypes.UID{})
+	observer := &testingPodStatisticObserver{}
+	output := testhelpers.NewLabeledMetricWriter(t, opts)
+
+	updateEvent := &podUpdateEvent{
+		pod:       newTestingPod(created),
+		eventTime: created,
+		options:   opts,
+		output:    output,
+		labelers:  []state.PodLabeler{testingPodLabeler{}},
+		observers: []types.PodStatisticObserver{observer},
+	}
+	podStatistics = updateEvent.Dispatch(0, podStatistics)
+	assert.Empty(t, observer.observed, "Expected partial pod statistic to not be observed")
+
+	updateEvent.pod = newTestingCompletePod(created)
+	updateEvent.eventTime = created.Add(3 * time.Second)
+	podStatistics = updateEvent.Dispatch(0, podStatistics)
+	require.Len(t, observer.observed, 1, "Expected complete pod statistic to be observed once")
+	assert.False(t, observer.observed[0].Partial(), "Expected observed pod statistic to be complete")
+	assert.Equal(t, 1, observer.created, "Expected pod creation to be observed once")
+	assert.Equal(t, 2, observer.updated, "Expected each pod update to be observed")
+
+	deleteEvent := &podDeleteEvent{
+		pod:       updateEvent.pod,
+		options:   opts,
+		output:    output,
+		observers: []types.PodStatisticObserver{observer},
+	}
+	deleteEvent.Dispatch(0, podStatistics)
+	deleteEvent.Dispatch(0, podStatistics.Delete(updateEvent.pod.UID))
+	assert.Equal(t, 1, observer.deleted, "Expected the deletion of tracked pods to be observed")
+
+	metrics := testhelpers.DecodeMetricOutput(t, output)
+	require.NotEmpty(t, metrics, "Expected metrics for complete pod")
+
+	for _, metric := range metrics {
+		assert.Equal(t, "test-team", metric["team"], "Expected labeler field in metric record")
+	}
+}
+
+func TestPodEndpointReady(t *testing.T) {
+	opts := &options.Options{}
+	testhelpers.ConfigureLogging(t, opts)
+
+	created := time.Now()
+	podStatistics := state.NewPodStatistics([]apimachinerytypes.UID{})
+	output := testhelpers.NewMetricWriter(t)
+
+	endpointReadyEvent := &podEndpointReadyEvent{
+		uid:       "test-uid",
+		service:   "test-service",
+		eventTime: created.Add(5 * time.Second),
+		output:    output,
+	}
+	assert.Same(t, podStatistics, endpointReadyEvent.Dispatch(0, podStatistics), "Expected untracked pod to be ignored")
+
+	podStatistics = podStatistics.Set(
+		"test-uid", state.NewPodStatistic(created.Add(3*time.Second), newTestingCompletePod(created)))
+	podStatistics = endpointReadyEvent.Dispatch(0, podStatistics)
+
+	statistic, ok := podStatistics.Get("test-uid")
+	require.True(t, ok, "Expected pod statistic to be found")
+	assert.Equal(t, created.Add(5*time.Second), statistic.EndpointReadyTimestamp())
+
+	endpointReadyEvent.eventTime = created.Add(10 * time.Second)
+	assert.Same(t, podStatistics, endpointReadyEvent.Dispatch(0, podStatistics),
+		"Expected endpoint readiness to only be recorded once")
+
+	metrics := testhelpers.DecodeMetricOutput(t, output)
+	require.Len(t, metrics, 1, "Expected a single endpoint metric")
+	assert.Equal(t, "endpoint", metrics[0]["type"])
+
+	endpoint, ok := metrics[0]["endpoint"].(map[string]any)
+	require.True(t, ok, "Expected endpoint to be an object")
+	assert.Equal(t, "test-service", endpoint["service"])
+	assert.InDelta(t, 2, endpoint["ready_to_endpoint_ready_seconds"], 0.001)
+}
+
+func TestPodEndpointReadyBeforePodReady(t *testing.T) {
+	opts := &options.Options{}
+	testhelpers.ConfigureLogging(t, opts)
+
+	created := time.Now()
+	podStatistics := state.NewPodStatistics([]apimachinerytypes.UID{})
+	podStatistics = podStatistics.Set("test-uid", state.NewPodStatistic(created, newTestingPod(created)))
+	output := testhelpers.NewMetricWriter(t)
+
+	// The EndpointSlice is received before the pod update with the Ready condition.
+	podStatistics = (&podEndpointReadyEvent{
+		uid:       "test-uid",
+		service:   "test-service",
+		eventTime: created.Add(5 * time.Second),
+		output:    output,
+	}).Dispatch(0, podStatistics)
+	assert.Empty(t, testhelpers.DecodeMetricOutput(t, output), "Expected no endpoint metric before the pod is Ready")
+
+	(&podUpdateEvent{
+		options:   opts,
+		pod:       newTestingCompletePod(created),
+		eventTime: created.Add(6 * time.Second),
+		output:    output,
+	}).Dispatch(0, podStatistics)
+
+	var endpoints []map[string]any
+
+	for _, metric := range testhelpers.DecodeMetricOutput(t, output) {
+		if endpoint, ok := metric["endpoint"].(map[string]any); ok {
+			endpoints = append(endpoints, endpoint)
+		}
+	}
+
+	require.Len(t, endpoints, 1, "Expected the endpoint metric once the pod is Ready")
+	assert.Equal(t, "test-service", endpoints[0]["service"])
+	assert.InDelta(t, 2, endpoints[0]["ready_to_endpoint_ready_seconds"], 0.001)
+}
+
+func TestPodEndpointReadyNotReadyAddress(t *testing.T) {
+	opts := &options.Options{}
+	testhelpers.ConfigureLogging(t, opts)
+
+	created := time.Now()
+	podStatistics := state.NewPodStatistics([]apimachinerytypes.UID{})
+	podStatistics = podStatistics.Set("test-uid", state.NewPodStatistic(created, newTestingPod(created)))
+	output := testhelpers.NewMetricWriter(t)
+
+	// The address is published before the pod is Ready, e.g. with publishNotReadyAddresses.
+	podStatistics = (&podEndpointReadyEvent{
+		uid:       "test-uid",
+		service:   "test-service",
+		eventTime: created.Add(time.Second),
+		output:    output,
+	}).Dispatch(0, podStatistics)
+
+	(&podUpdateEvent{
+		options:   opts,
+		pod:       newTestingCompletePod(created),
+		eventTime: created.Add(4 * time.Second),
+		output:    output,
+	}).Dispatch(0, podStatistics)
+
+	for _, metric := range testhelpers.DecodeMetricOutput(t, output) {
+		assert.NotEqual(t, "endpoint", metric["type"], "Expected no endpoint metric for a not Ready address")
+	}
+}
+
+func TestPodK8sEventImagePull(t *testing.T) {
+	opts := &options.Options{}
+	testhelpers.ConfigureLogging(t, opts)
+
+	created := time.Now()
+	podStatistics := state.NewPodStatistics([]apimachinerytypes.UID{})
+	updateEvent := &podUpdateEvent{
+		pod:       newTestingPod(created),
+		eventTime: created,
+		options:   opts,
+		output:    io.Discard,
+	}
+	podStatistics = updateEvent.Dispatch(0, podStatistics)
+
+	pullingEvent := &podK8sEvent{uid: "test-uid", k8sEvent: &corev1.Event{
+		InvolvedObject: corev1.ObjectReference{FieldPath: "spec.containers{test-container}"},
+		Reason:         "Pulling",
+		LastTimestamp:  metav1.NewTime(created),
+	}}
+	nextStats := pullingEvent.Dispatch(0, podStatistics)
+	assert.NotSame(t, podStatistics, nextStats, "Expected image pull to update the pod statistic")
+
+	pullingEvent.k8sEvent.InvolvedObject.FieldPath = "spec.volumes{data}"
+	assert.Same(t, nextStats, pullingEvent.Dispatch(0, nextStats),
+		"Expected image pull of unknown field path to be ignored")
+}
+
+func TestPodK8sEventImagePullAfterReady(t *testing.T) {
+	opts := &options.Options{}
+	testhelpers.ConfigureLogging(t, opts)
+
+	created := time.Now()
+	podStatistics := state.NewPodStatistics([]apimachinerytypes.UID{})
+	podStatistics = (&podUpdateEvent{
+		pod:       newTestingPod(created),
+		eventTime: created,
+		options:   opts,
+		output:    io.Discard,
+	}).Dispatch(0, podStatistics)
+
+	pullingEvent := &podK8sEvent{uid: "test-uid", k8sEvent: &corev1.Event{
+		InvolvedObject: corev1.ObjectReference{FieldPath: "spec.containers{test-container}"},
+		Reason:         "Pulling",
+		LastTimestamp:  metav1.NewTime(created.Add(time.Second)),
+	}}
+	podStatistics = pullingEvent.Dispatch(0, podStatistics)
+
+	// The pod update with the Ready condition is received before the Pulled Event.
+	output := testhelpers.NewMetricWriter(t)
+	podStatistics = (&podUpdateEvent{
+		pod:       newTestingCompletePod(created),
+		eventTime: created.Add(3 * time.Second),
+		options:   opts,
+		output:    output,
+	}).Dispatch(0, podStatistics)
+
+	pulledEvent := &podK8sEvent{uid: "test-uid", k8sEvent: &corev1.Event{
+		InvolvedObject: corev1.ObjectReference{FieldPath: "spec.containers{test-container}"},
+		Reason:         "Pulled",
+		LastTimestamp:  metav1.NewTime(created.Add(2 * time.Second)),
+	}}
+	assert.Same(t, podStatistics, pulledEvent.Dispatch(0, podStatistics),
+		"Expected image pull received after the pod record to be ignored")
+
+	var criticalPath map[string]any
+
+	for _, metric := range testhelpers.DecodeMetricOutput(t, output) {
+		if pod, ok := metric["pod"].(map[string]any); ok {
+			criticalPath, _ = pod["critical_path"].(map[string]any)
+		}
+	}
+
+	require.NotNil(t, criticalPath, "Expected a critical path in the pod metric")
+	assert.Equal(t, true, criticalPath["incomplete"], "Expected the critical path to be marked incomplete")
+	assert.InDelta(t, 0, criticalPath["image_pull_seconds"], 0.001, "Expected the image pull to not be counted")
+}
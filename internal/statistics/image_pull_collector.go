@@ -2,11 +2,13 @@ package statistics
 
 import (
 	"context"
+	"fmt"
 	"sync/atomic"
 	"time"
 
 	"github.com/BackMarket-oss/kube-transition-metrics/internal/options"
 	"github.com/BackMarket-oss/kube-transition-metrics/internal/prommetrics"
+	"github.com/BackMarket-oss/kube-transition-metrics/internal/statistics/state"
 	"github.com/BackMarket-oss/kube-transition-metrics/internal/statistics/types"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/rs/zerolog"
@@ -17,6 +19,7 @@ import (
 	"k8s.io/apimachinery/pkg/util/json"
 	"k8s.io/apimachinery/pkg/watch"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/utils/clock"
 )
 
 // imagePullCollector is a collector that watches for image pull events in a Kubernetes pod.
@@ -29,49 +32,101 @@ type imagePullCollector struct {
 	// canceled is an atomic boolean that indicates whether the collector has been canceled.
 	canceled *atomic.Bool
 	// cancelChan is a channel used to signal cancellation of the collector.
+	// It is buffered, as the collector may be canceled after it stopped watching on the context.
 	cancelChan chan string
 
 	// statisticEventLoop is the [github.com/Izzette/go-safeconcurrency/types.EventLoop] used to handle image pull
 	// statistic states.
 	statisticEventLoop types.ImagePullStatisticEventLoop
 
+	// podEventLoop is the [github.com/Izzette/go-safeconcurrency/types.EventLoop] used to handle pod statistic states, to
+	// which the sandbox Events of the pod and the attach and mount Events of its volumes are sent.
+	podEventLoop types.PodStatisticEventLoop
+
 	// pod is the Kubernetes pod for which image pull events are being collected.
 	pod *corev1.Pod
+
+	// fieldPaths restricts the collected events to the containers with these involved object field-paths, or collects
+	// the events of all the containers if nil.
+	// It is used for the ephemeral containers added to pods which are already running.
+	fieldPaths map[string]struct{}
+
+	// recorder records the Event watch events received by the collector, if recording is enabled.
+	recorder *WatchRecorder
+
+	// clock is the clock used to wait for the image pull cancel delay.
+	clock clock.Clock
 }
 
 // imagePullCollectorFactory is a function type that creates a new imagePullCollector instance.
 // It is used to allow mocking in tests and to provide a clear contract for the collector's behavior.
 type imagePullCollectorFactory func(
-	*options.Options, types.ImagePullStatisticEventLoop, *corev1.Pod,
+	*options.Options, types.ImagePullStatisticEventLoop, types.PodStatisticEventLoop, *corev1.Pod,
 ) types.ImagePullCollector
 
 // newImagePullCollector creates (but does not start) a new imagePullCollector instance.
 func newImagePullCollector(
 	options *options.Options,
 	statisticEventLoop types.ImagePullStatisticEventLoop,
+	podEventLoop types.PodStatisticEventLoop,
 	pod *corev1.Pod,
 ) *imagePullCollector {
+	var fieldPaths map[string]struct{}
+
+	// The image pulls of the other containers of a running pod were already collected.
+	if pod.Status.Phase == corev1.PodRunning {
+		fieldPaths = make(map[string]struct{})
+		for _, name := range pendingEphemeralContainers(pod) {
+			fieldPaths[fmt.Sprintf("spec.ephemeralContainers{%s}", name)] = struct{}{}
+		}
+	}
+
 	return &imagePullCollector{
 		options:            options,
 		canceled:           &atomic.Bool{},
-		cancelChan:         make(chan string),
+		cancelChan:         make(chan string, 1),
 		statisticEventLoop: statisticEventLoop,
+		podEventLoop:       podEventLoop,
 		pod:                pod,
+		fieldPaths:         fieldPaths,
+		clock:              clock.RealClock{},
+	}
+}
+
+// pendingEphemeralContainers returns the names of the ephemeral containers of the pod which are not yet running.
+func pendingEphemeralContainers(pod *corev1.Pod) []string {
+	statuses := make(map[string]corev1.ContainerStatus, len(pod.Status.EphemeralContainerStatuses))
+	for _, status := range pod.Status.EphemeralContainerStatuses {
+		statuses[status.Name] = status
 	}
+
+	var pending []string
+
+	for _, container := range pod.Spec.EphemeralContainers {
+		status, ok := statuses[container.Name]
+		if !ok || (status.State.Running == nil && status.State.Terminated == nil) {
+			pending = append(pending, container.Name)
+		}
+	}
+
+	return pending
 }
 
 // Run starts the imagePullCollector and begins watching for image pull events.
-// It does not start a new goroutine and will block until the image pull is complete or the collector is canceled.
+// It does not start a new goroutine and will block until the image pull is complete, the collector is canceled, or the
+// context is canceled.
 //
 // Run implements [types.ImagePullCollector.Run].
-func (c *imagePullCollector) Run(clientset *kubernetes.Clientset) {
+func (c *imagePullCollector) Run(ctx context.Context, clientset kubernetes.Interface) {
 	logger := c.Logger()
 
 	logger.Debug().Msg("Started ImagePullCollector ...")
 	prommetrics.ImagePullCollectorRoutines.Inc()
 
 	defer func() {
-		_, err := c.statisticEventLoop.ImagePullDelete(context.TODO(), c.pod)
+		// The context may already be canceled, but the image pull statistic must still be cleaned up before the event
+		// loop is closed.
+		_, err := c.statisticEventLoop.ImagePullDelete(context.WithoutCancel(ctx), c.pod)
 		if err != nil {
 			logger.Error().Err(err).Msg("Error cleaning up image pull statistic")
 		}
@@ -81,7 +136,7 @@ func (c *imagePullCollector) Run(clientset *kubernetes.Clientset) {
 	}()
 
 	for {
-		if c.Watch(clientset) {
+		if c.Watch(ctx, clientset) {
 			return
 		}
 
@@ -110,12 +165,14 @@ func (c *imagePullCollector) HandleWatchEvent(watchEvent watch.Event) bool {
 		logger.Panic().Msgf("Watch event is not an Event: %+v", watchEvent)
 	}
 
+	c.recorder.RecordEvent(watchEvent.Type, event)
 	c.HandleEvent(watchEvent.Type, event)
 
 	return false
 }
 
-// HandleEvent handles a Kubernetes event and publishes it to the statistic event loop if it is an image pull event.
+// HandleEvent handles a Kubernetes event and publishes it to the statistic event loop if it is an image pull event, and
+// to the pod statistic event loop if it is an image pull, sandbox or volume event.
 //
 // HandleEvent implements [types.ImagePullCollector.HandleEvent].
 func (c *imagePullCollector) HandleEvent(
@@ -124,27 +181,71 @@ func (c *imagePullCollector) HandleEvent(
 ) {
 	logger := c.Logger()
 
+	if c.isPodEvent(eventType, event) {
+		_, err := c.podEventLoop.PodEvent(context.TODO(), c.pod.UID, event)
+		if err != nil {
+			logger.Error().Err(err).Any("event", event).Msg("Error publishing Pod event")
+		}
+
+		return
+	}
+
 	if eventType != watch.Added {
 		logger.Debug().Msgf("Ignoring non-Added event: %+v", eventType)
 
 		return
 	}
 
+	if c.fieldPaths != nil {
+		if _, ok := c.fieldPaths[event.InvolvedObject.FieldPath]; !ok {
+			logger.Debug().Msgf("Ignoring event of already collected container: %s", event.InvolvedObject.FieldPath)
+
+			return
+		}
+	}
+
 	switch event.Reason {
 	case "Pulling", "Pulled":
 		_, err := c.statisticEventLoop.ImagePullUpdate(context.TODO(), c.pod, event)
 		if err != nil {
 			logger.Error().Err(err).Any("event", event).Msg("Error publishing ImagePull event")
 		}
+
+		// The image pulls are also part of the critical path of the pod.
+		if c.podEventLoop != nil {
+			_, err = c.podEventLoop.PodEvent(context.TODO(), c.pod.UID, event)
+			if err != nil {
+				logger.Error().Err(err).Any("event", event).Msg("Error publishing Pod event")
+			}
+		}
 	default:
 		logger.Debug().Msgf("Ignoring non-ImagePull event: %+v", event.Reason)
 	}
 }
 
+// isPodEvent indicates if the event is a sandbox event of the pod, or an attach or mount event of its volumes when
+// the volumes are tracked, which must be sent to the pod statistic event loop.
+// Failure events which are repeated are modified by the Events API to increase their count, so modified events are
+// also sent.
+func (c *imagePullCollector) isPodEvent(eventType watch.EventType, event *corev1.Event) bool {
+	if c.podEventLoop == nil || (eventType != watch.Added && eventType != watch.Modified) {
+		return false
+	}
+
+	switch event.Reason {
+	case state.SandboxReasonCreateFailed, state.SandboxReasonChanged:
+		return true
+	case state.VolumeReasonAttached, state.VolumeReasonAttachFailed, state.VolumeReasonMountFailed:
+		return c.options.TrackVolumes
+	default:
+		return false
+	}
+}
+
 // Watch performs a Watch on the Kubernetes API for image pull events related to the pod.
 //
 // Watch implements [types.ImagePullCollector.Watch].
-func (c *imagePullCollector) Watch(clientset *kubernetes.Clientset) bool {
+func (c *imagePullCollector) Watch(ctx context.Context, clientset kubernetes.Interface) bool {
 	logger := c.Logger()
 
 	// TODO: use a ("k8s.io/client-go/tools/watch").RetryWatcher to allow fetching
@@ -152,8 +253,12 @@ func (c *imagePullCollector) Watch(clientset *kubernetes.Clientset) bool {
 	watchOpts := c.WatchOptions()
 
 	watcher, err :=
-		clientset.CoreV1().Events(c.pod.Namespace).Watch(context.TODO(), watchOpts)
+		clientset.CoreV1().Events(c.pod.Namespace).Watch(ctx, watchOpts)
 	if err != nil {
+		if ctx.Err() != nil {
+			return true
+		}
+
 		watchOptsJSON, marshalErr := json.Marshal(watchOpts)
 		if marshalErr != nil {
 			watchOptsJSON = []byte("null")
@@ -169,6 +274,10 @@ func (c *imagePullCollector) Watch(clientset *kubernetes.Clientset) bool {
 
 	for {
 		select {
+		case <-ctx.Done():
+			logger.Debug().Msg("Context canceled")
+
+			return true
 		case reason := <-c.cancelChan:
 			logger.Debug().Msgf("Received cancel event: %s", reason)
 
@@ -221,7 +330,9 @@ func (c *imagePullCollector) Cancel(reason string) {
 	// will be delivered before the pod is deleted.
 	//
 	// TODO(Izzette): surely there's a better way to do this?
-	time.Sleep(time.Second * time.Duration(c.options.ImagePullCancelDelay))
+	if delay := time.Second * time.Duration(c.options.ImagePullCancelDelay); delay > 0 {
+		<-c.clock.After(delay)
+	}
 
 	if !c.canceled.Swap(true) {
 		logger.Debug().Msgf("Canceling collector: %s", reason)
@@ -0,0 +1,40 @@
+package statistics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/BackMarket-oss/kube-transition-metrics/internal/options"
+	"github.com/BackMarket-oss/kube-transition-metrics/internal/testhelpers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestImagePullCollectorCancelWithoutWatch(t *testing.T) {
+	testhelpers.ConfigureLogging(t, &options.Options{})
+
+	pod := testhelpers.NewReadyPod("test-namespace", "web", testhelpers.Created, testhelpers.PodTransitions{})
+	pod.Status.Phase = corev1.PodPending
+	collector := newImagePullCollector(&options.Options{}, nil, nil, pod)
+
+	// The collector is canceled after it stopped watching, for example on shutdown, so nothing receives the reason.
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		collector.Cancel("pod deleted")
+		collector.Cancel("pod deleted")
+	}()
+
+	require.Eventually(t, func() bool {
+		select {
+		case <-done:
+			return true
+		default:
+			return false
+		}
+	}, time.Second, time.Millisecond, "Expected the cancellation not to block")
+	assert.Equal(t, "pod deleted", <-collector.cancelChan)
+}
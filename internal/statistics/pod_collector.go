@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"net/http"
 	"sync"
+	"sync/atomic"
 
 	"github.com/BackMarket-oss/kube-transition-metrics/internal/options"
 	"github.com/BackMarket-oss/kube-transition-metrics/internal/prommetrics"
@@ -20,6 +21,7 @@ import (
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/cache"
 	watch_tools "k8s.io/client-go/tools/watch"
+	"k8s.io/utils/clock"
 )
 
 // podCollector uses the Kubernetes Watch API to monitor for all changes on Pods
@@ -46,6 +48,50 @@ type podCollector struct {
 	// Moving the imagePullCollectors to an event loop to avoid having to handle concurrent access would simplify the code
 	// and make it easier to reason about.
 	imagePullCollectors *sync.Map
+	// imagePullCollectorsWG tracks the running imagePullCollectors, so that Run can wait for them to stop before
+	// returning.
+	imagePullCollectorsWG *sync.WaitGroup
+
+	// filteredPods is a set of the UIDs of the pods whose namespace was filtered out when they were added.
+	// Keys are [apimachinerytypes.UID] and values are empty structs.
+	// The namespace filters are only applied when a pod is added, so that reloading them neither leaves the statistics
+	// of the tracked pods incomplete, nor starts tracking pods halfway through their startup.
+	filteredPods *sync.Map
+
+	// ready indicates the initial pod sync is done and the pod Watch is live.
+	ready *atomic.Bool
+
+	// recorder records the Pod and Event watch events received by the collectors, if recording is enabled.
+	recorder *WatchRecorder
+
+	// clock is the clock used by the image pull collectors to wait for the image pull cancel delay.
+	clock clock.Clock
+}
+
+// PodCollectorOption configures the optional extensions of the pod collector.
+type PodCollectorOption func(collector *podCollector)
+
+// WithWatchRecorder records the Pod watch events received by the pod collector, and the Event watch events received by
+// its image pull collectors, with the provided recorder.
+func WithWatchRecorder(recorder *WatchRecorder) PodCollectorOption {
+	return func(collector *podCollector) {
+		collector.recorder = recorder
+	}
+}
+
+// WithCollectorClock replaces the clock used by the image pull collectors of the pod collector to wait for the image
+// pull cancel delay, which defaults to the real clock.
+func WithCollectorClock(clock clock.Clock) PodCollectorOption {
+	return func(collector *podCollector) {
+		collector.clock = clock
+	}
+}
+
+// withImagePullCollectorFactory replaces the function creating the image pull collectors of the pod collector.
+func withImagePullCollectorFactory(factory imagePullCollectorFactory) PodCollectorOption {
+	return func(collector *podCollector) {
+		collector.newImagePullCollector = factory
+	}
 }
 
 // NewPodCollector creates a new podCollector using the provided statistic event loops.
@@ -55,67 +101,137 @@ func NewPodCollector(
 	opts *options.Options,
 	statisticEventLoop types.PodStatisticEventLoop,
 	imagePullEventLoop types.ImagePullStatisticEventLoop,
+	collectorOpts ...PodCollectorOption,
 ) *podCollector {
-	return &podCollector{
-		options:            opts,
-		statisticEventLoop: statisticEventLoop,
-		imagePullEventLoop: imagePullEventLoop,
-		newImagePullCollector: func(
-			options *options.Options,
-			el types.ImagePullStatisticEventLoop,
-			pod *corev1.Pod,
-		) types.ImagePullCollector {
-			return newImagePullCollector(options, el, pod)
-		},
-		imagePullCollectors: &sync.Map{},
+	collector := &podCollector{
+		options:               opts,
+		statisticEventLoop:    statisticEventLoop,
+		imagePullEventLoop:    imagePullEventLoop,
+		imagePullCollectors:   &sync.Map{},
+		imagePullCollectorsWG: &sync.WaitGroup{},
+		filteredPods:          &sync.Map{},
+		ready:                 &atomic.Bool{},
+		clock:                 clock.RealClock{},
 	}
+	collector.newImagePullCollector = func(
+		options *options.Options,
+		el types.ImagePullStatisticEventLoop,
+		podEl types.PodStatisticEventLoop,
+		pod *corev1.Pod,
+	) types.ImagePullCollector {
+		imagePullCollector := newImagePullCollector(options, el, podEl, pod)
+		imagePullCollector.recorder = collector.recorder
+		imagePullCollector.clock = collector.clock
+
+		return imagePullCollector
+	}
+
+	for _, opt := range collectorOpts {
+		opt(collector)
+	}
+
+	return collector
+}
+
+// Ready reports whether the initial pod sync is done and the pod Watch is live.
+// Ready implements [types.PodCollector.Ready].
+func (w *podCollector) Ready() bool {
+	return w.ready.Load()
 }
 
 // Run watches the Kubernetes Pods objects and reports them to the statistic
 // event loop. It is blocking and should be run in another goroutine to the
 // statistic event loop and other collectors.
-func (w *podCollector) Run(clientset *kubernetes.Clientset) {
-	for {
-		resyncUIDs, resourceVersion, err := w.collectInitialPods(clientset)
+// Run returns once the context is canceled and all the image pull collectors it started have stopped, after which no
+// more events are sent to the statistic event loops.
+func (w *podCollector) Run(ctx context.Context, clientset kubernetes.Interface) {
+	defer w.imagePullCollectorsWG.Wait()
+
+	for ctx.Err() == nil {
+		resyncUIDs, resourceVersion, err := w.collectInitialPods(ctx, clientset)
 		if err != nil {
+			if ctx.Err() != nil {
+				break
+			}
+
 			log.Panic().Err(err).Msg(
 				"Failed to resync after 410 Gone from kubernetes Watch API")
 		}
 
-		_, err = w.statisticEventLoop.PodResync(context.TODO(), resyncUIDs)
+		w.recorder.RecordResync(resyncUIDs)
+
+		err = w.resync(ctx, resyncUIDs)
 		if err != nil {
+			if ctx.Err() != nil {
+				break
+			}
+
 			log.Panic().Err(err).Msg("Failed to publish resync pods")
 		}
 
-		resyncUIDSet := make(map[apimachinerytypes.UID]struct{}, len(resyncUIDs))
-		for _, uid := range resyncUIDs {
-			resyncUIDSet[uid] = struct{}{}
+		w.watch(ctx, clientset, resourceVersion)
+
+		if ctx.Err() != nil {
+			break
 		}
 
-		w.imagePullCollectors.Range(func(key, _ any) bool {
-			// We are the only ones using the map, so we can safely cast to apimachinerytypes.UID.
-			uid, isUID := key.(apimachinerytypes.UID)
-			if !isUID {
-				log.Panic().Any("key", key).Msgf("Non-UID key found in imagePullCollectors map")
-			}
+		log.Warn().Msg("Watch ended, restarting. Some events may be lost.")
+		prommetrics.PodCollectorRestarts.Inc()
+	}
 
-			if _, ok := resyncUIDSet[uid]; !ok {
-				// Cancel image pull collectors containers who deletion even was missed
-				w.cancelImagePullCollector(uid, "pod deleting event missed")
-			}
+	log.Info().Msg("Pod collector stopped, waiting for image pull collectors to stop ...")
+}
 
-			return true
-		})
+// resync sends the pods listed by a resync to the statistic event loop, and cancels the image pull collectors of the
+// pods which are no longer listed.
+func (w *podCollector) resync(ctx context.Context, resyncUIDs []apimachinerytypes.UID) error {
+	_, err := w.statisticEventLoop.PodResync(ctx, resyncUIDs)
+	if err != nil {
+		return fmt.Errorf("failed to publish resync pods: %w", err)
+	}
 
-		w.watch(clientset, resourceVersion)
-		log.Warn().Msg("Watch ended, restarting. Some events may be lost.")
-		prommetrics.PodCollectorRestarts.Inc()
+	resyncUIDSet := make(map[apimachinerytypes.UID]struct{}, len(resyncUIDs))
+	for _, uid := range resyncUIDs {
+		resyncUIDSet[uid] = struct{}{}
 	}
+
+	w.imagePullCollectors.Range(func(key, _ any) bool {
+		// We are the only ones using the map, so we can safely cast to apimachinerytypes.UID.
+		uid, isUID := key.(apimachinerytypes.UID)
+		if !isUID {
+			log.Panic().Any("key", key).Msgf("Non-UID key found in imagePullCollectors map")
+		}
+
+		if _, ok := resyncUIDSet[uid]; !ok {
+			// Cancel image pull collectors containers who deletion even was missed
+			w.cancelImagePullCollector(uid, "pod deleting event missed")
+		}
+
+		return true
+	})
+
+	w.filteredPods.Range(func(key, _ any) bool {
+		// We are the only ones using the map, so we can safely cast to apimachinerytypes.UID.
+		uid, isUID := key.(apimachinerytypes.UID)
+		if !isUID {
+			log.Panic().Any("key", key).Msgf("Non-UID key found in filteredPods map")
+		}
+
+		if _, ok := resyncUIDSet[uid]; !ok {
+			// The deletion event of the filtered pod was missed.
+			w.filteredPods.Delete(uid)
+		}
+
+		return true
+	})
+
+	return nil
 }
 
 // handlePod processes a Pod event and sends the appropriate statistic event to the statistic event loop.
 func (w *podCollector) handlePod(
-	clientset *kubernetes.Clientset,
+	ctx context.Context,
+	clientset kubernetes.Interface,
 	eventType watch.EventType,
 	pod *corev1.Pod,
 ) {
@@ -127,26 +243,48 @@ func (w *podCollector) handlePod(
 		Logger()
 	logger.Debug().Msg("Collecting statistics for pod")
 
+	if eventType == watch.Added && !w.options.Current().NamespaceIncluded(pod.Namespace) {
+		w.filteredPods.Store(pod.UID, struct{}{})
+	}
+
+	if _, filtered := w.filteredPods.Load(pod.UID); filtered {
+		logger.Trace().Msg("Ignoring pod in filtered namespace")
+
+		if eventType == watch.Deleted {
+			w.filteredPods.Delete(pod.UID)
+		}
+
+		return
+	}
+
 	// The watch.EventType watch.Error is already tested in the caller, as if there
 	// is an error no pod is sent.
 	//nolint:exhaustive
 	switch eventType {
 	case watch.Added:
-		w.addImagePullCollector(clientset, pod)
+		w.addImagePullCollector(ctx, clientset, pod)
 
 		fallthrough
 	case watch.Modified:
-		_, err := w.statisticEventLoop.PodUpdate(context.TODO(), pod)
+		_, err := w.statisticEventLoop.PodUpdate(ctx, pod)
 		if err != nil {
 			logger.Error().Err(err).Msg("Error publishing PodUpdate event")
 			prommetrics.PodCollectorErrors.Inc()
 		}
 
-		if pod.Status.Phase == corev1.PodRunning {
+		pending := len(pendingEphemeralContainers(pod)) > 0
+
+		switch {
+		case pod.Status.Phase == corev1.PodRunning && !pending:
 			w.cancelImagePullCollector(pod.UID, "pod already running")
+		case pending && eventType == watch.Modified:
+			// Ephemeral containers, e.g. added by kubectl debug, pull their images after the pod is already running.
+			if _, ok := w.imagePullCollectors.Load(pod.UID); !ok {
+				w.addImagePullCollector(ctx, clientset, pod)
+			}
 		}
 	case watch.Deleted:
-		_, err := w.statisticEventLoop.PodDelete(context.TODO(), pod)
+		_, err := w.statisticEventLoop.PodDelete(ctx, pod)
 		if err != nil {
 			logger.Error().Err(err).Msg("Error publishing PodDelete event")
 			prommetrics.PodCollectorErrors.Inc()
@@ -160,11 +298,13 @@ func (w *podCollector) handlePod(
 
 // addImagePullCollector adds a new image pull collector for the given pod UID.
 // If an image pull collector already exists for the given UID, it is replaced and the old one is cancelled.
+// The image pull collector stops when the context is canceled.
 func (w *podCollector) addImagePullCollector(
-	clientset *kubernetes.Clientset,
+	ctx context.Context,
+	clientset kubernetes.Interface,
 	pod *corev1.Pod,
 ) {
-	collector := w.newImagePullCollector(w.options, w.imagePullEventLoop, pod)
+	collector := w.newImagePullCollector(w.options.Current(), w.imagePullEventLoop, w.statisticEventLoop, pod)
 	// Cancel any image pull collectors before removing them from the map
 	if existing, ok := w.imagePullCollectors.Swap(pod.UID, collector); ok {
 		existingCollector, isCollector := existing.(types.ImagePullCollector)
@@ -175,11 +315,11 @@ func (w *podCollector) addImagePullCollector(
 		go existingCollector.Cancel("pod replaced")
 	}
 
-	go func() {
-		collector.Run(clientset)
+	w.imagePullCollectorsWG.Go(func() {
+		collector.Run(ctx, clientset)
 		// Delete the collector from the map if it is the same as the one that finished running
 		w.imagePullCollectors.CompareAndDelete(pod.UID, collector)
-	}()
+	})
 }
 
 // cancelImagePullCollector cancels and removes the image pull collector for the given pod UID.
@@ -198,7 +338,7 @@ func (w *podCollector) cancelImagePullCollector(uid apimachinerytypes.UID, reaso
 // It uses the provided resourceVersion to start watching from that version.
 func (w *podCollector) getWatcher(
 	ctx context.Context,
-	clientset *kubernetes.Clientset,
+	clientset kubernetes.Interface,
 	resourceVersion string,
 ) (*watch_tools.RetryWatcher, error) {
 	watcher, err := watch_tools.NewRetryWatcherWithContext(ctx, resourceVersion, &cache.ListWatch{
@@ -217,11 +357,13 @@ func (w *podCollector) getWatcher(
 }
 
 // watch performs the actual watch on the Kubernetes API for all Pod objects.
+// The collector is reported ready for as long as the watch is live.
 func (w *podCollector) watch(
-	clientset *kubernetes.Clientset,
+	ctx context.Context,
+	clientset kubernetes.Interface,
 	resourceVersion string,
 ) {
-	ctx, cancel := context.WithCancel(context.TODO())
+	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
 	watcher, err := w.getWatcher(ctx, clientset, resourceVersion)
@@ -230,6 +372,9 @@ func (w *podCollector) watch(
 	}
 	defer watcher.Stop()
 
+	w.ready.Store(true)
+	defer w.ready.Store(false)
+
 	for event := range watcher.ResultChan() {
 		var (
 			pod    *corev1.Pod
@@ -255,7 +400,8 @@ func (w *podCollector) watch(
 		} else if pod, isAPod = event.Object.(*corev1.Pod); !isAPod {
 			log.Panic().Msgf("Watch event is not a Pod: %+v", event)
 		} else {
-			w.handlePod(clientset, event.Type, pod)
+			w.recorder.RecordPod(event.Type, pod)
+			w.handlePod(ctx, clientset, event.Type, pod)
 		}
 
 		prommetrics.PodWatchEvents.With(
@@ -270,7 +416,8 @@ func (w *podCollector) watch(
 // It returns the list of Pod UIDs, the resource version for these UIDs, and an
 // error if one occurred.
 func (w *podCollector) collectInitialPods(
-	clientset *kubernetes.Clientset,
+	ctx context.Context,
+	clientset kubernetes.Interface,
 ) ([]apimachinerytypes.UID, string, error) {
 	timeOut := w.options.KubeWatchTimeout
 	listOptions := metav1.ListOptions{
@@ -294,7 +441,7 @@ func (w *podCollector) collectInitialPods(
 		var err error
 
 		list, err =
-			clientset.CoreV1().Pods("").List(context.TODO(), listOptions)
+			clientset.CoreV1().Pods("").List(ctx, listOptions)
 		if err != nil {
 			log.Error().Err(err).Msg("Error performing initial sync.")
 
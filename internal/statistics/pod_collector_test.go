@@ -0,0 +1,79 @@
+package statistics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/BackMarket-oss/kube-transition-metrics/internal/options"
+	"github.com/BackMarket-oss/kube-transition-metrics/internal/testhelpers"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	apimachinerytypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+// inNamespace returns a copy of the pod with the given UID, in the given namespace.
+func inNamespace(pod *corev1.Pod, uid apimachinerytypes.UID, namespace string) *corev1.Pod {
+	pod = pod.DeepCopy()
+	pod.UID = uid
+	pod.Namespace = namespace
+
+	return pod
+}
+
+func TestPodCollectorNamespaceFilterReload(t *testing.T) {
+	opts := &options.Options{
+		StatisticEventQueueLength: 10,
+		ImagePullCancelDelay:      3,
+		LogLevel:                  zerolog.FatalLevel,
+		ExcludeNamespaces:         []string{"excluded"},
+	}
+	testhelpers.ConfigureLogging(t, opts)
+
+	created := time.Date(2023, 8, 28, 0, 0, 0, 0, time.UTC)
+	output := testhelpers.NewMetricWriter(t)
+	clock := NewVirtualClock()
+
+	podStatisticEventLoop := NewStatisticEventLoop(opts, output, WithClock(clock))
+	podStatisticEventLoop.Start()
+
+	imagePullStatisticEventLoop := NewImagePullStatisticEventLoop(opts, output)
+	imagePullStatisticEventLoop.Start()
+
+	replayer := NewReplayer(opts, podStatisticEventLoop, imagePullStatisticEventLoop, clock)
+	replay := func(eventType watch.EventType, pod *corev1.Pod) {
+		t.Helper()
+		require.NoError(t, replayer.replay(t.Context(), &RecordedWatchEvent{Time: created, Type: eventType, Pod: pod}))
+	}
+
+	replay(WatchEventResync, nil)
+	replay(watch.Added, inNamespace(newTestingPod(created), "tracked-uid", "included"))
+	replay(watch.Added, inNamespace(newTestingPod(created), "filtered-uid", "excluded"))
+
+	// The filters are reloaded while both pods are starting.
+	opts.ExcludeNamespaces = []string{"included"}
+
+	replay(watch.Modified, inNamespace(newTestingCompletePod(created), "tracked-uid", "included"))
+	replay(watch.Modified, inNamespace(newTestingCompletePod(created), "filtered-uid", "excluded"))
+	replay(watch.Deleted, inNamespace(newTestingCompletePod(created), "filtered-uid", "excluded"))
+
+	replayer.stopAll()
+	podStatisticEventLoop.Close()
+	imagePullStatisticEventLoop.Close()
+
+	var namespaces []any
+
+	for _, metric := range testhelpers.DecodeMetricOutput(t, output) {
+		if metric["type"] == "pod" {
+			namespaces = append(namespaces, metric["kube_namespace"])
+		}
+	}
+
+	assert.Equal(t, []any{"included"}, namespaces,
+		"Expected the pods to be filtered according to the namespace filters when they were added")
+
+	_, filtered := replayer.collector.filteredPods.Load(apimachinerytypes.UID("filtered-uid"))
+	assert.False(t, filtered, "Expected the deleted pod to be removed from the filtered pods")
+}
@@ -0,0 +1,52 @@
+package statistics
+
+import (
+	"time"
+
+	"github.com/BackMarket-oss/kube-transition-metrics/internal/prommetrics"
+	"github.com/BackMarket-oss/kube-transition-metrics/internal/statistics/state"
+	"github.com/BackMarket-oss/kube-transition-metrics/internal/statistics/types"
+	"github.com/prometheus/client_golang/prometheus"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// podTransitionObserver observes the pod transition durations of complete pod statistics in a Prometheus histogram.
+//
+// The embedded histogram implements [prometheus.Collector], the observer must be registered to export the metric.
+type podTransitionObserver struct {
+	*prometheus.HistogramVec
+
+	labeler types.PrometheusLabeler
+	guard   *prommetrics.CardinalityGuard
+}
+
+// NewPodTransitionObserver creates a new podTransitionObserver, labeling the observations with the labels of the
+// provided labeler, whose cardinality is limited by the guard, which may be nil.
+//
+// The returned *podTransitionObserver implements [types.PodStatisticObserver] and [prometheus.Collector].
+func NewPodTransitionObserver(
+	labeler types.PrometheusLabeler,
+	guard *prommetrics.CardinalityGuard,
+) *podTransitionObserver {
+	return &podTransitionObserver{
+		HistogramVec: prommetrics.NewPodTransitionSeconds(labeler.PrometheusLabelNames()),
+		labeler:      labeler,
+		guard:        guard,
+	}
+}
+
+// ObservePodStatistic implements [types.PodStatisticObserver.ObservePodStatistic].
+func (o *podTransitionObserver) ObservePodStatistic(pod *corev1.Pod, statistic *state.PodStatistic) {
+	// The guard folds the values of the labels of the pod, which are the same for all its transitions.
+	labels := o.guard.Labels(o.labeler.PrometheusLabels(pod))
+
+	observe := func(transition string, from, to time.Time) {
+		labels["transition"] = transition
+		o.With(labels).Observe(to.Sub(from).Seconds())
+	}
+
+	observe("creation_to_scheduled", statistic.CreationTimestamp(), statistic.ScheduledTimestamp())
+	observe("scheduled_to_initialized", statistic.ScheduledTimestamp(), statistic.InitializedTimestamp())
+	observe("initialized_to_ready", statistic.InitializedTimestamp(), statistic.ReadyTimestamp())
+	observe("creation_to_ready", statistic.CreationTimestamp(), statistic.ReadyTimestamp())
+}
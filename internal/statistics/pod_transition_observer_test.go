@@ -0,0 +1,49 @@
+package statistics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/BackMarket-oss/kube-transition-metrics/internal/labelmapper"
+	"github.com/BackMarket-oss/kube-transition-metrics/internal/options"
+	"github.com/BackMarket-oss/kube-transition-metrics/internal/statistics/state"
+	"github.com/BackMarket-oss/kube-transition-metrics/internal/testhelpers"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPodTransitionObserver(t *testing.T) {
+	opts := &options.Options{
+		LabelMappings: []options.LabelMapping{
+			{Field: "team", Source: options.PodLabelSource, Key: "example.com/team"},
+		},
+		PrometheusLabels: []string{"team"},
+	}
+	testhelpers.ConfigureLogging(t, opts)
+
+	created := time.Now()
+	pod := newTestingCompletePod(created)
+	pod.Labels = map[string]string{"example.com/team": "test-team"}
+
+	statistic := state.NewPodStatistic(created.Add(3*time.Second), pod)
+	require.False(t, statistic.Partial(), "Expected pod statistic to not be partial")
+
+	observer := NewPodTransitionObserver(labelmapper.NewMapper(opts, nil), nil)
+	observer.ObservePodStatistic(pod, statistic)
+
+	assert.Equal(t, 4, testutil.CollectAndCount(observer), "Expected one series per transition")
+
+	histogram, err := observer.GetMetricWith(map[string]string{"transition": "creation_to_ready", "team": "test-team"})
+	require.NoError(t, err, "Expected team label to be accepted")
+
+	metric, ok := histogram.(prometheus.Metric)
+	require.True(t, ok, "Expected histogram to be a metric")
+
+	written := &dto.Metric{}
+	require.NoError(t, metric.Write(written))
+	assert.Equal(t, uint64(1), written.GetHistogram().GetSampleCount(), "Expected one creation_to_ready observation")
+	assert.InDelta(t, 3, written.GetHistogram().GetSampleSum(), 0.001, "Expected creation_to_ready to be 3 seconds")
+}
@@ -0,0 +1,103 @@
+package statistics
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	corev1 "k8s.io/api/core/v1"
+	apimachinerytypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+// WatchEventResync is the type of the recorded watch events marking a resync of the pod collector, after which the
+// pods not listed in ResyncUIDs are considered deleted.
+const WatchEventResync watch.EventType = "RESYNC"
+
+// RecordedWatchEvent is a Pod or Event watch event, or a resync of the pod collector, recorded with the time it was
+// received.
+// The watch events are recorded as newline-delimited JSON, in the order they were received.
+type RecordedWatchEvent struct {
+	// Time is the time the watch event was received.
+	Time time.Time `json:"time"`
+	// Type is the type of the watch event, or WatchEventResync.
+	Type watch.EventType `json:"type"`
+	// Pod is the object of a Pod watch event.
+	Pod *corev1.Pod `json:"pod,omitempty"`
+	// Event is the object of an Event watch event.
+	Event *corev1.Event `json:"event,omitempty"`
+	// ResyncUIDs are the UIDs of the pods listed by a resync.
+	ResyncUIDs []apimachinerytypes.UID `json:"resyncUIDs,omitempty"`
+}
+
+// WatchRecorder records the Pod and Event watch events received by the collectors, which can be replayed offline by a
+// [Replayer].
+// The methods of a nil *WatchRecorder do nothing, so that the collectors do not need to check if recording is enabled.
+type WatchRecorder struct {
+	mu      sync.Mutex
+	encoder *json.Encoder
+}
+
+// NewWatchRecorder creates a new WatchRecorder writing the recorded watch events to output.
+func NewWatchRecorder(output io.Writer) *WatchRecorder {
+	return &WatchRecorder{encoder: json.NewEncoder(output)}
+}
+
+// RecordPod records a Pod watch event.
+func (r *WatchRecorder) RecordPod(eventType watch.EventType, pod *corev1.Pod) {
+	r.record(&RecordedWatchEvent{Type: eventType, Pod: pod})
+}
+
+// RecordEvent records an Event watch event.
+func (r *WatchRecorder) RecordEvent(eventType watch.EventType, event *corev1.Event) {
+	r.record(&RecordedWatchEvent{Type: eventType, Event: event})
+}
+
+// RecordResync records a resync of the pod collector, listing the UIDs of the existing pods.
+func (r *WatchRecorder) RecordResync(uids []apimachinerytypes.UID) {
+	r.record(&RecordedWatchEvent{Type: WatchEventResync, ResyncUIDs: uids})
+}
+
+// record timestamps and writes the recorded watch event.
+func (r *WatchRecorder) record(event *RecordedWatchEvent) {
+	if r == nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// The watch events are timestamped under the lock, so that they are recorded in chronological order.
+	event.Time = time.Now()
+	if err := r.encoder.Encode(event); err != nil {
+		log.Error().Err(err).Str("event_type", string(event.Type)).Msg("Error recording watch event")
+	}
+}
+
+// DecodeRecordedWatchEvents returns the recorded watch events read from input, as written by a [WatchRecorder].
+// The iteration stops after the first decoding error.
+func DecodeRecordedWatchEvents(input io.Reader) iter.Seq2[*RecordedWatchEvent, error] {
+	return func(yield func(*RecordedWatchEvent, error) bool) {
+		decoder := json.NewDecoder(input)
+
+		for {
+			recorded := &RecordedWatchEvent{}
+			if err := decoder.Decode(recorded); errors.Is(err, io.EOF) {
+				return
+			} else if err != nil {
+				yield(nil, fmt.Errorf("failed to decode recorded watch event: %w", err))
+
+				return
+			}
+
+			if !yield(recorded, nil) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,259 @@
+package statistics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"iter"
+	"sync/atomic"
+	"time"
+
+	"github.com/BackMarket-oss/kube-transition-metrics/internal/options"
+	"github.com/BackMarket-oss/kube-transition-metrics/internal/statistics/types"
+	corev1 "k8s.io/api/core/v1"
+	apimachinerytypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes"
+)
+
+// errInvalidRecordedWatchEvent is returned when a recorded watch event has neither a Pod, an Event nor a resync.
+var errInvalidRecordedWatchEvent = errors.New("recorded watch event has no object")
+
+// VirtualClock is a clock which only advances when it is set, used to timestamp the transitions of the pods with the
+// time the watch events were recorded when replaying them.
+//
+// The returned *VirtualClock implements [k8s.io/utils/clock.PassiveClock].
+type VirtualClock struct {
+	now atomic.Pointer[time.Time]
+}
+
+// NewVirtualClock creates a new VirtualClock set to the zero time.
+func NewVirtualClock() *VirtualClock {
+	return &VirtualClock{}
+}
+
+// Now returns the current time of the clock.
+// Now implements [k8s.io/utils/clock.PassiveClock.Now].
+func (c *VirtualClock) Now() time.Time {
+	if now := c.now.Load(); now != nil {
+		return *now
+	}
+
+	return time.Time{}
+}
+
+// Since returns the time elapsed since t according to the clock.
+// Since implements [k8s.io/utils/clock.PassiveClock.Since].
+func (c *VirtualClock) Since(t time.Time) time.Duration {
+	return c.Now().Sub(t)
+}
+
+// SetTime sets the current time of the clock.
+func (c *VirtualClock) SetTime(now time.Time) {
+	c.now.Store(&now)
+}
+
+// Replayer replays recorded Pod and Event watch events through the pod collector and the image pull collectors, to
+// compute the same statistics offline as the controller did from the live watch events.
+type Replayer struct {
+	options   *options.Options
+	clock     *VirtualClock
+	collector *podCollector
+
+	// imagePullCollectors are the image pull collectors still receiving the Events of each pod, including the canceled
+	// collectors waiting for the image pull cancel delay to elapse.
+	// Only the goroutine running Replay accesses it, as the collectors are created synchronously by the pod collector.
+	imagePullCollectors map[apimachinerytypes.UID][]*replayImagePullCollector
+}
+
+// NewReplayer creates a new Replayer sending the statistic events to the provided event loops.
+// The pod statistic event loop should use the clock, see [WithClock], which is advanced to the time of each recorded
+// watch event before it is replayed.
+func NewReplayer(
+	opts *options.Options,
+	statisticEventLoop types.PodStatisticEventLoop,
+	imagePullEventLoop types.ImagePullStatisticEventLoop,
+	clock *VirtualClock,
+) *Replayer {
+	replayer := &Replayer{
+		options:             opts,
+		clock:               clock,
+		imagePullCollectors: make(map[apimachinerytypes.UID][]*replayImagePullCollector),
+	}
+	replayer.collector = NewPodCollector(
+		opts,
+		statisticEventLoop,
+		imagePullEventLoop,
+		withImagePullCollectorFactory(replayer.newImagePullCollector),
+	)
+
+	return replayer
+}
+
+// Replay replays the recorded watch events in order, e.g. decoded with [DecodeRecordedWatchEvents], stopping at the
+// first error.
+// Replay returns once all the recorded watch events are replayed and all the image pull collectors have stopped, after
+// which no more events are sent to the statistic event loops.
+func (r *Replayer) Replay(ctx context.Context, events iter.Seq2[*RecordedWatchEvent, error]) error {
+	defer r.stopAll()
+
+	for recorded, err := range events {
+		if err != nil {
+			return err
+		}
+
+		if err := ctx.Err(); err != nil {
+			return fmt.Errorf("replay interrupted: %w", err)
+		}
+
+		if err := r.replay(ctx, recorded); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// replay replays a single recorded watch event at the time it was recorded.
+func (r *Replayer) replay(ctx context.Context, recorded *RecordedWatchEvent) error {
+	// The collectors canceled before the watch event was received have stopped by now.
+	r.stopCanceled(recorded.Time)
+	r.clock.SetTime(recorded.Time)
+
+	switch {
+	case recorded.Type == WatchEventResync:
+		if err := r.collector.resync(ctx, recorded.ResyncUIDs); err != nil {
+			return err
+		}
+
+		for uid := range r.imagePullCollectors {
+			r.markCanceled(uid)
+		}
+	case recorded.Pod != nil:
+		r.collector.handlePod(ctx, nil, recorded.Type, recorded.Pod)
+		r.markCanceled(recorded.Pod.UID)
+	case recorded.Event != nil:
+		watchEvent := watch.Event{Type: recorded.Type, Object: recorded.Event}
+		for _, collector := range r.imagePullCollectors[recorded.Event.InvolvedObject.UID] {
+			collector.HandleWatchEvent(watchEvent)
+		}
+	default:
+		return fmt.Errorf("%w: %s at %s", errInvalidRecordedWatchEvent, recorded.Type, recorded.Time)
+	}
+
+	return nil
+}
+
+// newImagePullCollector creates a new image pull collector which receives the recorded Events of the pod instead of
+// watching them.
+// It implements imagePullCollectorFactory.
+func (r *Replayer) newImagePullCollector(
+	opts *options.Options,
+	statisticEventLoop types.ImagePullStatisticEventLoop,
+	podEventLoop types.PodStatisticEventLoop,
+	pod *corev1.Pod,
+) types.ImagePullCollector {
+	collector := &replayImagePullCollector{
+		imagePullCollector: newImagePullCollector(opts, statisticEventLoop, podEventLoop, pod),
+		stopChan:           make(chan struct{}),
+		done:               make(chan struct{}),
+	}
+	r.imagePullCollectors[pod.UID] = append(r.imagePullCollectors[pod.UID], collector)
+
+	return collector
+}
+
+// markCanceled records the time the image pull collectors of the pod were canceled by the pod collector, i.e. removed
+// from its image pull collectors.
+// The pod collector cancels the collectors in another goroutine, so they are looked up instead to be deterministic.
+func (r *Replayer) markCanceled(uid apimachinerytypes.UID) {
+	current, _ := r.collector.imagePullCollectors.Load(uid)
+
+	for _, collector := range r.imagePullCollectors[uid] {
+		if collector.canceledAt.IsZero() && current != collector {
+			collector.canceledAt = r.clock.Now()
+		}
+	}
+}
+
+// stopCanceled stops the image pull collectors canceled before the image pull cancel delay preceding now.
+func (r *Replayer) stopCanceled(now time.Time) {
+	delay := time.Duration(r.options.ImagePullCancelDelay * float64(time.Second))
+
+	for uid, collectors := range r.imagePullCollectors {
+		running := collectors[:0]
+
+		for _, collector := range collectors {
+			if !collector.canceledAt.IsZero() && !collector.canceledAt.Add(delay).After(now) {
+				collector.stop()
+			} else {
+				running = append(running, collector)
+			}
+		}
+
+		if len(running) == 0 {
+			delete(r.imagePullCollectors, uid)
+		} else {
+			r.imagePullCollectors[uid] = running
+		}
+	}
+}
+
+// stopAll stops all the image pull collectors and waits for them to clean up their image pull statistics.
+func (r *Replayer) stopAll() {
+	for uid, collectors := range r.imagePullCollectors {
+		for _, collector := range collectors {
+			collector.stop()
+		}
+
+		delete(r.imagePullCollectors, uid)
+	}
+
+	r.collector.imagePullCollectorsWG.Wait()
+}
+
+// replayImagePullCollector is an image pull collector which receives the recorded Events of the pod from the
+// [Replayer] instead of watching them, and which is stopped by the Replayer once the image pull cancel delay has
+// elapsed in the virtual time of the replay.
+type replayImagePullCollector struct {
+	*imagePullCollector
+
+	// canceledAt is the virtual time the collector was canceled by the pod collector, or the zero time.
+	canceledAt time.Time
+	// stopChan is closed by the Replayer to stop the collector.
+	stopChan chan struct{}
+	// done is closed once the collector has stopped and cleaned up its image pull statistic.
+	done chan struct{}
+}
+
+// Run waits for the collector to be stopped by the Replayer or for the context to be canceled, and cleans up the
+// image pull statistic of the pod.
+//
+// Run implements [types.ImagePullCollector.Run].
+func (c *replayImagePullCollector) Run(ctx context.Context, _ kubernetes.Interface) {
+	defer close(c.done)
+
+	select {
+	case <-ctx.Done():
+	case <-c.stopChan:
+	}
+
+	_, err := c.statisticEventLoop.ImagePullDelete(context.WithoutCancel(ctx), c.pod)
+	if err != nil {
+		c.Logger().Error().Err(err).Msg("Error cleaning up image pull statistic")
+	}
+}
+
+// Cancel only logs the cancellation, as the Replayer stops the collector once the image pull cancel delay has elapsed
+// in the virtual time of the replay.
+//
+// Cancel implements [types.ImagePullCollector.Cancel].
+func (c *replayImagePullCollector) Cancel(reason string) {
+	c.Logger().Debug().Msgf("Canceling collector: %s", reason)
+}
+
+// stop stops the collector and waits for it to clean up its image pull statistic.
+func (c *replayImagePullCollector) stop() {
+	close(c.stopChan)
+	<-c.done
+}
@@ -0,0 +1,147 @@
+package statistics
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/BackMarket-oss/kube-transition-metrics/internal/options"
+	"github.com/BackMarket-oss/kube-transition-metrics/internal/testhelpers"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	apimachinerytypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+// newTestingImagePullEvent creates a Pulling or Pulled Event of the test container of the testing pod.
+func newTestingImagePullEvent(reason string, timestamp time.Time) *corev1.Event {
+	return &corev1.Event{
+		ObjectMeta: metav1.ObjectMeta{UID: apimachinerytypes.UID("event-" + reason)},
+		InvolvedObject: corev1.ObjectReference{
+			UID:       "test-uid",
+			FieldPath: "spec.containers{test-container}",
+		},
+		Reason:        reason,
+		LastTimestamp: metav1.NewTime(timestamp),
+	}
+}
+
+func TestWatchRecorder(t *testing.T) {
+	var recorder *WatchRecorder
+
+	assert.NotPanics(t, func() {
+		recorder.RecordResync(nil)
+	}, "Expected a nil recorder to do nothing")
+
+	output := &bytes.Buffer{}
+	recorder = NewWatchRecorder(output)
+
+	pod := newTestingPod(time.Now())
+	recorder.RecordResync([]apimachinerytypes.UID{"other-uid"})
+	recorder.RecordPod(watch.Added, pod)
+	recorder.RecordEvent(watch.Added, newTestingImagePullEvent("Pulling", time.Now()))
+
+	decoder := json.NewDecoder(output)
+
+	var recorded []RecordedWatchEvent
+
+	for decoder.More() {
+		var event RecordedWatchEvent
+		require.NoError(t, decoder.Decode(&event), "Expected recorded watch events to be valid JSON")
+
+		recorded = append(recorded, event)
+	}
+
+	require.Len(t, recorded, 3)
+	assert.Equal(t, WatchEventResync, recorded[0].Type)
+	assert.Equal(t, []apimachinerytypes.UID{"other-uid"}, recorded[0].ResyncUIDs)
+	assert.Equal(t, watch.Added, recorded[1].Type)
+	require.NotNil(t, recorded[1].Pod)
+	assert.Equal(t, pod.UID, recorded[1].Pod.UID)
+	require.NotNil(t, recorded[2].Event)
+	assert.Equal(t, "Pulling", recorded[2].Event.Reason)
+	assert.False(t, recorded[2].Time.Before(recorded[0].Time), "Expected watch events to be recorded in order")
+}
+
+func TestReplay(t *testing.T) {
+	opts := &options.Options{
+		StatisticEventQueueLength: 10,
+		ImagePullCancelDelay:      3,
+		LogLevel:                  zerolog.FatalLevel,
+	}
+	testhelpers.ConfigureLogging(t, opts)
+
+	created := time.Date(2023, 8, 28, 0, 0, 0, 0, time.UTC)
+	at := func(seconds int) time.Time {
+		return created.Add(time.Duration(seconds) * time.Second)
+	}
+
+	input := &bytes.Buffer{}
+	encoder := json.NewEncoder(input)
+
+	for _, event := range []RecordedWatchEvent{
+		{Time: at(0), Type: WatchEventResync},
+		{Time: at(0), Type: watch.Added, Pod: newTestingPod(created)},
+		{Time: at(1), Type: watch.Added, Event: newTestingImagePullEvent("Pulling", at(1))},
+		{Time: at(2), Type: watch.Added, Event: newTestingImagePullEvent("Pulled", at(2))},
+		{Time: at(4), Type: watch.Modified, Pod: newTestingCompletePod(created)},
+	} {
+		require.NoError(t, encoder.Encode(event))
+	}
+
+	podOutput := testhelpers.NewMetricWriter(t)
+	imagePullOutput := testhelpers.NewMetricWriter(t)
+	clock := NewVirtualClock()
+
+	podStatisticEventLoop := NewStatisticEventLoop(opts, podOutput, WithClock(clock))
+	podStatisticEventLoop.Start()
+
+	imagePullStatisticEventLoop := NewImagePullStatisticEventLoop(opts, imagePullOutput)
+	imagePullStatisticEventLoop.Start()
+
+	replayer := NewReplayer(opts, podStatisticEventLoop, imagePullStatisticEventLoop, clock)
+	err := replayer.Replay(t.Context(), DecodeRecordedWatchEvents(input))
+	require.NoError(t, err, "Expected the recorded watch events to be replayed")
+
+	podStatisticEventLoop.Close()
+	imagePullStatisticEventLoop.Close()
+
+	assert.Equal(t, at(4), clock.Now(), "Expected the clock to be set to the time of the last watch event")
+	assert.Empty(t, replayer.imagePullCollectors, "Expected all the image pull collectors to be stopped")
+
+	var container map[string]any
+
+	for _, metric := range testhelpers.DecodeMetricOutput(t, podOutput) {
+		if metric["type"] == "container" {
+			container, _ = metric["container"].(map[string]any)
+		}
+	}
+
+	require.NotNil(t, container, "Expected a container metric")
+	// The container was observed running when the pod was modified, at the virtual time of the watch event.
+	assert.Equal(t, "observed", container["running_timestamp_source"])
+	assert.InDelta(t, 2.0, container["pulled_to_running_seconds"], 0.001)
+
+	imagePulls := testhelpers.DecodeMetricOutput(t, imagePullOutput)
+	require.Len(t, imagePulls, 1, "Expected an image pull metric")
+	assert.Equal(t, "image_pull", imagePulls[0]["type"])
+}
+
+func TestReplayInvalidWatchEvent(t *testing.T) {
+	opts := &options.Options{StatisticEventQueueLength: 1, LogLevel: zerolog.FatalLevel}
+	testhelpers.ConfigureLogging(t, opts)
+
+	clock := NewVirtualClock()
+	replayer := NewReplayer(opts, nil, nil, clock)
+
+	err := replayer.Replay(t.Context(), DecodeRecordedWatchEvents(
+		bytes.NewBufferString(`{"time":"2023-08-28T00:00:00Z","type":"ADDED"}`)))
+	require.ErrorIs(t, err, errInvalidRecordedWatchEvent)
+
+	err = replayer.Replay(t.Context(), DecodeRecordedWatchEvents(bytes.NewBufferString(`{"time":`)))
+	require.Error(t, err, "Expected truncated recorded watch events to be rejected")
+}
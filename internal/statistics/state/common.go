@@ -26,9 +26,18 @@ var appLabelFields = map[string]string{
 	"kube_app_version":    "app.kubernetes.io/version",
 }
 
-// commonPodLabels returns a function that adds common pod labels to the event.
+// PodLabeler adds additional labels of a pod to the metric records, such as custom labels mapped from the pod or
+// namespace metadata.
+type PodLabeler interface {
+	// PodLabels returns a function that adds the labels of the pod to the event.
+	// This can be used with [zerolog.Event.Func] to add labels to the event.
+	PodLabels(pod *corev1.Pod) func(event *zerolog.Event)
+}
+
+// commonPodLabels returns a function that adds common pod labels to the event, followed by the labels of the provided
+// labelers.
 // This can be used with [zerolog.Event.Func] to add labels to the event.
-func commonPodLabels(pod *corev1.Pod) func(event *zerolog.Event) {
+func commonPodLabels(pod *corev1.Pod, labelers []PodLabeler) func(event *zerolog.Event) {
 	return func(event *zerolog.Event) {
 		event.Str("kube_namespace", pod.Namespace)
 		event.Str("pod_name", pod.Name)
@@ -51,8 +60,11 @@ func commonPodLabels(pod *corev1.Pod) func(event *zerolog.Event) {
 
 		event.Func(ownerRefLabels(pod.OwnerReferences))
 		event.Func(appLabels(pod.Labels))
-		// TODO: find Service pointing to this pod and add kube_service label.
-		// TODO: add custom labels from commandline flag.
+		// Additional labels, such as kube_service from
+		// [github.com/BackMarket-oss/kube-transition-metrics/internal/services], are added by the labelers.
+		for _, labeler := range labelers {
+			event.Func(labeler.PodLabels(pod))
+		}
 	}
 }
 
@@ -106,7 +118,8 @@ func ownerRefLabels(ownerRefs []metav1.OwnerReference) func(event *zerolog.Event
 		event.Str("kube_ownerref_kind", strings.ToLower(ownerRef.Kind))
 		event.Str("kube_ownerref_name", ownerRef.Name)
 
-		// TODO: recursively resolve cronjob and deployment as well.
+		// Indirect owners, such as the Deployment of a ReplicaSet, are added by the owner resolver in
+		// [github.com/BackMarket-oss/kube-transition-metrics/internal/owners].
 		switch ownerRef.Kind {
 		case "DaemonSet":
 			event.Str("kube_daemon_set", ownerRef.Name)
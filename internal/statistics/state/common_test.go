@@ -66,7 +66,7 @@ func TestCommonPodLabelsAll(t *testing.T) {
 	}
 
 	// Get the logger function
-	commonLabels := commonPodLabels(pod)
+	commonLabels := commonPodLabels(pod, nil)
 
 	// Create a zerolog event
 	logger, buf := testLogger(t)
@@ -133,7 +133,7 @@ func (st *commonNodeNameTest) Test(t *testing.T) {
 		},
 	}
 
-	commonLabels := commonPodLabels(pod)
+	commonLabels := commonPodLabels(pod, nil)
 
 	logger, buf := testLogger(t)
 	logger.Info().Func(commonLabels).Msg("Test message")
@@ -186,7 +186,7 @@ func (st *commonQOSTest) Test(t *testing.T) {
 		},
 	}
 
-	commonLabels := commonPodLabels(pod)
+	commonLabels := commonPodLabels(pod, nil)
 
 	logger, buf := testLogger(t)
 	logger.Info().Func(commonLabels).Msg("Test message")
@@ -239,7 +239,7 @@ func (st *commonPriorityClassTest) Test(t *testing.T) {
 		},
 	}
 
-	commonLabels := commonPodLabels(pod)
+	commonLabels := commonPodLabels(pod, nil)
 
 	logger, buf := testLogger(t)
 	logger.Info().Func(commonLabels).Msg("Test message")
@@ -297,7 +297,7 @@ func (st *commonRuntimeClassTest) Test(t *testing.T) {
 		},
 	}
 
-	commonLabels := commonPodLabels(pod)
+	commonLabels := commonPodLabels(pod, nil)
 
 	logger, buf := testLogger(t)
 	logger.Info().Func(commonLabels).Msg("Test message")
@@ -2,6 +2,7 @@ package state
 
 import (
 	"io"
+	"iter"
 	"time"
 
 	"github.com/Izzette/go-safeconcurrency/eventloop/snapshot"
@@ -24,6 +25,32 @@ type ContainerStatistic struct {
 
 	// readyTimestamp for when the container first turned Ready (readinessProbe passed).
 	readyTimestamp time.Time
+
+	// runningSkew and readySkew are the delays between the timestamps reported by the kubelet and the time the
+	// controller observed them, or zero if the timestamps were only observed by the controller.
+	// The startedTimestamp is never reported by the kubelet.
+	runningSkew time.Duration
+	readySkew   time.Duration
+	// runningAuthoritative and readyAuthoritative are true if the timestamps were reported by the kubelet.
+	runningAuthoritative bool
+	readyAuthoritative   bool
+}
+
+// TimestampSkews returns an iterator for each timestamp field of the container reported by the kubelet.
+func (cs *ContainerStatistic) TimestampSkews() iter.Seq2[string, time.Duration] {
+	return cs.EachTimestampSkew
+}
+
+// EachTimestampSkew is an [iter.Seq2] of the timestamp fields of the container reported by the kubelet (string), and
+// the delay until the controller observed them ([time.Duration]).
+func (cs *ContainerStatistic) EachTimestampSkew(yield func(string, time.Duration) bool) {
+	if cs.runningAuthoritative && !yield("running_timestamp", cs.runningSkew) {
+		return
+	}
+
+	if cs.readyAuthoritative {
+		yield("ready_timestamp", cs.readySkew)
+	}
 }
 
 // Copy implements [github.com/Izzette/go-safeconcurrency/types.Copyable.Copy].
@@ -49,10 +76,12 @@ func (cs *ContainerStatistic) logger(logger zerolog.Logger) zerolog.Logger {
 func (cs *ContainerStatistic) event(event *zerolog.Event) {
 	if !cs.runningTimestamp.IsZero() {
 		event.Time("running_timestamp", cs.runningTimestamp)
+		event.Str("running_timestamp_source", timestampSource(cs.runningAuthoritative))
 	}
 
 	if !cs.startedTimestamp.IsZero() {
 		event.Time("started_timestamp", cs.startedTimestamp)
+		event.Str("started_timestamp_source", timestampSource(false))
 
 		if !cs.runningTimestamp.IsZero() {
 			event.Dur("running_to_started_seconds", cs.startedTimestamp.Sub(cs.runningTimestamp))
@@ -61,6 +90,7 @@ func (cs *ContainerStatistic) event(event *zerolog.Event) {
 
 	if !cs.readyTimestamp.IsZero() {
 		event.Time("ready_timestamp", cs.readyTimestamp)
+		event.Str("ready_timestamp_source", timestampSource(cs.readyAuthoritative))
 
 		// As init containers do not supported startup, liveliness, or readiness probes the Started container status field is
 		// not set for init containers.
@@ -74,7 +104,7 @@ func (cs *ContainerStatistic) event(event *zerolog.Event) {
 		}
 
 		if !cs.startedTimestamp.IsZero() {
-			event.Dur("started_to_ready_seconds", cs.readyTimestamp.Sub(cs.runningTimestamp))
+			event.Dur("started_to_ready_seconds", cs.readyTimestamp.Sub(cs.startedTimestamp))
 		}
 	}
 }
@@ -107,6 +137,23 @@ func (cs *ContainerStatistic) logContainerStatus(pod *PodStatistic, status corev
 	}
 }
 
+// imagePullEvent appends the image pull statistics of the container to the event.
+// The image pull events are watched separately from the pod, so they may be received after the container record is
+// reported, in which case the fields are omitted.
+func (cs *ContainerStatistic) imagePullEvent(event *zerolog.Event, pod *PodStatistic) {
+	imagePull, ok := pod.imagePulls.Get(cs.name)
+	if !ok || imagePull.Partial() {
+		return
+	}
+
+	event.Bool("already_present", imagePull.alreadyPresent)
+	event.Dur("image_pull_duration_seconds", imagePull.Duration())
+
+	if !cs.runningTimestamp.IsZero() {
+		event.Dur("pulled_to_running_seconds", cs.runningTimestamp.Sub(imagePull.finishedTimestamp))
+	}
+}
+
 // update updates the containers statistic based on the latest Kubernetes container status.
 // update returns a new instance of the container statistic with the updated fields.
 func (cs *ContainerStatistic) update(
@@ -121,8 +168,20 @@ func (cs *ContainerStatistic) update(
 
 	cs.logContainerStatus(pod, status)
 
-	if cs.runningTimestamp.IsZero() && status.State.Running != nil {
-		cs.runningTimestamp = now
+	if cs.runningTimestamp.IsZero() {
+		switch {
+		case status.State.Running != nil && !status.State.Running.StartedAt.IsZero():
+			cs.runningTimestamp = status.State.Running.StartedAt.Time
+			cs.runningSkew = now.Sub(cs.runningTimestamp)
+			cs.runningAuthoritative = true
+		case status.State.Running != nil:
+			cs.runningTimestamp = now
+		case status.State.Terminated != nil && !status.State.Terminated.StartedAt.IsZero():
+			// Init containers which complete quickly may never be observed Running.
+			cs.runningTimestamp = status.State.Terminated.StartedAt.Time
+			cs.runningSkew = now.Sub(cs.runningTimestamp)
+			cs.runningAuthoritative = true
+		}
 	}
 
 	if cs.startedTimestamp.IsZero() && status.Started != nil && *status.Started {
@@ -130,15 +189,104 @@ func (cs *ContainerStatistic) update(
 	}
 
 	if cs.readyTimestamp.IsZero() && status.Ready {
-		cs.readyTimestamp = now
+		switch terminated := status.State.Terminated; {
+		case terminated != nil && !terminated.FinishedAt.IsZero():
+			// Only init containers are Ready once Terminated, when they exited successfully.
+			cs.readyTimestamp = terminated.FinishedAt.Time
+			cs.readySkew = now.Sub(cs.readyTimestamp)
+			cs.readyAuthoritative = true
+		case !pod.containersReadyTimestamp.IsZero():
+			// The containers of the pod were all Ready when the container was first observed Ready, the ContainersReady
+			// condition transitioned when the last of them became Ready.
+			cs.readyTimestamp = pod.containersReadyTimestamp
+			cs.readySkew = now.Sub(cs.readyTimestamp)
+			cs.readyAuthoritative = true
+		default:
+			cs.readyTimestamp = now
+		}
 	}
 
+	cs.clampTimestamps()
+
 	return cs
 }
 
+// clampTimestamps orders the running, started and ready timestamps of the container.
+// The kubelet reports the timestamps truncated to the second and from the clock of the node, while the started
+// timestamp and the timestamps which are not reported by the kubelet are the time the controller observed the change,
+// so the durations between timestamps of different sources could otherwise be negative.
+// The started timestamp is only ever observed, so it is clamped between the running and ready timestamps.
+func (cs *ContainerStatistic) clampTimestamps() {
+	if !cs.readyTimestamp.IsZero() && cs.readyTimestamp.Before(cs.runningTimestamp) {
+		cs.readyTimestamp = cs.runningTimestamp
+	}
+
+	if cs.startedTimestamp.IsZero() {
+		return
+	}
+
+	if cs.startedTimestamp.Before(cs.runningTimestamp) {
+		cs.startedTimestamp = cs.runningTimestamp
+	}
+
+	if !cs.readyTimestamp.IsZero() && cs.readyTimestamp.Before(cs.startedTimestamp) {
+		cs.startedTimestamp = cs.readyTimestamp
+	}
+}
+
+// timestampSource returns the source of a timestamp: authoritative if reported by the kubelet or the conditions of the
+// pod, or observed if it is the time the controller observed the change.
+func timestampSource(authoritative bool) string {
+	if authoritative {
+		return "authoritative"
+	}
+
+	return "observed"
+}
+
 // InitContainerStatistic holds the transition statistics for an init container in a pod.
 type InitContainerStatistic struct {
 	*ContainerStatistic
+
+	// sidecar is true for restartable init containers (restartPolicy: Always), which keep running alongside the
+	// containers of the pod instead of running to completion.
+	sidecar bool
+}
+
+// newInitContainerStatistic creates a new InitContainerStatistic for the init container of the pod spec.
+func newInitContainerStatistic(container corev1.Container) *InitContainerStatistic {
+	return &InitContainerStatistic{
+		ContainerStatistic: &ContainerStatistic{name: container.Name},
+		sidecar: container.RestartPolicy != nil &&
+			*container.RestartPolicy == corev1.ContainerRestartPolicyAlways,
+	}
+}
+
+// Sidecar indicates if the init container is a sidecar, i.e. a restartable init container.
+func (cs *InitContainerStatistic) Sidecar() bool {
+	return cs.sidecar
+}
+
+// Partial indicates if the init container statistic does not contain all the metrics for a complete init container
+// lifecycle.
+// Sidecars are complete once Ready (readinessProbe success), like non-init containers, while other init containers are
+// complete once Ready (exited successfully), as their Started status may never be observed.
+func (cs *InitContainerStatistic) Partial() bool {
+	if cs.sidecar {
+		return cs.ContainerStatistic.Partial()
+	}
+
+	return cs.runningTimestamp.IsZero() || cs.readyTimestamp.IsZero()
+}
+
+// completedTimestamp returns the timestamp for when the following init container was allowed to start: when a sidecar
+// started, or when another init container exited successfully.
+func (cs *InitContainerStatistic) completedTimestamp() time.Time {
+	if cs.sidecar {
+		return cs.startedTimestamp
+	}
+
+	return cs.readyTimestamp
 }
 
 // Report reports the container statistic to the output writer.
@@ -147,6 +295,7 @@ func (cs *InitContainerStatistic) Report(
 	pod *corev1.Pod,
 	podStatistic *PodStatistic,
 	previous *InitContainerStatistic,
+	labelers ...PodLabeler,
 ) {
 	logger := cs.logger(podStatistic.logger())
 
@@ -157,9 +306,9 @@ func (cs *InitContainerStatistic) Report(
 
 	metrics := zerolog.Dict().
 		Bool("partial", cs.Partial()).
-		Func(commonPodLabels(pod)).
+		Func(commonPodLabels(pod, labelers)).
 		Func(commonContainerLabels(&logger, container)).
-		Dict("container", cs.event(previous))
+		Dict("container", cs.event(podStatistic, previous))
 
 	logMetrics(output, "container", metrics, "")
 }
@@ -181,15 +330,17 @@ func (cs *InitContainerStatistic) Update(
 }
 
 // event returns the event dictionary for the init container statistic.
-func (cs *InitContainerStatistic) event(previous *InitContainerStatistic) *zerolog.Event {
+func (cs *InitContainerStatistic) event(pod *PodStatistic, previous *InitContainerStatistic) *zerolog.Event {
 	event := zerolog.Dict()
 	event.Bool("init_container", true)
+	event.Bool("sidecar", cs.sidecar)
 
-	if !cs.runningTimestamp.IsZero() && previous != nil && !previous.readyTimestamp.IsZero() {
-		event.Dur("previous_to_running_seconds", cs.runningTimestamp.Sub(previous.readyTimestamp))
+	if !cs.runningTimestamp.IsZero() && previous != nil && !previous.completedTimestamp().IsZero() {
+		event.Dur("previous_to_running_seconds", cs.runningTimestamp.Sub(previous.completedTimestamp()))
 	}
 
 	cs.ContainerStatistic.event(event)
+	cs.imagePullEvent(event, pod)
 
 	return event
 }
@@ -200,7 +351,12 @@ type NonInitContainerStatistic struct {
 }
 
 // Report reports the container statistic to the output writer.
-func (cs *NonInitContainerStatistic) Report(output io.Writer, pod *corev1.Pod, podStatistic *PodStatistic) {
+func (cs *NonInitContainerStatistic) Report(
+	output io.Writer,
+	pod *corev1.Pod,
+	podStatistic *PodStatistic,
+	labelers ...PodLabeler,
+) {
 	logger := cs.logger(podStatistic.logger())
 
 	container := findContainer(cs.name, pod.Spec.Containers)
@@ -210,7 +366,7 @@ func (cs *NonInitContainerStatistic) Report(output io.Writer, pod *corev1.Pod, p
 
 	metrics := zerolog.Dict().
 		Bool("partial", cs.Partial()).
-		Func(commonPodLabels(pod)).
+		Func(commonPodLabels(pod, labelers)).
 		Func(commonContainerLabels(&logger, container)).
 		Dict("container", cs.event(podStatistic))
 
@@ -243,6 +399,7 @@ func (cs *NonInitContainerStatistic) event(pod *PodStatistic) *zerolog.Event {
 	}
 
 	cs.ContainerStatistic.event(event)
+	cs.imagePullEvent(event, pod)
 
 	return event
 }
@@ -0,0 +1,217 @@
+package state
+
+import (
+	"slices"
+	"time"
+
+	"github.com/rs/zerolog"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// The phases of the critical path of a pod, from its creation to it first turning Ready, in order of precedence: when
+// phases overlap, the time is attributed to the first one.
+// The time which is not covered by any phase, e.g. creating and starting the containers, is attributed to phaseOther.
+const (
+	phaseScheduling     = "scheduling"
+	phaseSandbox        = "sandbox"
+	phaseImagePull      = "image_pull"
+	phaseInitContainers = "init_containers"
+	phaseStartupProbe   = "startup_probe"
+	phaseReadinessProbe = "readiness_probe"
+	phaseReadinessGates = "readiness_gates"
+	phaseOther          = "other"
+)
+
+// criticalPathPhases are the phases of the critical path, in order of precedence.
+var criticalPathPhases = []string{
+	phaseScheduling,
+	phaseSandbox,
+	phaseImagePull,
+	phaseInitContainers,
+	phaseStartupProbe,
+	phaseReadinessProbe,
+	phaseReadinessGates,
+	phaseOther,
+}
+
+// phaseInterval is an interval of time spent by the pod in a phase of its critical path.
+type phaseInterval struct {
+	phase string
+	start time.Time
+	end   time.Time
+}
+
+// ImagePullEvent updates the image pulls of the pod from a Pulling or Pulled Event of one of its init containers or
+// containers, which are reported with the container records and in the critical path of the pod.
+// It returns a new instance of the pod statistic, or the receiver if the image pull is not relevant.
+func (s *PodStatistic) ImagePullEvent(containerName string, k8sEvent *corev1.Event) *PodStatistic {
+	imagePull, ok := s.imagePulls.Get(containerName)
+	// Ephemeral containers are not part of the pod lifecycle.
+	if !ok || imagePull.ephemeralContainer || !imagePull.Partial() {
+		return s
+	}
+
+	// As this type is immutable, we should shadow the receiver.
+	s = s.Copy()
+	s.imagePulls = s.imagePulls.Set(imagePull.Update(k8sEvent))
+
+	return s
+}
+
+// CriticalPath returns the time spent in each phase of the critical path of the pod, from its creation to it first
+// turning Ready, and the dominant phase.
+// The durations of the phases sum to the creation to ready duration.
+// It returns false if the pod never turned Ready.
+func (s *PodStatistic) CriticalPath() (map[string]time.Duration, string, bool) {
+	if s.creationTimestamp.IsZero() || s.readyTimestamp.IsZero() || s.readyTimestamp.Before(s.creationTimestamp) {
+		return nil, "", false
+	}
+
+	intervals := s.phaseIntervals()
+
+	// The breakpoints split the time from creation to ready into segments, each covered by the same phases.
+	breakpoints := []time.Time{s.creationTimestamp, s.readyTimestamp}
+	for _, interval := range intervals {
+		breakpoints = append(breakpoints, interval.start, interval.end)
+	}
+
+	breakpoints = slices.DeleteFunc(breakpoints, func(t time.Time) bool {
+		return t.Before(s.creationTimestamp) || t.After(s.readyTimestamp)
+	})
+	slices.SortFunc(breakpoints, time.Time.Compare)
+	breakpoints = slices.CompactFunc(breakpoints, time.Time.Equal)
+
+	durations := make(map[string]time.Duration, len(criticalPathPhases))
+	for _, phase := range criticalPathPhases {
+		durations[phase] = 0
+	}
+
+	for i := 1; i < len(breakpoints); i++ {
+		start, end := breakpoints[i-1], breakpoints[i]
+		phase := phaseOther
+
+		for _, interval := range intervals {
+			if !interval.start.After(start) && !interval.end.Before(end) {
+				phase = interval.phase
+
+				break
+			}
+		}
+
+		durations[phase] += end.Sub(start)
+	}
+
+	dominant := phaseOther
+	for _, phase := range criticalPathPhases {
+		if durations[phase] > durations[dominant] {
+			dominant = phase
+		}
+	}
+
+	return durations, dominant, true
+}
+
+// phaseIntervals returns the intervals of the phases of the critical path of the pod, sorted by precedence.
+func (s *PodStatistic) phaseIntervals() []phaseInterval {
+	var intervals []phaseInterval
+
+	add := func(phase string, start, end time.Time) {
+		if !start.IsZero() && !end.IsZero() && end.After(start) {
+			intervals = append(intervals, phaseInterval{phase: phase, start: start, end: end})
+		}
+	}
+
+	add(phaseScheduling, s.creationTimestamp, s.scheduledTimestamp)
+	add(phaseSandbox, s.scheduledTimestamp, s.sandboxReadyTimestamp)
+
+	for _, imagePull := range s.imagePulls.Containers() {
+		add(phaseImagePull, imagePull.startedTimestamp, imagePull.finishedTimestamp)
+	}
+
+	if s.initContainerNames.Len() > 0 {
+		add(phaseInitContainers, s.scheduledTimestamp, s.initializedTimestamp)
+	}
+
+	var containersReady time.Time
+
+	for _, container := range s.ContainerStatistics() {
+		add(phaseStartupProbe, container.runningTimestamp, container.startedTimestamp)
+
+		started := container.startedTimestamp
+		if started.IsZero() {
+			started = container.runningTimestamp
+		}
+
+		add(phaseReadinessProbe, started, container.readyTimestamp)
+
+		if container.readyTimestamp.After(containersReady) {
+			containersReady = container.readyTimestamp
+		}
+	}
+
+	if !s.containersReadyTimestamp.IsZero() {
+		containersReady = s.containersReadyTimestamp
+	}
+
+	add(phaseReadinessGates, containersReady, s.readyTimestamp)
+
+	slices.SortStableFunc(intervals, func(a, b phaseInterval) int {
+		return slices.Index(criticalPathPhases, a.phase) - slices.Index(criticalPathPhases, b.phase)
+	})
+
+	return intervals
+}
+
+// criticalPathEvent returns the event dictionary for the critical path of the pod, or nil if the pod never turned
+// Ready.
+func (s *PodStatistic) criticalPathEvent() *zerolog.Event {
+	durations, dominant, ok := s.CriticalPath()
+	if !ok {
+		return nil
+	}
+
+	total := s.readyTimestamp.Sub(s.creationTimestamp)
+	event := zerolog.Dict()
+
+	for _, phase := range criticalPathPhases {
+		event.Dur(phase+"_seconds", durations[phase])
+
+		fraction := 0.0
+		if total > 0 {
+			fraction = durations[phase].Seconds() / total.Seconds()
+		}
+
+		event.Float64(phase+"_fraction", fraction)
+	}
+
+	event.Str("dominant_phase", dominant)
+	event.Bool("incomplete", s.imagePullsPending())
+
+	return event
+}
+
+// imagePullsPending indicates if the image pull Events of one of the init containers or containers which ran were not
+// all received when the pod turned Ready.
+// The Events are watched separately from the pod, so they may be received after the pod record is reported, in which
+// case the time spent pulling the image is attributed to phaseOther.
+func (s *PodStatistic) imagePullsPending() bool {
+	pending := func(name string, container *ContainerStatistic) bool {
+		imagePull, ok := s.imagePulls.Get(name)
+
+		return ok && !container.runningTimestamp.IsZero() && imagePull.Partial()
+	}
+
+	for name, container := range s.InitContainerStatistics() {
+		if pending(name, container.ContainerStatistic) {
+			return true
+		}
+	}
+
+	for name, container := range s.ContainerStatistics() {
+		if pending(name, container.ContainerStatistic) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,143 @@
+package state
+
+import (
+	"testing"
+	"time"
+
+	"github.com/BackMarket-oss/kube-transition-metrics/internal/options"
+	"github.com/BackMarket-oss/kube-transition-metrics/internal/testhelpers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// withCondition returns a copy of the pod with the condition of the given type set to True at timestamp.
+func withCondition(pod *corev1.Pod, conditionType corev1.PodConditionType, timestamp time.Time) *corev1.Pod {
+	pod = pod.DeepCopy()
+	pod.Status.Conditions = append(pod.Status.Conditions, corev1.PodCondition{
+		Type:               conditionType,
+		Status:             corev1.ConditionTrue,
+		LastTransitionTime: metav1.NewTime(timestamp),
+	})
+
+	return pod
+}
+
+// newImagePullEvent returns a Pulling or Pulled Event with the given timestamp.
+func newImagePullEvent(reason string, timestamp time.Time) *corev1.Event {
+	return &corev1.Event{Reason: reason, LastTimestamp: metav1.NewTime(timestamp)}
+}
+
+func TestPodStatisticCriticalPath(t *testing.T) {
+	testhelpers.ConfigureLogging(t, &options.Options{})
+
+	created := time.Date(2023, 8, 28, 0, 0, 0, 0, time.UTC)
+	at := func(seconds int) time.Time {
+		return created.Add(time.Duration(seconds) * time.Second)
+	}
+
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			CreationTimestamp: metav1.NewTime(created),
+			Name:              "test-pod",
+			Namespace:         "test-namespace",
+		},
+		Spec: corev1.PodSpec{
+			InitContainers: []corev1.Container{{Name: "test-init-container", Image: "test-image"}},
+			Containers:     []corev1.Container{{Name: "test-container", Image: "test-image"}},
+		},
+	}
+
+	stat := NewPodStatistic(created, pod)
+	_, _, ok := stat.CriticalPath()
+	assert.False(t, ok, "Expected no critical path before the pod is Ready")
+
+	pod = withCondition(pod, corev1.PodScheduled, at(1))
+	pod = withCondition(pod, corev1.PodReadyToStartContainers, at(2))
+	stat = stat.Update(at(2), pod)
+
+	stat = stat.ImagePullEvent("test-init-container", newImagePullEvent("Pulling", at(2)))
+	stat = stat.ImagePullEvent("test-init-container", newImagePullEvent("Pulled", at(3)))
+	assert.Same(t, stat, stat.ImagePullEvent("unknown", newImagePullEvent("Pulling", at(3))),
+		"Expected image pulls of unknown containers to be ignored")
+
+	pod = withCondition(pod, corev1.PodInitialized, at(8))
+	pod.Status.InitContainerStatuses = []corev1.ContainerStatus{{
+		Name:  "test-init-container",
+		State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{}},
+		Ready: true,
+	}}
+	stat = stat.Update(at(8), pod)
+
+	// The image pull of the container overlaps with the initialization of the pod.
+	stat = stat.ImagePullEvent("test-container", newImagePullEvent("Pulling", at(7)))
+	stat = stat.ImagePullEvent("test-container", newImagePullEvent("Pulled", at(9)))
+
+	pod = pod.DeepCopy()
+	pod.Status.ContainerStatuses = []corev1.ContainerStatus{{
+		Name:  "test-container",
+		State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{}},
+	}}
+	stat = stat.Update(at(10), pod)
+
+	pod = pod.DeepCopy()
+	pod.Status.ContainerStatuses[0].Started = new(true)
+	stat = stat.Update(at(12), pod)
+
+	pod = withCondition(pod, corev1.ContainersReady, at(15))
+	pod.Status.ContainerStatuses[0].Ready = true
+	stat = stat.Update(at(15), pod)
+
+	pod = withCondition(pod, corev1.PodReady, at(17))
+	stat = stat.Update(at(17), pod)
+
+	durations, dominant, ok := stat.CriticalPath()
+	require.True(t, ok, "Expected critical path once the pod is Ready")
+
+	assert.Equal(t, map[string]time.Duration{
+		phaseScheduling:     time.Second,
+		phaseSandbox:        time.Second,
+		phaseImagePull:      3 * time.Second,
+		phaseInitContainers: 4 * time.Second,
+		phaseStartupProbe:   2 * time.Second,
+		phaseReadinessProbe: 3 * time.Second,
+		phaseReadinessGates: 2 * time.Second,
+		phaseOther:          time.Second,
+	}, durations)
+	assert.Equal(t, phaseInitContainers, dominant)
+
+	writer := testhelpers.NewMetricWriter(t)
+	stat.Report(writer, pod)
+
+	var criticalPath, container map[string]any
+
+	for _, metric := range testhelpers.DecodeMetricOutput(t, writer) {
+		switch {
+		case metric["type"] == "pod":
+			event, _ := metric["pod"].(map[string]any)
+			criticalPath, _ = event["critical_path"].(map[string]any)
+		case metric["type"] == "container" && metric["container_name"] == "test-container":
+			container, _ = metric["container"].(map[string]any)
+		}
+	}
+
+	require.NotNil(t, container, "Expected a container metric")
+	assert.Equal(t, false, container["already_present"])
+	assert.InDelta(t, 2.0, container["image_pull_duration_seconds"], 0.001)
+	assert.InDelta(t, 1.0, container["pulled_to_running_seconds"], 0.001)
+
+	require.NotNil(t, criticalPath, "Expected a critical path in the pod metric")
+	assert.Equal(t, phaseInitContainers, criticalPath["dominant_phase"])
+	assert.Equal(t, false, criticalPath["incomplete"], "Expected all the image pulls to be received")
+
+	sum := 0.0
+
+	for _, phase := range criticalPathPhases {
+		fraction, _ := criticalPath[phase+"_fraction"].(float64)
+		sum += fraction
+	}
+
+	assert.InDelta(t, 1.0, sum, 0.001, "Expected the fractions to sum to 1")
+	assert.InDelta(t, 4.0/17.0, criticalPath["init_containers_fraction"], 0.001)
+}
@@ -0,0 +1,74 @@
+package state
+
+import (
+	"io"
+	"time"
+
+	"github.com/rs/zerolog"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// Pod returns the latest version of the pod the statistic was updated with.
+func (s *PodStatistic) Pod() *corev1.Pod {
+	return s.pod
+}
+
+// EndpointReadyTimestamp returns the timestamp for when the pod address first appeared as ready in an EndpointSlice,
+// or the zero time if it never did.
+func (s *PodStatistic) EndpointReadyTimestamp() time.Time {
+	return s.endpointReadyTimestamp
+}
+
+// EndpointReady records the first time the pod address appeared as ready in an EndpointSlice of the Service.
+// It returns a new instance of the pod statistic, or the receiver if the endpoint readiness was already recorded.
+func (s *PodStatistic) EndpointReady(now time.Time, service string) *PodStatistic {
+	if !s.endpointReadyTimestamp.IsZero() {
+		return s
+	}
+
+	// As this type is immutable, we should shadow the receiver.
+	s = s.Copy()
+	s.endpointReadyTimestamp = now
+	s.endpointService = service
+
+	return s
+}
+
+// EndpointReadyAfterReady indicates if the endpoint readiness was recorded once the pod was Ready.
+// The address of a pod which is not Ready may still appear as ready in an EndpointSlice, e.g. with
+// publishNotReadyAddresses, which is not reported.
+func (s *PodStatistic) EndpointReadyAfterReady() bool {
+	return !s.readyTimestamp.IsZero() && !s.endpointReadyTimestamp.IsZero() &&
+		!s.endpointReadyTimestamp.Before(s.readyTimestamp)
+}
+
+// ReportEndpoint reports the endpoint statistic of the pod to the given output writer.
+// It must only be called once the endpoint readiness is recorded.
+func (s *PodStatistic) ReportEndpoint(output io.Writer, labelers ...PodLabeler) {
+	logger := s.logger()
+
+	if s.endpointReadyTimestamp.IsZero() || s.pod == nil {
+		logger.Panic().Msg("endpoint statistic reported before the endpoint is ready")
+	}
+
+	metrics := zerolog.Dict().
+		Bool("partial", false).
+		Func(commonPodLabels(s.pod, labelers)).
+		Dict("endpoint", s.endpointEvent())
+	logMetrics(output, "endpoint", metrics, "")
+}
+
+// endpointEvent returns the event dictionary for the endpoint statistic.
+func (s *PodStatistic) endpointEvent() *zerolog.Event {
+	event := zerolog.Dict()
+
+	event.Str("service", s.endpointService)
+	event.Time("endpoint_ready_timestamp", s.endpointReadyTimestamp)
+
+	if !s.readyTimestamp.IsZero() {
+		event.Time("ready_timestamp", s.readyTimestamp)
+		event.Dur("ready_to_endpoint_ready_seconds", s.endpointReadyTimestamp.Sub(s.readyTimestamp))
+	}
+
+	return event
+}
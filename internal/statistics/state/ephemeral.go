@@ -0,0 +1,180 @@
+package state
+
+import (
+	"io"
+	"iter"
+	"time"
+
+	"github.com/Izzette/go-safeconcurrency/eventloop/snapshot"
+	"github.com/benbjohnson/immutable"
+	"github.com/rs/zerolog"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// EphemeralContainerStatistic holds the transition statistics for an ephemeral container, e.g. added by kubectl debug,
+// in a pod.
+// EphemeralContainerStatistic is immutable, all the methods return a new instance of the struct.
+// Do not lose track of the returned instance, it should be assigned to the containing structure.
+type EphemeralContainerStatistic struct {
+	*ContainerStatistic
+
+	// addedTimestamp for when the ephemeral container was first seen in the pod spec.
+	addedTimestamp time.Time
+}
+
+// Partial indicates if the ephemeral container statistic does not contain all the metrics for a complete ephemeral
+// container lifecycle.
+// As ephemeral containers do not support probes, they are complete once Running.
+func (cs *EphemeralContainerStatistic) Partial() bool {
+	return cs.runningTimestamp.IsZero()
+}
+
+// Report reports the ephemeral container statistic to the output writer.
+func (cs *EphemeralContainerStatistic) Report(
+	output io.Writer,
+	pod *corev1.Pod,
+	podStatistic *PodStatistic,
+	labelers ...PodLabeler,
+) {
+	logger := cs.logger(podStatistic.logger())
+
+	container := findEphemeralContainer(cs.name, pod.Spec.EphemeralContainers)
+	if container == nil {
+		logger.Panic().Msg("ephemeral container not found")
+	}
+
+	metrics := zerolog.Dict().
+		Bool("partial", cs.Partial()).
+		Func(commonPodLabels(pod, labelers)).
+		Func(commonContainerLabels(&logger, container)).
+		Dict("ephemeral_container", cs.event())
+
+	logMetrics(output, "ephemeral_container", metrics, "")
+}
+
+// Update updates the ephemeral container statistic based on the latest Kubernetes container status.
+func (cs *EphemeralContainerStatistic) Update(
+	now time.Time,
+	status corev1.ContainerStatus,
+	pod *PodStatistic,
+) *EphemeralContainerStatistic {
+	// As this type is immutable, we should shadow the receiver.
+	cs = snapshot.CopyPtr(cs)
+
+	cs.ContainerStatistic = cs.update(now, status, pod)
+
+	return cs
+}
+
+// event returns the event dictionary for the ephemeral container statistic.
+func (cs *EphemeralContainerStatistic) event() *zerolog.Event {
+	event := zerolog.Dict()
+	event.Time("added_timestamp", cs.addedTimestamp)
+
+	if !cs.runningTimestamp.IsZero() {
+		event.Time("running_timestamp", cs.runningTimestamp)
+		event.Str("running_timestamp_source", timestampSource(cs.runningAuthoritative))
+		event.Dur("added_to_running_seconds", cs.runningTimestamp.Sub(cs.addedTimestamp))
+	}
+
+	return event
+}
+
+// EphemeralContainerStatistics returns an iterator for each ephemeral container statistic in the pod.
+func (s *PodStatistic) EphemeralContainerStatistics() iter.Seq2[string, *EphemeralContainerStatistic] {
+	return s.EachEphemeralContainerStatistic
+}
+
+// EachEphemeralContainerStatistic is an [iter.Seq2] of the ephemeral container name (string) and the ephemeral
+// container statistic ([*EphemeralContainerStatistic]).
+func (s *PodStatistic) EachEphemeralContainerStatistic(yield func(string, *EphemeralContainerStatistic) bool) {
+	if s.ephemeralContainers == nil {
+		return
+	}
+
+	containers := s.ephemeralContainers.Iterator()
+	for !containers.Done() {
+		containerName, container, _ := containers.Next()
+		if !yield(containerName, container) {
+			break
+		}
+	}
+}
+
+// EphemeralContainerStatistic returns the statistic of the ephemeral container with the given name, if tracked.
+func (s *PodStatistic) EphemeralContainerStatistic(name string) (*EphemeralContainerStatistic, bool) {
+	if s.ephemeralContainers == nil {
+		return nil, false
+	}
+
+	return s.ephemeralContainers.Get(name)
+}
+
+// EphemeralContainersPending indicates if the pod has ephemeral containers which are not yet tracked or not yet
+// running, which must still be updated after the pod statistic is complete.
+func (s *PodStatistic) EphemeralContainersPending(pod *corev1.Pod) bool {
+	for _, container := range pod.Spec.EphemeralContainers {
+		statistic, ok := s.EphemeralContainerStatistic(container.Name)
+		if !ok || statistic.Partial() {
+			return true
+		}
+	}
+
+	return false
+}
+
+// updateEphemeralContainers tracks the ephemeral containers added to the pod spec and updates their statistics from
+// the latest container statuses.
+// It returns a new instance of the pod statistic with the updated values.
+func (s *PodStatistic) updateEphemeralContainers(now time.Time, pod *corev1.Pod) *PodStatistic {
+	if len(pod.Spec.EphemeralContainers) == 0 {
+		return s
+	}
+
+	statuses := make(map[string]corev1.ContainerStatus, len(pod.Status.EphemeralContainerStatuses))
+	for _, status := range pod.Status.EphemeralContainerStatuses {
+		statuses[status.Name] = status
+	}
+
+	containers := s.ephemeralContainers
+	if containers == nil {
+		containers = immutable.NewMap[string, *EphemeralContainerStatistic](nil)
+	}
+
+	for _, container := range pod.Spec.EphemeralContainers {
+		statistic, ok := containers.Get(container.Name)
+		if !ok {
+			statistic = &EphemeralContainerStatistic{
+				ContainerStatistic: &ContainerStatistic{name: container.Name},
+				addedTimestamp:     now,
+			}
+		}
+
+		if status, ok := statuses[container.Name]; ok {
+			statistic = statistic.Update(now, status, s)
+		}
+
+		containers = containers.Set(container.Name, statistic)
+	}
+
+	// As this type is immutable, we should shadow the receiver.
+	s = s.Copy()
+	s.ephemeralContainers = containers
+
+	return s
+}
+
+// findEphemeralContainer finds the ephemeral container from the list by specified name, as a [corev1.Container].
+// It returns nil if the container is not found.
+func findEphemeralContainer(name string, containers []corev1.EphemeralContainer) *corev1.Container {
+	for _, container := range containers {
+		if container.Name == name {
+			// EphemeralContainerCommon has the same fields as Container.
+			converted := corev1.Container(container.EphemeralContainerCommon)
+
+			return &converted
+		}
+	}
+
+	return nil
+}
@@ -34,6 +34,10 @@ func NewPodImagePullStatistic(pod *corev1.Pod) *PodImagePullStatistic {
 		containers.Set(c.Name, NewContainerImagePullStatistic(pod, false, c))
 	}
 
+	for _, c := range pod.Spec.EphemeralContainers {
+		containers.Set(c.Name, newEphemeralContainerImagePullStatistic(pod, c))
+	}
+
 	return &PodImagePullStatistic{
 		podNamespace: pod.Namespace,
 		podName:      pod.Name,
@@ -97,6 +101,22 @@ func (s *PodImagePullStatistic) Get(containerName string) (*ContainerImagePullSt
 	return s.containers.Get(containerName)
 }
 
+// GetForPod returns the image pull statistic for the container of the pod with the given name, adding it for an
+// ephemeral container added to the pod after the image pull statistic was created.
+func (s *PodImagePullStatistic) GetForPod(pod *corev1.Pod, containerName string) (*ContainerImagePullStatistic, bool) {
+	if container, ok := s.containers.Get(containerName); ok {
+		return container, true
+	}
+
+	for _, c := range pod.Spec.EphemeralContainers {
+		if c.Name == containerName {
+			return newEphemeralContainerImagePullStatistic(pod, c), true
+		}
+	}
+
+	return nil, false
+}
+
 // Set updates the image pull statistic for the container with the given name, or adds it if it doesn't exist.
 // Set returns a new instance of the PodImagePullStatistic with the updated fields.
 func (s *PodImagePullStatistic) Set(
@@ -116,6 +136,8 @@ type ContainerImagePullStatistic struct {
 	podName       string
 	containerName string
 	initContainer bool
+	// ephemeralContainer is true for ephemeral containers, e.g. added by kubectl debug.
+	ephemeralContainer bool
 
 	alreadyPresent    bool
 	startedTimestamp  time.Time
@@ -137,6 +159,20 @@ func NewContainerImagePullStatistic(
 	}
 }
 
+// newEphemeralContainerImagePullStatistic creates a new ContainerImagePullStatistic instance for an ephemeral
+// container.
+func newEphemeralContainerImagePullStatistic(
+	pod *corev1.Pod,
+	container corev1.EphemeralContainer,
+) *ContainerImagePullStatistic {
+	return &ContainerImagePullStatistic{
+		podNamespace:       pod.Namespace,
+		podName:            pod.Name,
+		containerName:      container.Name,
+		ephemeralContainer: true,
+	}
+}
+
 // Copy implements [github.com/Izzette/go-safeconcurrency/types.Copyable.Copy].
 func (s *ContainerImagePullStatistic) Copy() *ContainerImagePullStatistic {
 	return snapshot.CopyPtr(s)
@@ -147,6 +183,46 @@ func (s *ContainerImagePullStatistic) Partial() bool {
 	return s.startedTimestamp.IsZero() || s.finishedTimestamp.IsZero()
 }
 
+// ContainerName returns the name of the container pulling the image.
+func (s *ContainerImagePullStatistic) ContainerName() string {
+	return s.containerName
+}
+
+// Image returns the image pulled for the container of the pod, or an empty string if the container is not found.
+func (s *ContainerImagePullStatistic) Image(pod *corev1.Pod) string {
+	if container := s.container(pod); container != nil {
+		return container.Image
+	}
+
+	return ""
+}
+
+// container returns the container of the pod pulling the image, or nil if not found.
+func (s *ContainerImagePullStatistic) container(pod *corev1.Pod) *corev1.Container {
+	switch {
+	case s.initContainer:
+		return findContainer(s.containerName, pod.Spec.InitContainers)
+	case s.ephemeralContainer:
+		return findEphemeralContainer(s.containerName, pod.Spec.EphemeralContainers)
+	default:
+		return findContainer(s.containerName, pod.Spec.Containers)
+	}
+}
+
+// AlreadyPresent indicates if the image was already present on the node, so that it was not pulled.
+func (s *ContainerImagePullStatistic) AlreadyPresent() bool {
+	return s.alreadyPresent
+}
+
+// Duration returns the duration of the image pull, or zero if the image pull is partial.
+func (s *ContainerImagePullStatistic) Duration() time.Duration {
+	if s.Partial() {
+		return 0
+	}
+
+	return s.finishedTimestamp.Sub(s.startedTimestamp)
+}
+
 // Update updates the image pull statistic with the provided event.
 // If the event is a pull event, it sets the startedTimestamp.
 func (s *ContainerImagePullStatistic) Update(event *corev1.Event) *ContainerImagePullStatistic {
@@ -173,23 +249,23 @@ func (s *ContainerImagePullStatistic) Update(event *corev1.Event) *ContainerImag
 }
 
 // Report logs the image pull statistic to the provided output writer.
-func (s *ContainerImagePullStatistic) Report(output io.Writer, pod *corev1.Pod, message string) {
+// The labels of the provided labelers are added to the metric record.
+func (s *ContainerImagePullStatistic) Report(
+	output io.Writer,
+	pod *corev1.Pod,
+	message string,
+	labelers ...PodLabeler,
+) {
 	logger := s.logger()
 
-	var container *corev1.Container
-	if s.initContainer {
-		container = findContainer(s.containerName, pod.Spec.InitContainers)
-	} else {
-		container = findContainer(s.containerName, pod.Spec.Containers)
-	}
-
+	container := s.container(pod)
 	if container == nil {
 		logger.Panic().Msg("container not found")
 	}
 
 	metrics := zerolog.Dict().
 		Bool("partial", s.Partial()).
-		Func(commonPodLabels(pod)).
+		Func(commonPodLabels(pod, labelers)).
 		Func(commonContainerLabels(&logger, container)).
 		Dict("image_pull", s.event())
 	logMetrics(output, "image_pull", metrics, message)
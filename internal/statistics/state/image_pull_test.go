@@ -106,3 +106,53 @@ func TestImagePullStatisticLog(t *testing.T) {
 		assert.Equal(t, expected["message"], actual["message"], "Output does not match expected values")
 	}
 }
+
+func TestImagePullStatisticEphemeralContainer(t *testing.T) {
+	testhelpers.ConfigureLogging(t, &options.Options{})
+
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-pod",
+			Namespace: "test-namespace",
+		},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{{Name: "test-container"}},
+		},
+	}
+	podStat := NewPodImagePullStatistic(pod)
+
+	// The ephemeral container is added after the image pull statistic is created.
+	debugPod := pod.DeepCopy()
+	debugPod.Spec.EphemeralContainers = []corev1.EphemeralContainer{{
+		EphemeralContainerCommon: corev1.EphemeralContainerCommon{Name: "debugger", Image: "busybox:1.36"},
+	}}
+
+	_, ok := podStat.GetForPod(debugPod, "missing")
+	assert.False(t, ok, "Expected unknown container to not be found")
+
+	imagePullStat, ok := podStat.GetForPod(debugPod, "debugger")
+	if !assert.True(t, ok, "Expected ephemeral container to be found") {
+		return
+	}
+
+	assert.True(t, imagePullStat.ephemeralContainer, "Expected ephemeral container image pull statistic")
+	assert.True(t, imagePullStat.Partial(), "Expected image pull to be partial")
+
+	now := time.Now()
+	imagePullStat = imagePullStat.Update(&corev1.Event{Reason: "Pulling", LastTimestamp: metav1.NewTime(now)})
+	imagePullStat = imagePullStat.Update(&corev1.Event{
+		Reason:        "Pulled",
+		LastTimestamp: metav1.NewTime(now.Add(2 * time.Second)),
+	})
+
+	buf := &bytes.Buffer{}
+	imagePullStat.Report(buf, debugPod, "")
+
+	actual := make(map[string]any)
+	if assert.NoError(t, json.Unmarshal(buf.Bytes(), &actual)) {
+		metrics, _ := actual["kube_transition_metrics"].(map[string]any)
+		assert.Equal(t, "debugger", metrics["container_name"])
+		assert.Equal(t, "docker.io/library/busybox", metrics["image_name"])
+		assert.Equal(t, false, metrics["partial"])
+	}
+}
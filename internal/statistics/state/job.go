@@ -0,0 +1,278 @@
+package state
+
+import (
+	"io"
+	"slices"
+	"time"
+
+	"github.com/Izzette/go-safeconcurrency/eventloop/snapshot"
+	"github.com/benbjohnson/immutable"
+	"github.com/rs/zerolog"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	apimachinerytypes "k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	// CronJobScheduledTimestampAnnotation is set by the CronJob controller on the Jobs it creates to the time the Job was
+	// scheduled at.
+	CronJobScheduledTimestampAnnotation = "batch.kubernetes.io/cronjob-scheduled-timestamp"
+	// defaultBackoffLimit is the number of retries of a Job when spec.backoffLimit is not set.
+	defaultBackoffLimit = 6
+	// maxReportedJobPods is the maximum number of pods reported in a job record, to bound the size of the records of
+	// highly parallel Jobs.
+	maxReportedJobPods = 64
+)
+
+// jobPod holds the statistics of a pod of a Job.
+type jobPod struct {
+	name string
+	// The timestamp for when the pod was created.
+	createdTimestamp time.Time
+	// The timestamp for when the first container of the pod started running.
+	runningTimestamp time.Time
+	// The timestamp for when the last container of the pod terminated, once the pod succeeded or failed.
+	finishedTimestamp time.Time
+	// phase is the phase of the pod, Succeeded or Failed once finished.
+	phase corev1.PodPhase
+}
+
+// JobStatistic holds the statistics of a run of a Job, from the time its CronJob scheduled it to its completion.
+// JobStatistic is immutable, all the methods return a new instance of the struct.
+// Do not lose track of the returned instance, it should be assigned to the containing structure.
+type JobStatistic struct {
+	namespace string
+	name      string
+	// cronJob is the name of the CronJob which created the Job, or empty.
+	cronJob string
+
+	// The time the CronJob scheduled the Job at, or the zero time if not created by a CronJob.
+	scheduledTimestamp time.Time
+	// The timestamp for when the Job was created.
+	creationTimestamp time.Time
+	// The timestamp for when the Job completed or failed.
+	finishedTimestamp time.Time
+	// failed is true if the Job failed.
+	failed bool
+
+	backoffLimit int32
+	// succeeded and failedPods are the numbers of succeeded and failed pods reported in the status of the Job.
+	succeeded  int32
+	failedPods int32
+
+	pods *immutable.Map[apimachinerytypes.UID, *jobPod]
+}
+
+// NewJobStatistic creates a new JobStatistic for the Job.
+func NewJobStatistic(job *batchv1.Job) *JobStatistic {
+	statistic := &JobStatistic{
+		namespace:         job.Namespace,
+		name:              job.Name,
+		creationTimestamp: job.CreationTimestamp.Time,
+		backoffLimit:      defaultBackoffLimit,
+		pods:              immutable.NewMap[apimachinerytypes.UID, *jobPod](nil),
+	}
+
+	if ownerRef := metav1.GetControllerOfNoCopy(job); ownerRef != nil && ownerRef.Kind == "CronJob" {
+		statistic.cronJob = ownerRef.Name
+	}
+
+	if scheduled, err := time.Parse(time.RFC3339, job.Annotations[CronJobScheduledTimestampAnnotation]); err == nil {
+		statistic.scheduledTimestamp = scheduled
+	}
+
+	if job.Spec.BackoffLimit != nil {
+		statistic.backoffLimit = *job.Spec.BackoffLimit
+	}
+
+	return statistic
+}
+
+// Copy implements [github.com/Izzette/go-safeconcurrency/types.Copyable.Copy].
+func (s *JobStatistic) Copy() *JobStatistic {
+	return snapshot.CopyPtr(s)
+}
+
+// PodUpdated records the pod of the Job, with the running timestamp of its pod statistic, and the finished timestamp
+// of its containers once it succeeded or failed, which the pod statistic does not track.
+// It returns a new instance of the job statistic.
+func (s *JobStatistic) PodUpdated(pod *corev1.Pod, statistic *PodStatistic) *JobStatistic {
+	previous, ok := s.pods.Get(pod.UID)
+	if !ok {
+		previous = &jobPod{name: pod.Name, createdTimestamp: statistic.CreationTimestamp()}
+	}
+
+	next := *previous
+	next.phase = pod.Status.Phase
+	next.runningTimestamp = statistic.firstRunningTimestamp()
+
+	if next.finishedTimestamp.IsZero() && (next.phase == corev1.PodSucceeded || next.phase == corev1.PodFailed) {
+		for _, status := range pod.Status.ContainerStatuses {
+			if terminated := status.State.Terminated; terminated != nil &&
+				terminated.FinishedAt.After(next.finishedTimestamp) {
+				next.finishedTimestamp = terminated.FinishedAt.Time
+			}
+		}
+	}
+
+	s = s.Copy()
+	s.pods = s.pods.Set(pod.UID, &next)
+
+	return s
+}
+
+// Finish records the completion or failure of the Job from its status.
+// It returns a new instance of the job statistic, or the receiver if the Job has not finished.
+func (s *JobStatistic) Finish(now time.Time, job *batchv1.Job) *JobStatistic {
+	var finished *batchv1.JobCondition
+
+	for _, condition := range job.Status.Conditions {
+		if (condition.Type == batchv1.JobComplete || condition.Type == batchv1.JobFailed) &&
+			condition.Status == corev1.ConditionTrue {
+			finished = &condition
+
+			break
+		}
+	}
+
+	if finished == nil {
+		return s
+	}
+
+	s = s.Copy()
+	s.failed = finished.Type == batchv1.JobFailed
+	s.finishedTimestamp = finished.LastTransitionTime.Time
+	s.succeeded = job.Status.Succeeded
+	s.failedPods = job.Status.Failed
+
+	if s.finishedTimestamp.IsZero() {
+		s.finishedTimestamp = now
+	}
+
+	return s
+}
+
+// Partial indicates if the Job has not yet completed or failed.
+func (s *JobStatistic) Partial() bool {
+	return s.finishedTimestamp.IsZero()
+}
+
+// Report reports the job statistic to the given output writer.
+func (s *JobStatistic) Report(output io.Writer) {
+	metrics := zerolog.Dict().
+		Bool("partial", s.Partial()).
+		Str("kube_namespace", s.namespace).
+		Str("kube_job", s.name)
+
+	if s.cronJob != "" {
+		metrics.Str("kube_cron_job", s.cronJob).
+			Str("kube_top_owner_kind", "cronjob").
+			Str("kube_top_owner_name", s.cronJob)
+	} else {
+		metrics.Str("kube_top_owner_kind", "job").
+			Str("kube_top_owner_name", s.name)
+	}
+
+	logMetrics(output, "job", metrics.Dict("job", s.event()), "")
+}
+
+// event returns the event dictionary for the job statistic.
+func (s *JobStatistic) event() *zerolog.Event {
+	event := zerolog.Dict()
+
+	event.Time("creation_timestamp", s.creationTimestamp)
+
+	if !s.scheduledTimestamp.IsZero() {
+		event.Time("scheduled_timestamp", s.scheduledTimestamp)
+		event.Dur("scheduled_to_creation_seconds", s.creationTimestamp.Sub(s.scheduledTimestamp))
+	}
+
+	pods := s.sortedPods()
+	firstCreated, firstRunning := s.firstPodTimestamps(pods)
+
+	if !firstCreated.IsZero() {
+		event.Time("first_pod_creation_timestamp", firstCreated)
+		event.Dur("creation_to_first_pod_creation_seconds", firstCreated.Sub(s.creationTimestamp))
+	}
+
+	if !firstRunning.IsZero() {
+		event.Time("first_pod_running_timestamp", firstRunning)
+		event.Dur("creation_to_first_pod_running_seconds", firstRunning.Sub(s.creationTimestamp))
+
+		if !s.scheduledTimestamp.IsZero() {
+			event.Dur("scheduled_to_first_pod_running_seconds", firstRunning.Sub(s.scheduledTimestamp))
+		}
+	}
+
+	if !s.Partial() {
+		event.Time("finished_timestamp", s.finishedTimestamp)
+		event.Dur("creation_to_finished_seconds", s.finishedTimestamp.Sub(s.creationTimestamp))
+		event.Bool("failed", s.failed)
+		event.Int32("pods_succeeded", s.succeeded)
+		event.Int32("retries", s.failedPods)
+	}
+
+	event.Int32("backoff_limit", s.backoffLimit)
+	event.Int("pods_created", len(pods))
+
+	podEvents := zerolog.Arr()
+	for _, pod := range pods[:min(len(pods), maxReportedJobPods)] {
+		podEvents.Dict(pod.event())
+	}
+
+	return event.Array("pods", podEvents)
+}
+
+// sortedPods returns the pods of the Job, sorted by creation timestamp.
+func (s *JobStatistic) sortedPods() []*jobPod {
+	pods := make([]*jobPod, 0, s.pods.Len())
+
+	iterator := s.pods.Iterator()
+	for !iterator.Done() {
+		_, pod, _ := iterator.Next()
+		pods = append(pods, pod)
+	}
+
+	slices.SortFunc(pods, func(a, b *jobPod) int {
+		return a.createdTimestamp.Compare(b.createdTimestamp)
+	})
+
+	return pods
+}
+
+// firstPodTimestamps returns the timestamps for when the first pod was created and when the first pod started
+// running.
+func (s *JobStatistic) firstPodTimestamps(sorted []*jobPod) (time.Time, time.Time) {
+	var firstCreated, firstRunning time.Time
+
+	if len(sorted) > 0 {
+		firstCreated = sorted[0].createdTimestamp
+	}
+
+	for _, pod := range sorted {
+		if !pod.runningTimestamp.IsZero() && (firstRunning.IsZero() || pod.runningTimestamp.Before(firstRunning)) {
+			firstRunning = pod.runningTimestamp
+		}
+	}
+
+	return firstCreated, firstRunning
+}
+
+// event returns the event dictionary for the pod of the Job.
+func (p *jobPod) event() *zerolog.Event {
+	event := zerolog.Dict()
+
+	event.Str("pod_name", p.name)
+	event.Str("phase", string(p.phase))
+
+	if !p.runningTimestamp.IsZero() {
+		event.Dur("creation_to_running_seconds", p.runningTimestamp.Sub(p.createdTimestamp))
+	}
+
+	if !p.finishedTimestamp.IsZero() {
+		event.Dur("creation_to_finished_seconds", p.finishedTimestamp.Sub(p.createdTimestamp))
+	}
+
+	return event
+}
@@ -0,0 +1,155 @@
+package state
+
+import (
+	"testing"
+	"time"
+
+	"github.com/BackMarket-oss/kube-transition-metrics/internal/options"
+	"github.com/BackMarket-oss/kube-transition-metrics/internal/testhelpers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	apimachinerytypes "k8s.io/apimachinery/pkg/types"
+)
+
+func newTestingJob(created time.Time) *batchv1.Job {
+	return &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:         "test-namespace",
+			Name:              "report-28190000",
+			CreationTimestamp: metav1.NewTime(created),
+			Annotations: map[string]string{
+				CronJobScheduledTimestampAnnotation: created.Add(-3 * time.Second).Format(time.RFC3339),
+			},
+			OwnerReferences: []metav1.OwnerReference{
+				{APIVersion: "batch/v1", Kind: "CronJob", Name: "report", Controller: new(true)},
+			},
+		},
+		Spec: batchv1.JobSpec{BackoffLimit: new(int32(2))},
+	}
+}
+
+func newTestingJobPod(
+	name string,
+	created time.Time,
+	phase corev1.PodPhase,
+	running, finished time.Duration,
+) *corev1.Pod {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:         "test-namespace",
+			Name:              name,
+			UID:               apimachinerytypes.UID(name),
+			CreationTimestamp: metav1.NewTime(created),
+		},
+		Spec:   corev1.PodSpec{Containers: []corev1.Container{{Name: "main", Image: "report:1"}}},
+		Status: corev1.PodStatus{Phase: phase},
+	}
+
+	containerState := corev1.ContainerState{
+		Running: &corev1.ContainerStateRunning{StartedAt: metav1.NewTime(created.Add(running))},
+	}
+	if phase == corev1.PodSucceeded || phase == corev1.PodFailed {
+		containerState = corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{
+			StartedAt:  metav1.NewTime(created.Add(running)),
+			FinishedAt: metav1.NewTime(created.Add(finished)),
+		}}
+	}
+
+	pod.Status.ContainerStatuses = []corev1.ContainerStatus{{Name: "main", State: containerState}}
+
+	return pod
+}
+
+func TestJobStatisticReport(t *testing.T) {
+	testhelpers.ConfigureLogging(t, &options.Options{})
+
+	created := time.Date(2023, 8, 28, 0, 0, 0, 0, time.UTC)
+	job := newTestingJob(created)
+	statistic := NewJobStatistic(job)
+
+	// The running timestamps are taken from the pod statistics.
+	pod := newTestingJobPod("report-a", created.Add(time.Second), corev1.PodRunning, 4*time.Second, 0)
+	podStatistic := NewPodStatistic(created.Add(6*time.Second), pod)
+	statistic = statistic.PodUpdated(pod, podStatistic)
+
+	pod = newTestingJobPod("report-a", created.Add(time.Second), corev1.PodFailed, 4*time.Second, 10*time.Second)
+	statistic = statistic.PodUpdated(pod, podStatistic.Update(created.Add(11*time.Second), pod))
+
+	pod = newTestingJobPod("report-b", created.Add(12*time.Second), corev1.PodSucceeded, 2*time.Second, 20*time.Second)
+	statistic = statistic.PodUpdated(pod, NewPodStatistic(created.Add(33*time.Second), pod))
+
+	assert.Same(t, statistic, statistic.Finish(created, job), "Expected unfinished Job to be unchanged")
+	assert.True(t, statistic.Partial(), "Expected unfinished Job to be partial")
+
+	job.Status = batchv1.JobStatus{
+		Succeeded: 1,
+		Failed:    1,
+		Conditions: []batchv1.JobCondition{{
+			Type:               batchv1.JobComplete,
+			Status:             corev1.ConditionTrue,
+			LastTransitionTime: metav1.NewTime(created.Add(40 * time.Second)),
+		}},
+	}
+	statistic = statistic.Finish(created.Add(time.Minute), job)
+	assert.False(t, statistic.Partial(), "Expected complete Job to not be partial")
+
+	writer := testhelpers.NewMetricWriter(t)
+	statistic.Report(writer)
+
+	metrics := testhelpers.DecodeMetricOutput(t, writer)
+	require.Len(t, metrics, 1)
+	assert.Equal(t, "job", metrics[0]["type"])
+	assert.Equal(t, "report-28190000", metrics[0]["kube_job"])
+	assert.Equal(t, "report", metrics[0]["kube_cron_job"])
+	assert.Equal(t, "cronjob", metrics[0]["kube_top_owner_kind"])
+
+	jobMetrics, ok := metrics[0]["job"].(map[string]any)
+	require.True(t, ok, "Expected job metrics to be an object")
+	assert.InDelta(t, 3, jobMetrics["scheduled_to_creation_seconds"], 1e-5)
+	assert.InDelta(t, 1, jobMetrics["creation_to_first_pod_creation_seconds"], 1e-5)
+	assert.InDelta(t, 5, jobMetrics["creation_to_first_pod_running_seconds"], 1e-5)
+	assert.InDelta(t, 8, jobMetrics["scheduled_to_first_pod_running_seconds"], 1e-5)
+	assert.InDelta(t, 40, jobMetrics["creation_to_finished_seconds"], 1e-5)
+	assert.Equal(t, false, jobMetrics["failed"])
+	assert.InDelta(t, 1, jobMetrics["retries"], 1e-5)
+	assert.InDelta(t, 2, jobMetrics["backoff_limit"], 1e-5)
+	assert.InDelta(t, 2, jobMetrics["pods_created"], 1e-5)
+
+	pods, ok := jobMetrics["pods"].([]any)
+	require.True(t, ok, "Expected job pods to be an array")
+	require.Len(t, pods, 2)
+
+	first, _ := pods[0].(map[string]any)
+	assert.Equal(t, "report-a", first["pod_name"])
+	assert.Equal(t, "Failed", first["phase"])
+	assert.InDelta(t, 4, first["creation_to_running_seconds"], 1e-5)
+	assert.InDelta(t, 10, first["creation_to_finished_seconds"], 1e-5)
+}
+
+func TestJobStatisticWithoutCronJob(t *testing.T) {
+	testhelpers.ConfigureLogging(t, &options.Options{})
+
+	created := time.Date(2023, 8, 28, 0, 0, 0, 0, time.UTC)
+	job := newTestingJob(created)
+	job.Annotations = nil
+	job.OwnerReferences = nil
+	job.Spec.BackoffLimit = nil
+
+	writer := testhelpers.NewMetricWriter(t)
+	NewJobStatistic(job).Report(writer)
+
+	metrics := testhelpers.DecodeMetricOutput(t, writer)
+	require.Len(t, metrics, 1)
+	assert.Equal(t, true, metrics[0]["partial"])
+	assert.Equal(t, "job", metrics[0]["kube_top_owner_kind"])
+	assert.NotContains(t, metrics[0], "kube_cron_job")
+
+	jobMetrics, ok := metrics[0]["job"].(map[string]any)
+	require.True(t, ok, "Expected job metrics to be an object")
+	assert.InDelta(t, defaultBackoffLimit, jobMetrics["backoff_limit"], 1e-5)
+	assert.NotContains(t, jobMetrics, "scheduled_timestamp")
+	assert.NotContains(t, jobMetrics, "finished_timestamp")
+}
@@ -27,16 +27,53 @@ type PodStatistic struct {
 	// The timestamp for when the pod was scheduled.
 	scheduledTimestamp time.Time
 
+	// The timestamp for when the pod sandbox was created and its network configured (PodReadyToStartContainers).
+	sandboxReadyTimestamp time.Time
+
+	// The timestamp for when the IP of the pod was first seen in its status.
+	podIPTimestamp time.Time
+
 	// The timestamp for when the pod was initialized.
 	initializedTimestamp time.Time
 
+	// The timestamp for when all the containers of the pod first turned Ready.
+	containersReadyTimestamp time.Time
+
 	// The timestamp for when the pod first turned Ready.
 	readyTimestamp time.Time
 
+	// The timestamp for when the pod address first appeared as ready in an EndpointSlice, and the name of the Service
+	// of the EndpointSlice.
+	endpointReadyTimestamp time.Time
+	endpointService        string
+
+	// The latest version of the pod, used to report statistics for events which do not carry the pod.
+	pod *corev1.Pod
+
 	// List of the container names, in order
 	initContainerNames *immutable.List[string]
 	initContainers     *immutable.Map[string, *InitContainerStatistic]
 	containers         *immutable.Map[string, *NonInitContainerStatistic]
+	// ephemeralContainers are tracked as they are added to the pod, they are not part of the pod lifecycle and do not
+	// affect Partial.
+	ephemeralContainers *immutable.Map[string, *EphemeralContainerStatistic]
+
+	// resources are the resource requirements of the containers in the latest pod spec, used to detect in-place
+	// resizes, and resize is the statistic of the latest resize.
+	resources []corev1.ResourceRequirements
+	resize    *ResizeStatistic
+
+	// imagePulls are the image pulls of the init containers and containers, used to break down the critical path of the
+	// pod.
+	imagePulls *PodImagePullStatistic
+
+	// volumes are only tracked once TrackVolumes is called.
+	volumes *immutable.Map[string, *VolumeStatistic]
+
+	// sandboxCreateFailures and sandboxChanges are the counts of each FailedCreatePodSandBox and SandboxChanged Event,
+	// which are aggregated by the Events API when repeated.
+	sandboxCreateFailures *immutable.Map[apimachinerytypes.UID, int32]
+	sandboxChanges        *immutable.Map[apimachinerytypes.UID, int32]
 }
 
 // NewPodStatistic creates a new PodStatistic instance populated with the containers in the pod.
@@ -45,6 +82,11 @@ func NewPodStatistic(now time.Time, pod *corev1.Pod) *PodStatistic {
 		name:              pod.Name,
 		namespace:         pod.Namespace,
 		creationTimestamp: pod.CreationTimestamp.Time,
+		resources:         containerResources(pod),
+		imagePulls:        NewPodImagePullStatistic(pod),
+
+		sandboxCreateFailures: immutable.NewMap[apimachinerytypes.UID, int32](nil),
+		sandboxChanges:        immutable.NewMap[apimachinerytypes.UID, int32](nil),
 	}
 
 	initContainerNames := immutable.NewListBuilder[string]()
@@ -52,11 +94,7 @@ func NewPodStatistic(now time.Time, pod *corev1.Pod) *PodStatistic {
 
 	for _, container := range pod.Spec.InitContainers {
 		initContainerNames.Append(container.Name)
-		initContainers.Set(container.Name, &InitContainerStatistic{
-			&ContainerStatistic{
-				name: container.Name,
-			},
-		})
+		initContainers.Set(container.Name, newInitContainerStatistic(container))
 	}
 
 	podStatistic.initContainerNames = initContainerNames.List()
@@ -102,6 +140,39 @@ func (s *PodStatistic) Partial() bool {
 	return false
 }
 
+// CreationTimestamp returns the timestamp for when the pod was created.
+func (s *PodStatistic) CreationTimestamp() time.Time {
+	return s.creationTimestamp
+}
+
+// ScheduledTimestamp returns the timestamp for when the pod was scheduled, or the zero time if not yet scheduled.
+func (s *PodStatistic) ScheduledTimestamp() time.Time {
+	return s.scheduledTimestamp
+}
+
+// SandboxReadyTimestamp returns the timestamp for when the pod sandbox was created and its network configured, or the
+// zero time if the sandbox is not yet ready.
+func (s *PodStatistic) SandboxReadyTimestamp() time.Time {
+	return s.sandboxReadyTimestamp
+}
+
+// InitializedTimestamp returns the timestamp for when the pod was initialized, or the zero time if not yet
+// initialized.
+func (s *PodStatistic) InitializedTimestamp() time.Time {
+	return s.initializedTimestamp
+}
+
+// ReadyTimestamp returns the timestamp for when the pod first turned Ready, or the zero time if never Ready.
+func (s *PodStatistic) ReadyTimestamp() time.Time {
+	return s.readyTimestamp
+}
+
+// ImagePulls returns an iterator for each image pull of the init containers and containers of the pod, by container
+// name.
+func (s *PodStatistic) ImagePulls() iter.Seq2[string, *ContainerImagePullStatistic] {
+	return s.imagePulls.Containers()
+}
+
 // InitContainerStatistics returns an iterator for each init container statistic in the pod.
 func (s *PodStatistic) InitContainerStatistics() iter.Seq2[string, *InitContainerStatistic] {
 	return s.EachInitContainerStatistic
@@ -177,6 +248,32 @@ func (s *PodStatistic) EachContainerStatistic(yield func(string, *NonInitContain
 	}
 }
 
+// TimestampSkews returns an iterator for each timestamp field of the init containers and containers of the pod reported
+// by the kubelet.
+func (s *PodStatistic) TimestampSkews() iter.Seq2[string, time.Duration] {
+	return s.EachTimestampSkew
+}
+
+// EachTimestampSkew is an [iter.Seq2] of the timestamp fields of the init containers and containers of the pod reported
+// by the kubelet (string), and the delay until the controller observed them ([time.Duration]).
+func (s *PodStatistic) EachTimestampSkew(yield func(string, time.Duration) bool) {
+	for _, container := range s.InitContainerStatistics() {
+		for field, skew := range container.TimestampSkews() {
+			if !yield(field, skew) {
+				return
+			}
+		}
+	}
+
+	for _, container := range s.ContainerStatistics() {
+		for field, skew := range container.TimestampSkews() {
+			if !yield(field, skew) {
+				return
+			}
+		}
+	}
+}
+
 // MapContainerStatistics applies the given function to each container statistic in the pod.
 // If the function returns false, the iteration is stopped.
 // It returns a new instance of the pod statistic with the updated container statistics.
@@ -202,27 +299,21 @@ func (s *PodStatistic) MapContainerStatistics(
 }
 
 // Report reports the pod statistic to the given output writer.
-func (s *PodStatistic) Report(output io.Writer, pod *corev1.Pod) {
+// The labels of the provided labelers are added to the pod and container metric records.
+func (s *PodStatistic) Report(output io.Writer, pod *corev1.Pod, labelers ...PodLabeler) {
 	logger := s.logger()
 
 	metrics := zerolog.Dict().
 		Bool("partial", s.Partial()).
-		Func(commonPodLabels(pod)).
+		Func(commonPodLabels(pod, labelers)).
 		Dict("pod", s.event())
 	logMetrics(output, "pod", metrics, "")
 
-	initContainers := s.initContainers.Iterator()
-
+	// The init containers are reported in the order of the pod spec, as each is compared with the previous one.
 	var previous *InitContainerStatistic
 
-	for !initContainers.Done() {
-		_, containerStatistics, ok := initContainers.Next()
-		if !ok {
-			// This should never happen as we're checking `.Done()` on the iterator.
-			logger.Panic().Msg("init container statistics not found")
-		}
-
-		containerStatistics.Report(output, pod, s, previous)
+	for _, containerStatistics := range s.InitContainerStatistics() {
+		containerStatistics.Report(output, pod, s, previous, labelers...)
 		previous = containerStatistics
 	}
 
@@ -234,7 +325,11 @@ func (s *PodStatistic) Report(output io.Writer, pod *corev1.Pod) {
 			logger.Panic().Msg("container statistics not found")
 		}
 
-		containerStatistics.Report(output, pod, s)
+		containerStatistics.Report(output, pod, s, labelers...)
+	}
+
+	for _, volumeStatistics := range s.VolumeStatistics() {
+		volumeStatistics.Report(output, pod, s, labelers...)
 	}
 }
 
@@ -244,6 +339,7 @@ func (s *PodStatistic) Update(now time.Time, pod *corev1.Pod) *PodStatistic {
 	// We will return a copy of the pod statistic, so that we can safely update the pod statistic in the event loop.
 	// As this type is immutable, we should shadow the receiver.
 	s = s.Copy()
+	s.pod = pod
 
 	logger := s.logger()
 
@@ -254,16 +350,24 @@ func (s *PodStatistic) Update(now time.Time, pod *corev1.Pod) *PodStatistic {
 			continue
 		}
 
-		// TODO: include core/v1.ContainersReady and core/v1.DisruptionTarget
+		// TODO: include core/v1.DisruptionTarget
 		switch condition.Type { //nolint:exhaustive
 		case corev1.PodScheduled:
 			if s.scheduledTimestamp.IsZero() {
 				s.scheduledTimestamp = condition.LastTransitionTime.Time
 			}
+		case corev1.PodReadyToStartContainers:
+			if s.sandboxReadyTimestamp.IsZero() {
+				s.sandboxReadyTimestamp = condition.LastTransitionTime.Time
+			}
 		case corev1.PodInitialized:
 			if s.initializedTimestamp.IsZero() {
 				s.initializedTimestamp = condition.LastTransitionTime.Time
 			}
+		case corev1.ContainersReady:
+			if s.containersReadyTimestamp.IsZero() {
+				s.containersReadyTimestamp = condition.LastTransitionTime.Time
+			}
 		case corev1.PodReady:
 			if s.readyTimestamp.IsZero() {
 				s.readyTimestamp = condition.LastTransitionTime.Time
@@ -271,7 +375,14 @@ func (s *PodStatistic) Update(now time.Time, pod *corev1.Pod) *PodStatistic {
 		}
 	}
 
+	if s.podIPTimestamp.IsZero() && (pod.Status.PodIP != "" || len(pod.Status.PodIPs) > 0) {
+		s.podIPTimestamp = now
+	}
+
 	s = s.updateContainers(now, pod)
+	s = s.updateEphemeralContainers(now, pod)
+	s = s.updateResize(now, pod)
+	s = s.updateVolumes()
 
 	return s
 }
@@ -302,6 +413,8 @@ func (s *PodStatistic) event() *zerolog.Event {
 		event.Dur("creation_to_scheduled_seconds", s.scheduledTimestamp.Sub(s.creationTimestamp))
 	}
 
+	s.sandboxEvent(event)
+
 	if !s.initializedTimestamp.IsZero() {
 		event.Time("initialized_timestamp", s.initializedTimestamp)
 		event.Dur("creation_to_initialized_seconds", s.initializedTimestamp.Sub(s.creationTimestamp))
@@ -320,6 +433,10 @@ func (s *PodStatistic) event() *zerolog.Event {
 		}
 	}
 
+	if criticalPath := s.criticalPathEvent(); criticalPath != nil {
+		event.Dict("critical_path", criticalPath)
+	}
+
 	return event
 }
 
@@ -468,3 +585,23 @@ func (eh *PodStatistics) Delete(uid apimachinerytypes.UID) *PodStatistics {
 func (eh *PodStatistics) IsBlacklisted(uid apimachinerytypes.UID) bool {
 	return eh.blacklistUIDs.Has(uid)
 }
+
+// firstRunningTimestamp returns the earliest time an init container or container of the pod was seen running, or the
+// zero time if none was.
+func (s *PodStatistic) firstRunningTimestamp() time.Time {
+	var running time.Time
+
+	for _, container := range s.InitContainerStatistics() {
+		if !container.runningTimestamp.IsZero() && (running.IsZero() || container.runningTimestamp.Before(running)) {
+			running = container.runningTimestamp
+		}
+	}
+
+	for _, container := range s.ContainerStatistics() {
+		if !container.runningTimestamp.IsZero() && (running.IsZero() || container.runningTimestamp.Before(running)) {
+			running = container.runningTimestamp
+		}
+	}
+
+	return running
+}
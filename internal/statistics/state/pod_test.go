@@ -11,6 +11,7 @@ import (
 	"github.com/BackMarket-oss/kube-transition-metrics/internal/options"
 	"github.com/BackMarket-oss/kube-transition-metrics/internal/testhelpers"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
@@ -237,3 +238,223 @@ func TestContainerStatisticUpdate(t *testing.T) {
 	assert.NotZero(t,
 		containerStat.readyTimestamp, "readyTimestamp was not set")
 }
+
+func TestContainerStatisticAuthoritativeTimestamps(t *testing.T) {
+	testhelpers.ConfigureLogging(t, &options.Options{})
+
+	created := time.Date(2023, 8, 28, 0, 0, 0, 0, time.UTC)
+	pod := &corev1.Pod{Spec: corev1.PodSpec{
+		InitContainers: []corev1.Container{{Name: "test-init-container"}},
+		Containers:     []corev1.Container{{Name: "test-container"}},
+	}}
+	podStat := NewPodStatistic(created, pod)
+
+	// The init container completed before it was ever observed Running.
+	initContainerStat, ok := podStat.initContainers.Get("test-init-container")
+	require.True(t, ok)
+	initContainerStat = initContainerStat.Update(created.Add(10*time.Second), corev1.ContainerStatus{
+		Name: "test-init-container",
+		State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{
+			StartedAt:  metav1.NewTime(created.Add(2 * time.Second)),
+			FinishedAt: metav1.NewTime(created.Add(4 * time.Second)),
+		}},
+		Ready: true,
+	}, podStat)
+
+	assert.Equal(t, created.Add(2*time.Second), initContainerStat.runningTimestamp)
+	assert.Equal(t, created.Add(4*time.Second), initContainerStat.readyTimestamp)
+	assert.False(t, initContainerStat.Partial(), "Expected init container to be complete")
+
+	skews := map[string]time.Duration{}
+	for field, skew := range initContainerStat.TimestampSkews() {
+		skews[field] = skew
+	}
+
+	assert.Equal(t, map[string]time.Duration{
+		"running_timestamp": 8 * time.Second,
+		"ready_timestamp":   6 * time.Second,
+	}, skews)
+
+	// The container is Ready, which is only observed by the controller.
+	containerStat, ok := podStat.containers.Get("test-container")
+	require.True(t, ok)
+	containerStat = containerStat.Update(created.Add(12*time.Second), corev1.ContainerStatus{
+		Name: "test-container",
+		State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{
+			StartedAt: metav1.NewTime(created.Add(5 * time.Second)),
+		}},
+		Ready: true,
+	}, podStat)
+
+	assert.Equal(t, created.Add(5*time.Second), containerStat.runningTimestamp)
+	assert.Equal(t, created.Add(12*time.Second), containerStat.readyTimestamp)
+	assert.True(t, containerStat.runningAuthoritative)
+	assert.False(t, containerStat.readyAuthoritative)
+}
+
+func TestContainerStatisticMixedTimestampSources(t *testing.T) {
+	testhelpers.ConfigureLogging(t, &options.Options{})
+
+	created := time.Date(2023, 8, 28, 0, 0, 0, 0, time.UTC)
+	newPod := func(status corev1.ContainerStatus, conditions ...corev1.PodCondition) *corev1.Pod {
+		return &corev1.Pod{
+			Spec:   corev1.PodSpec{Containers: []corev1.Container{{Name: "test-container"}}},
+			Status: corev1.PodStatus{Conditions: conditions, ContainerStatuses: []corev1.ContainerStatus{status}},
+		}
+	}
+	running := func(startedAt time.Duration) corev1.ContainerState {
+		return corev1.ContainerState{Running: &corev1.ContainerStateRunning{
+			StartedAt: metav1.NewTime(created.Add(startedAt)),
+		}}
+	}
+
+	t.Run("ContainersReady", func(t *testing.T) {
+		// The container is first observed started and Ready after the ContainersReady condition transitioned.
+		pod := newPod(
+			corev1.ContainerStatus{Name: "test-container", State: running(5 * time.Second), Started: new(true), Ready: true},
+			corev1.PodCondition{
+				Type:               corev1.ContainersReady,
+				Status:             corev1.ConditionTrue,
+				LastTransitionTime: metav1.NewTime(created.Add(7 * time.Second)),
+			},
+		)
+		stat := NewPodStatistic(created, &corev1.Pod{Spec: pod.Spec}).Update(created.Add(12*time.Second), pod)
+
+		containerStat, ok := stat.containers.Get("test-container")
+		require.True(t, ok)
+		assert.Equal(t, created.Add(5*time.Second), containerStat.runningTimestamp)
+		assert.Equal(t, created.Add(7*time.Second), containerStat.readyTimestamp,
+			"Expected the ready timestamp to be taken from the ContainersReady condition")
+		assert.True(t, containerStat.readyAuthoritative)
+		assert.Equal(t, 5*time.Second, containerStat.readySkew)
+		assert.Equal(t, created.Add(7*time.Second), containerStat.startedTimestamp,
+			"Expected the observed started timestamp to be clamped to the ready timestamp")
+	})
+
+	t.Run("node clock ahead", func(t *testing.T) {
+		// The clock of the node is ahead of the clock of the controller.
+		status := corev1.ContainerStatus{Name: "test-container", State: running(13 * time.Second), Started: new(true)}
+		stat := NewPodStatistic(created, &corev1.Pod{Spec: newPod(status).Spec}).
+			Update(created.Add(12*time.Second), newPod(status))
+
+		status.Ready = true
+		stat = stat.Update(created.Add(12500*time.Millisecond), newPod(status))
+
+		containerStat, ok := stat.containers.Get("test-container")
+		require.True(t, ok)
+		assert.Equal(t, created.Add(13*time.Second), containerStat.runningTimestamp)
+		assert.Equal(t, created.Add(13*time.Second), containerStat.startedTimestamp,
+			"Expected the observed started timestamp to be clamped to the running timestamp")
+		assert.Equal(t, created.Add(13*time.Second), containerStat.readyTimestamp,
+			"Expected the observed ready timestamp to be clamped to the running timestamp")
+	})
+}
+
+func TestContainerStatisticStartedToReady(t *testing.T) {
+	testhelpers.ConfigureLogging(t, &options.Options{})
+
+	created := time.Date(2023, 8, 28, 0, 0, 0, 0, time.UTC)
+	pod := newTestingPod(created)
+	pod.Status.ContainerStatuses = nil
+	stat := NewPodStatistic(created, pod)
+
+	update := func(now time.Time, status corev1.ContainerStatus) {
+		pod = pod.DeepCopy()
+		pod.Status.ContainerStatuses = []corev1.ContainerStatus{status}
+		stat = stat.Update(now, pod)
+	}
+	running := corev1.ContainerState{Running: &corev1.ContainerStateRunning{}}
+
+	update(created.Add(1*time.Second), corev1.ContainerStatus{Name: "test-container", State: running, Started: new(false)})
+	update(created.Add(3*time.Second), corev1.ContainerStatus{Name: "test-container", State: running, Started: new(true)})
+	update(created.Add(8*time.Second),
+		corev1.ContainerStatus{Name: "test-container", State: running, Started: new(true), Ready: true})
+
+	writer := testhelpers.NewMetricWriter(t)
+	stat.Report(writer, pod)
+
+	var container map[string]any
+
+	for _, metric := range testhelpers.DecodeMetricOutput(t, writer) {
+		if metric["type"] == "container" {
+			container, _ = metric["container"].(map[string]any)
+		}
+	}
+
+	require.NotNil(t, container, "Expected a container metric")
+	assert.InDelta(t, 2, container["running_to_started_seconds"], 1e-5)
+	// The duration is measured from the container becoming started, not running.
+	assert.InDelta(t, 5, container["started_to_ready_seconds"], 1e-5)
+	assert.InDelta(t, 7, container["running_to_ready_seconds"], 1e-5)
+}
+
+func TestSidecarInitContainerStatistic(t *testing.T) {
+	testhelpers.ConfigureLogging(t, &options.Options{})
+
+	created := time.Date(2023, 8, 28, 0, 0, 0, 0, time.UTC)
+	pod := newTestingPod(created)
+	pod.Spec.InitContainers = []corev1.Container{
+		{Name: "migrate", Image: "migrate"},
+		{Name: "proxy", Image: "proxy", RestartPolicy: new(corev1.ContainerRestartPolicyAlways)},
+		{Name: "warmup", Image: "warmup"},
+	}
+
+	stat := NewPodStatistic(created, pod)
+
+	migrate, _ := stat.initContainers.Get("migrate")
+	proxy, _ := stat.initContainers.Get("proxy")
+	assert.False(t, migrate.Sidecar(), "Expected regular init container to not be a sidecar")
+	assert.True(t, proxy.Sidecar(), "Expected restartable init container to be a sidecar")
+
+	update := func(now time.Time, statuses ...corev1.ContainerStatus) {
+		pod = pod.DeepCopy()
+		pod.Status.InitContainerStatuses = statuses
+		stat = stat.Update(now, pod)
+	}
+	running := corev1.ContainerState{Running: &corev1.ContainerStateRunning{}}
+	terminated := corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{}}
+
+	update(created.Add(1*time.Second), corev1.ContainerStatus{Name: "migrate", State: running})
+	update(created.Add(2*time.Second),
+		corev1.ContainerStatus{Name: "migrate", State: terminated, Ready: true},
+		corev1.ContainerStatus{Name: "proxy", State: running, Started: new(false)})
+	update(created.Add(4*time.Second),
+		corev1.ContainerStatus{Name: "migrate", State: terminated, Ready: true},
+		corev1.ContainerStatus{Name: "proxy", State: running, Started: new(true)},
+		corev1.ContainerStatus{Name: "warmup", State: running})
+
+	migrate, _ = stat.initContainers.Get("migrate")
+	proxy, _ = stat.initContainers.Get("proxy")
+	assert.False(t, migrate.Partial(), "Expected init container which exited to be complete without Started")
+	assert.True(t, proxy.Partial(), "Expected sidecar which is not yet Ready to be partial")
+
+	update(created.Add(7*time.Second),
+		corev1.ContainerStatus{Name: "migrate", State: terminated, Ready: true},
+		corev1.ContainerStatus{Name: "proxy", State: running, Started: new(true), Ready: true},
+		corev1.ContainerStatus{Name: "warmup", State: terminated, Ready: true})
+
+	proxy, _ = stat.initContainers.Get("proxy")
+	assert.False(t, proxy.Partial(), "Expected sidecar which is Ready to be complete without terminating")
+
+	writer := testhelpers.NewMetricWriter(t)
+	stat.Report(writer, pod)
+
+	containers := map[string]map[string]any{}
+
+	for _, metric := range testhelpers.DecodeMetricOutput(t, writer) {
+		if container, ok := metric["container"].(map[string]any); ok && container["init_container"] == true {
+			name, _ := metric["container_name"].(string)
+			containers[name] = container
+		}
+	}
+
+	require.Len(t, containers, 3)
+	assert.Equal(t, false, containers["migrate"]["sidecar"])
+	assert.NotContains(t, containers["migrate"], "previous_to_running_seconds")
+	assert.Equal(t, true, containers["proxy"]["sidecar"])
+	assert.InDelta(t, 0, containers["proxy"]["previous_to_running_seconds"], 1e-5)
+	assert.InDelta(t, 2, containers["proxy"]["running_to_started_seconds"], 1e-5)
+	assert.InDelta(t, 3, containers["proxy"]["started_to_ready_seconds"], 1e-5)
+	// The container following a sidecar starts once the sidecar is Started, not Ready.
+	assert.InDelta(t, 0, containers["warmup"]["previous_to_running_seconds"], 1e-5)
+}
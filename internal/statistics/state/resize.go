@@ -0,0 +1,291 @@
+package state
+
+import (
+	"io"
+	"slices"
+	"time"
+
+	"github.com/Izzette/go-safeconcurrency/eventloop/snapshot"
+	"github.com/rs/zerolog"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+)
+
+// ResizeStatistic holds the statistics of an in-place resize of the resources of the containers of a pod, from the
+// time the resize was requested to the time the resources were applied, or found infeasible.
+// ResizeStatistic is immutable, all the methods return a new instance of the struct.
+// Do not lose track of the returned instance, it should be assigned to the containing structure.
+type ResizeStatistic struct {
+	// The timestamp for when the change of the resources in the pod spec was first seen.
+	requestedTimestamp time.Time
+	// The timestamp for when the kubelet started actuating the resize (PodResizeInProgress condition).
+	inProgressTimestamp time.Time
+	// The timestamp for when the kubelet first deferred the resize, as the node does not currently have the resources.
+	deferredTimestamp time.Time
+	// The timestamp for when the kubelet found the resize infeasible, as the node can never have the resources.
+	infeasibleTimestamp time.Time
+	// The timestamp for when the allocated resources were seen to match the pod spec.
+	appliedTimestamp time.Time
+
+	// message is the message of the latest PodResizePending condition, explaining why the resize is deferred or
+	// infeasible.
+	message string
+}
+
+// newResizeStatistic creates a new ResizeStatistic for a resize requested at now.
+func newResizeStatistic(now time.Time) *ResizeStatistic {
+	return &ResizeStatistic{requestedTimestamp: now}
+}
+
+// Partial indicates if the resize was neither applied nor found infeasible.
+func (s *ResizeStatistic) Partial() bool {
+	return s.appliedTimestamp.IsZero() && s.infeasibleTimestamp.IsZero()
+}
+
+// Outcome returns the outcome of the resize: applied, infeasible, deferred, in_progress or pending.
+func (s *ResizeStatistic) Outcome() string {
+	switch {
+	case !s.appliedTimestamp.IsZero():
+		return "applied"
+	case !s.infeasibleTimestamp.IsZero():
+		return "infeasible"
+	case !s.deferredTimestamp.IsZero():
+		return "deferred"
+	case !s.inProgressTimestamp.IsZero():
+		return "in_progress"
+	default:
+		return "pending"
+	}
+}
+
+// Update updates the resize statistic from the resize conditions and the allocated resources of the pod.
+// It returns a new instance of the resize statistic, or the receiver if nothing changed.
+func (s *ResizeStatistic) Update(now time.Time, pod *corev1.Pod) *ResizeStatistic {
+	previous := s
+
+	// As this type is immutable, we should shadow the receiver.
+	s = snapshot.CopyPtr(s)
+
+	pending, inProgress := false, false
+
+	for _, condition := range pod.Status.Conditions {
+		if condition.Status != corev1.ConditionTrue {
+			continue
+		}
+
+		switch condition.Type { //nolint:exhaustive
+		case corev1.PodResizePending:
+			pending = true
+			s.message = condition.Message
+			s.pending(condition.Reason, s.conditionTimestamp(now, condition))
+		case corev1.PodResizeInProgress:
+			inProgress = true
+
+			if s.inProgressTimestamp.IsZero() {
+				s.inProgressTimestamp = s.conditionTimestamp(now, condition)
+			}
+		}
+	}
+
+	// Before Kubernetes 1.33, the resize status is only reported in status.resize.
+	switch pod.Status.Resize { //nolint:staticcheck,exhaustive // Fallback for older clusters.
+	case corev1.PodResizeStatusDeferred:
+		pending = true
+		s.pending(corev1.PodReasonDeferred, now)
+	case corev1.PodResizeStatusInfeasible:
+		pending = true
+		s.pending(corev1.PodReasonInfeasible, now)
+	case corev1.PodResizeStatusInProgress:
+		inProgress = true
+
+		if s.inProgressTimestamp.IsZero() {
+			s.inProgressTimestamp = now
+		}
+	}
+
+	if !pending && !inProgress && resourcesApplied(pod) {
+		s.appliedTimestamp = now
+	}
+
+	if *s == *previous {
+		return previous
+	}
+
+	return s
+}
+
+// Report reports the resize statistic of the pod to the given output writer.
+// The labels of the provided labelers are added to the metric record.
+func (s *ResizeStatistic) Report(output io.Writer, pod *corev1.Pod, labelers ...PodLabeler) {
+	metrics := zerolog.Dict().
+		Bool("partial", s.Partial()).
+		Func(commonPodLabels(pod, labelers)).
+		Dict("resize", s.event())
+	logMetrics(output, "resize", metrics, "")
+}
+
+// pending records the first time the resize was deferred or found infeasible, from the reason of the
+// PodResizePending condition.
+func (s *ResizeStatistic) pending(reason string, timestamp time.Time) {
+	switch reason {
+	case corev1.PodReasonDeferred:
+		if s.deferredTimestamp.IsZero() {
+			s.deferredTimestamp = timestamp
+		}
+	case corev1.PodReasonInfeasible:
+		if s.infeasibleTimestamp.IsZero() {
+			s.infeasibleTimestamp = timestamp
+		}
+	}
+}
+
+// conditionTimestamp returns the last transition time of the condition, or now if it is not set.
+// Conditions left over from a previous resize transitioned before the resize was requested, the requested timestamp
+// is returned for them so that durations are never negative.
+func (s *ResizeStatistic) conditionTimestamp(now time.Time, condition corev1.PodCondition) time.Time {
+	switch {
+	case condition.LastTransitionTime.IsZero():
+		return now
+	case condition.LastTransitionTime.Time.Before(s.requestedTimestamp):
+		return s.requestedTimestamp
+	default:
+		return condition.LastTransitionTime.Time
+	}
+}
+
+// event returns the event dictionary for the resize statistic.
+func (s *ResizeStatistic) event() *zerolog.Event {
+	event := zerolog.Dict()
+
+	event.Str("outcome", s.Outcome())
+	event.Time("requested_timestamp", s.requestedTimestamp)
+
+	if !s.inProgressTimestamp.IsZero() {
+		event.Time("in_progress_timestamp", s.inProgressTimestamp)
+		event.Dur("requested_to_in_progress_seconds", s.inProgressTimestamp.Sub(s.requestedTimestamp))
+	}
+
+	if !s.deferredTimestamp.IsZero() {
+		event.Time("deferred_timestamp", s.deferredTimestamp)
+		event.Dur("requested_to_deferred_seconds", s.deferredTimestamp.Sub(s.requestedTimestamp))
+	}
+
+	if !s.infeasibleTimestamp.IsZero() {
+		event.Time("infeasible_timestamp", s.infeasibleTimestamp)
+		event.Dur("requested_to_infeasible_seconds", s.infeasibleTimestamp.Sub(s.requestedTimestamp))
+	}
+
+	if !s.appliedTimestamp.IsZero() {
+		event.Time("applied_timestamp", s.appliedTimestamp)
+		event.Dur("requested_to_applied_seconds", s.appliedTimestamp.Sub(s.requestedTimestamp))
+	}
+
+	if s.message != "" {
+		event.Str("pending_message", s.message)
+	}
+
+	return event
+}
+
+// ResizeStatistic returns the statistic of the latest in-place resize of the pod, if any.
+func (s *PodStatistic) ResizeStatistic() (*ResizeStatistic, bool) {
+	return s.resize, s.resize != nil
+}
+
+// ResizePending indicates if the resources of the pod spec were changed, or the latest resize is neither applied nor
+// infeasible, so the pod must still be updated after the pod statistic is complete.
+func (s *PodStatistic) ResizePending(pod *corev1.Pod) bool {
+	if s.resize != nil && s.resize.Partial() {
+		return true
+	}
+
+	return !equality.Semantic.DeepEqual(s.resources, containerResources(pod))
+}
+
+// updateResize starts tracking a new resize when the resources of the pod spec change, and updates the latest resize.
+// It returns a new instance of the pod statistic with the updated values.
+func (s *PodStatistic) updateResize(now time.Time, pod *corev1.Pod) *PodStatistic {
+	resize := s.resize
+	resources := containerResources(pod)
+
+	if !equality.Semantic.DeepEqual(s.resources, resources) {
+		resize = newResizeStatistic(now)
+	}
+
+	if resize != nil && resize.Partial() {
+		resize = resize.Update(now, pod)
+	}
+
+	// As this type is immutable, we should shadow the receiver.
+	s = s.Copy()
+	s.resources = resources
+	s.resize = resize
+
+	return s
+}
+
+// containerResources returns the resource requirements of the init containers and containers of the pod spec.
+func containerResources(pod *corev1.Pod) []corev1.ResourceRequirements {
+	resources := make([]corev1.ResourceRequirements, 0, len(pod.Spec.InitContainers)+len(pod.Spec.Containers))
+
+	for _, container := range pod.Spec.InitContainers {
+		resources = append(resources, container.Resources)
+	}
+
+	for _, container := range pod.Spec.Containers {
+		resources = append(resources, container.Resources)
+	}
+
+	return resources
+}
+
+// resourcesApplied indicates if the CPU and memory allocated to the containers, and configured for them by the
+// container runtime, match the pod spec.
+// Resources which are not reported in the container statuses are assumed to match.
+func resourcesApplied(pod *corev1.Pod) bool {
+	statuses := make(map[string]corev1.ContainerStatus, len(pod.Status.InitContainerStatuses)+
+		len(pod.Status.ContainerStatuses))
+	for _, status := range pod.Status.InitContainerStatuses {
+		statuses[status.Name] = status
+	}
+
+	for _, status := range pod.Status.ContainerStatuses {
+		statuses[status.Name] = status
+	}
+
+	for _, container := range slices.Concat(pod.Spec.InitContainers, pod.Spec.Containers) {
+		status, ok := statuses[container.Name]
+		if !ok {
+			continue
+		}
+
+		if status.AllocatedResources != nil &&
+			!resourceListApplied(container.Resources.Requests, status.AllocatedResources) {
+			return false
+		}
+
+		if status.Resources != nil &&
+			(!resourceListApplied(container.Resources.Requests, status.Resources.Requests) ||
+				!resourceListApplied(container.Resources.Limits, status.Resources.Limits)) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// resourceListApplied indicates if the CPU and memory of the actual resource list match the ones of the spec.
+func resourceListApplied(spec, actual corev1.ResourceList) bool {
+	for _, name := range []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory} {
+		want, ok := spec[name]
+		if !ok {
+			continue
+		}
+
+		if got, ok := actual[name]; !ok || want.Cmp(got) != 0 {
+			return false
+		}
+	}
+
+	return true
+}
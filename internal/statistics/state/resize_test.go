@@ -0,0 +1,117 @@
+package state
+
+import (
+	"testing"
+	"time"
+
+	"github.com/BackMarket-oss/kube-transition-metrics/internal/options"
+	"github.com/BackMarket-oss/kube-transition-metrics/internal/testhelpers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// withCPU returns a copy of the pod with the CPU request of its container set in the spec and allocated in the status.
+func withCPU(pod *corev1.Pod, spec, allocated string) *corev1.Pod {
+	pod = pod.DeepCopy()
+	pod.Spec.Containers[0].Resources.Requests = corev1.ResourceList{corev1.ResourceCPU: resource.MustParse(spec)}
+	pod.Status.ContainerStatuses = []corev1.ContainerStatus{{
+		Name:               pod.Spec.Containers[0].Name,
+		AllocatedResources: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse(allocated)},
+	}}
+
+	return pod
+}
+
+func TestResizeStatisticApplied(t *testing.T) {
+	testhelpers.ConfigureLogging(t, &options.Options{})
+
+	created := time.Date(2023, 8, 28, 0, 0, 0, 0, time.UTC)
+	pod := withCPU(newTestingPod(created), "100m", "100m")
+
+	stat := NewPodStatistic(created, pod)
+	_, ok := stat.ResizeStatistic()
+	assert.False(t, ok, "Expected no resize for a new pod")
+	assert.False(t, stat.ResizePending(pod), "Expected no resize pending for unchanged resources")
+
+	resized := withCPU(pod, "200m", "100m")
+	require.True(t, stat.ResizePending(resized), "Expected resize pending when the resources of the spec change")
+
+	stat = stat.Update(created.Add(time.Minute), resized)
+	resize, ok := stat.ResizeStatistic()
+	require.True(t, ok, "Expected resize to be tracked")
+	assert.Equal(t, "pending", resize.Outcome())
+
+	resized = withCPU(pod, "200m", "200m")
+	resized.Status.Conditions = append(resized.Status.Conditions, corev1.PodCondition{
+		Type:               corev1.PodResizeInProgress,
+		Status:             corev1.ConditionTrue,
+		LastTransitionTime: metav1.NewTime(created.Add(time.Minute + time.Second)),
+	})
+	stat = stat.Update(created.Add(time.Minute+2*time.Second), resized)
+	resize, _ = stat.ResizeStatistic()
+	assert.Equal(t, "in_progress", resize.Outcome())
+	assert.True(t, resize.Partial(), "Expected resize in progress to be partial")
+	assert.Same(t, resize, resize.Update(created.Add(time.Minute+2*time.Second), resized),
+		"Expected unchanged resize to not be copied")
+
+	stat = stat.Update(created.Add(time.Minute+3*time.Second), withCPU(pod, "200m", "200m"))
+	resize, _ = stat.ResizeStatistic()
+	assert.False(t, resize.Partial(), "Expected applied resize to be complete")
+	assert.False(t, stat.ResizePending(withCPU(pod, "200m", "200m")), "Expected no resize pending once applied")
+
+	writer := testhelpers.NewMetricWriter(t)
+	resize.Report(writer, resized)
+
+	metrics := testhelpers.DecodeMetricOutput(t, writer)
+	require.Len(t, metrics, 1)
+	assert.Equal(t, "resize", metrics[0]["type"])
+
+	event, ok := metrics[0]["resize"].(map[string]any)
+	require.True(t, ok, "Expected resize to be an object")
+	assert.Equal(t, "applied", event["outcome"])
+	assert.InDelta(t, 1.0, event["requested_to_in_progress_seconds"], 0.001)
+	assert.InDelta(t, 3.0, event["requested_to_applied_seconds"], 0.001)
+}
+
+func TestResizeStatisticInfeasible(t *testing.T) {
+	testhelpers.ConfigureLogging(t, &options.Options{})
+
+	created := time.Date(2023, 8, 28, 0, 0, 0, 0, time.UTC)
+	pod := withCPU(newTestingPod(created), "100m", "100m")
+	stat := NewPodStatistic(created, pod)
+
+	resized := withCPU(pod, "64", "100m")
+	resized.Status.Conditions = append(resized.Status.Conditions, corev1.PodCondition{
+		Type:    corev1.PodResizePending,
+		Status:  corev1.ConditionTrue,
+		Reason:  corev1.PodReasonDeferred,
+		Message: "Node didn't have enough capacity",
+	})
+	stat = stat.Update(created.Add(time.Minute), resized)
+
+	resize, _ := stat.ResizeStatistic()
+	assert.Equal(t, "deferred", resize.Outcome())
+	assert.True(t, resize.Partial(), "Expected deferred resize to be partial")
+
+	resized.Status.Conditions[len(resized.Status.Conditions)-1].Reason = corev1.PodReasonInfeasible
+	stat = stat.Update(created.Add(2*time.Minute), resized)
+
+	resize, _ = stat.ResizeStatistic()
+	assert.Equal(t, "infeasible", resize.Outcome())
+	assert.False(t, resize.Partial(), "Expected infeasible resize to be complete")
+
+	writer := testhelpers.NewMetricWriter(t)
+	resize.Report(writer, resized)
+
+	metrics := testhelpers.DecodeMetricOutput(t, writer)
+	require.Len(t, metrics, 1)
+
+	event, ok := metrics[0]["resize"].(map[string]any)
+	require.True(t, ok, "Expected resize to be an object")
+	assert.InDelta(t, 0.0, event["requested_to_deferred_seconds"], 0.001)
+	assert.InDelta(t, 60.0, event["requested_to_infeasible_seconds"], 0.001)
+	assert.Equal(t, "Node didn't have enough capacity", event["pending_message"])
+}
@@ -0,0 +1,285 @@
+package state
+
+import (
+	"io"
+	"math"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Izzette/go-safeconcurrency/eventloop/snapshot"
+	"github.com/benbjohnson/immutable"
+	"github.com/rs/zerolog"
+	corev1 "k8s.io/api/core/v1"
+	apimachinerytypes "k8s.io/apimachinery/pkg/types"
+)
+
+// workloadKindFields maps the kinds of the workloads to the fields of the metric records.
+//
+//nolint:gochecknoglobals // This is a constant map of workload kinds to metric labels.
+var workloadKindFields = map[string]string{
+	"DaemonSet":   "kube_daemon_set",
+	"Deployment":  "kube_deployment",
+	"StatefulSet": "kube_stateful_set",
+}
+
+// rolloutPod holds the statistics of a pod participating in a rollout.
+type rolloutPod struct {
+	name string
+	// statistic is the complete pod statistic, it is nil until the pod becomes Ready.
+	statistic *PodStatistic
+	// imagePull is the total duration of the image pulls of the pod.
+	imagePull time.Duration
+}
+
+// RolloutStatistic holds the aggregated statistics of the pods participating in a rollout of a workload.
+// RolloutStatistic is immutable, all the methods return a new instance of the struct.
+// Do not lose track of the returned instance, it should be assigned to the containing structure.
+type RolloutStatistic struct {
+	kind      string
+	namespace string
+	name      string
+	revision  string
+
+	// The timestamp for when the rollout was detected.
+	startedTimestamp time.Time
+	// The timestamp for when the rollout completed or stalled.
+	finishedTimestamp time.Time
+	// stalled is true if the rollout exceeded its progress deadline.
+	stalled bool
+
+	pods *immutable.Map[apimachinerytypes.UID, *rolloutPod]
+}
+
+// NewRolloutStatistic creates a new RolloutStatistic for a rollout of the workload to the revision.
+func NewRolloutStatistic(kind, namespace, name, revision string, started time.Time) *RolloutStatistic {
+	return &RolloutStatistic{
+		kind:             kind,
+		namespace:        namespace,
+		name:             name,
+		revision:         revision,
+		startedTimestamp: started,
+		pods:             immutable.NewMap[apimachinerytypes.UID, *rolloutPod](nil),
+	}
+}
+
+// Copy implements [github.com/Izzette/go-safeconcurrency/types.Copyable.Copy].
+func (s *RolloutStatistic) Copy() *RolloutStatistic {
+	return snapshot.CopyPtr(s)
+}
+
+// Workload returns the kind, namespace and name of the workload of the rollout.
+func (s *RolloutStatistic) Workload() (string, string, string) {
+	return s.kind, s.namespace, s.name
+}
+
+// Revision returns the revision of the workload the rollout is rolling out.
+func (s *RolloutStatistic) Revision() string {
+	return s.revision
+}
+
+// WithRevision returns a new instance of the rollout statistic for the revision.
+func (s *RolloutStatistic) WithRevision(revision string) *RolloutStatistic {
+	s = s.Copy()
+	s.revision = revision
+
+	return s
+}
+
+// StartedTimestamp returns the timestamp for when the rollout was detected.
+func (s *RolloutStatistic) StartedTimestamp() time.Time {
+	return s.startedTimestamp
+}
+
+// FinishedTimestamp returns the timestamp for when the rollout completed or stalled, or the zero time if it did not
+// finish.
+func (s *RolloutStatistic) FinishedTimestamp() time.Time {
+	return s.finishedTimestamp
+}
+
+// HasPod indicates if the pod is participating in the rollout.
+func (s *RolloutStatistic) HasPod(uid apimachinerytypes.UID) bool {
+	_, ok := s.pods.Get(uid)
+
+	return ok
+}
+
+// PodCreated adds the pod to the rollout.
+// It returns a new instance of the rollout statistic, or the receiver if the pod was already added.
+func (s *RolloutStatistic) PodCreated(pod *corev1.Pod) *RolloutStatistic {
+	if s.HasPod(pod.UID) {
+		return s
+	}
+
+	s = s.Copy()
+	s.pods = s.pods.Set(pod.UID, &rolloutPod{name: pod.Name})
+
+	return s
+}
+
+// PodReady records the complete statistic of the pod, adding the pod to the rollout if needed.
+// It returns a new instance of the rollout statistic.
+func (s *RolloutStatistic) PodReady(pod *corev1.Pod, statistic *PodStatistic) *RolloutStatistic {
+	s = s.PodCreated(pod).Copy()
+
+	previous, _ := s.pods.Get(pod.UID)
+	next := *previous
+	next.statistic = statistic
+	s.pods = s.pods.Set(pod.UID, &next)
+
+	return s
+}
+
+// ImagePulled adds the duration of an image pull to the pod, it returns the receiver if the pod is not participating in
+// the rollout.
+// It returns a new instance of the rollout statistic.
+func (s *RolloutStatistic) ImagePulled(uid apimachinerytypes.UID, duration time.Duration) *RolloutStatistic {
+	previous, ok := s.pods.Get(uid)
+	if !ok {
+		return s
+	}
+
+	s = s.Copy()
+	next := *previous
+	next.imagePull += duration
+	s.pods = s.pods.Set(uid, &next)
+
+	return s
+}
+
+// Finish marks the rollout as complete, or stalled if it exceeded its progress deadline.
+// It returns a new instance of the rollout statistic.
+func (s *RolloutStatistic) Finish(now time.Time, stalled bool) *RolloutStatistic {
+	s = s.Copy()
+	s.finishedTimestamp = now
+	s.stalled = stalled
+
+	return s
+}
+
+// Partial indicates if the rollout did not complete, because it stalled, was superseded by another rollout, or the
+// workload was deleted.
+func (s *RolloutStatistic) Partial() bool {
+	return s.finishedTimestamp.IsZero() || s.stalled
+}
+
+// Report reports the rollout statistic to the given output writer.
+func (s *RolloutStatistic) Report(output io.Writer) {
+	metrics := zerolog.Dict().
+		Bool("partial", s.Partial()).
+		Str("kube_namespace", s.namespace).
+		Str("kube_top_owner_kind", strings.ToLower(s.kind)).
+		Str("kube_top_owner_name", s.name).
+		Dict("rollout", s.event())
+
+	if field, ok := workloadKindFields[s.kind]; ok {
+		metrics.Str(field, s.name)
+	}
+
+	logMetrics(output, "rollout", metrics, "")
+}
+
+// event returns the event dictionary for the rollout statistic.
+func (s *RolloutStatistic) event() *zerolog.Event {
+	event := zerolog.Dict()
+
+	event.Str("revision", s.revision)
+	event.Time("started_timestamp", s.startedTimestamp)
+	event.Bool("stalled", s.stalled)
+
+	if !s.finishedTimestamp.IsZero() {
+		event.Time("finished_timestamp", s.finishedTimestamp)
+		event.Dur("duration_seconds", s.finishedTimestamp.Sub(s.startedTimestamp))
+	}
+
+	var (
+		creationToReady []time.Duration
+		slowest         *rolloutPod
+		imagePull       time.Duration
+		totalReady      time.Duration
+	)
+
+	podsCreated := 0
+
+	for _, pod := range s.eachPod {
+		podsCreated++
+
+		if pod.statistic == nil {
+			continue
+		}
+
+		duration := pod.statistic.ReadyTimestamp().Sub(pod.statistic.CreationTimestamp())
+		creationToReady = append(creationToReady, duration)
+		totalReady += duration
+		imagePull += pod.imagePull
+
+		if slowest == nil || duration > slowest.creationToReady() {
+			slowest = pod
+		}
+	}
+
+	event.Int("pods_created", podsCreated)
+	event.Int("pods_ready", len(creationToReady))
+
+	if len(creationToReady) == 0 {
+		return event
+	}
+
+	slices.Sort(creationToReady)
+	event.Dur("creation_to_ready_p50_seconds", percentile(creationToReady, 0.5)) //nolint:mnd
+	event.Dur("creation_to_ready_p90_seconds", percentile(creationToReady, 0.9)) //nolint:mnd
+	event.Dur("creation_to_ready_max_seconds", creationToReady[len(creationToReady)-1])
+	event.Str("slowest_pod_name", slowest.name)
+	event.Str("slowest_pod_phase", slowest.slowestPhase())
+	event.Dur("image_pull_seconds", imagePull)
+
+	if totalReady > 0 {
+		event.Float64("image_pull_share", imagePull.Seconds()/totalReady.Seconds())
+	}
+
+	return event
+}
+
+// eachPod is an [iter.Seq2] over the pods participating in the rollout.
+func (s *RolloutStatistic) eachPod(yield func(apimachinerytypes.UID, *rolloutPod) bool) {
+	pods := s.pods.Iterator()
+	for !pods.Done() {
+		uid, pod, _ := pods.Next()
+		if !yield(uid, pod) {
+			break
+		}
+	}
+}
+
+// creationToReady returns the duration from the creation of the pod to it becoming Ready.
+func (p *rolloutPod) creationToReady() time.Duration {
+	return p.statistic.ReadyTimestamp().Sub(p.statistic.CreationTimestamp())
+}
+
+// slowestPhase returns the name of the longest phase of the pod startup.
+func (p *rolloutPod) slowestPhase() string {
+	phases := []struct {
+		name     string
+		duration time.Duration
+	}{
+		{"creation_to_scheduled", p.statistic.ScheduledTimestamp().Sub(p.statistic.CreationTimestamp())},
+		{"scheduled_to_initialized", p.statistic.InitializedTimestamp().Sub(p.statistic.ScheduledTimestamp())},
+		{"initialized_to_ready", p.statistic.ReadyTimestamp().Sub(p.statistic.InitializedTimestamp())},
+	}
+
+	slowest := phases[0]
+	for _, phase := range phases[1:] {
+		if phase.duration > slowest.duration {
+			slowest = phase
+		}
+	}
+
+	return slowest.name
+}
+
+// percentile returns the nearest-rank percentile of the sorted durations.
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	rank := max(int(math.Ceil(p*float64(len(sorted)))), 1)
+
+	return sorted[rank-1]
+}
@@ -0,0 +1,127 @@
+package state
+
+import (
+	"testing"
+	"time"
+
+	"github.com/BackMarket-oss/kube-transition-metrics/internal/options"
+	"github.com/BackMarket-oss/kube-transition-metrics/internal/testhelpers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	apimachinerytypes "k8s.io/apimachinery/pkg/types"
+)
+
+// newReadyPod returns a Ready pod whose conditions transition at the given offsets from its creation.
+func newReadyPod(name string, created time.Time, scheduled, initialized, ready time.Duration) *corev1.Pod {
+	condition := func(conditionType corev1.PodConditionType, offset time.Duration) corev1.PodCondition {
+		return corev1.PodCondition{
+			Type:               conditionType,
+			Status:             corev1.ConditionTrue,
+			LastTransitionTime: metav1.NewTime(created.Add(offset)),
+		}
+	}
+
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              name,
+			Namespace:         "test-namespace",
+			UID:               apimachinerytypes.UID(name),
+			CreationTimestamp: metav1.NewTime(created),
+		},
+		Status: corev1.PodStatus{
+			Conditions: []corev1.PodCondition{
+				condition(corev1.PodScheduled, scheduled),
+				condition(corev1.PodInitialized, initialized),
+				condition(corev1.PodReady, ready),
+			},
+		},
+	}
+}
+
+func TestRolloutStatisticReport(t *testing.T) {
+	testhelpers.ConfigureLogging(t, &options.Options{})
+
+	started := time.Date(2023, 8, 28, 0, 0, 0, 0, time.UTC)
+	rollout := NewRolloutStatistic("Deployment", "test-namespace", "web", "3", started)
+
+	pods := []*corev1.Pod{
+		newReadyPod("web-a", started, time.Second, 2*time.Second, 4*time.Second),
+		newReadyPod("web-b", started, time.Second, 8*time.Second, 10*time.Second),
+		newReadyPod("web-c", started, 5*time.Second, 6*time.Second, 7*time.Second),
+	}
+	for _, pod := range pods {
+		rollout = rollout.PodCreated(pod)
+		rollout = rollout.ImagePulled(pod.UID, time.Second)
+		rollout = rollout.PodReady(pod, NewPodStatistic(started, pod).Update(started, pod))
+	}
+
+	rollout = rollout.PodCreated(newTestingPod(started))
+	rollout = rollout.ImagePulled("unknown", time.Hour)
+
+	assert.True(t, rollout.Partial(), "Expected unfinished rollout to be partial")
+
+	rollout = rollout.Finish(started.Add(20*time.Second), false)
+	assert.False(t, rollout.Partial(), "Expected finished rollout not to be partial")
+
+	writer := testhelpers.NewMetricWriter(t)
+	rollout.Report(writer)
+
+	metrics := testhelpers.DecodeMetricOutput(t, writer)
+	require.Len(t, metrics, 1)
+	assert.Equal(t, "rollout", metrics[0]["type"])
+	assert.Equal(t, "web", metrics[0]["kube_deployment"])
+	assert.Equal(t, "deployment", metrics[0]["kube_top_owner_kind"])
+	assert.Equal(t, "web", metrics[0]["kube_top_owner_name"])
+
+	rolloutMetrics, ok := metrics[0]["rollout"].(map[string]any)
+	require.True(t, ok, "Expected rollout metrics to be an object")
+	assert.Equal(t, "3", rolloutMetrics["revision"])
+	assert.InDelta(t, 20, rolloutMetrics["duration_seconds"], 1e-5)
+	assert.InDelta(t, 4, rolloutMetrics["pods_created"], 1e-5)
+	assert.InDelta(t, 3, rolloutMetrics["pods_ready"], 1e-5)
+	assert.InDelta(t, 7, rolloutMetrics["creation_to_ready_p50_seconds"], 1e-5)
+	assert.InDelta(t, 10, rolloutMetrics["creation_to_ready_p90_seconds"], 1e-5)
+	assert.InDelta(t, 10, rolloutMetrics["creation_to_ready_max_seconds"], 1e-5)
+	assert.Equal(t, "web-b", rolloutMetrics["slowest_pod_name"])
+	assert.Equal(t, "scheduled_to_initialized", rolloutMetrics["slowest_pod_phase"])
+	assert.InDelta(t, 3, rolloutMetrics["image_pull_seconds"], 1e-5)
+	assert.InDelta(t, 3.0/21.0, rolloutMetrics["image_pull_share"], 1e-5)
+}
+
+func TestRolloutStatisticStalled(t *testing.T) {
+	testhelpers.ConfigureLogging(t, &options.Options{})
+
+	started := time.Date(2023, 8, 28, 0, 0, 0, 0, time.UTC)
+	rollout := NewRolloutStatistic("StatefulSet", "test-namespace", "db", "", started).
+		WithRevision("db-5d8f").
+		Finish(started.Add(time.Minute), true)
+
+	assert.True(t, rollout.Partial(), "Expected stalled rollout to be partial")
+
+	writer := testhelpers.NewMetricWriter(t)
+	rollout.Report(writer)
+
+	metrics := testhelpers.DecodeMetricOutput(t, writer)
+	require.Len(t, metrics, 1)
+	assert.Equal(t, true, metrics[0]["partial"])
+	assert.Equal(t, "db", metrics[0]["kube_stateful_set"])
+
+	rolloutMetrics, ok := metrics[0]["rollout"].(map[string]any)
+	require.True(t, ok, "Expected rollout metrics to be an object")
+	assert.Equal(t, "db-5d8f", rolloutMetrics["revision"])
+	assert.Equal(t, true, rolloutMetrics["stalled"])
+	assert.InDelta(t, 0, rolloutMetrics["pods_ready"], 1e-5)
+	assert.NotContains(t, rolloutMetrics, "slowest_pod_name")
+}
+
+func TestPercentile(t *testing.T) {
+	t.Parallel()
+
+	sorted := []time.Duration{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}
+	assert.Equal(t, time.Duration(5), percentile(sorted, 0.5))
+	assert.Equal(t, time.Duration(9), percentile(sorted, 0.9))
+	assert.Equal(t, time.Duration(1), percentile(sorted, 0))
+	assert.Equal(t, time.Duration(10), percentile(sorted, 1))
+}
@@ -0,0 +1,59 @@
+package state
+
+import (
+	"github.com/rs/zerolog"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// Reasons of the Events of pods about their sandbox.
+	SandboxReasonCreateFailed = "FailedCreatePodSandBox"
+	SandboxReasonChanged      = "SandboxChanged"
+)
+
+// Event updates the pod statistic from a sandbox Event of the pod, or an attach or mount Event of its volumes.
+// It returns a new instance of the pod statistic, or the receiver if the Event is not relevant to the pod statistic.
+func (s *PodStatistic) Event(k8sEvent *corev1.Event) *PodStatistic {
+	switch k8sEvent.Reason {
+	case SandboxReasonCreateFailed:
+		// As this type is immutable, we should shadow the receiver.
+		s = s.Copy()
+		s.sandboxCreateFailures = s.sandboxCreateFailures.Set(k8sEvent.UID, eventCount(k8sEvent))
+
+		return s
+	case SandboxReasonChanged:
+		// As this type is immutable, we should shadow the receiver.
+		s = s.Copy()
+		s.sandboxChanges = s.sandboxChanges.Set(k8sEvent.UID, eventCount(k8sEvent))
+
+		return s
+	default:
+		return s.VolumeEvent(k8sEvent)
+	}
+}
+
+// sandboxEvent adds the sandbox and network setup fields of the pod statistic to the event dictionary.
+func (s *PodStatistic) sandboxEvent(event *zerolog.Event) {
+	if !s.sandboxReadyTimestamp.IsZero() {
+		event.Time("sandbox_ready_timestamp", s.sandboxReadyTimestamp)
+
+		if !s.scheduledTimestamp.IsZero() {
+			event.Dur("scheduled_to_sandbox_ready_seconds", s.sandboxReadyTimestamp.Sub(s.scheduledTimestamp))
+		}
+
+		if running := s.firstRunningTimestamp(); !running.IsZero() {
+			event.Dur("sandbox_ready_to_first_running_seconds", running.Sub(s.sandboxReadyTimestamp))
+		}
+	}
+
+	if !s.podIPTimestamp.IsZero() {
+		event.Time("pod_ip_timestamp", s.podIPTimestamp)
+
+		if !s.scheduledTimestamp.IsZero() {
+			event.Dur("scheduled_to_pod_ip_seconds", s.podIPTimestamp.Sub(s.scheduledTimestamp))
+		}
+	}
+
+	event.Int32("sandbox_create_failures", sumCounts(s.sandboxCreateFailures))
+	event.Int32("sandbox_changes", sumCounts(s.sandboxChanges))
+}
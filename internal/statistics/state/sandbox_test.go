@@ -0,0 +1,66 @@
+package state
+
+import (
+	"testing"
+	"time"
+
+	"github.com/BackMarket-oss/kube-transition-metrics/internal/options"
+	"github.com/BackMarket-oss/kube-transition-metrics/internal/testhelpers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestPodStatisticSandbox(t *testing.T) {
+	testhelpers.ConfigureLogging(t, &options.Options{})
+
+	created := time.Date(2023, 8, 28, 0, 0, 0, 0, time.UTC)
+	pod := newTestingPod(created)
+	pod.Status.ContainerStatuses = nil
+
+	stat := NewPodStatistic(created, pod)
+
+	// The CNI plugin fails twice to set up the network of the sandbox, and the Event is updated with the new count.
+	stat = stat.Event(&corev1.Event{ObjectMeta: metav1.ObjectMeta{UID: "e1"}, Reason: SandboxReasonCreateFailed, Count: 1})
+	stat = stat.Event(&corev1.Event{ObjectMeta: metav1.ObjectMeta{UID: "e1"}, Reason: SandboxReasonCreateFailed, Count: 2})
+	stat = stat.Event(&corev1.Event{ObjectMeta: metav1.ObjectMeta{UID: "e2"}, Reason: SandboxReasonChanged, Count: 1})
+
+	unrelated := &corev1.Event{ObjectMeta: metav1.ObjectMeta{UID: "e3"}, Reason: "Scheduled"}
+	assert.Same(t, stat, stat.Event(unrelated), "Expected unrelated events to be ignored")
+
+	pod = pod.DeepCopy()
+	pod.Status.Conditions = append(pod.Status.Conditions, corev1.PodCondition{
+		Type:               corev1.PodReadyToStartContainers,
+		Status:             corev1.ConditionTrue,
+		LastTransitionTime: metav1.NewTime(created.Add(4 * time.Second)),
+	})
+	pod.Status.PodIP = "10.0.0.1"
+	stat = stat.Update(created.Add(5*time.Second), pod)
+	assert.Equal(t, created.Add(4*time.Second), stat.SandboxReadyTimestamp())
+
+	pod = pod.DeepCopy()
+	pod.Status.ContainerStatuses = []corev1.ContainerStatus{{
+		Name:  "test-container",
+		State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{}},
+	}}
+	stat = stat.Update(created.Add(7*time.Second), pod)
+
+	writer := testhelpers.NewMetricWriter(t)
+	stat.Report(writer, pod)
+
+	var event map[string]any
+
+	for _, metric := range testhelpers.DecodeMetricOutput(t, writer) {
+		if metric["type"] == "pod" {
+			event, _ = metric["pod"].(map[string]any)
+		}
+	}
+
+	require.NotNil(t, event, "Expected a pod metric")
+	assert.InDelta(t, 3.0, event["scheduled_to_sandbox_ready_seconds"], 0.001)
+	assert.InDelta(t, 3.0, event["sandbox_ready_to_first_running_seconds"], 0.001)
+	assert.InDelta(t, 4.0, event["scheduled_to_pod_ip_seconds"], 0.001)
+	assert.InDelta(t, 2.0, event["sandbox_create_failures"], 0.001)
+	assert.InDelta(t, 1.0, event["sandbox_changes"], 0.001)
+}
@@ -0,0 +1,80 @@
+package state
+
+import (
+	"io"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// SLOViolation holds the duration of a pod transition or of an image pull which exceeded the threshold of a latency
+// SLO.
+type SLOViolation struct {
+	slo        string
+	transition string
+	threshold  time.Duration
+	objective  float64
+	duration   time.Duration
+
+	// containerName and image identify the container pulling the image, they are empty for pod transitions.
+	containerName string
+	image         string
+}
+
+// NewSLOViolation creates a new SLOViolation of the SLO, whose transition took the duration.
+func NewSLOViolation(
+	slo, transition string,
+	threshold time.Duration,
+	objective float64,
+	duration time.Duration,
+) *SLOViolation {
+	return &SLOViolation{
+		slo:        slo,
+		transition: transition,
+		threshold:  threshold,
+		objective:  objective,
+		duration:   duration,
+	}
+}
+
+// WithContainer returns a copy of the violation for the image pull of the container.
+func (v *SLOViolation) WithContainer(containerName, image string) *SLOViolation {
+	violation := *v
+	violation.containerName = containerName
+	violation.image = image
+
+	return &violation
+}
+
+// Report reports the SLO violation to the given output writer.
+// The labels of the provided labelers are added to the metric record.
+func (v *SLOViolation) Report(output io.Writer, pod *corev1.Pod, labelers ...PodLabeler) {
+	logger := log.With().
+		Str("kube_namespace", pod.Namespace).
+		Str("pod_name", pod.Name).
+		Logger()
+
+	metrics := zerolog.Dict().
+		Bool("partial", false).
+		Func(commonPodLabels(pod, labelers))
+
+	if v.containerName != "" {
+		metrics.
+			Str("container_name", v.containerName).
+			Func(imageLabels(&logger, v.image))
+	}
+
+	logMetrics(output, "slo_violation", metrics.Dict("slo_violation", v.event()), "")
+}
+
+// event returns the event dictionary for the SLO violation.
+func (v *SLOViolation) event() *zerolog.Event {
+	return zerolog.Dict().
+		Str("slo", v.slo).
+		Str("transition", v.transition).
+		Dur("threshold_seconds", v.threshold).
+		Dur("duration_seconds", v.duration).
+		Float64("objective", v.objective)
+}
@@ -0,0 +1,41 @@
+package state
+
+import (
+	"testing"
+	"time"
+
+	"github.com/BackMarket-oss/kube-transition-metrics/internal/options"
+	"github.com/BackMarket-oss/kube-transition-metrics/internal/testhelpers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestSLOViolationReport(t *testing.T) {
+	testhelpers.ConfigureLogging(t, &options.Options{})
+
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "test-pod", Namespace: "test-namespace"}}
+	violation := NewSLOViolation("test-slo", "creation_to_ready", time.Minute, 0.95, 90*time.Second)
+
+	writer := testhelpers.NewMetricWriter(t)
+	violation.Report(writer, pod)
+	violation.WithContainer("test-container", "docker.io/library/nginx:1.25").Report(writer, pod)
+
+	metrics := testhelpers.DecodeMetricOutput(t, writer)
+	require.Len(t, metrics, 2)
+	assert.Equal(t, "slo_violation", metrics[0]["type"])
+	assert.Equal(t, "test-pod", metrics[0]["pod_name"])
+	assert.NotContains(t, metrics[0], "container_name", "Expected no container for pod transitions")
+
+	event, _ := metrics[0]["slo_violation"].(map[string]any)
+	require.NotNil(t, event)
+	assert.Equal(t, "test-slo", event["slo"])
+	assert.Equal(t, "creation_to_ready", event["transition"])
+	assert.InDelta(t, 60, event["threshold_seconds"], 0.001)
+	assert.InDelta(t, 90, event["duration_seconds"], 0.001)
+	assert.InDelta(t, 0.95, event["objective"], 0.001)
+
+	assert.Equal(t, "test-container", metrics[1]["container_name"])
+	assert.Equal(t, "docker.io/library/nginx", metrics[1]["image_name"])
+}
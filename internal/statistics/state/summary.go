@@ -0,0 +1,86 @@
+package state
+
+import (
+	"io"
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// DurationSummary summarizes the values of a duration field of the metric records over a window.
+type DurationSummary struct {
+	Count uint64
+	Mean  time.Duration
+	P50   time.Duration
+	P90   time.Duration
+	P99   time.Duration
+	Max   time.Duration
+}
+
+// SummaryStatistic holds the summaries of the duration fields of the metric records of a type, for the pods of a
+// namespace and owner, over a window.
+type SummaryStatistic struct {
+	recordType string
+	namespace  string
+	ownerKind  string
+	ownerName  string
+	window     time.Duration
+
+	durations map[string]DurationSummary
+}
+
+// NewSummaryStatistic creates a new SummaryStatistic of the records of the type, e.g. pod, for the pods of the
+// namespace and owner, which may be empty for pods without an owner.
+// The durations are the summaries of each duration field of the records over the window.
+func NewSummaryStatistic(
+	recordType, namespace, ownerKind, ownerName string,
+	window time.Duration,
+	durations map[string]DurationSummary,
+) *SummaryStatistic {
+	return &SummaryStatistic{
+		recordType: recordType,
+		namespace:  namespace,
+		ownerKind:  ownerKind,
+		ownerName:  ownerName,
+		window:     window,
+		durations:  durations,
+	}
+}
+
+// Report reports the summary statistic to the given output writer.
+func (s *SummaryStatistic) Report(output io.Writer) {
+	metrics := zerolog.Dict().
+		Bool("partial", false).
+		Str("kube_namespace", s.namespace)
+
+	if s.ownerKind != "" {
+		metrics.
+			Str("kube_top_owner_kind", s.ownerKind).
+			Str("kube_top_owner_name", s.ownerName)
+	}
+
+	logMetrics(output, "summary", metrics.Dict("summary", s.event()), "")
+}
+
+// event returns the event dictionary for the summary statistic.
+func (s *SummaryStatistic) event() *zerolog.Event {
+	durations := zerolog.Dict()
+
+	for _, field := range slices.Sorted(maps.Keys(s.durations)) {
+		summary := s.durations[field]
+		durations.Dict(field, zerolog.Dict().
+			Uint64("count", summary.Count).
+			Dur("mean_seconds", summary.Mean).
+			Dur("p50_seconds", summary.P50).
+			Dur("p90_seconds", summary.P90).
+			Dur("p99_seconds", summary.P99).
+			Dur("max_seconds", summary.Max))
+	}
+
+	return zerolog.Dict().
+		Str("record_type", s.recordType).
+		Dur("window_seconds", s.window).
+		Dict("durations", durations)
+}
@@ -0,0 +1,59 @@
+package state
+
+import (
+	"testing"
+	"time"
+
+	"github.com/BackMarket-oss/kube-transition-metrics/internal/options"
+	"github.com/BackMarket-oss/kube-transition-metrics/internal/testhelpers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSummaryStatisticReport(t *testing.T) {
+	testhelpers.ConfigureLogging(t, &options.Options{})
+
+	summary := NewSummaryStatistic("pod", "test-namespace", "deployment", "web", 5*time.Minute,
+		map[string]DurationSummary{
+			"creation_to_ready_seconds": {
+				Count: 3,
+				Mean:  4 * time.Second,
+				P50:   3 * time.Second,
+				P90:   6 * time.Second,
+				P99:   6 * time.Second,
+				Max:   6 * time.Second,
+			},
+		})
+
+	writer := testhelpers.NewMetricWriter(t)
+	summary.Report(writer)
+
+	metrics := testhelpers.DecodeMetricOutput(t, writer)
+	require.Len(t, metrics, 1)
+	assert.Equal(t, "summary", metrics[0]["type"])
+	assert.Equal(t, "deployment", metrics[0]["kube_top_owner_kind"])
+	assert.Equal(t, "web", metrics[0]["kube_top_owner_name"])
+
+	event, _ := metrics[0]["summary"].(map[string]any)
+	require.NotNil(t, event)
+	assert.Equal(t, "pod", event["record_type"])
+	assert.InDelta(t, 300, event["window_seconds"], 0.001)
+
+	durations, _ := event["durations"].(map[string]any)
+	creationToReady, _ := durations["creation_to_ready_seconds"].(map[string]any)
+	require.NotNil(t, creationToReady)
+	assert.InDelta(t, 3, creationToReady["count"], 0)
+	assert.InDelta(t, 4, creationToReady["mean_seconds"], 0.001)
+	assert.InDelta(t, 6, creationToReady["p90_seconds"], 0.001)
+}
+
+func TestSummaryStatisticReportWithoutOwner(t *testing.T) {
+	testhelpers.ConfigureLogging(t, &options.Options{})
+
+	writer := testhelpers.NewMetricWriter(t)
+	NewSummaryStatistic("image_pull", "test-namespace", "", "", time.Minute, map[string]DurationSummary{}).Report(writer)
+
+	metrics := testhelpers.DecodeMetricOutput(t, writer)
+	require.Len(t, metrics, 1)
+	assert.NotContains(t, metrics[0], "kube_top_owner_kind", "Expected no owner for pods without an owner")
+}
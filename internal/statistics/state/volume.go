@@ -0,0 +1,401 @@
+package state
+
+import (
+	"io"
+	"iter"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/Izzette/go-safeconcurrency/eventloop/snapshot"
+	"github.com/benbjohnson/immutable"
+	"github.com/rs/zerolog"
+	corev1 "k8s.io/api/core/v1"
+	apimachinerytypes "k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	// Reasons of the Events of pods about their volumes.
+	VolumeReasonAttached     = "SuccessfulAttachVolume"
+	VolumeReasonAttachFailed = "FailedAttachVolume"
+	VolumeReasonMountFailed  = "FailedMount"
+	// Reasons of the Events of PersistentVolumeClaims about their provisioning.
+	ClaimReasonWaitForFirstConsumer = "WaitForFirstConsumer"
+	ClaimReasonProvisioned          = "ProvisioningSucceeded"
+)
+
+var (
+	// volumeMessageRegex matches the volume name in the messages of attach and mount Events, e.g.
+	// `AttachVolume.Attach succeeded for volume "pvc-..."` or `MountVolume.SetUp failed for volume "config" : ...`.
+	volumeMessageRegex = regexp.MustCompile(`for volume "([^"]+)"`)
+	// unmountedVolumesRegex matches the volume names in the message of the FailedMount Events emitted when the kubelet
+	// times out waiting for the volumes, e.g. `Unable to attach or mount volumes: unmounted volumes=[data], ...`.
+	unmountedVolumesRegex = regexp.MustCompile(`unmounted volumes=\[([^\]]*)\]`)
+	// provisionedMessageRegex matches the PersistentVolume name in the message of the ProvisioningSucceeded Events.
+	provisionedMessageRegex = regexp.MustCompile(`^Successfully provisioned volume (\S+)`)
+)
+
+// VolumeStatistic holds the provisioning, attach and mount statistics of a volume of a pod.
+// VolumeStatistic is immutable, all the methods return a new instance of the struct.
+// Do not lose track of the returned instance, it should be assigned to the containing structure.
+type VolumeStatistic struct {
+	// name is the name of the volume in the pod spec.
+	name string
+	// claimName is the name of the PersistentVolumeClaim of the volume, or empty for other volumes.
+	claimName string
+	// claimUID is the UID of the PersistentVolumeClaim, once an Event of the claim is seen.
+	claimUID apimachinerytypes.UID
+	// persistentVolume is the name of the PersistentVolume provisioned for the claim, once provisioned.
+	persistentVolume string
+
+	// The timestamp for when the claim started waiting for the pod to be scheduled to provision the volume.
+	waitForFirstConsumerTimestamp time.Time
+	// The timestamp for when the volume was provisioned.
+	provisionedTimestamp time.Time
+	// The timestamp for when the volume was attached to the node.
+	attachedTimestamp time.Time
+	// The timestamp for when the first container of the pod started running, as the kubelet mounts all the volumes of
+	// the pod before starting its containers.
+	mountedTimestamp time.Time
+
+	// attachFailures and mountFailures are the counts of each failure Event, which are aggregated by the Events API when
+	// repeated.
+	attachFailures *immutable.Map[apimachinerytypes.UID, int32]
+	mountFailures  *immutable.Map[apimachinerytypes.UID, int32]
+}
+
+// newVolumeStatistic creates a new VolumeStatistic for the volume of the pod with the given name and claim.
+func newVolumeStatistic(name, claimName string) *VolumeStatistic {
+	return &VolumeStatistic{
+		name:           name,
+		claimName:      claimName,
+		attachFailures: immutable.NewMap[apimachinerytypes.UID, int32](nil),
+		mountFailures:  immutable.NewMap[apimachinerytypes.UID, int32](nil),
+	}
+}
+
+// Partial indicates if the volume is not yet mounted.
+func (s *VolumeStatistic) Partial() bool {
+	return s.mountedTimestamp.IsZero()
+}
+
+// Report reports the volume statistic to the output writer.
+func (s *VolumeStatistic) Report(
+	output io.Writer,
+	pod *corev1.Pod,
+	podStatistic *PodStatistic,
+	labelers ...PodLabeler,
+) {
+	metrics := zerolog.Dict().
+		Bool("partial", s.Partial()).
+		Func(commonPodLabels(pod, labelers)).
+		Dict("volume", s.event(podStatistic))
+
+	logMetrics(output, "volume", metrics, "")
+}
+
+// event returns the event dictionary for the volume statistic.
+func (s *VolumeStatistic) event(podStatistic *PodStatistic) *zerolog.Event {
+	event := zerolog.Dict()
+	event.Str("volume_name", s.name)
+
+	if s.claimName != "" {
+		event.Str("claim_name", s.claimName)
+	}
+
+	if s.persistentVolume != "" {
+		event.Str("persistent_volume", s.persistentVolume)
+	}
+
+	if !s.waitForFirstConsumerTimestamp.IsZero() {
+		event.Time("wait_for_first_consumer_timestamp", s.waitForFirstConsumerTimestamp)
+	}
+
+	created, scheduled := podStatistic.creationTimestamp, podStatistic.scheduledTimestamp
+
+	if !s.provisionedTimestamp.IsZero() {
+		event.Time("provisioned_timestamp", s.provisionedTimestamp)
+		event.Dur("creation_to_provisioned_seconds", s.provisionedTimestamp.Sub(created))
+
+		// Volumes waiting for their first consumer are only provisioned once the pod is scheduled.
+		if !s.waitForFirstConsumerTimestamp.IsZero() && !scheduled.IsZero() {
+			event.Dur("scheduled_to_provisioned_seconds", s.provisionedTimestamp.Sub(scheduled))
+		}
+	}
+
+	if !s.attachedTimestamp.IsZero() {
+		event.Time("attached_timestamp", s.attachedTimestamp)
+
+		if !scheduled.IsZero() {
+			event.Dur("scheduled_to_attached_seconds", s.attachedTimestamp.Sub(scheduled))
+		}
+	}
+
+	if !s.mountedTimestamp.IsZero() {
+		event.Time("mounted_timestamp", s.mountedTimestamp)
+
+		if !scheduled.IsZero() {
+			event.Dur("scheduled_to_mounted_seconds", s.mountedTimestamp.Sub(scheduled))
+		}
+
+		if !s.attachedTimestamp.IsZero() {
+			event.Dur("attached_to_mounted_seconds", s.mountedTimestamp.Sub(s.attachedTimestamp))
+		}
+	}
+
+	event.Int32("attach_failures", sumCounts(s.attachFailures))
+	event.Int32("mount_failures", sumCounts(s.mountFailures))
+
+	return event
+}
+
+// matches indicates if the volume name of an attach or mount Event designates this volume, either by its name in the
+// pod spec or by the name of its PersistentVolume.
+func (s *VolumeStatistic) matches(name string) bool {
+	return name == s.name ||
+		(s.persistentVolume != "" && name == s.persistentVolume) ||
+		// Dynamically provisioned PersistentVolumes are named after the UID of their claim.
+		(s.claimUID != "" && name == "pvc-"+string(s.claimUID))
+}
+
+// TrackVolumes starts tracking the volumes of the pod backed by PersistentVolumeClaims.
+// Other volumes are only tracked once an Event reports a failure to mount them.
+// It returns a new instance of the pod statistic.
+func (s *PodStatistic) TrackVolumes(pod *corev1.Pod) *PodStatistic {
+	volumes := immutable.NewMap[string, *VolumeStatistic](nil)
+
+	for _, volume := range pod.Spec.Volumes {
+		switch {
+		case volume.PersistentVolumeClaim != nil:
+			volumes = volumes.Set(volume.Name, newVolumeStatistic(volume.Name, volume.PersistentVolumeClaim.ClaimName))
+		case volume.Ephemeral != nil:
+			// The claims of generic ephemeral volumes are named after the pod and the volume.
+			volumes = volumes.Set(volume.Name, newVolumeStatistic(volume.Name, pod.Name+"-"+volume.Name))
+		}
+	}
+
+	// As this type is immutable, we should shadow the receiver.
+	s = s.Copy()
+	s.volumes = volumes
+
+	return s.updateVolumes()
+}
+
+// VolumeStatistics returns an iterator for each volume statistic of the pod.
+func (s *PodStatistic) VolumeStatistics() iter.Seq2[string, *VolumeStatistic] {
+	return s.EachVolumeStatistic
+}
+
+// EachVolumeStatistic is an [iter.Seq2] of the volume name (string) and the volume statistic ([*VolumeStatistic]).
+func (s *PodStatistic) EachVolumeStatistic(yield func(string, *VolumeStatistic) bool) {
+	if s.volumes == nil {
+		return
+	}
+
+	volumes := s.volumes.Iterator()
+	for !volumes.Done() {
+		name, volume, _ := volumes.Next()
+		if !yield(name, volume) {
+			break
+		}
+	}
+}
+
+// VolumeEvent updates the volume statistics from an attach or mount Event of the pod.
+// It is called by [PodStatistic.Event] for the volume Events.
+// It returns a new instance of the pod statistic, or the receiver if the volumes are not tracked or the Event does not
+// designate any volume of the pod.
+func (s *PodStatistic) VolumeEvent(k8sEvent *corev1.Event) *PodStatistic {
+	if s.volumes == nil {
+		return s
+	}
+
+	var names []string
+
+	if matches := volumeMessageRegex.FindStringSubmatch(k8sEvent.Message); matches != nil {
+		names = []string{matches[1]}
+	} else if matches := unmountedVolumesRegex.FindStringSubmatch(k8sEvent.Message); matches != nil {
+		names = strings.Fields(matches[1])
+	}
+
+	volumes := s.volumes
+
+	for _, name := range names {
+		volume := s.findVolume(name)
+
+		switch {
+		case volume != nil:
+		case k8sEvent.Reason == VolumeReasonMountFailed && s.hasVolume(name):
+			// e.g. a missing ConfigMap or Secret.
+			volume = newVolumeStatistic(name, "")
+		default:
+			continue
+		}
+
+		volume = snapshot.CopyPtr(volume)
+
+		switch k8sEvent.Reason {
+		case VolumeReasonAttached:
+			if volume.attachedTimestamp.IsZero() {
+				volume.attachedTimestamp = eventTimestamp(k8sEvent)
+			}
+		case VolumeReasonAttachFailed:
+			volume.attachFailures = volume.attachFailures.Set(k8sEvent.UID, eventCount(k8sEvent))
+		case VolumeReasonMountFailed:
+			volume.mountFailures = volume.mountFailures.Set(k8sEvent.UID, eventCount(k8sEvent))
+		}
+
+		volumes = volumes.Set(volume.name, volume)
+	}
+
+	if volumes == s.volumes {
+		return s
+	}
+
+	// As this type is immutable, we should shadow the receiver.
+	s = s.Copy()
+	s.volumes = volumes
+
+	return s
+}
+
+// ClaimEvent updates the statistics of the volumes of the pod backed by the PersistentVolumeClaim of the Event.
+// It returns a new instance of the pod statistic, or the receiver if no volume of the pod is backed by the claim.
+func (s *PodStatistic) ClaimEvent(k8sEvent *corev1.Event) *PodStatistic {
+	if s.volumes == nil || s.namespace != k8sEvent.InvolvedObject.Namespace {
+		return s
+	}
+
+	volumes := s.volumes
+
+	for name, volume := range s.VolumeStatistics() {
+		if volume.claimName == "" || volume.claimName != k8sEvent.InvolvedObject.Name {
+			continue
+		}
+
+		volume = snapshot.CopyPtr(volume)
+		volume.claimUID = k8sEvent.InvolvedObject.UID
+
+		switch k8sEvent.Reason {
+		case ClaimReasonWaitForFirstConsumer:
+			if volume.waitForFirstConsumerTimestamp.IsZero() {
+				volume.waitForFirstConsumerTimestamp = eventTimestamp(k8sEvent)
+			}
+		case ClaimReasonProvisioned:
+			if volume.provisionedTimestamp.IsZero() {
+				volume.provisionedTimestamp = eventTimestamp(k8sEvent)
+			}
+
+			if matches := provisionedMessageRegex.FindStringSubmatch(k8sEvent.Message); matches != nil {
+				volume.persistentVolume = matches[1]
+			}
+		}
+
+		volumes = volumes.Set(name, volume)
+	}
+
+	if volumes == s.volumes {
+		return s
+	}
+
+	// As this type is immutable, we should shadow the receiver.
+	s = s.Copy()
+	s.volumes = volumes
+
+	return s
+}
+
+// findVolume returns the statistic of the volume designated by the name in an attach or mount Event.
+// Attach Events name the PersistentVolume, which is unknown for volumes which were not provisioned for the pod: if a
+// single such volume is tracked, it is assumed to be designated.
+func (s *PodStatistic) findVolume(name string) *VolumeStatistic {
+	var unresolved []*VolumeStatistic
+
+	for _, volume := range s.VolumeStatistics() {
+		if volume.matches(name) {
+			return volume
+		}
+
+		if volume.claimName != "" && volume.persistentVolume == "" {
+			unresolved = append(unresolved, volume)
+		}
+	}
+
+	if len(unresolved) == 1 {
+		return unresolved[0]
+	}
+
+	return nil
+}
+
+// hasVolume indicates if the latest pod spec has a volume with the given name.
+func (s *PodStatistic) hasVolume(name string) bool {
+	if s.pod == nil {
+		return false
+	}
+
+	for _, volume := range s.pod.Spec.Volumes {
+		if volume.Name == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// updateVolumes records the mount of the volumes once the first container of the pod is running.
+// It must only be called on a copy of the pod statistic.
+func (s *PodStatistic) updateVolumes() *PodStatistic {
+	if s.volumes == nil {
+		return s
+	}
+
+	mounted := s.firstRunningTimestamp()
+	if mounted.IsZero() {
+		return s
+	}
+
+	for name, volume := range s.VolumeStatistics() {
+		if volume.Partial() {
+			volume = snapshot.CopyPtr(volume)
+			volume.mountedTimestamp = mounted
+			s.volumes = s.volumes.Set(name, volume)
+		}
+	}
+
+	return s
+}
+
+// eventTimestamp returns the time an Event first occurred.
+func eventTimestamp(k8sEvent *corev1.Event) time.Time {
+	switch {
+	case !k8sEvent.FirstTimestamp.IsZero():
+		return k8sEvent.FirstTimestamp.Time
+	case !k8sEvent.EventTime.IsZero():
+		return k8sEvent.EventTime.Time
+	default:
+		return k8sEvent.LastTimestamp.Time
+	}
+}
+
+// eventCount returns the number of occurrences of an Event.
+func eventCount(k8sEvent *corev1.Event) int32 {
+	if k8sEvent.Series != nil {
+		return max(1, k8sEvent.Series.Count)
+	}
+
+	return max(1, k8sEvent.Count)
+}
+
+// sumCounts returns the sum of the values of the map.
+func sumCounts(counts *immutable.Map[apimachinerytypes.UID, int32]) int32 {
+	var sum int32
+
+	iterator := counts.Iterator()
+	for !iterator.Done() {
+		_, count, _ := iterator.Next()
+		sum += count
+	}
+
+	return sum
+}
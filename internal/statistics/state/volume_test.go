@@ -0,0 +1,129 @@
+package state
+
+import (
+	"testing"
+	"time"
+
+	"github.com/BackMarket-oss/kube-transition-metrics/internal/options"
+	"github.com/BackMarket-oss/kube-transition-metrics/internal/testhelpers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	apimachinerytypes "k8s.io/apimachinery/pkg/types"
+)
+
+// newVolumeEvent returns an Event of the kind of object with the given reason and message, first seen at timestamp.
+func newVolumeEvent(
+	uid apimachinerytypes.UID,
+	kind, reason, message string,
+	timestamp time.Time,
+	count int32,
+) *corev1.Event {
+	return &corev1.Event{
+		ObjectMeta: metav1.ObjectMeta{UID: uid},
+		InvolvedObject: corev1.ObjectReference{
+			Kind:      kind,
+			Namespace: "test-namespace",
+			Name:      "data-test-pod",
+			UID:       "claim-uid",
+		},
+		Reason:         reason,
+		Message:        message,
+		FirstTimestamp: metav1.NewTime(timestamp),
+		LastTimestamp:  metav1.NewTime(timestamp),
+		Count:          count,
+	}
+}
+
+func TestVolumeStatistic(t *testing.T) {
+	testhelpers.ConfigureLogging(t, &options.Options{})
+
+	created := time.Date(2023, 8, 28, 0, 0, 0, 0, time.UTC)
+	pod := newTestingPod(created)
+	pod.Status.ContainerStatuses = nil
+	pod.Spec.Volumes = []corev1.Volume{
+		{
+			Name: "data",
+			VolumeSource: corev1.VolumeSource{
+				PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: "data-test-pod"},
+			},
+		},
+		{
+			Name:         "config",
+			VolumeSource: corev1.VolumeSource{ConfigMap: &corev1.ConfigMapVolumeSource{}},
+		},
+		{
+			Name:         "cache",
+			VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}},
+		},
+	}
+
+	stat := NewPodStatistic(created, pod).TrackVolumes(pod)
+
+	names := []string{}
+	for name := range stat.VolumeStatistics() {
+		names = append(names, name)
+	}
+
+	assert.Equal(t, []string{"data"}, names, "Expected only the volumes backed by claims to be tracked")
+
+	stat = stat.ClaimEvent(newVolumeEvent("e1", "PersistentVolumeClaim", ClaimReasonWaitForFirstConsumer,
+		"waiting for first consumer to be created before binding", created.Add(-time.Second), 3))
+	stat = stat.ClaimEvent(newVolumeEvent("e2", "PersistentVolumeClaim", ClaimReasonProvisioned,
+		"Successfully provisioned volume pvc-1234 using ebs.csi.aws.com", created.Add(5*time.Second), 1))
+	stat = stat.VolumeEvent(newVolumeEvent("e3", "Pod", VolumeReasonAttachFailed,
+		`AttachVolume.Attach failed for volume "pvc-1234" : rpc error`, created.Add(6*time.Second), 1))
+	// The failure is repeated, and the Event is updated with the new count.
+	stat = stat.VolumeEvent(newVolumeEvent("e3", "Pod", VolumeReasonAttachFailed,
+		`AttachVolume.Attach failed for volume "pvc-1234" : rpc error`, created.Add(6*time.Second), 2))
+	stat = stat.VolumeEvent(newVolumeEvent("e4", "Pod", VolumeReasonAttached,
+		`AttachVolume.Attach succeeded for volume "pvc-1234"`, created.Add(10*time.Second), 1))
+	stat = stat.VolumeEvent(newVolumeEvent("e5", "Pod", VolumeReasonMountFailed,
+		`MountVolume.SetUp failed for volume "config" : configmap "test-config" not found`, created.Add(11*time.Second), 1))
+	stat = stat.VolumeEvent(newVolumeEvent("e6", "Pod", VolumeReasonMountFailed,
+		`MountVolume.SetUp failed for volume "unknown" : not found`, created.Add(11*time.Second), 1))
+
+	data, ok := stat.volumes.Get("data")
+	require.True(t, ok, "Expected data volume to be tracked")
+	assert.True(t, data.Partial(), "Expected volume to be partial before the containers run")
+
+	pod = pod.DeepCopy()
+	pod.Status.ContainerStatuses = []corev1.ContainerStatus{{
+		Name:  "test-container",
+		State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{}},
+	}}
+	stat = stat.Update(created.Add(15*time.Second), pod)
+
+	writer := testhelpers.NewMetricWriter(t)
+	for _, volume := range stat.VolumeStatistics() {
+		volume.Report(writer, pod, stat)
+	}
+
+	volumes := map[string]map[string]any{}
+
+	for _, metric := range testhelpers.DecodeMetricOutput(t, writer) {
+		assert.Equal(t, "volume", metric["type"])
+		assert.Equal(t, false, metric["partial"])
+
+		volume, ok := metric["volume"].(map[string]any)
+		require.True(t, ok, "Expected volume to be an object")
+
+		name, _ := volume["volume_name"].(string)
+		volumes[name] = volume
+	}
+
+	require.Len(t, volumes, 2, "Expected the claim and the volume which failed to mount to be reported")
+
+	assert.Equal(t, "data-test-pod", volumes["data"]["claim_name"])
+	assert.Equal(t, "pvc-1234", volumes["data"]["persistent_volume"])
+	assert.InDelta(t, 5.0, volumes["data"]["creation_to_provisioned_seconds"], 0.001)
+	assert.InDelta(t, 4.0, volumes["data"]["scheduled_to_provisioned_seconds"], 0.001)
+	assert.InDelta(t, 9.0, volumes["data"]["scheduled_to_attached_seconds"], 0.001)
+	assert.InDelta(t, 5.0, volumes["data"]["attached_to_mounted_seconds"], 0.001)
+	assert.InDelta(t, 2.0, volumes["data"]["attach_failures"], 0.001)
+	assert.InDelta(t, 0.0, volumes["data"]["mount_failures"], 0.001)
+
+	assert.NotContains(t, volumes["config"], "claim_name")
+	assert.InDelta(t, 1.0, volumes["config"]["mount_failures"], 0.001)
+}
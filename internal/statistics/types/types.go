@@ -5,6 +5,7 @@ import (
 
 	"github.com/BackMarket-oss/kube-transition-metrics/internal/statistics/state"
 	safeconcurrencytypes "github.com/Izzette/go-safeconcurrency/api/types"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/rs/zerolog"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -18,7 +19,8 @@ import (
 //
 // Implemented by podCollector in [github.com/BackMarket-oss/kube-transition-metrics/internal/statistics].
 type PodCollector interface {
-	Run(clientset *kubernetes.Clientset)
+	Run(ctx context.Context, clientset kubernetes.Interface)
+	Ready() bool
 }
 
 // ImagePullCollector is an interface that defines the methods for collecting image pull events for a pod.
@@ -26,10 +28,10 @@ type PodCollector interface {
 //
 // Implemented by imagePullCollector in [github.com/BackMarket-oss/kube-transition-metrics/internal/statistics].
 type ImagePullCollector interface {
-	Run(clientset *kubernetes.Clientset)
+	Run(ctx context.Context, clientset kubernetes.Interface)
 	HandleWatchEvent(watchEvent watch.Event) bool
 	HandleEvent(eventType watch.EventType, event *corev1.Event)
-	Watch(clientset *kubernetes.Clientset) bool
+	Watch(ctx context.Context, clientset kubernetes.Interface) bool
 	WatchOptions() metav1.ListOptions
 	Cancel(reason string)
 	Logger() *zerolog.Logger
@@ -44,6 +46,11 @@ type PodStatisticEventLoop interface {
 	PodUpdate(ctx context.Context, pod *corev1.Pod) (safeconcurrencytypes.GenerationID, error)
 	PodDelete(ctx context.Context, pod *corev1.Pod) (safeconcurrencytypes.GenerationID, error)
 	PodResync(ctx context.Context, blacklistUIDs []apimachinerytypes.UID) (safeconcurrencytypes.GenerationID, error)
+	PodEndpointReady(
+		ctx context.Context, uid apimachinerytypes.UID, service string) (safeconcurrencytypes.GenerationID, error)
+	PodEvent(
+		ctx context.Context, uid apimachinerytypes.UID, k8sEvent *corev1.Event) (safeconcurrencytypes.GenerationID, error)
+	ClaimVolumeEvent(ctx context.Context, k8sEvent *corev1.Event) (safeconcurrencytypes.GenerationID, error)
 }
 
 // ImagePullStatisticEventLoop is an interface for the image pull statistic event loop.
@@ -56,3 +63,47 @@ type ImagePullStatisticEventLoop interface {
 		ctx context.Context, pod *corev1.Pod, k8sEvent *corev1.Event) (safeconcurrencytypes.GenerationID, error)
 	ImagePullDelete(ctx context.Context, pod *corev1.Pod) (safeconcurrencytypes.GenerationID, error)
 }
+
+// PodStatisticObserver is notified by the pod statistic event loop when a pod statistic is complete, i.e. when the
+// pod first becomes Ready.
+// ObservePodStatistic is called from the event loop, it must not block.
+type PodStatisticObserver interface {
+	ObservePodStatistic(pod *corev1.Pod, statistic *state.PodStatistic)
+}
+
+// PodCreationObserver may be implemented by a [PodStatisticObserver] to also be notified by the pod statistic event
+// loop when it starts tracking a new pod.
+// ObservePodCreation is called from the event loop, it must not block.
+type PodCreationObserver interface {
+	ObservePodCreation(pod *corev1.Pod)
+}
+
+// PodUpdateObserver may be implemented by a [PodStatisticObserver] to also be notified by the pod statistic event
+// loop of each update of a tracked pod, including after the pod statistic is complete, e.g. when the pod terminates.
+// The pod statistic is updated from the pod, unless it was already complete.
+// ObservePodUpdate is called from the event loop, it must not block.
+type PodUpdateObserver interface {
+	ObservePodUpdate(pod *corev1.Pod, statistic *state.PodStatistic)
+}
+
+// PodDeletionObserver may be implemented by a [PodStatisticObserver] to also be notified by the pod statistic event
+// loop when a tracked pod is deleted.
+// ObservePodDeletion is called from the event loop, it must not block.
+type PodDeletionObserver interface {
+	ObservePodDeletion(pod *corev1.Pod)
+}
+
+// ImagePullStatisticObserver is notified by the image pull statistic event loop when a container image pull statistic
+// is complete.
+// ObserveImagePullStatistic is called from the event loop, it must not block.
+type ImagePullStatisticObserver interface {
+	ObserveImagePullStatistic(pod *corev1.Pod, statistic *state.ContainerImagePullStatistic)
+}
+
+// PrometheusLabeler provides additional Prometheus labels for a pod.
+//
+// Implemented by Mapper in [github.com/BackMarket-oss/kube-transition-metrics/internal/labelmapper].
+type PrometheusLabeler interface {
+	PrometheusLabelNames() []string
+	PrometheusLabels(pod *corev1.Pod) prometheus.Labels
+}
@@ -0,0 +1,337 @@
+// Package summaries aggregates the durations of the pod, container and image_pull metric records per namespace and
+// owner over rolling windows, and periodically reports them as summary records.
+package summaries
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/BackMarket-oss/kube-transition-metrics/internal/statistics/state"
+	"github.com/rs/zerolog/log"
+	"k8s.io/utils/clock"
+)
+
+// bucketsPerWindow is the number of buckets each window is divided into, the windows roll by one bucket at a time.
+const bucketsPerWindow = 6
+
+//nolint:gochecknoglobals // These are constant sets of windows and record types.
+var (
+	// windows are the spans of the rolling windows the durations are summarized over.
+	windows = []time.Duration{time.Minute, 5 * time.Minute, time.Hour}
+	// summarizedTypes are the types of the metric records whose durations are summarized.
+	summarizedTypes = map[string]struct{}{
+		"pod":        {},
+		"container":  {},
+		"image_pull": {},
+	}
+)
+
+// groupKey identifies the records summarized together.
+type groupKey struct {
+	recordType string
+	namespace  string
+	ownerKind  string
+	ownerName  string
+}
+
+// bucket holds the sketches of the duration fields of the records written during a fraction of a window.
+type bucket struct {
+	start    time.Time
+	sketches map[string]*sketch
+}
+
+// rollingWindow holds the sketches of the duration fields of the records written during the span of the window, in
+// buckets which are reused as the window rolls.
+type rollingWindow struct {
+	span    time.Duration
+	buckets [bucketsPerWindow]bucket
+}
+
+// add counts the duration of the field in the bucket of the window at the time.
+func (w *rollingWindow) add(now time.Time, field string, value time.Duration) {
+	width := w.span / bucketsPerWindow
+	start := now.Truncate(width)
+	current := &w.buckets[(start.UnixNano()/int64(width))%bucketsPerWindow]
+
+	if !current.start.Equal(start) {
+		*current = bucket{start: start, sketches: make(map[string]*sketch)}
+	}
+
+	fieldSketch, ok := current.sketches[field]
+	if !ok {
+		fieldSketch = newSketch()
+		current.sketches[field] = fieldSketch
+	}
+
+	fieldSketch.add(value)
+}
+
+// summarize returns the summaries of the duration fields of the records written during the span of the window before
+// the time, which is empty if no records were written.
+// The current bucket is included, so the records are summarized over five sixths to the whole span of the window.
+func (w *rollingWindow) summarize(now time.Time) map[string]state.DurationSummary {
+	merged := make(map[string]*sketch)
+
+	for _, bucket := range w.buckets {
+		if bucket.sketches == nil || !bucket.start.After(now.Add(-w.span)) {
+			continue
+		}
+
+		for field, bucketSketch := range bucket.sketches {
+			fieldSketch, ok := merged[field]
+			if !ok {
+				fieldSketch = newSketch()
+				merged[field] = fieldSketch
+			}
+
+			fieldSketch.merge(bucketSketch)
+		}
+	}
+
+	summaries := make(map[string]state.DurationSummary, len(merged))
+
+	for field, fieldSketch := range merged {
+		summaries[field] = state.DurationSummary{
+			Count: fieldSketch.count,
+			Mean:  fieldSketch.mean(),
+			P50:   fieldSketch.quantile(0.5),  //nolint:mnd
+			P90:   fieldSketch.quantile(0.9),  //nolint:mnd
+			P99:   fieldSketch.quantile(0.99), //nolint:mnd
+			Max:   fieldSketch.max,
+		}
+	}
+
+	return summaries
+}
+
+// Aggregator summarizes the durations of the pod, container and image_pull metric records written to it per
+// namespace and owner, over rolling windows of 1m, 5m and 1h, and periodically reports the summaries as summary
+// records.
+// The owner of the pods is their top-level owner when the owners are resolved, or their controller otherwise.
+// Only the complete records are summarized, as the partial records may be written several times for the same pod.
+//
+// Aggregator implements [io.Writer], to consume the records written by the statistic event loops, and
+// [http.Handler], to serve the latest summary records as a JSON array.
+type Aggregator struct {
+	output   io.Writer
+	interval time.Duration
+	clock    clock.WithTicker
+
+	// mu protects the groups and the latest records, as the records are written by both statistic event loops.
+	mu     sync.Mutex
+	groups map[groupKey][]*rollingWindow
+	latest []json.RawMessage
+}
+
+// NewAggregator creates a new Aggregator reporting the summaries to the output at the interval, once started by
+// [Aggregator.Run].
+// The records are bucketed by the time they are written to the aggregator, according to the clock.
+func NewAggregator(output io.Writer, interval time.Duration, clock clock.WithTicker) *Aggregator {
+	return &Aggregator{
+		output:   output,
+		interval: interval,
+		clock:    clock,
+		groups:   make(map[groupKey][]*rollingWindow),
+		latest:   []json.RawMessage{},
+	}
+}
+
+// record is the subset of a metric record used to summarize its durations.
+type record struct {
+	Metrics map[string]any `json:"kube_transition_metrics"`
+}
+
+// Write summarizes the durations of the metric record, ignoring the records of other types and the partial records.
+// Write implements [io.Writer].
+func (a *Aggregator) Write(data []byte) (int, error) {
+	var written record
+	if err := json.Unmarshal(data, &written); err != nil {
+		return len(data), fmt.Errorf("failed to decode record for summaries: %w", err)
+	}
+
+	key, fields, ok := summarized(written.Metrics)
+	if !ok {
+		return len(data), nil
+	}
+
+	now := a.clock.Now()
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	group, ok := a.groups[key]
+	if !ok {
+		group = make([]*rollingWindow, 0, len(windows))
+		for _, span := range windows {
+			group = append(group, &rollingWindow{span: span})
+		}
+
+		a.groups[key] = group
+	}
+
+	for field, value := range fields {
+		for _, window := range group {
+			window.add(now, field, value)
+		}
+	}
+
+	return len(data), nil
+}
+
+// summarized returns the group and the duration fields of the metric record, or false if it is not summarized.
+func summarized(metrics map[string]any) (groupKey, map[string]time.Duration, bool) {
+	recordType, _ := metrics["type"].(string)
+	if _, ok := summarizedTypes[recordType]; !ok {
+		return groupKey{}, nil, false
+	}
+
+	if partial, _ := metrics["partial"].(bool); partial {
+		return groupKey{}, nil, false
+	}
+
+	values, ok := metrics[recordType].(map[string]any)
+	if !ok {
+		return groupKey{}, nil, false
+	}
+
+	key := groupKey{recordType: recordType}
+	key.namespace, _ = metrics["kube_namespace"].(string)
+
+	key.ownerKind, _ = metrics["kube_top_owner_kind"].(string)
+	key.ownerName, _ = metrics["kube_top_owner_name"].(string)
+
+	if key.ownerKind == "" {
+		key.ownerKind, _ = metrics["kube_ownerref_kind"].(string)
+		key.ownerName, _ = metrics["kube_ownerref_name"].(string)
+	}
+
+	fields := make(map[string]time.Duration)
+
+	for field, value := range values {
+		if seconds, ok := value.(float64); ok && strings.HasSuffix(field, "_seconds") {
+			fields[field] = time.Duration(seconds * float64(time.Second))
+		}
+	}
+
+	return key, fields, true
+}
+
+// Run reports the summaries at the interval until the context is done.
+func (a *Aggregator) Run(ctx context.Context) {
+	ticker := a.clock.NewTicker(a.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C():
+			a.report()
+		}
+	}
+}
+
+// Close reports the summaries a last time, after the statistic event loops are drained and [Run] returned.
+func (a *Aggregator) Close() {
+	a.report()
+}
+
+// report reports a summary record for each group and window with records, and forgets the groups without records in
+// any window.
+func (a *Aggregator) report() {
+	statistics := a.summarize()
+	latest := make([]json.RawMessage, 0, len(statistics))
+
+	for _, statistic := range statistics {
+		var buffer bytes.Buffer
+
+		statistic.Report(&buffer)
+
+		if buffer.Len() == 0 {
+			// The records are not written when the logging is disabled.
+			continue
+		}
+
+		if _, err := a.output.Write(buffer.Bytes()); err != nil {
+			log.Error().Err(err).Msg("Failed to write summary record")
+		}
+
+		latest = append(latest, bytes.TrimSpace(buffer.Bytes()))
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.latest = latest
+}
+
+// summarize returns the summary statistics of each group and window with records.
+func (a *Aggregator) summarize() []*state.SummaryStatistic {
+	now := a.clock.Now()
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	var statistics []*state.SummaryStatistic
+
+	// The groups are sorted so that the summary records are reported in a stable order.
+	for _, key := range slices.SortedFunc(maps.Keys(a.groups), compareGroupKeys) {
+		group := a.groups[key]
+		empty := true
+
+		for _, window := range group {
+			durations := window.summarize(now)
+			if len(durations) == 0 {
+				continue
+			}
+
+			empty = false
+
+			statistics = append(statistics, state.NewSummaryStatistic(
+				key.recordType, key.namespace, key.ownerKind, key.ownerName, window.span, durations))
+		}
+
+		if empty {
+			delete(a.groups, key)
+		}
+	}
+
+	return statistics
+}
+
+// compareGroupKeys orders the groups by record type, namespace and owner.
+func compareGroupKeys(a, b groupKey) int {
+	return cmp.Or(
+		strings.Compare(a.recordType, b.recordType),
+		strings.Compare(a.namespace, b.namespace),
+		strings.Compare(a.ownerKind, b.ownerKind),
+		strings.Compare(a.ownerName, b.ownerName),
+	)
+}
+
+// ServeHTTP serves the latest summary records as a JSON array.
+// ServeHTTP implements [http.Handler].
+func (a *Aggregator) ServeHTTP(writer http.ResponseWriter, _ *http.Request) {
+	a.mu.Lock()
+	body, err := json.Marshal(a.latest)
+	a.mu.Unlock()
+
+	if err != nil {
+		http.Error(writer, err.Error(), http.StatusInternalServerError)
+
+		return
+	}
+
+	writer.Header().Set("Content-Type", "application/json")
+	_, _ = writer.Write(body)
+}
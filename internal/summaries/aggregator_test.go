@@ -0,0 +1,184 @@
+package summaries
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/BackMarket-oss/kube-transition-metrics/internal/options"
+	"github.com/BackMarket-oss/kube-transition-metrics/internal/testhelpers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	clocktesting "k8s.io/utils/clock/testing"
+)
+
+var started = time.Date(2023, 8, 28, 0, 0, 0, 0, time.UTC)
+
+// writeRecord writes a metric record of the type with the labels and the fields of the type to the aggregator.
+func writeRecord(t *testing.T, aggregator *Aggregator, recordType string, labels, fields map[string]any) {
+	t.Helper()
+
+	metrics := map[string]any{"type": recordType, recordType: fields}
+	for label, value := range labels {
+		metrics[label] = value
+	}
+
+	data, err := json.Marshal(map[string]any{"kube_transition_metrics": metrics})
+	require.NoError(t, err)
+
+	written, err := aggregator.Write(data)
+	require.NoError(t, err)
+	assert.Equal(t, len(data), written)
+}
+
+// summaries returns the summary records by record type, owner name and window.
+func summaries(t *testing.T, writer *testhelpers.MetricWriter) map[string]map[string]any {
+	t.Helper()
+
+	records := make(map[string]map[string]any)
+
+	for _, metrics := range testhelpers.DecodeMetricOutput(t, writer) {
+		require.Equal(t, "summary", metrics["type"])
+
+		summary, _ := metrics["summary"].(map[string]any)
+		require.NotNil(t, summary)
+
+		owner, _ := metrics["kube_top_owner_name"].(string)
+		window, _ := summary["window_seconds"].(float64)
+		key := summary["record_type"].(string) + "/" + owner + "/" + time.Duration(window*float64(time.Second)).String()
+		records[key], _ = summary["durations"].(map[string]any)
+	}
+
+	return records
+}
+
+// count returns the count of the summary of the duration field.
+func count(durations map[string]any, field string) any {
+	summary, _ := durations[field].(map[string]any)
+
+	return summary["count"]
+}
+
+func TestAggregatorWindows(t *testing.T) {
+	testhelpers.ConfigureLogging(t, &options.Options{})
+
+	clock := clocktesting.NewFakeClock(started)
+	writer := testhelpers.NewMetricWriter(t)
+	aggregator := NewAggregator(writer, time.Minute, clock)
+
+	web := map[string]any{
+		"kube_namespace":      "test-namespace",
+		"kube_top_owner_kind": "deployment",
+		"kube_top_owner_name": "web",
+		"kube_ownerref_kind":  "replicaset",
+		"kube_ownerref_name":  "web-abc",
+	}
+	writeRecord(t, aggregator, "pod", web, map[string]any{"creation_to_ready_seconds": 2.0})
+	writeRecord(t, aggregator, "pod", web, map[string]any{"creation_to_ready_seconds": 4.0, "ready_timestamp": "x"})
+	writeRecord(t, aggregator, "pod", map[string]any{"kube_namespace": "test-namespace", "partial": true},
+		map[string]any{"creation_to_ready_seconds": 100.0})
+	writeRecord(t, aggregator, "rollout", web, map[string]any{"duration_seconds": 100.0})
+	writeRecord(t, aggregator, "container",
+		map[string]any{"kube_namespace": "test-namespace", "kube_ownerref_kind": "job", "kube_ownerref_name": "batch"},
+		map[string]any{"running_to_ready_seconds": 1.0})
+
+	clock.Step(2 * time.Minute)
+	writeRecord(t, aggregator, "pod", web, map[string]any{"creation_to_ready_seconds": 6.0})
+
+	aggregator.report()
+
+	records := summaries(t, writer)
+	assert.Len(t, records, 5, "Expected a summary of each window with records for each group")
+
+	assert.InDelta(t, 1, count(records["pod/web/1m0s"], "creation_to_ready_seconds"), 0)
+	assert.InDelta(t, 3, count(records["pod/web/5m0s"], "creation_to_ready_seconds"), 0)
+	assert.InDelta(t, 3, count(records["pod/web/1h0m0s"], "creation_to_ready_seconds"), 0)
+	assert.NotContains(t, records["pod/web/1h0m0s"], "ready_timestamp", "Expected only the durations to be summarized")
+	assert.Len(t, records["pod/web/1h0m0s"], 1, "Expected the partial and rollout records to be ignored")
+
+	summary, _ := records["pod/web/5m0s"]["creation_to_ready_seconds"].(map[string]any)
+	assert.InDelta(t, 4, summary["mean_seconds"], 0.001)
+	assert.InEpsilon(t, 4, summary["p50_seconds"], sketchAccuracy)
+	assert.InEpsilon(t, 6, summary["p90_seconds"], sketchAccuracy)
+	assert.InDelta(t, 6, summary["max_seconds"], 0.001)
+
+	assert.NotContains(t, records, "container/batch/1m0s", "Expected the 1m window to exclude the older records")
+	assert.InDelta(t, 1, count(records["container/batch/5m0s"], "running_to_ready_seconds"), 0,
+		"Expected the controller to be the owner when the owners are not resolved")
+}
+
+func TestAggregatorForgetsGroups(t *testing.T) {
+	testhelpers.ConfigureLogging(t, &options.Options{})
+
+	clock := clocktesting.NewFakeClock(started)
+	writer := testhelpers.NewMetricWriter(t)
+	aggregator := NewAggregator(writer, time.Minute, clock)
+
+	writeRecord(t, aggregator, "image_pull", map[string]any{"kube_namespace": "test-namespace"},
+		map[string]any{"duration_seconds": 3.0})
+
+	clock.Step(time.Hour)
+	aggregator.report()
+
+	assert.Empty(t, testhelpers.DecodeMetricOutput(t, writer), "Expected no summaries once the windows elapsed")
+	assert.Empty(t, aggregator.groups, "Expected the groups without records to be forgotten")
+}
+
+func TestAggregatorClose(t *testing.T) {
+	testhelpers.ConfigureLogging(t, &options.Options{})
+
+	clock := clocktesting.NewFakeClock(started)
+	writer := testhelpers.NewMetricWriter(t)
+	aggregator := NewAggregator(writer, time.Minute, clock)
+
+	writeRecord(t, aggregator, "image_pull", map[string]any{"kube_namespace": "test-namespace"},
+		map[string]any{"duration_seconds": 3.0})
+	aggregator.Close()
+
+	assert.Len(t, testhelpers.DecodeMetricOutput(t, writer), len(windows), "Expected a summary of each window on close")
+}
+
+func TestAggregatorRunAndServeHTTP(t *testing.T) {
+	testhelpers.ConfigureLogging(t, &options.Options{})
+
+	clock := clocktesting.NewFakeClock(started)
+	writer := testhelpers.NewMetricWriter(t)
+	aggregator := NewAggregator(writer, time.Minute, clock)
+
+	ctx, cancel := context.WithCancel(t.Context())
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		aggregator.Run(ctx)
+	}()
+
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+
+	require.Eventually(t, clock.HasWaiters, time.Second, time.Millisecond, "Expected the aggregator to wait to report")
+	clock.Step(30 * time.Second)
+
+	writeRecord(t, aggregator, "image_pull", map[string]any{"kube_namespace": "test-namespace"},
+		map[string]any{"duration_seconds": 3.0})
+	clock.Step(30 * time.Second)
+
+	require.Eventually(t, func() bool {
+		return len(testhelpers.DecodeMetricOutput(t, writer)) == len(windows)
+	}, time.Second, time.Millisecond, "Expected a summary of each window to be reported")
+
+	recorder := httptest.NewRecorder()
+	aggregator.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/summaries", nil))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "application/json", recorder.Header().Get("Content-Type"))
+
+	var records []map[string]any
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &records))
+	assert.Len(t, records, len(windows), "Expected the latest summary records to be served")
+}
@@ -0,0 +1,122 @@
+package summaries
+
+import (
+	"maps"
+	"math"
+	"slices"
+	"time"
+)
+
+// sketchAccuracy is the relative accuracy of the quantiles estimated by the sketches.
+const sketchAccuracy = 0.01
+
+// minSketchValue is the smallest duration counted in a logarithmic bucket, smaller durations are counted as zero.
+const minSketchValue = time.Microsecond
+
+//nolint:gochecknoglobals // These are constants derived from sketchAccuracy.
+var (
+	// sketchGamma is the ratio between the bounds of consecutive buckets.
+	sketchGamma = (1 + sketchAccuracy) / (1 - sketchAccuracy)
+	// sketchLogGamma is the logarithm of sketchGamma, used to compute the bucket of a value.
+	sketchLogGamma = math.Log(sketchGamma)
+)
+
+// sketch is a mergeable quantile sketch of durations, with a relative accuracy of sketchAccuracy.
+//
+// As in DDSketch, the durations are counted in buckets whose bounds grow logarithmically, so that the sketches of
+// separate windows are merged by adding their bucket counts, and the number of buckets grows with the logarithm of the
+// range of the durations rather than with their count.
+// Negative durations, e.g. caused by clock skew, are counted as zero for the quantiles.
+type sketch struct {
+	buckets map[int]uint64
+	zeros   uint64
+	count   uint64
+	sum     time.Duration
+	max     time.Duration
+}
+
+// newSketch creates a new empty sketch.
+func newSketch() *sketch {
+	return &sketch{buckets: make(map[int]uint64)}
+}
+
+// add counts a duration in the sketch.
+func (s *sketch) add(value time.Duration) {
+	if s.count == 0 || value > s.max {
+		s.max = value
+	}
+
+	s.count++
+	s.sum += value
+
+	if value < minSketchValue {
+		s.zeros++
+
+		return
+	}
+
+	s.buckets[bucketOf(value)]++
+}
+
+// merge adds the durations counted by the other sketch to the sketch.
+func (s *sketch) merge(other *sketch) {
+	if other.count == 0 {
+		return
+	}
+
+	if s.count == 0 || other.max > s.max {
+		s.max = other.max
+	}
+
+	s.count += other.count
+	s.sum += other.sum
+	s.zeros += other.zeros
+
+	for bucket, count := range other.buckets {
+		s.buckets[bucket] += count
+	}
+}
+
+// mean returns the mean of the durations counted.
+func (s *sketch) mean() time.Duration {
+	if s.count == 0 {
+		return 0
+	}
+
+	return s.sum / time.Duration(s.count) //nolint:gosec // The count of a window does not overflow int64.
+}
+
+// quantile returns an estimate of the nearest-rank quantile of the durations counted, within sketchAccuracy of the
+// exact value.
+func (s *sketch) quantile(q float64) time.Duration {
+	if s.count == 0 {
+		return 0
+	}
+
+	rank := max(uint64(math.Ceil(q*float64(s.count))), 1)
+	if rank <= s.zeros {
+		return 0
+	}
+
+	cumulative := s.zeros
+
+	for _, bucket := range slices.Sorted(maps.Keys(s.buckets)) {
+		cumulative += s.buckets[bucket]
+		if cumulative >= rank {
+			// The estimate of the largest bucket may exceed the maximum, which is exact.
+			return min(bucketValue(bucket), s.max)
+		}
+	}
+
+	return s.max
+}
+
+// bucketOf returns the bucket counting the duration, whose bounds are (gamma^(bucket-1), gamma^bucket] nanoseconds.
+func bucketOf(value time.Duration) int {
+	return int(math.Ceil(math.Log(float64(value)) / sketchLogGamma))
+}
+
+// bucketValue returns the estimate of the durations counted in the bucket, within sketchAccuracy of its bounds.
+func bucketValue(bucket int) time.Duration {
+	return time.Duration(2 * math.Pow(sketchGamma, float64(bucket)) / (sketchGamma + 1))
+}
@@ -0,0 +1,83 @@
+package summaries
+
+import (
+	"math"
+	"math/rand/v2"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// assertWithinAccuracy asserts the estimate is within the accuracy of the sketches of the expected duration.
+func assertWithinAccuracy(t *testing.T, expected, estimate time.Duration, msgAndArgs ...any) {
+	t.Helper()
+
+	assert.InEpsilon(t, expected.Seconds(), estimate.Seconds(), sketchAccuracy, msgAndArgs...)
+}
+
+func TestSketchQuantiles(t *testing.T) {
+	t.Parallel()
+
+	random := rand.New(rand.NewPCG(1, 2)) //nolint:gosec // The samples do not need to be secure.
+	values := make([]time.Duration, 0, 10000)
+	sketch := newSketch()
+
+	for range 10000 {
+		value := time.Duration(random.ExpFloat64() * float64(10*time.Second))
+		values = append(values, value)
+		sketch.add(value)
+	}
+
+	slices.Sort(values)
+
+	for _, q := range []float64{0.5, 0.9, 0.99} {
+		expected := values[int(math.Ceil(q*float64(len(values))))-1]
+		assertWithinAccuracy(t, expected, sketch.quantile(q), "Unexpected estimate of quantile %v", q)
+	}
+
+	assert.Equal(t, uint64(len(values)), sketch.count)
+	assert.Equal(t, values[len(values)-1], sketch.max, "Expected the maximum to be exact")
+	assert.LessOrEqual(t, sketch.quantile(1), sketch.max, "Expected the estimate not to exceed the maximum")
+}
+
+func TestSketchMerge(t *testing.T) {
+	t.Parallel()
+
+	first, second, all := newSketch(), newSketch(), newSketch()
+
+	for i := range 100 {
+		value := time.Duration(i+1) * time.Second
+		all.add(value)
+
+		if i%2 == 0 {
+			first.add(value)
+		} else {
+			second.add(value)
+		}
+	}
+
+	first.merge(second)
+	first.merge(newSketch())
+
+	assert.Equal(t, all, first, "Expected merged sketches to equal the sketch of all the durations")
+	assert.Equal(t, 50500*time.Millisecond, first.mean())
+	assertWithinAccuracy(t, 50*time.Second, first.quantile(0.5))
+}
+
+func TestSketchZeros(t *testing.T) {
+	t.Parallel()
+
+	sketch := newSketch()
+	assert.Zero(t, sketch.quantile(0.5), "Expected empty sketch quantiles to be zero")
+	assert.Zero(t, sketch.mean(), "Expected empty sketch mean to be zero")
+
+	sketch.add(-time.Second)
+	sketch.add(0)
+	sketch.add(time.Second)
+
+	assert.Zero(t, sketch.quantile(0.5), "Expected negative and zero durations to be counted as zero")
+	assert.Equal(t, time.Second, sketch.max)
+	assert.Zero(t, sketch.mean())
+}
@@ -5,16 +5,20 @@ import (
 	"encoding/json"
 	"io"
 	"strings"
+	"sync"
 	"testing"
 
 	"github.com/BackMarket-oss/kube-transition-metrics/internal/logging"
+	"github.com/BackMarket-oss/kube-transition-metrics/internal/options"
 	"github.com/stretchr/testify/require"
 )
 
 // MetricWriter is an [io.Writer] that validates each written JSON document against the
 // kube_transition_metrics JSON schema and captures the data for inspection.
+// It is safe for concurrent use, so it can be shared by the pod and image pull statistic event loops.
 type MetricWriter struct {
 	t        *testing.T
+	mu       *sync.Mutex
 	buf      *bytes.Buffer
 	validate io.Writer
 }
@@ -23,10 +27,19 @@ type MetricWriter struct {
 func NewMetricWriter(t *testing.T) *MetricWriter {
 	t.Helper()
 
+	return NewLabeledMetricWriter(t, &options.Options{})
+}
+
+// NewLabeledMetricWriter creates a new MetricWriter for the given test, accepting the fields of the label mappings of
+// the options.
+func NewLabeledMetricWriter(t *testing.T, opts *options.Options) *MetricWriter {
+	t.Helper()
+
 	return &MetricWriter{
 		t:        t,
+		mu:       &sync.Mutex{},
 		buf:      &bytes.Buffer{},
-		validate: logging.NewValidationWriter(),
+		validate: logging.NewValidationWriter(opts),
 	}
 }
 
@@ -35,6 +48,9 @@ func NewMetricWriter(t *testing.T) *MetricWriter {
 func (w *MetricWriter) Write(data []byte) (int, error) {
 	w.t.Helper()
 
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
 	_, err := w.validate.Write(data)
 	if err != nil {
 		w.t.Errorf("metric output failed schema validation: %v", err)
@@ -51,9 +67,13 @@ func (w *MetricWriter) Write(data []byte) (int, error) {
 func DecodeMetricOutput(t *testing.T, writer *MetricWriter) []map[string]any {
 	t.Helper()
 
+	writer.mu.Lock()
+	output := writer.buf.String()
+	writer.mu.Unlock()
+
 	var metrics []map[string]any
 
-	for line := range strings.SplitSeq(writer.buf.String(), "\n") {
+	for line := range strings.SplitSeq(output, "\n") {
 		if line == "" {
 			continue
 		}
@@ -0,0 +1,65 @@
+package testhelpers
+
+import (
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	apimachinerytypes "k8s.io/apimachinery/pkg/types"
+)
+
+// Created is the creation time of the pods of the tests.
+//
+//nolint:gochecknoglobals // This is a constant time shared by the tests.
+var Created = time.Date(2023, 8, 28, 0, 0, 0, 0, time.UTC)
+
+// PodTransitions are the delays from the creation of a pod to its conditions becoming true.
+type PodTransitions struct {
+	Scheduled   time.Duration
+	Initialized time.Duration
+	Ready       time.Duration
+}
+
+// NewReadyPod returns a pod of the namespace created at the given time, with a single container app of the image
+// registry.example.com/app:1.2, which becomes Ready after the transitions.
+// The container is already running, started and ready.
+// The UID of the pod is its name.
+func NewReadyPod(namespace, name string, created time.Time, transitions PodTransitions) *corev1.Pod {
+	condition := func(conditionType corev1.PodConditionType, delay time.Duration) corev1.PodCondition {
+		return corev1.PodCondition{
+			Type:               conditionType,
+			Status:             corev1.ConditionTrue,
+			LastTransitionTime: metav1.NewTime(created.Add(delay)),
+		}
+	}
+
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              name,
+			Namespace:         namespace,
+			UID:               apimachinerytypes.UID(name),
+			CreationTimestamp: metav1.NewTime(created),
+		},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{{Name: "app", Image: "registry.example.com/app:1.2"}},
+		},
+		Status: corev1.PodStatus{
+			Conditions: []corev1.PodCondition{
+				condition(corev1.PodScheduled, transitions.Scheduled),
+				condition(corev1.PodInitialized, transitions.Initialized),
+				condition(corev1.PodReady, transitions.Ready),
+			},
+			ContainerStatuses: []corev1.ContainerStatus{{
+				Name:    "app",
+				State:   corev1.ContainerState{Running: &corev1.ContainerStateRunning{}},
+				Started: new(true),
+				Ready:   true,
+			}},
+		},
+	}
+}
+
+// ImagePullEvent returns a Pulling or Pulled Event at the offset from [Created].
+func ImagePullEvent(reason string, offset time.Duration) *corev1.Event {
+	return &corev1.Event{Reason: reason, LastTimestamp: metav1.NewTime(Created.Add(offset))}
+}
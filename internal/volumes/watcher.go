@@ -0,0 +1,118 @@
+// Package volumes watches the Events of PersistentVolumeClaims to report the provisioning of the volumes of pods.
+package volumes
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/BackMarket-oss/kube-transition-metrics/internal/statistics/state"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+)
+
+// ErrCacheSync is returned when the Event informer cache fails to sync.
+var ErrCacheSync = errors.New("failed to sync claim event informer cache")
+
+// ClaimEventHandler is called for the provisioning Events of PersistentVolumeClaims.
+type ClaimEventHandler func(event *corev1.Event)
+
+// Watcher watches the Events of the PersistentVolumeClaims of the cluster.
+type Watcher struct {
+	factory informers.SharedInformerFactory
+	events  cache.SharedIndexInformer
+}
+
+// Start starts the informer caching the Events of PersistentVolumeClaims until the context is done, waits for its
+// initial sync, and returns a Watcher backed by it.
+func Start(ctx context.Context, clientset kubernetes.Interface) (*Watcher, error) {
+	factory := informers.NewSharedInformerFactoryWithOptions(
+		clientset,
+		0,
+		informers.WithTweakListOptions(func(options *metav1.ListOptions) {
+			options.FieldSelector = fields.OneTermEqualSelector("involvedObject.kind", "PersistentVolumeClaim").String()
+		}),
+		informers.WithTransform(strip),
+	)
+	// The informer must be requested before starting the factory.
+	events := factory.Core().V1().Events().Informer()
+
+	factory.Start(ctx.Done())
+
+	if !cache.WaitForCacheSync(ctx.Done(), events.HasSynced) {
+		return nil, fmt.Errorf("%w: %w", ErrCacheSync, ctx.Err())
+	}
+
+	return &Watcher{factory: factory, events: events}, nil
+}
+
+// HandleClaimEvents calls the handler for each provisioning Event of a PersistentVolumeClaim, including when a
+// repeated Event is updated, but not for the Events already present when the handler is added.
+func (w *Watcher) HandleClaimEvents(handler ClaimEventHandler) error {
+	_, err := w.events.AddEventHandler(cache.ResourceEventHandlerDetailedFuncs{
+		AddFunc: func(obj any, isInInitialList bool) {
+			if !isInInitialList {
+				notify(obj, handler)
+			}
+		},
+		UpdateFunc: func(_, newObj any) {
+			notify(newObj, handler)
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to add claim Event handler: %w", err)
+	}
+
+	return nil
+}
+
+// Close waits for the informer to stop after the context passed to [Start] is done, so that the handler is no longer
+// called.
+func (w *Watcher) Close() {
+	w.factory.Shutdown()
+}
+
+// notify calls the handler if the object is a provisioning Event of a PersistentVolumeClaim.
+func notify(obj any, handler ClaimEventHandler) {
+	event, ok := obj.(*corev1.Event)
+	if !ok || event.InvolvedObject.Kind != "PersistentVolumeClaim" {
+		return
+	}
+
+	switch event.Reason {
+	case state.ClaimReasonWaitForFirstConsumer, state.ClaimReasonProvisioned:
+		handler(event)
+	}
+}
+
+// strip keeps only the fields of the Events used by the watcher, to limit the memory used by the cache.
+// strip implements [k8s.io/client-go/tools/cache.TransformFunc].
+func strip(object any) (any, error) {
+	event, ok := object.(*corev1.Event)
+	if !ok {
+		// Tombstones of deleted objects are passed through unchanged.
+		return object, nil
+	}
+
+	return &corev1.Event{
+		TypeMeta: event.TypeMeta,
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            event.Name,
+			Namespace:       event.Namespace,
+			UID:             event.UID,
+			ResourceVersion: event.ResourceVersion,
+		},
+		InvolvedObject: event.InvolvedObject,
+		Reason:         event.Reason,
+		Message:        event.Message,
+		FirstTimestamp: event.FirstTimestamp,
+		LastTimestamp:  event.LastTimestamp,
+		EventTime:      event.EventTime,
+		Count:          event.Count,
+		Series:         event.Series,
+	}, nil
+}
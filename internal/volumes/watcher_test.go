@@ -0,0 +1,66 @@
+package volumes
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func newTestingEvent(kind, reason string) *corev1.Event {
+	return &corev1.Event{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:     "test-namespace",
+			Name:          "data-web-0.17c0",
+			ManagedFields: []metav1.ManagedFieldsEntry{{Manager: "kube-controller-manager"}},
+		},
+		InvolvedObject: corev1.ObjectReference{Kind: kind, Namespace: "test-namespace", Name: "data-web-0"},
+		Reason:         reason,
+		Message:        "Successfully provisioned volume pvc-1234",
+		Source:         corev1.EventSource{Component: "ebs.csi.aws.com"},
+	}
+}
+
+func TestNotify(t *testing.T) {
+	t.Parallel()
+
+	var handled []*corev1.Event
+
+	handler := func(event *corev1.Event) { handled = append(handled, event) }
+
+	notify(newTestingEvent("PersistentVolumeClaim", "ProvisioningSucceeded"), handler)
+	notify(newTestingEvent("PersistentVolumeClaim", "WaitForFirstConsumer"), handler)
+	notify(newTestingEvent("PersistentVolumeClaim", "ExternalProvisioning"), handler)
+	notify(newTestingEvent("Pod", "ProvisioningSucceeded"), handler)
+	notify(cacheTombstone{}, handler)
+
+	require.Len(t, handled, 2, "Expected only the provisioning Events of claims to be handled")
+	assert.Equal(t, "ProvisioningSucceeded", handled[0].Reason)
+	assert.Equal(t, "WaitForFirstConsumer", handled[1].Reason)
+}
+
+func TestStrip(t *testing.T) {
+	t.Parallel()
+
+	event := newTestingEvent("PersistentVolumeClaim", "ProvisioningSucceeded")
+
+	stripped, err := strip(event)
+	require.NoError(t, err)
+
+	strippedEvent, ok := stripped.(*corev1.Event)
+	require.True(t, ok, "Expected an Event")
+	assert.Empty(t, strippedEvent.ManagedFields, "Expected managed fields to be stripped")
+	assert.Empty(t, strippedEvent.Source, "Expected source to be stripped")
+	assert.Equal(t, event.InvolvedObject, strippedEvent.InvolvedObject)
+	assert.Equal(t, event.Message, strippedEvent.Message)
+
+	tombstone := cacheTombstone{}
+	passed, err := strip(tombstone)
+	require.NoError(t, err)
+	assert.Equal(t, tombstone, passed, "Expected other objects to be passed through")
+}
+
+// cacheTombstone stands for the objects which are not Events.
+type cacheTombstone struct{}
@@ -0,0 +1,322 @@
+// Package workloadreports keeps a WorkloadStartupReport custom resource updated for each Deployment and StatefulSet,
+// with the rolling startup statistics of its pods, so that teams can read them with kubectl in their own namespaces.
+package workloadreports
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/BackMarket-oss/kube-transition-metrics/internal/statistics/state"
+	"github.com/rs/zerolog/log"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/utils/clock"
+)
+
+const (
+	// Group is the API group of the WorkloadStartupReport custom resource.
+	Group = "transitionmetrics.backmarket.com"
+	// Version is the API version of the WorkloadStartupReport custom resource.
+	Version = "v1alpha1"
+	// Kind is the kind of the WorkloadStartupReport custom resource.
+	Kind = "WorkloadStartupReport"
+)
+
+// fieldManager is the field manager of the reports written by the controller.
+const fieldManager = "kube-transition-metrics"
+
+// idleRetention is the duration after which the statistics of a workload without pending pods nor new statistics are
+// forgotten. The report is kept, and deleted with the workload by the garbage collector.
+const idleRetention = 24 * time.Hour
+
+// Resource is the resource of the WorkloadStartupReport custom resource.
+//
+//nolint:gochecknoglobals // This is a constant resource.
+var Resource = schema.GroupVersionResource{Group: Group, Version: Version, Resource: "workloadstartupreports"}
+
+// reportedKinds are the kinds of the workloads with a report.
+//
+//nolint:gochecknoglobals // This is a constant set of kinds.
+var reportedKinds = []string{"Deployment", "StatefulSet"}
+
+// OwnerChainer resolves the chain of controllers owning a pod, from the direct controller to the top-level owner.
+//
+// OwnerChainer is implemented by [github.com/BackMarket-oss/kube-transition-metrics/internal/owners.Resolver].
+type OwnerChainer interface {
+	Chain(pod *corev1.Pod) []metav1.OwnerReference
+}
+
+// Reporter aggregates the recent pod startup durations, image pulls, pending pods and last rollout of each Deployment
+// and StatefulSet, and periodically writes them to the status of the WorkloadStartupReport of the workload, once
+// started by [Reporter.Run].
+// The reports are owned by their workloads, so they are deleted with them.
+// Only the reports whose status changed are written, and the reports failing to be written are retried at the next
+// interval.
+//
+// Reporter implements the PodStatisticObserver, PodCreationObserver, PodDeletionObserver and
+// ImagePullStatisticObserver interfaces of
+// [github.com/BackMarket-oss/kube-transition-metrics/internal/statistics/types], and the RolloutObserver interface of
+// [github.com/BackMarket-oss/kube-transition-metrics/internal/rollouts].
+type Reporter struct {
+	client         dynamic.Interface
+	owners         OwnerChainer
+	clock          clock.WithTicker
+	interval       time.Duration
+	stuckThreshold time.Duration
+
+	// mu protects the workloads, which are updated by both statistic event loops and the rollout tracker, and written
+	// by Run.
+	mu        sync.Mutex
+	workloads map[workloadKey]*workload
+}
+
+// NewReporter creates a new Reporter writing the reports with the client at the interval, reporting the pods pending
+// for longer than the stuck threshold as stuck.
+func NewReporter(
+	client dynamic.Interface,
+	owners OwnerChainer,
+	clock clock.WithTicker,
+	interval time.Duration,
+	stuckThreshold time.Duration,
+) *Reporter {
+	return &Reporter{
+		client:         client,
+		owners:         owners,
+		clock:          clock,
+		interval:       interval,
+		stuckThreshold: stuckThreshold,
+		workloads:      make(map[workloadKey]*workload),
+	}
+}
+
+// ObservePodCreation adds the pod to the pending pods of its workload.
+// ObservePodCreation implements
+// [github.com/BackMarket-oss/kube-transition-metrics/internal/statistics/types.PodCreationObserver].
+func (r *Reporter) ObservePodCreation(pod *corev1.Pod) {
+	r.update(pod, func(w *workload) {
+		w.pending[pod.UID] = pendingPod{name: pod.Name, created: pod.CreationTimestamp.Time}
+	})
+}
+
+// ObservePodStatistic adds the creation to ready duration of the pod to the recent startups of its workload.
+// ObservePodStatistic implements
+// [github.com/BackMarket-oss/kube-transition-metrics/internal/statistics/types.PodStatisticObserver].
+func (r *Reporter) ObservePodStatistic(pod *corev1.Pod, statistic *state.PodStatistic) {
+	r.update(pod, func(w *workload) {
+		delete(w.pending, pod.UID)
+		w.addStartup(statistic.ReadyTimestamp().Sub(statistic.CreationTimestamp()))
+	})
+}
+
+// ObservePodDeletion removes the pod from the pending pods of its workload.
+// ObservePodDeletion implements
+// [github.com/BackMarket-oss/kube-transition-metrics/internal/statistics/types.PodDeletionObserver].
+func (r *Reporter) ObservePodDeletion(pod *corev1.Pod) {
+	r.update(pod, func(w *workload) {
+		delete(w.pending, pod.UID)
+	})
+}
+
+// ObserveImagePullStatistic adds the image pull to the recent image pulls of the workload of the pod, unless the image
+// was already present on the node.
+// ObserveImagePullStatistic implements
+// [github.com/BackMarket-oss/kube-transition-metrics/internal/statistics/types.ImagePullStatisticObserver].
+func (r *Reporter) ObserveImagePullStatistic(pod *corev1.Pod, statistic *state.ContainerImagePullStatistic) {
+	if statistic.AlreadyPresent() {
+		return
+	}
+
+	r.update(pod, func(w *workload) {
+		w.addImagePull(imagePull{image: statistic.Image(pod), duration: statistic.Duration()})
+	})
+}
+
+// ObserveRollout records the rollout as the last rollout of its workload, if the workload has pods tracked.
+// ObserveRollout implements [github.com/BackMarket-oss/kube-transition-metrics/internal/rollouts.RolloutObserver].
+func (r *Reporter) ObserveRollout(statistic *state.RolloutStatistic) {
+	kind, namespace, name := statistic.Workload()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// The UID of the workload is only known from the owner references of its pods.
+	if w, ok := r.workloads[workloadKey{namespace: namespace, kind: kind, name: name}]; ok {
+		w.lastRollout = statistic
+		w.lastActivity = r.clock.Now()
+	}
+}
+
+// update applies the update to the workload of the pod, if the pod is owned by a Deployment or a StatefulSet.
+func (r *Reporter) update(pod *corev1.Pod, update func(*workload)) {
+	key, owner, ok := r.workloadOf(pod)
+	if !ok {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	w, ok := r.workloads[key]
+	if !ok {
+		w = newWorkload(owner)
+		r.workloads[key] = w
+	}
+
+	update(w)
+	w.lastActivity = r.clock.Now()
+}
+
+// workloadOf returns the key and the owner reference of the Deployment or StatefulSet owning the pod, ok is false if
+// the pod is not owned by one.
+func (r *Reporter) workloadOf(pod *corev1.Pod) (workloadKey, metav1.OwnerReference, bool) {
+	for _, ownerRef := range r.owners.Chain(pod) {
+		if ownerRef.APIVersion == "apps/v1" && slices.Contains(reportedKinds, ownerRef.Kind) {
+			key := workloadKey{namespace: pod.Namespace, kind: ownerRef.Kind, name: ownerRef.Name}
+			owner := metav1.OwnerReference{
+				APIVersion: ownerRef.APIVersion,
+				Kind:       ownerRef.Kind,
+				Name:       ownerRef.Name,
+				UID:        ownerRef.UID,
+			}
+
+			return key, owner, true
+		}
+	}
+
+	return workloadKey{}, metav1.OwnerReference{}, false
+}
+
+// Run writes the reports at the interval until the context is done.
+func (r *Reporter) Run(ctx context.Context) {
+	ticker := r.clock.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C():
+			r.flush(ctx)
+		}
+	}
+}
+
+// Close writes the changed reports a last time, after the statistic event loops are drained and [Run] returned.
+// The context of [Run] is done, so the reports are written with a new context.
+func (r *Reporter) Close() {
+	r.flush(context.Background())
+}
+
+// reportUpdate is a status to write to the report of a workload.
+type reportUpdate struct {
+	key    workloadKey
+	owner  metav1.OwnerReference
+	status map[string]any
+}
+
+// flush writes the reports whose status changed since they were last written.
+func (r *Reporter) flush(ctx context.Context) {
+	now := r.clock.Now()
+
+	for _, update := range r.changes(now) {
+		if err := r.write(ctx, update, now); err != nil {
+			log.Error().Err(err).
+				Str("kube_namespace", update.key.namespace).
+				Str("report_name", update.key.reportName()).
+				Msg("Failed to write workload startup report")
+
+			continue
+		}
+
+		r.mu.Lock()
+		if w, ok := r.workloads[update.key]; ok {
+			w.written = update.status
+		}
+		r.mu.Unlock()
+	}
+}
+
+// changes returns the statuses of the reports which changed since they were last written, and forgets the idle
+// workloads.
+func (r *Reporter) changes(now time.Time) []reportUpdate {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var updates []reportUpdate
+
+	for key, w := range r.workloads {
+		if len(w.pending) == 0 && now.Sub(w.lastActivity) >= idleRetention {
+			delete(r.workloads, key)
+
+			continue
+		}
+
+		status := w.status(now, r.stuckThreshold)
+		if !reflect.DeepEqual(status, w.written) {
+			updates = append(updates, reportUpdate{key: key, owner: w.owner, status: status})
+		}
+	}
+
+	return updates
+}
+
+// write creates the report of the workload if it does not exist, and updates its status.
+func (r *Reporter) write(ctx context.Context, update reportUpdate, now time.Time) error {
+	reports := r.client.Resource(Resource).Namespace(update.key.namespace)
+	name := update.key.reportName()
+
+	report, err := reports.Get(ctx, name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		report, err = reports.Create(ctx, newReport(update, name), metav1.CreateOptions{FieldManager: fieldManager})
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to get or create report %s: %w", name, err)
+	}
+
+	// The status is copied, so that the status compared with the next changes does not include the update time.
+	status := make(map[string]any, len(update.status)+1)
+	for field, value := range update.status {
+		status[field] = value
+	}
+
+	status["updatedAt"] = now.UTC().Format(time.RFC3339)
+
+	if err := unstructured.SetNestedMap(report.Object, status, "status"); err != nil {
+		return fmt.Errorf("failed to set status of report %s: %w", name, err)
+	}
+
+	if _, err := reports.UpdateStatus(ctx, report, metav1.UpdateOptions{FieldManager: fieldManager}); err != nil {
+		return fmt.Errorf("failed to update status of report %s: %w", name, err)
+	}
+
+	return nil
+}
+
+// newReport returns a new report of the workload, owned by the workload.
+func newReport(update reportUpdate, name string) *unstructured.Unstructured {
+	report := &unstructured.Unstructured{Object: map[string]any{
+		"spec": map[string]any{
+			"workloadRef": map[string]any{
+				"apiVersion": update.owner.APIVersion,
+				"kind":       update.owner.Kind,
+				"name":       update.owner.Name,
+			},
+		},
+	}}
+	report.SetAPIVersion(Group + "/" + Version)
+	report.SetKind(Kind)
+	report.SetNamespace(update.key.namespace)
+	report.SetName(name)
+	report.SetOwnerReferences([]metav1.OwnerReference{update.owner})
+
+	return report
+}
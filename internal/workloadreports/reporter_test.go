@@ -0,0 +1,293 @@
+package workloadreports
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/BackMarket-oss/kube-transition-metrics/internal/options"
+	"github.com/BackMarket-oss/kube-transition-metrics/internal/statistics/state"
+	"github.com/BackMarket-oss/kube-transition-metrics/internal/testhelpers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	apimachinerytypes "k8s.io/apimachinery/pkg/types"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	k8stesting "k8s.io/client-go/testing"
+	clocktesting "k8s.io/utils/clock/testing"
+)
+
+// chainOwners resolves the owner chain of pods from their owner references, followed by the Deployment named by
+// the deployment label of the pod.
+type chainOwners struct{}
+
+func (chainOwners) Chain(pod *corev1.Pod) []metav1.OwnerReference {
+	chain := append([]metav1.OwnerReference{}, pod.OwnerReferences...)
+	if deployment, ok := pod.Labels["deployment"]; ok {
+		chain = append(chain, metav1.OwnerReference{
+			APIVersion: "apps/v1",
+			Kind:       "Deployment",
+			Name:       deployment,
+			UID:        apimachinerytypes.UID("deployment-" + deployment),
+			Controller: new(true),
+		})
+	}
+
+	return chain
+}
+
+// newTestingPod returns a pod of the Deployment web created at the offset, which becomes Ready after the startup.
+func newTestingPod(name string, offset, startup time.Duration) *corev1.Pod {
+	pod := testhelpers.NewReadyPod("test-namespace", name, testhelpers.Created.Add(offset),
+		testhelpers.PodTransitions{Ready: startup})
+	pod.Labels = map[string]string{"deployment": "web"}
+	pod.OwnerReferences = []metav1.OwnerReference{
+		{APIVersion: "apps/v1", Kind: "ReplicaSet", Name: "web-5d8f", Controller: new(true)},
+	}
+
+	return pod
+}
+
+// observeReady notifies the reporter of the creation of the pod, and of its complete statistic.
+func observeReady(reporter *Reporter, pod *corev1.Pod) {
+	reporter.ObservePodCreation(pod)
+	reporter.ObservePodStatistic(pod,
+		state.NewPodStatistic(pod.CreationTimestamp.Time, pod).Update(testhelpers.Created, pod))
+}
+
+func newTestingReporter(t *testing.T) (*Reporter, *dynamicfake.FakeDynamicClient, *clocktesting.FakeClock) {
+	t.Helper()
+
+	testhelpers.ConfigureLogging(t, &options.Options{})
+
+	client := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(
+		runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{Resource: Kind + "List"},
+	)
+	clock := clocktesting.NewFakeClock(testhelpers.Created)
+
+	return NewReporter(client, chainOwners{}, clock, time.Minute, 5*time.Minute), client, clock
+}
+
+// getReport returns the report of the Deployment web.
+func getReport(t *testing.T, client *dynamicfake.FakeDynamicClient) *unstructured.Unstructured {
+	t.Helper()
+
+	report, err := client.Resource(Resource).Namespace("test-namespace").
+		Get(context.Background(), "deployment-web", metav1.GetOptions{})
+	require.NoError(t, err)
+
+	return report
+}
+
+// statusUpdates returns the number of status updates of the reports.
+func statusUpdates(client *dynamicfake.FakeDynamicClient) int {
+	updates := 0
+
+	for _, action := range client.Actions() {
+		if action.GetVerb() == "update" && action.GetSubresource() == "status" {
+			updates++
+		}
+	}
+
+	return updates
+}
+
+func TestReporterFlush(t *testing.T) {
+	t.Parallel()
+
+	reporter, client, clock := newTestingReporter(t)
+
+	observeReady(reporter, newTestingPod("web-1", 0, 10*time.Second))
+	observeReady(reporter, newTestingPod("web-2", 0, 20*time.Second))
+	reporter.ObservePodCreation(newTestingPod("web-3", 0, 0))
+
+	pulled := newTestingPod("web-2", 0, 20*time.Second)
+	reporter.ObserveImagePullStatistic(pulled,
+		state.NewContainerImagePullStatistic(pulled, false, pulled.Spec.Containers[0]).
+			Update(testhelpers.ImagePullEvent("Pulling", time.Second)).
+			Update(testhelpers.ImagePullEvent("Pulled", 9*time.Second)))
+	reporter.ObserveImagePullStatistic(pulled,
+		state.NewContainerImagePullStatistic(pulled, false, corev1.Container{Name: "app", Image: "cached:1.0"}).
+			Update(testhelpers.ImagePullEvent("Pulled", 9*time.Second)))
+
+	clock.Step(6 * time.Minute)
+	reporter.flush(context.Background())
+
+	report := getReport(t, client)
+	assert.Equal(t, Group+"/"+Version, report.GetAPIVersion())
+	assert.Equal(t, Kind, report.GetKind())
+	assert.Equal(t, []metav1.OwnerReference{
+		{APIVersion: "apps/v1", Kind: "Deployment", Name: "web", UID: "deployment-web"},
+	}, report.GetOwnerReferences())
+
+	workloadRef, _, err := unstructured.NestedStringMap(report.Object, "spec", "workloadRef")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"apiVersion": "apps/v1", "kind": "Deployment", "name": "web"}, workloadRef)
+
+	status, _, err := unstructured.NestedMap(report.Object, "status")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{
+		"observedPods": int64(2),
+		"startup": map[string]any{
+			"p50Seconds": 10.0,
+			"p90Seconds": 20.0,
+			"p99Seconds": 20.0,
+			"maxSeconds": 20.0,
+		},
+		"slowestImages": []any{
+			map[string]any{"image": "registry.example.com/app:1.2", "durationSeconds": 8.0},
+		},
+		"stuckPods": []any{
+			map[string]any{"name": "web-3", "createdAt": "2023-08-28T00:00:00Z"},
+		},
+		"updatedAt": "2023-08-28T00:06:00Z",
+	}, status)
+
+	// The unchanged report is not written again.
+	clock.Step(time.Minute)
+	reporter.flush(context.Background())
+	assert.Equal(t, 1, statusUpdates(client))
+
+	reporter.ObservePodDeletion(newTestingPod("web-3", 0, 0))
+	reporter.flush(context.Background())
+	assert.Equal(t, 2, statusUpdates(client))
+
+	stuck, found, err := unstructured.NestedSlice(getReport(t, client).Object, "status", "stuckPods")
+	require.NoError(t, err)
+	assert.False(t, found, "deleted pod should not be stuck: %v", stuck)
+}
+
+func TestReporterRollout(t *testing.T) {
+	t.Parallel()
+
+	reporter, client, clock := newTestingReporter(t)
+
+	// The rollouts of workloads without tracked pods are ignored.
+	reporter.ObserveRollout(state.NewRolloutStatistic("StatefulSet", "test-namespace", "db", "3", testhelpers.Created))
+
+	observeReady(reporter, newTestingPod("web-1", 0, 10*time.Second))
+	reporter.ObserveRollout(state.NewRolloutStatistic("Deployment", "test-namespace", "web", "2", testhelpers.Created).
+		Finish(testhelpers.Created.Add(90*time.Second), false))
+
+	clock.Step(2 * time.Minute)
+	reporter.flush(context.Background())
+
+	lastRollout, _, err := unstructured.NestedMap(getReport(t, client).Object, "status", "lastRollout")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{
+		"revision":        "2",
+		"startedAt":       "2023-08-28T00:00:00Z",
+		"partial":         false,
+		"durationSeconds": 90.0,
+	}, lastRollout)
+
+	_, err = client.Resource(Resource).Namespace("test-namespace").
+		Get(context.Background(), "statefulset-db", metav1.GetOptions{})
+	assert.True(t, apierrors.IsNotFound(err))
+}
+
+func TestReporterIgnoredPods(t *testing.T) {
+	t.Parallel()
+
+	reporter, client, _ := newTestingReporter(t)
+
+	standalone := newTestingPod("standalone", 0, 10*time.Second)
+	standalone.Labels = nil
+	standalone.OwnerReferences = nil
+	observeReady(reporter, standalone)
+
+	reporter.flush(context.Background())
+	assert.Empty(t, client.Actions())
+}
+
+func TestReporterRetry(t *testing.T) {
+	t.Parallel()
+
+	reporter, client, clock := newTestingReporter(t)
+
+	failures := 1
+	client.PrependReactor("update", "workloadstartupreports",
+		func(k8stesting.Action) (bool, runtime.Object, error) {
+			if failures == 0 {
+				return false, nil, nil
+			}
+
+			failures--
+
+			return true, nil, apierrors.NewServiceUnavailable("unavailable")
+		})
+
+	observeReady(reporter, newTestingPod("web-1", 0, 10*time.Second))
+	reporter.flush(context.Background())
+
+	_, found, err := unstructured.NestedMap(getReport(t, client).Object, "status")
+	require.NoError(t, err)
+	assert.False(t, found)
+
+	clock.Step(time.Minute)
+	reporter.flush(context.Background())
+
+	observedPods, _, err := unstructured.NestedInt64(getReport(t, client).Object, "status", "observedPods")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), observedPods)
+}
+
+func TestReporterIdle(t *testing.T) {
+	t.Parallel()
+
+	reporter, _, clock := newTestingReporter(t)
+
+	observeReady(reporter, newTestingPod("web-1", 0, 10*time.Second))
+	reporter.ObservePodCreation(newTestingPod("web-2", 0, 0))
+
+	// Workloads with pending pods are kept.
+	clock.Step(idleRetention)
+	reporter.flush(context.Background())
+	assert.Len(t, reporter.workloads, 1)
+
+	reporter.ObservePodDeletion(newTestingPod("web-2", 0, 0))
+	clock.Step(idleRetention)
+	reporter.flush(context.Background())
+	assert.Empty(t, reporter.workloads)
+}
+
+func TestReporterRun(t *testing.T) {
+	t.Parallel()
+
+	reporter, client, clock := newTestingReporter(t)
+	observeReady(reporter, newTestingPod("web-1", 0, 10*time.Second))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		reporter.Run(ctx)
+	}()
+
+	require.Eventually(t, clock.HasWaiters, time.Second, time.Millisecond)
+	clock.Step(time.Minute)
+	require.Eventually(t, func() bool {
+		reporter.mu.Lock()
+		defer reporter.mu.Unlock()
+
+		return reporter.workloads[workloadKey{"test-namespace", "Deployment", "web"}].written != nil
+	}, time.Second, time.Millisecond)
+
+	cancel()
+	<-done
+
+	assert.Equal(t, 1, statusUpdates(client))
+
+	// The reports changed after the context is done are written on close.
+	observeReady(reporter, newTestingPod("web-2", 0, 20*time.Second))
+	reporter.Close()
+	assert.Equal(t, 2, statusUpdates(client))
+}
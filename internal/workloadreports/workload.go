@@ -0,0 +1,191 @@
+package workloadreports
+
+import (
+	"cmp"
+	"maps"
+	"math"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/BackMarket-oss/kube-transition-metrics/internal/statistics/state"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	apimachinerytypes "k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	// recentPods is the number of the most recent Ready pods of each workload the startup percentiles are computed
+	// over.
+	recentPods = 100
+	// recentImagePulls is the number of the most recent image pulls of each workload the slowest images are selected
+	// from.
+	recentImagePulls = 100
+	// maxSlowestImages is the maximum number of images in the slowestImages of the reports.
+	maxSlowestImages = 5
+)
+
+// workloadKey identifies a workload, and its report.
+type workloadKey struct {
+	namespace string
+	kind      string
+	name      string
+}
+
+// reportName returns the name of the report of the workload, prefixed by its kind so that the reports of a Deployment
+// and a StatefulSet with the same name do not collide.
+func (k workloadKey) reportName() string {
+	return strings.ToLower(k.kind) + "-" + k.name
+}
+
+// pendingPod is a pod of the workload which is not yet Ready.
+type pendingPod struct {
+	name    string
+	created time.Time
+}
+
+// imagePull is the duration of a recent image pull of the workload.
+type imagePull struct {
+	image    string
+	duration time.Duration
+}
+
+// workload holds the recent statistics of the pods of a workload.
+type workload struct {
+	// owner references the workload from its report.
+	owner metav1.OwnerReference
+	// startups are the creation to ready durations of the most recent Ready pods, in the order they became Ready.
+	startups []time.Duration
+	// imagePulls are the most recent image pulls, in the order they completed.
+	imagePulls []imagePull
+	pending    map[apimachinerytypes.UID]pendingPod
+	// lastRollout is the latest rollout reported by the rollout tracker, it is nil until a rollout finishes.
+	lastRollout *state.RolloutStatistic
+	// lastActivity is the time of the latest statistic of the workload, idle workloads are forgotten.
+	lastActivity time.Time
+
+	// written is the status last written to the report, it is nil until the report is written.
+	written map[string]any
+}
+
+// newWorkload creates a new workload referenced by the owner reference.
+func newWorkload(owner metav1.OwnerReference) *workload {
+	return &workload{
+		owner:   owner,
+		pending: make(map[apimachinerytypes.UID]pendingPod),
+	}
+}
+
+// addStartup adds the creation to ready duration of a pod, keeping the most recent.
+func (w *workload) addStartup(duration time.Duration) {
+	w.startups = append(w.startups, duration)
+	if len(w.startups) > recentPods {
+		w.startups = w.startups[len(w.startups)-recentPods:]
+	}
+}
+
+// addImagePull adds the duration of an image pull, keeping the most recent.
+func (w *workload) addImagePull(pull imagePull) {
+	w.imagePulls = append(w.imagePulls, pull)
+	if len(w.imagePulls) > recentImagePulls {
+		w.imagePulls = w.imagePulls[len(w.imagePulls)-recentImagePulls:]
+	}
+}
+
+// status returns the status of the report of the workload at the time, as an unstructured object.
+// The pods pending for longer than the stuck threshold are reported as stuck.
+func (w *workload) status(now time.Time, stuckThreshold time.Duration) map[string]any {
+	status := map[string]any{
+		"observedPods": int64(len(w.startups)),
+	}
+
+	if len(w.startups) > 0 {
+		sorted := slices.Sorted(slices.Values(w.startups))
+		status["startup"] = map[string]any{
+			"p50Seconds": percentile(sorted, 0.5).Seconds(),  //nolint:mnd
+			"p90Seconds": percentile(sorted, 0.9).Seconds(),  //nolint:mnd
+			"p99Seconds": percentile(sorted, 0.99).Seconds(), //nolint:mnd
+			"maxSeconds": sorted[len(sorted)-1].Seconds(),
+		}
+	}
+
+	if w.lastRollout != nil {
+		status["lastRollout"] = rolloutStatus(w.lastRollout)
+	}
+
+	if images := w.slowestImages(); len(images) > 0 {
+		status["slowestImages"] = images
+	}
+
+	if stuck := w.stuckPods(now, stuckThreshold); len(stuck) > 0 {
+		status["stuckPods"] = stuck
+	}
+
+	return status
+}
+
+// rolloutStatus returns the status of the rollout, as an unstructured object.
+func rolloutStatus(rollout *state.RolloutStatistic) map[string]any {
+	status := map[string]any{
+		"revision":  rollout.Revision(),
+		"startedAt": rollout.StartedTimestamp().UTC().Format(time.RFC3339),
+		"partial":   rollout.Partial(),
+	}
+
+	if finished := rollout.FinishedTimestamp(); !finished.IsZero() {
+		status["durationSeconds"] = finished.Sub(rollout.StartedTimestamp()).Seconds()
+	}
+
+	return status
+}
+
+// slowestImages returns the images with the slowest recent pulls, with the duration of their slowest pull, as
+// unstructured objects.
+func (w *workload) slowestImages() []any {
+	slowest := make(map[string]time.Duration)
+
+	for _, pull := range w.imagePulls {
+		slowest[pull.image] = max(slowest[pull.image], pull.duration)
+	}
+
+	images := slices.SortedFunc(maps.Keys(slowest), func(a, b string) int {
+		return cmp.Or(cmp.Compare(slowest[b], slowest[a]), strings.Compare(a, b))
+	})
+
+	result := make([]any, 0, min(len(images), maxSlowestImages))
+	for _, image := range images[:min(len(images), maxSlowestImages)] {
+		result = append(result, map[string]any{
+			"image":           image,
+			"durationSeconds": slowest[image].Seconds(),
+		})
+	}
+
+	return result
+}
+
+// stuckPods returns the pods pending for longer than the threshold at the time, from the oldest, as unstructured
+// objects.
+func (w *workload) stuckPods(now time.Time, threshold time.Duration) []any {
+	stuck := slices.SortedFunc(maps.Values(w.pending), func(a, b pendingPod) int {
+		return cmp.Or(a.created.Compare(b.created), strings.Compare(a.name, b.name))
+	})
+	stuck = slices.DeleteFunc(stuck, func(pod pendingPod) bool {
+		return now.Sub(pod.created) < threshold
+	})
+
+	result := make([]any, 0, len(stuck))
+	for _, pod := range stuck {
+		result = append(result, map[string]any{
+			"name":      pod.name,
+			"createdAt": pod.created.UTC().Format(time.RFC3339),
+		})
+	}
+
+	return result
+}
+
+// percentile returns the nearest-rank percentile of the sorted durations, which must not be empty.
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	rank := max(int(math.Ceil(p*float64(len(sorted)))), 1)
+
+	return sorted[rank-1]
+}